@@ -2,7 +2,6 @@
 package builder
 
 import (
-	"sort"
 	"sync"
 
 	"github.com/verless/verless/config"
@@ -50,12 +49,21 @@ func (b *builder) RegisterPage(page model.Page) error {
 	// Otherwise, register the page as normal page.
 	node.Pages = append(node.Pages, page)
 
+	// On a multilingual site, the parent nodes above the route of the
+	// page's language don't reference the page, so that list pages only
+	// contain pages of a single language.
+	skip := 0
+	if b.cfg.I18n.Enabled() {
+		skip = len(tree.Edges(b.cfg.I18n.Route(page.Language)))
+	}
+
 	// Reference the new page in all parent nodes as well.
+	depth := 0
 	err = tree.WalkPath(page.Route, b.site.Root, func(currentNode tree.Node) error {
 		n := currentNode.(*model.Node)
 		p := &node.Pages[len(node.Pages)-1]
 
-		if p.Hidden {
+		if depth++; p.Hidden || depth <= skip {
 			return nil
 		}
 
@@ -71,25 +79,68 @@ func (b *builder) Dispatch() (model.Site, error) {
 	b.site.Meta = b.cfg.Site.Meta
 	b.site.Nav = b.cfg.Site.Nav
 	b.site.Footer = b.cfg.Site.Footer
+	b.site.Menus = make(map[string]model.Menu, len(b.cfg.Menus))
+
+	for name, menu := range b.cfg.Menus {
+		b.site.Menus[name] = SortMenu(menu)
+	}
+
+	b.site.Languages = b.languages()
 
 	// The final tree traversal does some final tasks:
 	//	1. Assign a route to all list pages
-	//	2. Sort the pages in all list pages by date
-	_ = tree.Walk(b.site.Root, func(path string, node tree.Node) error {
+	//	2. Sort the pages in all list pages
+	//	3. Link the pages of each section to their previous and next page
+	err := tree.Walk(b.site.Root, func(path string, node tree.Node) error {
 		n := node.(*model.Node)
 
 		n.ListPage.Route = path
 
-		sort.Slice(n.ListPage.Pages, func(i, j int) bool {
-			return n.ListPage.Pages[i].Date.After(n.ListPage.Pages[j].Date)
-		})
+		if err := SortPages(n.ListPage.Pages, b.cfg.Sort); err != nil {
+			return err
+		}
 
-		return nil
+		return linkSection(n, b.cfg.Sort)
 	}, -1)
+	if err != nil {
+		return model.Site{}, err
+	}
+
+	if err := b.linkTranslations(); err != nil {
+		return model.Site{}, err
+	}
 
 	return b.site, nil
 }
 
+// linkSection sets the previous and the next page of all pages inside the
+// node, which are ordered like in list pages. Drafts and hidden pages are
+// skipped and don't have a previous or next page themselves.
+func linkSection(n *model.Node, strategy string) error {
+	var pages []*model.Page
+
+	for i := range n.Pages {
+		if page := &n.Pages[i]; !page.Draft && !page.Hidden {
+			pages = append(pages, page)
+		}
+	}
+
+	if err := SortPages(pages, strategy); err != nil {
+		return err
+	}
+
+	for i, page := range pages {
+		if i > 0 {
+			page.Prev = pages[i-1]
+		}
+		if i < len(pages)-1 {
+			page.Next = pages[i+1]
+		}
+	}
+
+	return nil
+}
+
 // nodeFromCache loads a node from the cache. If the node isn't
 // registered in the cache yet, nodeFromCache will load it from
 // the route tree first.
@@ -127,3 +127,80 @@ func TestBuilder_Dispatch(t *testing.T) {
 		}, -1)
 	}
 }
+
+// TestBuilder_Dispatch_prevNext checks if the pages of a section are linked
+// to their previous and next page in the configured sort order, skipping
+// drafts, hidden pages and pages of sub-sections.
+func TestBuilder_Dispatch_prevNext(t *testing.T) {
+	pages := []model.Page{
+		{ID: "espresso", Route: "/blog", Href: "/blog/espresso", Title: "Espresso", Date: time.Date(2020, 10, 3, 0, 0, 0, 0, time.UTC)},
+		{ID: "latte", Route: "/blog", Href: "/blog/latte", Title: "Latte", Date: time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)},
+		{ID: "cappuccino", Route: "/blog", Href: "/blog/cappuccino", Title: "Cappuccino", Date: time.Date(2020, 10, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: "cold-brew", Route: "/blog", Href: "/blog/cold-brew", Title: "Cold brew", Date: time.Date(2020, 10, 4, 0, 0, 0, 0, time.UTC), Draft: true},
+		{ID: "imprint", Route: "/blog", Href: "/blog/imprint", Title: "Imprint", Hidden: true},
+		{ID: "mocha", Route: "/blog/archive", Href: "/blog/archive/mocha", Title: "Mocha", Date: time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := map[string]struct {
+		sort string
+		// expected maps the IDs of the pages in /blog to the IDs of their
+		// previous and next page, where an empty ID stands for nil.
+		expected map[string][2]string
+	}{
+		"sorted by date": {
+			sort: SortByDate,
+			expected: map[string][2]string{
+				"espresso":   {"", "cappuccino"},
+				"cappuccino": {"espresso", "latte"},
+				"latte":      {"cappuccino", ""},
+				"cold-brew":  {"", ""},
+				"imprint":    {"", ""},
+			},
+		},
+		"sorted by title": {
+			sort: SortByTitle,
+			expected: map[string][2]string{
+				"cappuccino": {"", "espresso"},
+				"espresso":   {"cappuccino", "latte"},
+				"latte":      {"espresso", ""},
+				"cold-brew":  {"", ""},
+				"imprint":    {"", ""},
+			},
+		},
+	}
+
+	id := func(page *model.Page) string {
+		if page == nil {
+			return ""
+		}
+		return page.ID
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		builder := New(&config.Config{Sort: testCase.sort})
+
+		for _, page := range pages {
+			test.Ok(t, builder.RegisterPage(page))
+		}
+
+		site, err := builder.Dispatch()
+		test.Ok(t, err)
+
+		node, err := tree.ResolveNode("/blog", site.Root)
+		test.Ok(t, err)
+
+		actual := make(map[string][2]string)
+		for _, page := range node.(*model.Node).Pages {
+			actual[page.ID] = [2]string{id(page.Prev), id(page.Next)}
+		}
+		test.Equals(t, testCase.expected, actual)
+
+		archive, err := tree.ResolveNode("/blog/archive", site.Root)
+		test.Ok(t, err)
+
+		mocha := archive.(*model.Node).Pages[0]
+		test.Assert(t, mocha.Prev == nil && mocha.Next == nil, "the only page of a section shouldn't be linked")
+	}
+}
@@ -0,0 +1,107 @@
+package builder
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/tree"
+)
+
+// languages returns all languages of a multilingual site, starting with
+// the default language.
+func (b *builder) languages() []model.Language {
+	if !b.cfg.I18n.Enabled() {
+		return nil
+	}
+
+	codes := b.cfg.I18n.Codes()
+	languages := make([]model.Language, len(codes))
+
+	for i, code := range codes {
+		languages[i] = model.Language{
+			Code: code,
+			Name: b.cfg.I18n.Languages[code].Name,
+			Href: model.ListPageHref(b.cfg.I18n.Route(code), b.cfg.Build.CleanURLs),
+		}
+	}
+
+	return languages
+}
+
+// linkTranslations stores the translations of each page on a multilingual
+// site. Pages are translations of each other if they have the same path
+// relative to the route of their language, like /blog/coffee for English
+// and /de/blog/coffee for German content. The translations are ordered
+// like the site's languages.
+func (b *builder) linkTranslations() error {
+	if !b.cfg.I18n.Enabled() {
+		return nil
+	}
+
+	translations := make(map[string][]*model.Page)
+
+	add := func(page *model.Page) {
+		key := b.translationKey(page)
+		translations[key] = append(translations[key], page)
+	}
+
+	err := tree.Walk(b.site.Root, func(_ string, node tree.Node) error {
+		n := node.(*model.Node)
+
+		for i := range n.Pages {
+			add(&n.Pages[i])
+		}
+
+		// Only custom list pages created from index.md have a language.
+		if n.ListPage.Page.ID != "" {
+			add(&n.ListPage.Page)
+		}
+
+		return nil
+	}, -1)
+	if err != nil {
+		return err
+	}
+
+	order := make(map[string]int)
+	for i, code := range b.cfg.I18n.Codes() {
+		order[code] = i
+	}
+
+	for _, pages := range translations {
+		for _, page := range pages {
+			page.Translations = nil
+
+			for _, other := range pages {
+				if other != page && other.Language != page.Language {
+					page.Translations = append(page.Translations, other)
+				}
+			}
+
+			sortByLanguage(page.Translations, order)
+		}
+	}
+
+	return nil
+}
+
+// translationKey returns the path of a page relative to the route of its
+// language, like /blog/coffee for /de/blog/coffee.
+func (b *builder) translationKey(page *model.Page) string {
+	route := page.Route
+
+	if langRoute := b.cfg.I18n.Route(page.Language); langRoute != tree.RootPath {
+		route = strings.TrimPrefix(route, langRoute)
+	}
+
+	return path.Join("/", route, page.ID)
+}
+
+// sortByLanguage sorts pages by the position of their language in order.
+func sortByLanguage(pages []*model.Page, order map[string]int) {
+	sort.SliceStable(pages, func(i, j int) bool {
+		return order[pages[i].Language] < order[pages[j].Language]
+	})
+}
@@ -0,0 +1,101 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/verless/verless/model"
+)
+
+const (
+	// SortByWeight sorts pages by their weight, starting with the lowest
+	// weight. Pages without weight follow after all weighted pages and
+	// are sorted by date. This is the default sort strategy.
+	SortByWeight string = "weight"
+	// SortByDate sorts pages by date, starting with the newest page.
+	SortByDate string = "date"
+	// SortByTitle sorts pages by title in lexical order.
+	SortByTitle string = "title"
+)
+
+var (
+	// ErrUnknownSort states that the configured sort strategy doesn't
+	// exist.
+	ErrUnknownSort = errors.New("unknown sort strategy, use weight, date or title")
+)
+
+// SortPages sorts pages using the given sort strategy. If the strategy
+// is empty, SortByWeight is used. Pages that are equal with respect to
+// the strategy are sorted by their Href, so that the order doesn't
+// depend on the order in which the pages have been registered.
+func SortPages(pages []*model.Page, strategy string) error {
+	var less func(a, b *model.Page) (bool, bool)
+
+	switch strategy {
+	case SortByWeight, "":
+		less = byWeight
+	case SortByDate:
+		less = byDate
+	case SortByTitle:
+		less = byTitle
+	default:
+		return fmt.Errorf("%s: %w", strategy, ErrUnknownSort)
+	}
+
+	sort.Slice(pages, func(i, j int) bool {
+		if isLess, decided := less(pages[i], pages[j]); decided {
+			return isLess
+		}
+		return pages[i].Href < pages[j].Href
+	})
+
+	return nil
+}
+
+// byWeight compares two pages by weight, falling back to byDate for pages
+// with the same weight. Pages without weight are considered heavier than
+// any weighted page. The second return value is false if both pages are
+// equal.
+func byWeight(a, b *model.Page) (bool, bool) {
+	switch {
+	case a.Weight == b.Weight:
+		return byDate(a, b)
+	case a.Weight == 0:
+		return false, true
+	case b.Weight == 0:
+		return true, true
+	default:
+		return a.Weight < b.Weight, true
+	}
+}
+
+// byDate compares two pages by date, the newer page being the lesser
+// one. The second return value is false if both pages are equal.
+func byDate(a, b *model.Page) (bool, bool) {
+	if a.Date.Equal(b.Date) {
+		return false, false
+	}
+	return a.Date.After(b.Date), true
+}
+
+// byTitle compares two pages by title. The second return value is false
+// if both pages are equal.
+func byTitle(a, b *model.Page) (bool, bool) {
+	if a.Title == b.Title {
+		return false, false
+	}
+	return a.Title < b.Title, true
+}
+
+// SortMenu returns a copy of the menu sorted by weight, starting with the
+// lowest weight. Entries with the same weight keep their order.
+func SortMenu(menu model.Menu) model.Menu {
+	sorted := append(model.Menu(nil), menu...)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Weight < sorted[j].Weight
+	})
+
+	return sorted
+}
@@ -0,0 +1,76 @@
+package builder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/test"
+)
+
+// TestSortPages checks if SortPages sorts pages according to all sort
+// strategies.
+func TestSortPages(t *testing.T) {
+	var (
+		older = time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+		newer = time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	)
+
+	pages := []model.Page{
+		{Href: "/a", Title: "Coffee", Date: older},
+		{Href: "/b", Title: "Beans", Date: newer, Weight: 2},
+		{Href: "/c", Title: "Espresso", Date: newer},
+		{Href: "/d", Title: "Arabica", Date: older, Weight: 1},
+		{Href: "/e", Title: "Decaf", Date: older, Weight: 2},
+		{Href: "/f", Title: "Filter", Date: older},
+	}
+
+	tests := map[string]struct {
+		strategy      string
+		expected      []string
+		expectedError error
+	}{
+		"default strategy": {
+			expected: []string{"/d", "/b", "/e", "/c", "/a", "/f"},
+		},
+		"weight": {
+			strategy: SortByWeight,
+			expected: []string{"/d", "/b", "/e", "/c", "/a", "/f"},
+		},
+		"date": {
+			strategy: SortByDate,
+			expected: []string{"/b", "/c", "/a", "/d", "/e", "/f"},
+		},
+		"title": {
+			strategy: SortByTitle,
+			expected: []string{"/d", "/b", "/a", "/e", "/c", "/f"},
+		},
+		"unknown strategy": {
+			strategy:      "random",
+			expectedError: ErrUnknownSort,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		// Sort the pages in reverse order to make sure that the result
+		// doesn't depend on the input order.
+		sorted := make([]*model.Page, 0, len(pages))
+		for i := len(pages) - 1; i >= 0; i-- {
+			sorted = append(sorted, &pages[i])
+		}
+
+		err := SortPages(sorted, testCase.strategy)
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			continue
+		}
+
+		hrefs := make([]string, len(sorted))
+		for i, page := range sorted {
+			hrefs[i] = page.Href
+		}
+
+		test.Equals(t, testCase.expected, hrefs)
+	}
+}
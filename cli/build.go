@@ -1,15 +1,26 @@
 package cli
 
 import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/verless/verless/core"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/out/style"
 )
 
 // newBuildCmd creates the `verless build` command.
 func newBuildCmd() *cobra.Command {
 	var (
-		options core.BuildOptions
+		options   core.BuildOptions
+		statsJSON bool
+		statsFile string
+		watch     bool
 	)
 
 	buildCmd := cobra.Command{
@@ -22,27 +33,175 @@ func newBuildCmd() *cobra.Command {
 			}
 			targetFs := afero.NewOsFs()
 
+			if watch {
+				if options.DryRun {
+					return errors.New("--watch cannot be used together with --dry-run")
+				}
+				if options.ArchivePath != "" {
+					return errors.New("--watch cannot be used together with --archive")
+				}
+				return core.BuildAndWatch(targetFs, path, options, nil)
+			}
+
+			// Printing anything else to stdout would break the JSON output.
+			if statsJSON && statsFile == "" {
+				out.SetLevel(out.LevelError)
+			}
+
 			build, err := core.NewBuild(targetFs, path, options)
 			if err != nil {
 				return err
 			}
 
-			return build.Run()
+			if err := build.Run(); err != nil {
+				return err
+			}
+
+			printWarnings(build.Warnings())
+
+			if statsJSON || statsFile != "" {
+				return writeStats(build.Stats(), statsFile)
+			}
+
+			return nil
 		},
 	}
 
 	addBuildOptions(&buildCmd, &options, true)
 
+	buildCmd.Flags().BoolVar(&options.DryRun, "dry-run",
+		false, `process all content files without writing the website or running hooks`)
+
+	buildCmd.Flags().BoolVar(&options.Clean, "clean",
+		false, `remove the output directory before building`)
+
+	buildCmd.Flags().StringVar(&options.ArchivePath, "archive",
+		"", `write the website into a zip or tar.gz archive instead of the output directory`)
+
+	buildCmd.Flags().BoolVarP(&watch, "watch", "w",
+		false, `rebuild the project into the output directory when a file changes`)
+
+	buildCmd.Flags().BoolVar(&statsJSON, "stats-json",
+		false, `print the build statistics as JSON instead of the usual output`)
+
+	buildCmd.Flags().StringVar(&statsFile, "stats-file",
+		"", `write the build statistics as JSON to the given file`)
+
 	return &buildCmd
 }
 
-func addBuildOptions(buildCmd *cobra.Command, options *core.BuildOptions, addOverwrite bool) {
+// writeStats writes the build statistics as JSON to the given file, or to
+// stdout if file is empty.
+func writeStats(stats core.BuildStats, file string) error {
+	b, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	if file == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+
+	return ioutil.WriteFile(file, b, 0644)
+}
+
+// printWarnings prints the number of pages missing each front matter
+// field along with the affected files, followed by all files skipped due
+// to invalid front matter, all broken links, all skipped root files and
+// all duplicate routes.
+func printWarnings(warnings []core.Warning) {
+	var (
+		fields []string
+		files  = make(map[string][]string)
+	)
+
+	for _, warning := range warnings {
+		for _, field := range warning.MissingFields {
+			if _, exists := files[field]; !exists {
+				fields = append(fields, field)
+			}
+			files[field] = append(files[field], warning.File)
+		}
+	}
+
+	for _, field := range fields {
+		noun := "pages have"
+		if len(files[field]) == 1 {
+			noun = "page has"
+		}
+
+		out.T(style.Warning, "%d %s no %s", len(files[field]), noun, strings.ToLower(field))
+
+		for _, file := range files[field] {
+			out.T(style.None, "  %s", file)
+		}
+	}
+
+	for _, warning := range warnings {
+		if warning.FrontMatterError != "" {
+			out.T(style.Warning, "skipped %s: %s", warning.File, warning.FrontMatterError)
+		}
+	}
+
+	for _, warning := range warnings {
+		for _, link := range warning.BrokenLinks {
+			out.T(style.Warning, "%s links to missing %s", warning.Page, link)
+		}
+	}
+
+	for _, warning := range warnings {
+		if warning.RootFile != "" {
+			out.T(style.Warning, "skipped root file %s: a generated file has the same path", warning.RootFile)
+		}
+	}
+
+	for _, warning := range warnings {
+		if len(warning.DuplicateFiles) > 0 {
+			out.T(style.Warning, "%s is rendered from %s", warning.Page, strings.Join(warning.DuplicateFiles, ", "))
+		}
+	}
+}
+
+func addBuildOptions(buildCmd *cobra.Command, options *core.BuildOptions, addForce bool) {
 	buildCmd.Flags().StringVarP(&options.OutputDir, "output", "o",
 		"", `specify an output directory`)
 
-	if addOverwrite {
-		// Overwrite should not have a shorthand to avoid accidental usage.
-		buildCmd.Flags().BoolVar(&options.Overwrite, "overwrite",
-			false, `allows overwriting an existing output directory`)
+	buildCmd.Flags().StringVar(&options.Env, "env",
+		"", `merge the configuration of an environment like production`)
+
+	buildCmd.Flags().BoolVar(&options.StrictFrontmatter, "strict-frontmatter",
+		false, `fail if a content file lacks required front matter fields`)
+
+	buildCmd.Flags().BoolVar(&options.StrictLinks, "strict-links",
+		false, `fail if a page links to a missing internal page or file`)
+
+	buildCmd.Flags().BoolVar(&options.IncludeDrafts, "drafts",
+		false, `include pages marked as draft`)
+
+	buildCmd.Flags().BoolVar(&options.IncludeFuture, "future",
+		false, `include pages dated in the future`)
+
+	buildCmd.Flags().BoolVar(&options.Incremental, "incremental",
+		false, `only write pages that have changed since the previous build`)
+
+	buildCmd.Flags().BoolVar(&options.NoCache, "no-cache",
+		false, `convert all Markdown files in incremental builds instead of reusing cached renders`)
+
+	buildCmd.Flags().BoolVar(&options.Minify, "minify",
+		false, `minify all HTML, CSS and JavaScript files`)
+
+	buildCmd.Flags().BoolVar(&options.Compress, "compress",
+		false, `write gzip-compressed copies of all text files`)
+
+	if addForce {
+		// Force should not have a shorthand to avoid accidental usage.
+		buildCmd.Flags().BoolVar(&options.Force, "force",
+			false, `allows overwriting an output directory with unexpected files`)
+
+		buildCmd.Flags().BoolVar(&options.Force, "overwrite",
+			false, `allows overwriting an output directory with unexpected files`)
+		_ = buildCmd.Flags().MarkDeprecated("overwrite", "use --force instead")
 	}
 }
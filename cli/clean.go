@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"github.com/verless/verless/core"
+)
+
+// newCleanCmd creates the `verless clean` command.
+func newCleanCmd() *cobra.Command {
+	var options core.CleanOptions
+
+	cleanCmd := cobra.Command{
+		Use:   "clean PROJECT",
+		Short: `Remove the output directory of your verless project`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var path = "."
+			if len(args) == 1 {
+				path = args[0]
+			}
+
+			return core.Clean(afero.NewOsFs(), path, options)
+		},
+	}
+
+	cleanCmd.Flags().StringVarP(&options.OutputDir, "output", "o",
+		"", `specify an output directory`)
+
+	cleanCmd.Flags().StringVar(&options.Env, "env",
+		"", `merge the configuration of an environment like production`)
+
+	// Force should not have a shorthand to avoid accidental usage.
+	cleanCmd.Flags().BoolVar(&options.Force, "force",
+		false, `allows removing an output directory with unexpected files`)
+
+	return &cleanCmd
+}
@@ -3,6 +3,8 @@ package cli
 import (
 	"github.com/spf13/cobra"
 	"github.com/verless/verless/core"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/out/style"
 )
 
 // newCreateCmd creates the `verless create` command.
@@ -25,6 +27,7 @@ func newCreateCmd() *cobra.Command {
 // newCreateFile creates the `verless create file` command
 func newCreateFile() *cobra.Command {
 	var (
+		project string
 		options core.CreateFileOptions
 	)
 	createFileCmd := cobra.Command{
@@ -32,12 +35,13 @@ func newCreateFile() *cobra.Command {
 		Short: `Create a new content file`,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			path := args[0]
-			return core.CreateFile(path, options)
+			route := args[0]
+			return core.CreateFile(project, route, options)
 		},
 	}
 
-	createFileCmd.Flags().StringVarP(&options.Project, "project", "p", ".", `project path to create file in.`)
+	createFileCmd.Flags().StringVarP(&project, "project", "p", ".", `project path to create file in.`)
+	createFileCmd.Flags().BoolVar(&options.Overwrite, "overwrite", false, `overwrite the file if it already exists.`)
 
 	return &createFileCmd
 }
@@ -54,16 +58,47 @@ func newCreateProjectCmd() *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			path := args[0]
-			return core.CreateProject(path, options)
+
+			plan, err := core.CreateProject(path, options)
+			if err != nil {
+				return err
+			}
+
+			if options.DryRun {
+				printProjectPlan(plan)
+			}
+
+			return nil
 		},
 	}
 
 	createProjectCmd.Flags().BoolVar(&options.Overwrite, "overwrite",
 		false, `overwrite the directory if it already exists`)
 
+	createProjectCmd.Flags().BoolVar(&options.DryRun, "dry-run",
+		false, `only print the changes without creating the project`)
+
+	createProjectCmd.Flags().BoolVar(&options.WithJS, "with-js",
+		false, `create a JavaScript file for the default theme`)
+
 	return &createProjectCmd
 }
 
+// printProjectPlan prints all changes listed in the plan.
+func printProjectPlan(plan core.ProjectPlan) {
+	out.T(style.Bulb, "the following changes would be made:")
+
+	for _, path := range plan.Remove {
+		out.T(style.None, "remove %s", path)
+	}
+	for _, dir := range plan.Dirs {
+		out.T(style.None, "create %s", dir)
+	}
+	for _, file := range plan.Files {
+		out.T(style.None, "write  %s", file)
+	}
+}
+
 // newCreateThemeCmd creates the `verless create theme` command.
 func newCreateThemeCmd() *cobra.Command {
 	var (
@@ -81,5 +116,6 @@ func newCreateThemeCmd() *cobra.Command {
 	}
 
 	createThemeCmd.Flags().StringVarP(&options.Project, "project", "p", ".", `project path to create new theme in.`)
+	createThemeCmd.Flags().StringVar(&options.From, "from", "", `existing theme to copy the new theme from.`)
 	return &createThemeCmd
 }
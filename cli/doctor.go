@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/verless/verless/core"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/out/style"
+)
+
+// newDoctorCmd creates the `verless doctor` command.
+func newDoctorCmd() *cobra.Command {
+	doctorCmd := cobra.Command{
+		Use:   "doctor PROJECT",
+		Short: `Check your verless project for common problems`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var path = "."
+			if len(args) == 1 {
+				path = args[0]
+			}
+
+			diagnostics, err := core.Doctor(path)
+			if err != nil {
+				return err
+			}
+
+			var errors int
+
+			for _, diagnostic := range diagnostics {
+				if diagnostic.Severity == core.SeverityError {
+					out.T(style.X, "%s", diagnostic.Message)
+					errors++
+					continue
+				}
+				out.T(style.Warning, "%s", diagnostic.Message)
+			}
+
+			if errors > 0 {
+				return fmt.Errorf("found %d problems that prevent building the project", errors)
+			}
+
+			if len(diagnostics) == 0 {
+				out.T(style.HeavyCheckMark, "no problems found")
+			}
+
+			return nil
+		},
+	}
+
+	return &doctorCmd
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+	"github.com/verless/verless/core"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/out/style"
+)
+
+// newImportCmd creates the `verless import` command.
+func newImportCmd() *cobra.Command {
+	var options core.ImportOptions
+
+	importCmd := cobra.Command{
+		Use:   "import SITE PROJECT",
+		Short: `Create a verless project from a Hugo or Jekyll site`,
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			warnings, err := core.Import(args[0], args[1], options)
+			if err != nil {
+				return err
+			}
+
+			for _, warning := range warnings {
+				out.T(style.Warning, "%s: %s", warning.File, warning.Message)
+			}
+
+			return nil
+		},
+	}
+
+	importCmd.Flags().StringVar(&options.From, "from",
+		"", `the generator of the site, either hugo or jekyll. Detected if omitted`)
+
+	importCmd.Flags().BoolVar(&options.Overwrite, "overwrite",
+		false, `overwrite the project directory if it already exists`)
+
+	return &importCmd
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/verless/verless/core"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/out/style"
+)
+
+// newLintCmd creates the `verless lint` command.
+func newLintCmd() *cobra.Command {
+	var options core.LintOptions
+
+	lintCmd := cobra.Command{
+		Use:   "lint PROJECT",
+		Short: `Check the content of your verless project for common problems`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var path = "."
+			if len(args) == 1 {
+				path = args[0]
+			}
+
+			issues, err := core.Lint(path, options)
+			if err != nil {
+				return err
+			}
+
+			var errors int
+
+			for _, issue := range issues {
+				if issue.Severity == core.SeverityError {
+					out.T(style.X, "%s: %s", issue.File, issue.Message)
+					errors++
+					continue
+				}
+				out.T(style.Warning, "%s: %s", issue.File, issue.Message)
+			}
+
+			if errors > 0 {
+				return fmt.Errorf("found %d problems in the content", errors)
+			}
+
+			if len(issues) == 0 {
+				out.T(style.HeavyCheckMark, "no problems found")
+			}
+
+			return nil
+		},
+	}
+
+	lintCmd.Flags().DurationVar(&options.DraftAge, "draft-age",
+		core.DefaultDraftAge, `report drafts modified within this period, e.g. 72h`)
+
+	return &lintCmd
+}
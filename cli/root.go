@@ -1,25 +1,62 @@
 package cli
 
 import (
+	"errors"
+
 	"github.com/spf13/cobra"
 	"github.com/verless/verless/config"
+	"github.com/verless/verless/out"
 )
 
 // NewRootCmd creates the `verless` command and its sub-commands.
 func NewRootCmd() *cobra.Command {
+	var (
+		quiet   bool
+		verbose bool
+	)
+
 	rootCmd := cobra.Command{
 		Use:     "verless",
 		Short:   `A simple and lightweight Static Site Generator.`,
 		Version: config.GitTag,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return setLogLevel(quiet, verbose)
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return cmd.Help()
 		},
 	}
 
+	rootCmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q",
+		false, `only print errors`)
+
+	rootCmd.PersistentFlags().BoolVar(&verbose, "verbose",
+		false, `print debug output, including each file read and written`)
+
 	rootCmd.AddCommand(newBuildCmd())
+	rootCmd.AddCommand(newCleanCmd())
 	rootCmd.AddCommand(newCreateCmd())
+	rootCmd.AddCommand(newDoctorCmd())
+	rootCmd.AddCommand(newImportCmd())
+	rootCmd.AddCommand(newLintCmd())
 	rootCmd.AddCommand(newServeCmd())
 	rootCmd.AddCommand(newVersionCmd())
 
 	return &rootCmd
 }
+
+// setLogLevel sets the level of the default logger used by all commands.
+func setLogLevel(quiet, verbose bool) error {
+	switch {
+	case quiet && verbose:
+		return errors.New("--quiet and --verbose cannot be used together")
+	case quiet:
+		out.SetLevel(out.LevelError)
+	case verbose:
+		out.SetLevel(out.LevelDebug)
+	default:
+		out.SetLevel(out.LevelInfo)
+	}
+
+	return nil
+}
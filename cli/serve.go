@@ -1,8 +1,6 @@
 package cli
 
 import (
-	"net"
-
 	"github.com/spf13/cobra"
 	"github.com/verless/verless/core"
 )
@@ -11,6 +9,7 @@ import (
 func newServeCmd() *cobra.Command {
 	var (
 		options core.ServeOptions
+		ip      string
 	)
 
 	serveCmd := cobra.Command{
@@ -21,18 +20,26 @@ func newServeCmd() *cobra.Command {
 			if len(args) == 1 {
 				path = args[0]
 			}
+			// The deprecated --ip flag is only used if --host isn't set.
+			if cmd.Flags().Changed("ip") && !cmd.Flags().Changed("host") {
+				options.Host = ip
+			}
 			return core.Serve(path, options)
 		},
 	}
 
-	serveCmd.Flags().Uint16VarP(&options.Port, "port", "p",
+	serveCmd.Flags().IntVarP(&options.Port, "port", "p",
 		8080, `specify the port for the web server`)
 
 	serveCmd.Flags().BoolVarP(&options.Watch, "watch", "w",
 		false, `rebuild the project when a file changes`)
 
-	serveCmd.Flags().IPVarP(&options.IP, "ip", "i",
-		net.IP{0, 0, 0, 0}, `specify the IP to listen on, it has to be a valid IPv4 or IPv6`)
+	serveCmd.Flags().StringVar(&options.Host, "host",
+		"localhost", `specify the host name or IP to listen on, use 0.0.0.0 for all interfaces`)
+
+	serveCmd.Flags().StringVarP(&ip, "ip", "i",
+		"", `specify the IP to listen on`)
+	_ = serveCmd.Flags().MarkDeprecated("ip", "use --host instead")
 
 	addBuildOptions(&serveCmd, &options.BuildOptions, false)
 
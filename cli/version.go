@@ -19,6 +19,8 @@ func newVersionCmd() *cobra.Command {
 
 	versionCmd.Flags().BoolVarP(&options.Quiet, "quiet", "q",
 		false, `only print the version number`)
+	versionCmd.Flags().BoolVar(&options.JSON, "json",
+		false, `print the version information as JSON`)
 
 	return &versionCmd
 }
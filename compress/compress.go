@@ -0,0 +1,191 @@
+// Package compress provides the precompression of text files written by
+// verless, so that static hosts can serve them without compressing them
+// on each request.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	// Gzip is the name of the gzip format, written to .gz files.
+	Gzip string = "gzip"
+	// Brotli is the name of the Brotli format, written to .br files. It
+	// isn't supported yet since there is no Brotli encoder available.
+	Brotli string = "brotli"
+	// MinSize is the minimum size of a file in bytes to be compressed.
+	// Smaller files barely benefit from compression.
+	MinSize int64 = 1024
+)
+
+var (
+	// ErrUnsupportedFormat states that a compression format is unknown
+	// or not supported.
+	ErrUnsupportedFormat = errors.New("unsupported compression format")
+)
+
+// Formats contains all supported compression formats.
+var Formats = []string{Gzip}
+
+// extensions maps the compression formats to the extensions of their
+// compressed files.
+var extensions = map[string]string{
+	Gzip:   ".gz",
+	Brotli: ".br",
+}
+
+// textExts contains the extensions of all files that are compressed.
+// Other files like images are usually compressed already.
+var textExts = []string{".html", ".htm", ".css", ".js", ".json", ".xml", ".svg", ".txt"}
+
+// Compressible reports whether the given file is a text file that is
+// compressed by Dir.
+func Compressible(file string) bool {
+	ext := strings.ToLower(filepath.Ext(file))
+
+	for _, textExt := range textExts {
+		if ext == textExt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Validate returns an error if the given format isn't supported.
+func Validate(format string) error {
+	for _, supported := range Formats {
+		if format == supported {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s: %w", format, ErrUnsupportedFormat)
+}
+
+// Compress compresses the content using the given format. The compressed
+// content only depends on the content, so that compressing an unchanged
+// file yields the same result.
+func Compress(format string, content []byte) ([]byte, error) {
+	if err := Validate(format); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	// The gzip header neither contains a filename nor a modification
+	// time, which would change the output for each build.
+	w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Dir writes a compressed sibling like index.html.gz for each text file
+// inside dir that is at least MinSize bytes large, using each of the
+// given formats. Files for which skip returns true are ignored.
+//
+// Siblings whose content hasn't changed aren't written again. Siblings
+// of files that have been removed or have become too small are removed,
+// so that a directory written incrementally never serves stale content.
+func Dir(targetFs afero.Fs, dir string, formats []string, skip func(file string) bool) error {
+	for _, format := range formats {
+		if err := Validate(format); err != nil {
+			return err
+		}
+	}
+
+	var files []string
+
+	err := afero.Walk(targetFs, dir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if skip != nil && skip(file) {
+			continue
+		}
+
+		for _, format := range formats {
+			if err := compressFile(targetFs, file, format); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// compressFile handles the given file inside a directory compressed by
+// Dir: It either is a text file whose sibling has to be written or
+// removed, or a compressed sibling whose original file may be gone.
+func compressFile(targetFs afero.Fs, file, format string) error {
+	ext := extensions[format]
+
+	if strings.HasSuffix(file, ext) {
+		original := strings.TrimSuffix(file, ext)
+		if !Compressible(original) {
+			return nil
+		}
+		if exists, err := afero.Exists(targetFs, original); err != nil || exists {
+			return err
+		}
+		return targetFs.Remove(file)
+	}
+
+	if !Compressible(file) {
+		return nil
+	}
+
+	sibling := file + ext
+
+	content, err := afero.ReadFile(targetFs, file)
+	if err != nil {
+		return err
+	}
+
+	if int64(len(content)) < MinSize {
+		if err := targetFs.Remove(sibling); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	compressed, err := Compress(format, content)
+	if err != nil {
+		return fmt.Errorf("compressing %s: %w", file, err)
+	}
+
+	if existing, err := afero.ReadFile(targetFs, sibling); err == nil && bytes.Equal(existing, compressed) {
+		return nil
+	}
+
+	return afero.WriteFile(targetFs, sibling, compressed, 0644)
+}
@@ -0,0 +1,113 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/test"
+)
+
+// TestCompress checks if compressed content decompresses to the original
+// content and if compressing the same content yields the same result.
+func TestCompress(t *testing.T) {
+	tests := map[string]struct {
+		format        string
+		expectedError error
+	}{
+		"gzip": {
+			format: Gzip,
+		},
+		"brotli": {
+			format:        Brotli,
+			expectedError: ErrUnsupportedFormat,
+		},
+		"unknown format": {
+			format:        "zip",
+			expectedError: ErrUnsupportedFormat,
+		},
+	}
+
+	content := []byte(strings.Repeat("<p>Espresso and milk</p>\n", 100))
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		compressed, err := Compress(testCase.format, content)
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			continue
+		}
+
+		test.Assert(t, len(compressed) < len(content), "compressed content should be smaller (%d >= %d bytes)", len(compressed), len(content))
+		test.Equals(t, content, decompress(t, compressed))
+
+		again, err := Compress(testCase.format, content)
+		test.Ok(t, err)
+		test.Equals(t, compressed, again)
+	}
+}
+
+// TestDir checks if Dir only compresses large text files and removes the
+// compressed siblings of removed or shrunken files.
+func TestDir(t *testing.T) {
+	var (
+		large = strings.Repeat("body { color: #333; }\n", 100)
+		small = "body{}"
+	)
+
+	memMapFs := afero.NewMemMapFs()
+
+	files := map[string]string{
+		"/target/index.html":        large,
+		"/target/style.css":         large,
+		"/target/small.js":          small,
+		"/target/image.png":         large,
+		"/target/skipped.json":      large,
+		"/target/shrunken.css":      small,
+		"/target/shrunken.css.gz":   "stale",
+		"/target/removed.html.gz":   "stale",
+		"/target/archive.tar.gz":    "archive",
+		"/target/blog/post.html":    large,
+		"/target/blog/post.html.gz": "stale",
+	}
+
+	for file, content := range files {
+		test.Ok(t, afero.WriteFile(memMapFs, file, []byte(content), 0644))
+	}
+
+	err := Dir(memMapFs, "/target", []string{Gzip}, func(file string) bool {
+		return file == "/target/skipped.json"
+	})
+	test.Ok(t, err)
+
+	for _, file := range []string{"/target/index.html", "/target/style.css", "/target/blog/post.html"} {
+		compressed, err := afero.ReadFile(memMapFs, file+".gz")
+		test.Ok(t, err)
+		test.Equals(t, []byte(large), decompress(t, compressed))
+	}
+
+	for _, file := range []string{"/target/small.js.gz", "/target/image.png.gz", "/target/skipped.json.gz", "/target/shrunken.css.gz", "/target/removed.html.gz"} {
+		_, err := memMapFs.Stat(file)
+		test.Assert(t, os.IsNotExist(err), "%s shouldn't exist", file)
+	}
+
+	_, err = memMapFs.Stat("/target/archive.tar.gz")
+	test.Ok(t, err)
+
+	err = Dir(memMapFs, "/target", []string{Gzip, Brotli}, nil)
+	test.ExpectedError(t, ErrUnsupportedFormat, err)
+}
+
+func decompress(tb testing.TB, compressed []byte) []byte {
+	r, err := gzip.NewReader(bytes.NewReader(compressed))
+	test.Ok(tb, err)
+
+	content, err := ioutil.ReadAll(r)
+	test.Ok(tb, err)
+
+	return content
+}
@@ -2,41 +2,412 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
 	"github.com/spf13/viper"
+	"github.com/verless/verless/fs"
 	"github.com/verless/verless/model"
+	"github.com/verless/verless/theme"
+)
+
+var (
+	// ErrMissingEnvConfig states that there is no configuration file for
+	// the selected environment.
+	ErrMissingEnvConfig = errors.New("missing configuration file for the selected environment")
 )
 
 // Config represents the user configuration stored in verless.yml.
 type Config struct {
 	Version string
-	Site    struct {
+	// BaseURL is the URL the website is served under, like
+	// https://example.com/blog/. It takes precedence over site.meta.base.
+	BaseURL string
+	// Timezone is the IANA name of the site's time zone, like
+	// Europe/Berlin. Templates format dates in this time zone.
+	Timezone string
+	Site     struct {
 		Meta   model.Meta
 		Nav    model.Nav
 		Footer model.Footer
 	}
+	// Menus contains named menus like main. Since viper lowercases all
+	// keys, menu names are available in lowercase.
+	Menus map[string]model.Menu
+	// I18n configures multilingual content.
+	I18n    I18n
 	Plugins []string
-	Theme   string
-	Types   map[string]*model.Type
-	Build   struct {
+	// Taxonomies contains the singular names of all taxonomies like
+	// category, which group pages by the terms in their front matter.
+	Taxonomies []string
+	// PluginConfig contains the settings of the individual plugins.
+	PluginConfig struct {
+		Related struct {
+			Limit       int
+			SameSection bool
+		}
+		Atom struct {
+			OutputPath string
+			ItemLimit  int
+			Title      string
+			Author     string
+			Section    string
+			RSS        bool
+		}
+		Robots struct {
+			Rules []string
+		}
+		Search struct {
+			OutputPath    string
+			Fields        []string
+			ExcerptLength int
+		}
+		Sitemap struct {
+			ChangeFreq string
+			Priority   float64
+		}
+		Tags struct {
+			GeneratePages bool
+		}
+	}
+	Theme string
+	// Sort is the sort strategy for pages in list pages.
+	Sort  string
+	Types map[string]*model.Type
+	// OutputFormats contains named formats like amp, in which pages are
+	// additionally rendered using their own template.
+	OutputFormats map[string]*model.OutputFormat
+	// Pagination configures how list pages are split into several pages.
+	Pagination struct {
+		PageSize int
+	}
+	// Markdown configures how Markdown content is rendered.
+	Markdown struct {
+		Highlight struct {
+			Enabled     bool
+			Style       string
+			LineNumbers bool
+		}
+		Extensions []string
+		// FileExtensions lists the extensions of content files that are
+		// parsed as Markdown, like .md. Defaults to fs.MarkdownExtensions.
+		FileExtensions []string
+		TOC            struct {
+			MinLevel int
+			MaxLevel int
+		}
+		WordsPerMinute int
+		SummaryLength  int
+		// Shortcodes determines whether shortcodes are rendered before
+		// or after converting the Markdown content to HTML.
+		Shortcodes string
+		// Emoji replaces emoji shortcodes like :tada: with their emoji.
+		Emoji bool
+		// Smartypants converts quotes, dashes and ellipses to their
+		// typographic counterparts.
+		Smartypants bool
+		// Diagrams configures fenced code blocks like ```mermaid that
+		// are rendered by a client-side library.
+		Diagrams struct {
+			Languages []string
+			Element   string
+			// Script is the HTML like a <script> tag inserted into each
+			// page containing a diagram.
+			Script string
+		}
+	}
+	// Assets configures how CSS and JavaScript files are written.
+	Assets struct {
+		Fingerprint bool
+		// Bundle overrides the bundling configuration of the theme.
+		Bundle theme.Bundle
+		// Precompress lists the compression formats like gzip used to
+		// write compressed siblings of all text files in the output
+		// directory, e.g. index.html.gz.
+		Precompress []string
+	}
+	// Images configures the variants created for responsive images.
+	Images struct {
+		Widths  []int
+		Quality int
+		Sizes   string
+	}
+	// Dirs overrides the directories of the project.
+	Dirs  Dirs
+	Build struct {
 		Overwrite bool
 		Before    []string
+		// CleanURLs renders pages to <page>/index.html instead of
+		// <page>.html, so that they're available under /<page>.
+		CleanURLs bool
+		// Keep lists paths inside the output directory like CNAME that
+		// are never removed when clearing the output directory.
+		Keep []string
+		// AllowDuplicateRoutes only warns about content files rendered
+		// to the same page instead of failing the build.
+		AllowDuplicateRoutes bool
+	}
+	// Slug configures how the Slug front matter field and optionally
+	// filenames are converted into route segments.
+	Slug struct {
+		// Filenames slugifies all route segments derived from the names
+		// of content files and directories.
+		Filenames     bool
+		Lowercase     bool
+		Transliterate bool
+	}
+	// Hooks contains commands that are executed inside the project
+	// directory before and after a build.
+	Hooks struct {
+		PreBuild  []string
+		PostBuild []string
+	}
+
+	// settings contains all resolved settings, including the settings of
+	// the environment and those overridden by environment variables.
+	settings       map[string]interface{}
+	pluginSettings map[string]interface{}
+	// keys contains all keys of the configuration file in lowercase.
+	keys []string
+}
+
+// Dirs contains the names of the project directories relative to the
+// project path. Empty names fall back to the defaults like ContentDir.
+type Dirs struct {
+	Content string
+	Output  string
+	Themes  string
+	Data    string
+	Root    string
+}
+
+// I18n contains the languages of a multilingual site. Since viper
+// lowercases all keys, language codes are available in lowercase.
+type I18n struct {
+	DefaultLanguage string
+	// DefaultInSubdir renders the content in the default language to
+	// a directory like /en instead of the root directory.
+	DefaultInSubdir bool
+	Languages       map[string]struct {
+		Name string
+	}
+}
+
+// Enabled reports whether the site is multilingual.
+func (i *I18n) Enabled() bool {
+	return len(i.Languages) > 0
+}
+
+// Codes returns the codes of all languages, starting with the default
+// language and followed by all other languages in lexical order.
+func (i *I18n) Codes() []string {
+	codes := make([]string, 0, len(i.Languages))
+
+	for code := range i.Languages {
+		if code != i.DefaultLanguage {
+			codes = append(codes, code)
+		}
+	}
+
+	sort.Strings(codes)
+
+	if _, exists := i.Languages[i.DefaultLanguage]; exists {
+		codes = append([]string{i.DefaultLanguage}, codes...)
+	}
+
+	return codes
+}
+
+// Route returns the route containing the content in the language with
+// the given code, like /de. The content in the default language is
+// located at the root route unless DefaultInSubdir is set.
+func (i *I18n) Route(code string) string {
+	if code == i.DefaultLanguage && !i.DefaultInSubdir {
+		return "/"
+	}
+	return "/" + code
+}
+
+// ContentPath returns the path of the content directory inside the given
+// project path.
+func (c *Config) ContentPath(path string) string {
+	return filepath.Join(path, orDefault(c.Dirs.Content, ContentDir))
+}
+
+// MarkdownExtensions returns the extensions of the Markdown files inside
+// the content directory.
+func (c *Config) MarkdownExtensions() []string {
+	if len(c.Markdown.FileExtensions) == 0 {
+		return fs.MarkdownExtensions
+	}
+	return c.Markdown.FileExtensions
+}
+
+// RenderableExtensions returns the extensions of all content files that
+// are rendered as pages, which are Markdown and HTML files.
+func (c *Config) RenderableExtensions() []string {
+	exts := c.MarkdownExtensions()
+	return append(exts[:len(exts):len(exts)], ".html")
+}
+
+// OutputPath returns the path of the default output directory inside the
+// given project path.
+func (c *Config) OutputPath(path string) string {
+	return filepath.Join(path, orDefault(c.Dirs.Output, OutputDir))
+}
+
+// ThemesPath returns the path of the themes directory inside the given
+// project path.
+func (c *Config) ThemesPath(path string) string {
+	return filepath.Join(path, orDefault(c.Dirs.Themes, ThemesDir))
+}
+
+// DataPath returns the path of the data directory inside the given project
+// path.
+func (c *Config) DataPath(path string) string {
+	return filepath.Join(path, orDefault(c.Dirs.Data, DataDir))
+}
+
+// RootPath returns the path of the directory whose files are copied into
+// the root of the output directory inside the given project path.
+func (c *Config) RootPath(path string) string {
+	return filepath.Join(path, orDefault(c.Dirs.Root, RootDir))
+}
+
+// orDefault returns value, or defaultValue if value is empty.
+func orDefault(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
 	}
+	return value
+}
+
+// PluginSettings returns the settings of the plugin with the given key
+// from the pluginConfig section, including settings of plugins that
+// aren't built into verless.
+func (c *Config) PluginSettings(key string) map[string]interface{} {
+	settings, _ := c.pluginSettings[strings.ToLower(key)].(map[string]interface{})
+	return settings
+}
+
+// Settings returns all resolved settings with lowercase keys, including
+// the settings merged from the environment configuration file and those
+// overridden by environment variables.
+func (c *Config) Settings() map[string]interface{} {
+	return c.settings
 }
 
 // FromFile looks for a configuration file and converts it to a Config.
+// If an environment has been selected using the VERLESS_ENV environment
+// variable, the configuration file of that environment is merged into
+// the configuration. See FromFileEnv.
 func FromFile(path, filename string) (Config, error) {
-	viper.AddConfigPath(path)
+	return FromFileEnv(path, filename, "")
+}
+
+// FromFileEnv looks for a configuration file and merges the configuration
+// file of the given environment like verless.production.yml into it. In
+// the merged configuration, values of the environment override scalar
+// values and lists, and maps are merged recursively. If env is empty, the
+// environment is read from the VERLESS_ENV environment variable.
+//
+// Environment variables like VERLESS_BASEURL take precedence over both
+// configuration files.
+func FromFileEnv(path, filename, env string) (Config, error) {
+	return fromFileEnv(afero.NewOsFs(), path, filename, env)
+}
+
+// FromFs is like FromFile, but reads the configuration files from the
+// given filesystem instead of the OS filesystem.
+func FromFs(sourceFs afero.Fs, path, filename string) (Config, error) {
+	return fromFileEnv(sourceFs, path, filename, "")
+}
+
+// fromFileEnv reads the configuration files from the given filesystem.
+// See FromFileEnv.
+func fromFileEnv(sourceFs afero.Fs, path, filename, env string) (Config, error) {
+	if env == "" {
+		env = os.Getenv(EnvVar)
+	}
+
+	// Use a dedicated viper instance so that the configuration paths of
+	// previously loaded projects aren't searched.
+	v := viper.New()
+	v.SetFs(sourceFs)
+	v.AddConfigPath(path)
 	// Set the filename without extension to allow all supported formats.
-	viper.SetConfigName(filename)
+	v.SetConfigName(filename)
+	setConfigFile(v, sourceFs, path, filename)
+
+	v.SetDefault("build.cleanURLs", true)
+	v.SetDefault("slug.lowercase", true)
+	v.SetDefault("slug.transliterate", true)
+	v.SetDefault("markdown.highlight.enabled", true)
+	v.SetDefault("markdown.toc.minLevel", 2)
+	v.SetDefault("markdown.toc.maxLevel", 3)
+	v.SetDefault("markdown.diagrams.languages", []string{"mermaid"})
+	v.SetDefault("pluginConfig.tags.generatePages", true)
 
 	var config Config
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		return config, err
 	}
-	if err := viper.Unmarshal(&config); err != nil {
+
+	if env != "" {
+		v.SetConfigName(filename + "." + env)
+		setConfigFile(v, sourceFs, path, filename+"."+env)
+		if err := v.MergeInConfig(); err != nil {
+			var notFound viper.ConfigFileNotFoundError
+			if errors.As(err, &notFound) {
+				return config, fmt.Errorf("%s.%s: %w", filename, env, ErrMissingEnvConfig)
+			}
+			return config, err
+		}
+	}
+	applyEnvVars(v)
+
+	if err := v.Unmarshal(&config); err != nil {
 		return config, err
 	}
 
+	// Overridden keys are only merged into the other settings when reading
+	// all settings, so pluginConfig can't be read directly.
+	config.settings = v.AllSettings()
+	config.pluginSettings, _ = config.settings["pluginconfig"].(map[string]interface{})
+	config.keys = v.AllKeys()
+
+	// Language codes are compared in lowercase like the keys of
+	// i18n.languages.
+	config.I18n.DefaultLanguage = strings.ToLower(config.I18n.DefaultLanguage)
+
+	// The base URL used to be configured as site.meta.base, so both keys
+	// are kept in sync.
+	if config.BaseURL != "" {
+		config.Site.Meta.Base = config.BaseURL
+	} else {
+		config.BaseURL = config.Site.Meta.Base
+	}
+
 	return config, nil
 }
+
+// setConfigFile sets the configuration file with the given name and any
+// supported extension inside path if it exists. viper resolves relative
+// configuration paths against the working directory, which only works
+// for the OS filesystem. If there is no such file, viper searches for the
+// file itself and reports it as missing.
+func setConfigFile(v *viper.Viper, sourceFs afero.Fs, path, filename string) {
+	for _, ext := range viper.SupportedExts {
+		file := filepath.Join(path, filename+"."+ext)
+		if info, err := sourceFs.Stat(file); err == nil && !info.IsDir() {
+			v.SetConfigFile(file)
+			return
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/verless/verless/test"
+)
+
+// TestFromFileEnv checks if the configuration of an environment is merged
+// into the project configuration.
+func TestFromFileEnv(t *testing.T) {
+	const (
+		base = `version: 1
+baseURL: http://localhost:8080
+site:
+  meta:
+    title: Coffee Blog
+    author: Barista
+plugins:
+  - atom
+  - tags
+markdown:
+  toc:
+    minLevel: 1
+`
+		production = `baseURL: https://example.com/blog/
+site:
+  meta:
+    title: The Coffee Blog
+plugins:
+  - sitemap
+markdown:
+  toc:
+    maxLevel: 4
+`
+	)
+
+	dir, err := ioutil.TempDir("", "verless-config")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	test.Ok(t, ioutil.WriteFile(filepath.Join(dir, Filename+".yml"), []byte(base), 0644))
+	test.Ok(t, ioutil.WriteFile(filepath.Join(dir, Filename+".production.yml"), []byte(production), 0644))
+
+	tests := map[string]struct {
+		env      string
+		envVar   string
+		expected func(t *testing.T, cfg Config)
+	}{
+		"without environment": {
+			expected: func(t *testing.T, cfg Config) {
+				test.Equals(t, "http://localhost:8080", cfg.BaseURL)
+				test.Equals(t, "Coffee Blog", cfg.Site.Meta.Title)
+				test.Equals(t, []string{"atom", "tags"}, cfg.Plugins)
+				test.Equals(t, 3, cfg.Markdown.TOC.MaxLevel)
+			},
+		},
+		"production environment": {
+			env: "production",
+			expected: func(t *testing.T, cfg Config) {
+				// Scalars are overridden.
+				test.Equals(t, "https://example.com/blog/", cfg.BaseURL)
+				test.Equals(t, "https://example.com/blog/", cfg.Site.Meta.Base)
+				test.Equals(t, "The Coffee Blog", cfg.Site.Meta.Title)
+				// Maps are merged.
+				test.Equals(t, "Barista", cfg.Site.Meta.Author)
+				test.Equals(t, 1, cfg.Markdown.TOC.MinLevel)
+				test.Equals(t, 4, cfg.Markdown.TOC.MaxLevel)
+				// Lists are replaced.
+				test.Equals(t, []string{"sitemap"}, cfg.Plugins)
+			},
+		},
+		"environment variable": {
+			envVar: "production",
+			expected: func(t *testing.T, cfg Config) {
+				test.Equals(t, "https://example.com/blog/", cfg.BaseURL)
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		test.Ok(t, os.Setenv(EnvVar, testCase.envVar))
+
+		cfg, err := FromFileEnv(dir, Filename, testCase.env)
+		test.Ok(t, err)
+		testCase.expected(t, cfg)
+	}
+
+	test.Ok(t, os.Unsetenv(EnvVar))
+}
+
+// TestFromFileEnv_missingEnvConfig checks if selecting an environment
+// without a configuration file fails.
+func TestFromFileEnv_missingEnvConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verless-config")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	test.Ok(t, ioutil.WriteFile(filepath.Join(dir, Filename+".yml"), []byte("version: 1\n"), 0644))
+
+	_, err = FromFileEnv(dir, Filename, "staging")
+	test.ExpectedError(t, ErrMissingEnvConfig, err)
+}
+
+// TestFromFile_envVars checks if environment variables override the values
+// of the configuration files.
+func TestFromFile_envVars(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verless-config")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	config := "version: 1\nbaseURL: http://localhost:8080\nsite:\n  meta:\n    title: Coffee Blog\nplugins:\n  - atom\npluginConfig:\n  atom:\n    itemLimit: 10\n    title: Coffee\n"
+	test.Ok(t, ioutil.WriteFile(filepath.Join(dir, Filename+".yml"), []byte(config), 0644))
+	test.Ok(t, ioutil.WriteFile(filepath.Join(dir, Filename+".production.yml"), []byte("baseURL: https://example.com\n"), 0644))
+
+	vars := map[string]string{
+		"VERLESS_BASEURL":                "https://example.com/blog/",
+		"VERLESS_SITE_META_AUTHOR":       "Barista",
+		"VERLESS_PLUGINS":                "atom,sitemap",
+		"VERLESS_PLUGINS_ATOM_ITEMLIMIT": "20",
+		"VERLESS_PAGINATION_PAGESIZE":    "5",
+		"VERLESS_BUILD_CLEANURLS":        "false",
+	}
+
+	for name, value := range vars {
+		test.Ok(t, os.Setenv(name, value))
+	}
+
+	cfg, err := FromFileEnv(dir, Filename, "production")
+
+	for name := range vars {
+		test.Ok(t, os.Unsetenv(name))
+	}
+
+	test.Ok(t, err)
+	test.Equals(t, "https://example.com/blog/", cfg.BaseURL)
+	test.Equals(t, "Coffee Blog", cfg.Site.Meta.Title)
+	test.Equals(t, "Barista", cfg.Site.Meta.Author)
+	test.Equals(t, []string{"atom", "sitemap"}, cfg.Plugins)
+	test.Equals(t, 20, cfg.PluginConfig.Atom.ItemLimit)
+	test.Equals(t, "Coffee", cfg.PluginConfig.Atom.Title)
+	test.Equals(t, 5, cfg.Pagination.PageSize)
+	test.Equals(t, false, cfg.Build.CleanURLs)
+	test.Equals(t, "20", cfg.PluginSettings("atom")["itemlimit"])
+	test.Ok(t, Validate(cfg))
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// envPrefix is the prefix of all environment variables overriding
+	// configuration keys, like VERLESS_BASEURL.
+	envPrefix string = "VERLESS_"
+)
+
+// applyEnvVars overrides configuration keys with the values of all
+// environment variables starting with VERLESS_. The environment variable
+// VERLESS_PLUGINCONFIG_ATOM_ITEMLIMIT overrides pluginConfig.atom.itemLimit,
+// for example. Lists are provided as comma-separated values. Keys that
+// don't exist are reported by Validate.
+func applyEnvVars(v *viper.Viper) {
+	for _, variable := range os.Environ() {
+		name, value := splitEnvVar(variable)
+
+		if !strings.HasPrefix(name, envPrefix) || name == EnvVar {
+			continue
+		}
+
+		v.Set(envKey(strings.TrimPrefix(name, envPrefix)), value)
+	}
+}
+
+// splitEnvVar splits an environment variable in the form key=value.
+func splitEnvVar(variable string) (string, string) {
+	i := strings.IndexByte(variable, '=')
+	if i < 0 {
+		return variable, ""
+	}
+	return variable[:i], variable[i+1:]
+}
+
+// envKey converts the name of an environment variable without prefix,
+// like PLUGINCONFIG_ATOM_ITEMLIMIT, to a configuration key. Since plugins
+// is a list, PLUGINS_ATOM_ITEMLIMIT refers to pluginConfig as well.
+func envKey(name string) string {
+	segments := strings.Split(strings.ToLower(name), "_")
+
+	if len(segments) > 1 && segments[0] == "plugins" {
+		segments[0] = "pluginconfig"
+	}
+
+	return strings.Join(segments, ".")
+}
@@ -5,15 +5,24 @@ var (
 	GitTag string = "UNDEFINED"
 	// GitCommit stores the latest Git commit.
 	GitCommit string = "UNKNOWN"
+	// BuildDate stores the time the binary has been built at.
+	BuildDate string = "UNKNOWN"
 )
 
 const (
 	// Filename is the name of the config file without extension.
 	Filename string = "verless"
 
+	// EnvVar is the environment variable selecting the environment whose
+	// configuration file is merged into the project configuration.
+	EnvVar string = "VERLESS_ENV"
+
 	// ContentDir is the directory for Markdown content.
 	ContentDir string = "content"
 
+	// DataDir is the directory for data files available to all templates.
+	DataDir string = "data"
+
 	// ThemesDir is the directory for verless themes.
 	ThemesDir string = "themes"
 
@@ -25,6 +34,10 @@ const (
 	// StaticDir is the directory for static files.
 	StaticDir string = "static"
 
+	// RootDir is the directory for files like favicon.ico that are copied
+	// verbatim into the root of the output directory.
+	RootDir string = "root"
+
 	// OutputDir is the default output directory.
 	OutputDir string = "target"
 )
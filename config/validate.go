@@ -0,0 +1,375 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/verless/verless/compress"
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/shortcode"
+)
+
+var (
+	// ErrInvalidConfig states that the configuration contains unknown
+	// keys, misses required keys or contains invalid values.
+	ErrInvalidConfig = errors.New("invalid configuration")
+)
+
+// taxonomyName matches valid taxonomy names like category.
+var taxonomyName = regexp.MustCompile(`^[a-z]+$`)
+
+// urlPlugins are the built-in plugins that need a base URL for creating
+// absolute URLs.
+var urlPlugins = []string{"atom", "robots", "sitemap"}
+
+// ValidationError contains all problems found by Validate.
+type ValidationError struct {
+	// Messages contains a message for each problem, starting with the
+	// path of the offending key like pluginConfig.atom.itemLimit.
+	Messages []string
+}
+
+// Error implements error.Error.
+func (v *ValidationError) Error() string {
+	return fmt.Sprintf("%s:\n  %s", ErrInvalidConfig, strings.Join(v.Messages, "\n  "))
+}
+
+// Unwrap returns ErrInvalidConfig, so that a ValidationError can be
+// checked using errors.Is.
+func (v *ValidationError) Unwrap() error {
+	return ErrInvalidConfig
+}
+
+// Validate checks the configuration for missing required keys, unknown
+// keys and invalid values. If there are any problems, a ValidationError
+// is returned. Unknown keys can only be detected for configurations
+// loaded using FromFile.
+func Validate(cfg Config) error {
+	var messages []string
+
+	if cfg.Version == "" {
+		messages = append(messages, "version: missing required key")
+	}
+
+	messages = append(messages, unknownKeys(cfg)...)
+
+	if cfg.BaseURL != "" {
+		if u, err := url.Parse(cfg.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			messages = append(messages, fmt.Sprintf("baseURL: %q is not an absolute URL like https://example.com", cfg.BaseURL))
+		}
+	} else {
+		for _, plugin := range cfg.Plugins {
+			if containsFold(urlPlugins, plugin) {
+				messages = append(messages, fmt.Sprintf("baseURL: missing required key for the %s plugin", plugin))
+			}
+		}
+	}
+
+	if cfg.Timezone != "" {
+		if _, err := time.LoadLocation(cfg.Timezone); err != nil {
+			messages = append(messages, fmt.Sprintf("timezone: %q is not a time zone like Europe/Berlin", cfg.Timezone))
+		}
+	}
+
+	if cfg.I18n.Enabled() {
+		if cfg.I18n.DefaultLanguage == "" {
+			messages = append(messages, "i18n.defaultLanguage: missing required key for multilingual content")
+		} else if _, exists := cfg.I18n.Languages[cfg.I18n.DefaultLanguage]; !exists {
+			messages = append(messages, fmt.Sprintf("i18n.defaultLanguage: %q is not one of i18n.languages", cfg.I18n.DefaultLanguage))
+		}
+	} else if cfg.I18n.DefaultLanguage != "" {
+		messages = append(messages, "i18n.languages: missing required key for the default language")
+	}
+
+	taxonomies := make(map[string]bool)
+
+	for _, name := range cfg.Taxonomies {
+		switch {
+		case !taxonomyName.MatchString(name):
+			messages = append(messages, fmt.Sprintf("taxonomies: %q is not a lowercase name like category", name))
+		case taxonomies[name]:
+			messages = append(messages, fmt.Sprintf("taxonomies: %q is listed more than once", name))
+		case name == model.TagTaxonomy && containsFold(cfg.Plugins, "tags"):
+			messages = append(messages, fmt.Sprintf("taxonomies: %q cannot be used together with the tags plugin", name))
+		}
+		taxonomies[name] = true
+	}
+
+	nonNegative := []struct {
+		path  string
+		value int
+	}{
+		{"pagination.pageSize", cfg.Pagination.PageSize},
+		{"markdown.wordsPerMinute", cfg.Markdown.WordsPerMinute},
+		{"markdown.summaryLength", cfg.Markdown.SummaryLength},
+		{"pluginConfig.atom.itemLimit", cfg.PluginConfig.Atom.ItemLimit},
+		{"pluginConfig.related.limit", cfg.PluginConfig.Related.Limit},
+	}
+
+	for _, field := range nonNegative {
+		if field.value < 0 {
+			messages = append(messages, fmt.Sprintf("%s: must not be negative, got %d", field.path, field.value))
+		}
+	}
+
+	for _, width := range cfg.Images.Widths {
+		if width <= 0 {
+			messages = append(messages, fmt.Sprintf("images.widths: must be positive, got %d", width))
+		}
+	}
+
+	if q := cfg.Images.Quality; q < 0 || q > 100 {
+		messages = append(messages, fmt.Sprintf("images.quality: must be between 1 and 100, got %d", q))
+	}
+
+	if p := cfg.PluginConfig.Sitemap.Priority; p < 0 || p > 1 {
+		messages = append(messages, fmt.Sprintf("pluginConfig.sitemap.priority: must be between 0 and 1, got %v", p))
+	}
+
+	for _, format := range cfg.Assets.Precompress {
+		if err := compress.Validate(format); err != nil {
+			messages = append(messages, fmt.Sprintf("assets.precompress: must be one of %s, got %q", strings.Join(compress.Formats, ", "), format))
+		}
+	}
+
+	messages = append(messages, outputFormatErrors(cfg)...)
+
+	for _, ext := range cfg.Markdown.FileExtensions {
+		if len(ext) < 2 || ext[0] != '.' || strings.ContainsAny(ext[1:], "./\\") {
+			messages = append(messages, fmt.Sprintf("markdown.fileExtensions: must be an extension like .md, got %q", ext))
+		}
+	}
+
+	switch cfg.Markdown.Shortcodes {
+	case "", shortcode.Before, shortcode.After:
+	default:
+		messages = append(messages, fmt.Sprintf("markdown.shortcodes: must be %s or %s, got %q", shortcode.Before, shortcode.After, cfg.Markdown.Shortcodes))
+	}
+
+	if len(messages) > 0 {
+		return &ValidationError{Messages: messages}
+	}
+
+	return nil
+}
+
+// outputFormatErrors returns a message for each output format without a
+// template or with an invalid extension or section.
+func outputFormatErrors(cfg Config) []string {
+	var (
+		messages []string
+		names    = make([]string, 0, len(cfg.OutputFormats))
+	)
+
+	for name := range cfg.OutputFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		format := cfg.OutputFormats[name]
+		if format == nil {
+			format = &model.OutputFormat{}
+		}
+
+		if format.Template == "" {
+			messages = append(messages, fmt.Sprintf("outputFormats.%s.template: missing required key", name))
+		}
+
+		switch ext := format.Extension; {
+		case ext == "":
+			messages = append(messages, fmt.Sprintf("outputFormats.%s.extension: missing required key", name))
+		case ext == "html" || strings.HasPrefix(ext, ".") || strings.HasSuffix(ext, ".") || strings.ContainsAny(ext, "/\\"):
+			messages = append(messages, fmt.Sprintf("outputFormats.%s.extension: must be an extension like txt or amp.html, got %q", name, ext))
+		}
+
+		for _, section := range format.Sections {
+			if !strings.HasPrefix(section, "/") {
+				messages = append(messages, fmt.Sprintf("outputFormats.%s.sections: must be a route like /blog, got %q", name, section))
+			}
+		}
+	}
+
+	return messages
+}
+
+// schema describes the known keys of a configuration section. Sections
+// backed by a map accept arbitrary keys, whose values are described by
+// the elem schema.
+type schema struct {
+	name   string
+	fields map[string]*schema
+	elem   *schema
+}
+
+// newSchema creates the schema for the given type from its exported
+// fields. Keys are compared in lowercase, since viper lowercases them.
+func newSchema(name string, t reflect.Type) *schema {
+	s := schema{name: name}
+
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		s.fields = make(map[string]*schema)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			s.fields[strings.ToLower(field.Name)] = newSchema(keyName(field.Name), field.Type)
+		}
+	case reflect.Map:
+		s.elem = newSchema("", t.Elem())
+	}
+
+	return &s
+}
+
+// unknownKeys returns a message for each key in the configuration that
+// doesn't correspond to a field of Config.
+func unknownKeys(cfg Config) []string {
+	var (
+		messages []string
+		root     = newSchema("", reflect.TypeOf(cfg))
+		reported = make(map[string]bool)
+	)
+
+	keys := append([]string(nil), cfg.keys...)
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var (
+			current = root
+			path    []string
+		)
+
+		for _, segment := range strings.Split(key, ".") {
+			// Custom plugins may have arbitrary settings.
+			if current.name == "pluginConfig" && current.fields[segment] == nil && containsFold(cfg.Plugins, segment) {
+				break
+			}
+
+			var next *schema
+
+			switch {
+			case current.fields != nil:
+				next = current.fields[segment]
+			case current.elem != nil:
+				elem := *current.elem
+				elem.name = segment
+				next = &elem
+			}
+
+			if next == nil {
+				unknown := strings.Join(append(path, segment), ".")
+				if !reported[unknown] {
+					messages = append(messages, fmt.Sprintf("%s: unknown key%s", unknown, suggestion(current, segment)))
+					reported[unknown] = true
+				}
+				break
+			}
+
+			// Leaf values like strings or lists don't have any fields, so
+			// that all keys below them are unknown.
+			path = append(path, next.name)
+			current = next
+		}
+	}
+
+	return messages
+}
+
+// suggestion returns a hint for the known key of the schema that is most
+// similar to an unknown key, or an empty string if none is similar.
+func suggestion(s *schema, unknown string) string {
+	var (
+		best     string
+		bestDist = 3
+	)
+
+	for key, field := range s.fields {
+		if dist := levenshtein(key, unknown); dist < bestDist || (dist == bestDist && field.name < best) {
+			best, bestDist = field.name, dist
+		}
+	}
+
+	if best == "" || bestDist > 2 {
+		return ""
+	}
+
+	return fmt.Sprintf(", did you mean %s?", best)
+}
+
+// keyName converts an exported field name like CleanURLs or TOC to the
+// configuration key like cleanURLs or toc.
+func keyName(field string) string {
+	runes := []rune(field)
+
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+
+	switch {
+	case upper == len(runes):
+		return strings.ToLower(field)
+	case upper > 1:
+		// The last upper-case letter begins the next word.
+		upper--
+	case upper == 0:
+		return field
+	}
+
+	return strings.ToLower(string(runes[:upper])) + string(runes[upper:])
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = prev[j] + 1
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if prev[j-1]+cost < current[j] {
+				current[j] = prev[j-1] + cost
+			}
+		}
+
+		prev = current
+	}
+
+	return prev[len(b)]
+}
+
+// containsFold reports whether values contains value, ignoring case.
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
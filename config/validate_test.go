@@ -0,0 +1,201 @@
+package config
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/verless/verless/test"
+)
+
+// TestValidate checks if Validate reports unknown keys, missing required
+// keys and invalid values along with their paths.
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		config           string
+		expectedMessages []string
+	}{
+		"valid config": {
+			config: `version: 1
+baseURL: https://example.com
+site:
+  meta:
+    title: Coffee Blog
+  nav:
+    items:
+      - label: Blog
+        target: /blog
+plugins:
+  - atom
+  - my-plugin
+pluginConfig:
+  atom:
+    itemLimit: 10
+  my-plugin:
+    anything: true
+types:
+  recipe:
+    template: recipe.html
+outputFormats:
+  amp:
+    template: page.amp.html
+    extension: amp.html
+  text:
+    template: page.txt
+    mediaType: text/plain
+    extension: txt
+    sections:
+      - /blog
+markdown:
+  toc:
+    maxLevel: 4
+build:
+  cleanURLs: false
+`,
+		},
+		"unknown keys": {
+			config: `version: 1
+site:
+  meta:
+    titel: Coffee Blog
+  colors: dark
+pluginConfig:
+  atom:
+    itemlimt: 10
+  my-plugin:
+    anything: true
+markdown:
+  tocs:
+    maxLevel: 4
+`,
+			expectedMessages: []string{
+				"markdown.tocs: unknown key, did you mean toc?",
+				"pluginConfig.atom.itemlimt: unknown key, did you mean itemLimit?",
+				"pluginConfig.my-plugin: unknown key",
+				"site.colors: unknown key",
+				"site.meta.titel: unknown key, did you mean title?",
+			},
+		},
+		"missing required keys": {
+			config: `site:
+  meta:
+    title: Coffee Blog
+plugins:
+  - sitemap
+`,
+			expectedMessages: []string{
+				"version: missing required key",
+				"baseURL: missing required key for the sitemap plugin",
+			},
+		},
+		"invalid values": {
+			config: `version: 1
+baseURL: example.com
+timezone: Europe/Coffee
+pagination:
+  pageSize: -1
+pluginConfig:
+  sitemap:
+    priority: 2
+markdown:
+  shortcodes: during
+  fileExtensions: [.md, markdown, .md/x]
+images:
+  widths: [480, 0]
+  quality: 101
+assets:
+  precompress: [gzip, brotli]
+`,
+			expectedMessages: []string{
+				`baseURL: "example.com" is not an absolute URL like https://example.com`,
+				`timezone: "Europe/Coffee" is not a time zone like Europe/Berlin`,
+				"pagination.pageSize: must not be negative, got -1",
+				"images.widths: must be positive, got 0",
+				"images.quality: must be between 1 and 100, got 101",
+				"pluginConfig.sitemap.priority: must be between 0 and 1, got 2",
+				`assets.precompress: must be one of gzip, got "brotli"`,
+				`markdown.fileExtensions: must be an extension like .md, got "markdown"`,
+				`markdown.fileExtensions: must be an extension like .md, got ".md/x"`,
+				`markdown.shortcodes: must be before or after, got "during"`,
+			},
+		},
+		"invalid taxonomies": {
+			config: `version: 1
+plugins:
+  - tags
+taxonomies:
+  - category
+  - Series
+  - category
+  - tag
+`,
+			expectedMessages: []string{
+				`taxonomies: "Series" is not a lowercase name like category`,
+				`taxonomies: "category" is listed more than once`,
+				`taxonomies: "tag" cannot be used together with the tags plugin`,
+			},
+		},
+		"invalid output formats": {
+			config: `version: 1
+outputFormats:
+  amp:
+    extension: .amp.html
+  html:
+    template: page.html
+    extension: html
+  text:
+    template: page.txt
+    sections:
+      - blog
+`,
+			expectedMessages: []string{
+				"outputFormats.amp.template: missing required key",
+				`outputFormats.amp.extension: must be an extension like txt or amp.html, got ".amp.html"`,
+				`outputFormats.html.extension: must be an extension like txt or amp.html, got "html"`,
+				"outputFormats.text.extension: missing required key",
+				`outputFormats.text.sections: must be a route like /blog, got "blog"`,
+			},
+		},
+		"unknown default language": {
+			config: `version: 1
+i18n:
+  defaultLanguage: FR
+  languages:
+    en:
+      name: English
+    de:
+      name: Deutsch
+`,
+			expectedMessages: []string{
+				`i18n.defaultLanguage: "fr" is not one of i18n.languages`,
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		dir, err := ioutil.TempDir("", "verless-config")
+		test.Ok(t, err)
+		test.Ok(t, ioutil.WriteFile(filepath.Join(dir, Filename+".yml"), []byte(testCase.config), 0644))
+
+		cfg, err := FromFile(dir, Filename)
+		_ = os.RemoveAll(dir)
+		test.Ok(t, err)
+
+		err = Validate(cfg)
+
+		if testCase.expectedMessages == nil {
+			test.Ok(t, err)
+			continue
+		}
+
+		test.ExpectedError(t, ErrInvalidConfig, err)
+
+		var validationErr *ValidationError
+		test.Assert(t, errors.As(err, &validationErr), "error should be a ValidationError")
+		test.Equals(t, testCase.expectedMessages, validationErr.Messages)
+	}
+}
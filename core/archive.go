@@ -0,0 +1,332 @@
+package core
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/fs"
+)
+
+var (
+	// ErrUnsupportedArchive states that an archive is neither a tar.gz
+	// nor a zip archive.
+	ErrUnsupportedArchive = errors.New("unsupported archive format, use tar.gz or zip")
+
+	// ErrIllegalArchivePath states that an archive entry would be
+	// extracted outside of the target directory.
+	ErrIllegalArchivePath = errors.New("archive entry points outside of the project")
+)
+
+const (
+	zipArchive   string = "zip"
+	tarGzArchive string = "tar.gz"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK")
+)
+
+// readArchive reads all entries of a tar.gz or zip archive and returns
+// the directories and files they would be extracted to inside path.
+// Only regular files and directories are considered, other entries like
+// symbolic links are ignored.
+func readArchive(path string, archive io.Reader) ([]string, map[string][]byte, error) {
+	reader := bufio.NewReader(archive)
+
+	magic, err := reader.Peek(2)
+	if err != nil {
+		return nil, nil, ErrUnsupportedArchive
+	}
+
+	var (
+		dirs  []string
+		seen  = make(map[string]bool)
+		files = make(map[string][]byte)
+	)
+
+	addDir := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	add := func(name string, isDir bool, content io.Reader) error {
+		entry, err := archivePath(path, name)
+		if err != nil {
+			return err
+		}
+
+		if isDir {
+			addDir(entry)
+			return nil
+		}
+
+		b, err := ioutil.ReadAll(content)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", name, err)
+		}
+
+		addDir(filepath.Dir(entry))
+		files[entry] = b
+
+		return nil
+	}
+
+	switch {
+	case bytes.Equal(magic, gzipMagic):
+		err = readTarGz(reader, add)
+	case bytes.Equal(magic, zipMagic):
+		err = readZip(reader, add)
+	default:
+		err = ErrUnsupportedArchive
+	}
+
+	return dirs, files, err
+}
+
+// readTarGz calls add for each directory and regular file in a tar.gz
+// archive.
+func readTarGz(archive io.Reader, add func(name string, isDir bool, content io.Reader) error) error {
+	gzipReader, err := gzip.NewReader(archive)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = add(header.Name, true, nil)
+		case tar.TypeReg:
+			err = add(header.Name, false, tarReader)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// readZip calls add for each directory and regular file in a zip
+// archive. Because zip archives require random access, the archive is
+// read into memory first.
+func readZip(archive io.Reader, add func(name string, isDir bool, content io.Reader) error) error {
+	b, err := ioutil.ReadAll(archive)
+	if err != nil {
+		return err
+	}
+
+	zipReader, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range zipReader.File {
+		mode := file.Mode()
+
+		if !mode.IsDir() && !mode.IsRegular() {
+			continue
+		}
+
+		if err := addZipFile(file, add); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addZipFile opens a file from a zip archive and passes it to add.
+func addZipFile(file *zip.File, add func(name string, isDir bool, content io.Reader) error) error {
+	if file.Mode().IsDir() {
+		return add(file.Name, true, nil)
+	}
+
+	content, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	return add(file.Name, false, content)
+}
+
+// archivePath returns the path an archive entry will be extracted to.
+// Entries with absolute paths or paths pointing to a parent directory
+// are rejected with ErrIllegalArchivePath.
+func archivePath(root, name string) (string, error) {
+	cleaned := path.Clean(strings.ReplaceAll(name, `\`, "/"))
+
+	if path.IsAbs(cleaned) || filepath.IsAbs(name) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%s: %w", name, ErrIllegalArchivePath)
+	}
+
+	return filepath.Join(root, filepath.FromSlash(cleaned)), nil
+}
+
+// archiveFormat returns the format of the archive to be written to the
+// given file based on its extension.
+func archiveFormat(file string) (string, error) {
+	lower := strings.ToLower(file)
+
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		return zipArchive, nil
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return tarGzArchive, nil
+	}
+
+	return "", fmt.Errorf("%s: %w", file, ErrUnsupportedArchive)
+}
+
+// writeArchive packs all files and directories inside dir into a zip or
+// tar.gz archive at the given file in dstFs, keeping their modes and
+// their paths relative to dir. The manifest isn't part of the website
+// and is therefore left out. All entries get the given modification
+// time, so that the archive only depends on the files' contents.
+func writeArchive(srcFs afero.Fs, dir string, dstFs afero.Fs, file string, modTime time.Time) error {
+	format, err := archiveFormat(file)
+	if err != nil {
+		return err
+	}
+
+	var (
+		buf    bytes.Buffer
+		entry  func(name string, info os.FileInfo, content []byte) error
+		finish func() error
+	)
+
+	switch format {
+	case zipArchive:
+		zipWriter := zip.NewWriter(&buf)
+		entry = func(name string, info os.FileInfo, content []byte) error {
+			return writeZipEntry(zipWriter, name, info, content, modTime)
+		}
+		finish = zipWriter.Close
+	default:
+		gzipWriter := gzip.NewWriter(&buf)
+		tarWriter := tar.NewWriter(gzipWriter)
+		entry = func(name string, info os.FileInfo, content []byte) error {
+			return writeTarEntry(tarWriter, name, info, content, modTime)
+		}
+		finish = func() error {
+			if err := tarWriter.Close(); err != nil {
+				return err
+			}
+			return gzipWriter.Close()
+		}
+	}
+
+	err = afero.Walk(srcFs, dir, func(current string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, current)
+		if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(rel)
+
+		if name == "." || name == outputManifest {
+			return nil
+		}
+
+		var content []byte
+
+		if !info.IsDir() {
+			if content, err = afero.ReadFile(srcFs, current); err != nil {
+				return err
+			}
+		}
+
+		return entry(name, info, content)
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := finish(); err != nil {
+		return err
+	}
+
+	if err := dstFs.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	return fs.WriteFileAtomic(dstFs, file, buf.Bytes(), 0644)
+}
+
+// writeZipEntry adds a file or directory to a zip archive.
+func writeZipEntry(zipWriter *zip.Writer, name string, info os.FileInfo, content []byte, modTime time.Time) error {
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return err
+	}
+
+	header.Name = name
+	header.Modified = modTime
+	header.Method = zip.Deflate
+
+	if info.IsDir() {
+		header.Name += "/"
+		header.Method = zip.Store
+	}
+
+	w, err := zipWriter.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(content)
+	return err
+}
+
+// writeTarEntry adds a file or directory to a tar archive.
+func writeTarEntry(tarWriter *tar.Writer, name string, info os.FileInfo, content []byte, modTime time.Time) error {
+	header, err := tar.FileInfoHeader(info, "")
+	if err != nil {
+		return err
+	}
+
+	header.Name = name
+	header.ModTime = modTime
+	if info.IsDir() {
+		header.Name += "/"
+	}
+
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return err
+	}
+
+	_, err = tarWriter.Write(content)
+	return err
+}
@@ -4,38 +4,47 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"os/exec"
+	"path"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 	"github.com/verless/verless/builder"
+	"github.com/verless/verless/compress"
 	"github.com/verless/verless/config"
 	"github.com/verless/verless/fs"
+	"github.com/verless/verless/images"
+	"github.com/verless/verless/minify"
 	"github.com/verless/verless/model"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/out/style"
 	"github.com/verless/verless/parser"
-	"github.com/verless/verless/plugin/atom"
-	"github.com/verless/verless/plugin/tags"
+	"github.com/verless/verless/plugin"
+	"github.com/verless/verless/shortcode"
+	"github.com/verless/verless/taxonomy"
 	"github.com/verless/verless/theme"
 	"github.com/verless/verless/writer"
 )
 
-const (
-	// parallelism specifies the number of parallel workers.
-	parallelism int = 4
-)
-
 var (
 	// ErrCannotOverwrite states that verless isn't allowed to delete or
 	// overwrite the output directory.
 	ErrCannotOverwrite = errors.New(`cannot overwrite the output directory.
-Consider using the --overwrite flag or enable build.overwrite in verless.yml`)
+Consider using the --force flag or enable build.overwrite in verless.yml`)
 
 	// ErrMissingVersionKey states that the top-level `version` key is
 	// empty or missing in verless.yml.
 	ErrMissingVersionKey = errors.New("missing `version` key in verless.yml")
+
+	// ErrDuplicateRoute states that several content files are rendered to
+	// the same page, so that all but one of them would be overwritten.
+	ErrDuplicateRoute = errors.New(`several content files have the same route.
+Consider renaming one of them or enable build.allowDuplicateRoutes in verless.yml`)
 )
 
 // Parser represents a parser that processes Markdown files and converts
@@ -59,26 +68,101 @@ type Writer interface {
 	Write(site model.Site) error
 }
 
-// Plugin represents a built-in verless plugin.
-type Plugin interface {
-	// ProcessPage will be invoked after parsing the page. Must be safe
-	// for concurrent usage.
-	ProcessPage(page *model.Page) error
-	// PreWrite will be invoked before writing the site.
-	PreWrite(site *model.Site) error
-	// PostWrite will be invoked after writing the site.
-	PostWrite() error
-}
+// Plugin represents a verless plugin. See plugin.Plugin.
+type Plugin = plugin.Plugin
 
 // BuildOptions represents options for running a verless build.
 type BuildOptions struct {
-	// OutputDir sets the output directory. If this field is empty, config.OutputDir
-	// will be used.
+	// OutputDir sets the output directory. If this field is empty, the
+	// output directory configured in the project is used.
 	OutputDir string
-	// Overwrite specifies that the output folder can be overwritten.
-	Overwrite bool
+	// ArchivePath writes the website into a zip or tar.gz archive at the
+	// given path instead of the output directory. The format is determined
+	// by the extension, which is either .zip, .tar.gz or .tgz.
+	ArchivePath string
+	// Force allows clearing an output directory that contains files not
+	// produced by verless. Output directories outside the project are
+	// never cleared, but Force allows writing into them.
+	Force bool
 	// RecompileTemplates forces a recompilation of all templates.
 	RecompileTemplates bool
+	// Parsers is the number of workers reading and parsing content files
+	// concurrently. If zero or negative, runtime.GOMAXPROCS is used.
+	Parsers int
+	// StrictFrontmatter fails the build if a content file lacks required
+	// front matter fields or its front matter can't be parsed instead of
+	// emitting a warning.
+	StrictFrontmatter bool
+	// StrictLinks fails the build if a rendered page contains an internal
+	// link to a missing file instead of emitting a warning.
+	StrictLinks bool
+	// IncludeDrafts includes pages marked as draft in the build. By
+	// default, drafts are excluded entirely.
+	IncludeDrafts bool
+	// IncludeFuture includes pages whose date is in the future. By default,
+	// those pages are excluded until their date has passed.
+	IncludeFuture bool
+	// Incremental only writes pages whose content files have changed
+	// since the previous build. All content files are still parsed, so
+	// that list pages are complete. If the project configuration, the
+	// theme, the output directory or one of the options affecting the
+	// output like Minify has changed, all pages are written.
+	Incremental bool
+	// NoCache disables the render cache of incremental builds, which
+	// stores the parsed Markdown files in the .verless directory of the
+	// project, so that unchanged files don't have to be converted again.
+	// The render cache is never used by dry runs and builds into other
+	// filesystems than the OS filesystem.
+	NoCache bool
+	// Minify minifies all rendered pages and all CSS and JavaScript files.
+	Minify bool
+	// Minifier is the minifier used if Minify is set. If it is nil, the
+	// default minifier is used.
+	Minifier minify.Minifier
+	// Compress writes gzip-compressed siblings of all text files in the
+	// output directory like assets.precompress. If the project configures
+	// assets.precompress, its formats are used instead.
+	Compress bool
+	// Env is the environment whose configuration file like
+	// verless.production.yml is merged into the project configuration.
+	// If it is empty, config.EnvVar is used.
+	Env string
+	// Clean removes the output directory before building like Clean, so
+	// that an incremental build renders all pages.
+	Clean bool
+	// DryRun processes all content files and reports their problems
+	// without writing the website or running any hooks.
+	DryRun bool
+	// Logger prints the progress of the build. Each processed content
+	// file and each written page is printed at debug level. If it is nil,
+	// the default logger of the out package is used.
+	Logger *out.Logger
+}
+
+// Warning represents a problem in a content file or a rendered page that
+// doesn't prevent the build from finishing.
+type Warning struct {
+	// File is the path of the content file inside the content directory.
+	File string
+	// MissingFields contains all required front matter fields that are
+	// missing in the file.
+	MissingFields []string
+	// Page is the path of the rendered page inside the output directory,
+	// like /blog/index.html.
+	Page string
+	// BrokenLinks contains all internal links in the page whose targets
+	// don't exist.
+	BrokenLinks []string
+	// FrontMatterError describes why the front matter of the file can't be
+	// parsed, including the line of the problem. The file is skipped.
+	FrontMatterError string
+	// RootFile is the path of a file inside the root directory, like
+	// /robots.txt. It hasn't been copied because the build has generated
+	// a file with the same path.
+	RootFile string
+	// DuplicateFiles contains the content files that are all rendered to
+	// Page. Only one of them is available in the website.
+	DuplicateFiles []string
 }
 
 // Build provides methods for building a static site.
@@ -90,11 +174,76 @@ type Build struct {
 	Plugins []Plugin
 	Types   map[string]*model.Type
 	Options BuildOptions
+	// HTMLParser parses HTML content files, whose content is used as-is
+	// instead of being converted.
+	HTMLParser Parser
+	// Now is the build time. Pages dated after Now are excluded unless
+	// Options.IncludeFuture is set. If SOURCE_DATE_EPOCH is set, NewBuild
+	// sets Now to Timestamp.
+	Now time.Time
+	// Timestamp is the time recorded in generated files, e.g. returned by
+	// the now template function and used as the modification time of all
+	// archive entries. NewBuild sets it to SOURCE_DATE_EPOCH if it is set,
+	// and to the latest modification time of the configuration and the
+	// content files otherwise, so that identical inputs produce identical
+	// output.
+	Timestamp time.Time
+
+	targetFs    afero.Fs
+	archiveFs   afero.Fs
+	preBuild    []string
+	postBuild   []string
+	contentDir  string
+	isContent   func(file string) bool
+	dataDir     string
+	rootDir     string
+	themesDir   string
+	theme       string
+	i18n        config.I18n
+	outputDir   string
+	keepFiles   []string
+	precompress []string
+	cleanURLs   bool
+	// allowDuplicates reports duplicate routes as a warning instead of
+	// failing the build.
+	allowDuplicates bool
+	// slug configures the conversion of the Slug front matter field and,
+	// if slugFilenames is set, of the route segments of content files.
+	slug          model.SlugOptions
+	slugFilenames bool
+	// routes maps the output files of all pages to the content files
+	// rendered to them, so that duplicate routes can be detected.
+	routes      map[string][]string
+	basePath    string
+	baseURL     string
+	incremental *incrementalBuild
+	warnings    []Warning
+	stats       BuildStats
+	bundles     []*pageBundle
+	mutex       sync.Mutex
+
+	shortcodes *shortcode.Shortcodes
+	// shortcodesAfter inserts the output of shortcodes into the converted
+	// HTML instead of rendering them before converting the Markdown.
+	shortcodesAfter bool
+	// outputFormats contains the additional formats pages are rendered
+	// in, and formatNames contains their names in lexical order.
+	outputFormats map[string]*model.OutputFormat
+	formatNames   []string
+	// renderCache stores the parsed Markdown files of incremental builds
+	// unless the cache has been disabled using Options.NoCache.
+	renderCache *renderCache
+	// ignoreFilter doesn't let pass content files matched by the ignore
+	// files inside the content directory.
+	ignoreFilter func(file string) bool
 }
 
-// New initializes a new Build instance.
+// NewBuild initializes a new Build instance for the project at the given
+// path. The project itself is read from disk, but the website is written
+// into targetFs only, so that a build can target an in-memory filesystem
+// like afero.MemMapFs.
 func NewBuild(targetFs afero.Fs, path string, options BuildOptions) (*Build, error) {
-	cfg, err := config.FromFile(path, config.Filename)
+	cfg, err := config.FromFileEnv(path, config.Filename, options.Env)
 	if err != nil {
 		return nil, err
 	}
@@ -103,10 +252,43 @@ func NewBuild(targetFs afero.Fs, path string, options BuildOptions) (*Build, err
 		return nil, ErrMissingVersionKey
 	}
 
-	outputDir := outputDir(path, &options)
+	if err := config.Validate(cfg); err != nil {
+		return nil, err
+	}
+
+	// The website is written into an in-memory filesystem and is packed
+	// into the archive afterwards, so that the output directory remains
+	// untouched.
+	archiveFs := targetFs
+	if options.ArchivePath != "" {
+		if _, err := archiveFormat(options.ArchivePath); err != nil {
+			return nil, err
+		}
+		targetFs = afero.NewMemMapFs()
+	}
+
+	outputDir := outputDir(path, &cfg, &options)
 
-	if !fs.IsSafeToRemove(targetFs, outputDir, options.Overwrite || cfg.Build.Overwrite) {
-		return nil, ErrCannotOverwrite
+	clearOutputDir, err := checkOutputDir(targetFs, path, outputDir, options.Force || cfg.Build.Overwrite, cfg.Build.Keep)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.Clean && !clearOutputDir {
+		return nil, fmt.Errorf("%s is not inside the project directory: %w", outputDir, ErrCannotOverwrite)
+	}
+
+	if cfg.Theme == "" {
+		cfg.Theme = theme.Default
+	}
+
+	if err := theme.Validate(cfg.ThemesPath(path), cfg.Theme); err != nil {
+		return nil, err
+	}
+
+	themeCfg, err := theme.GetConfig(cfg.ThemesPath(path), cfg.Theme)
+	if err != nil {
+		return nil, err
 	}
 
 	writerCtx := writer.Context{
@@ -114,39 +296,171 @@ func NewBuild(targetFs afero.Fs, path string, options BuildOptions) (*Build, err
 		Path:               path,
 		OutputDir:          outputDir,
 		Theme:              cfg.Theme,
+		ThemesDir:          cfg.ThemesPath(path),
 		RecompileTemplates: options.RecompileTemplates,
+		PageSize:           cfg.Pagination.PageSize,
+		CleanURLs:          cfg.Build.CleanURLs,
+		Fingerprint:        cfg.Assets.Fingerprint,
+		Bundle:             bundleConfig(cfg.Assets.Bundle, themeCfg.Bundle),
+		SCSS:               themeCfg.SCSS.Enabled,
+		BaseURL:            cfg.BaseURL,
+		KeepOutputDir:      !clearOutputDir,
+		KeepFiles:          cfg.Build.Keep,
+		DiagramScript:      cfg.Markdown.Diagrams.Script,
+		OutputFormats:      cfg.OutputFormats,
+		Logger:             options.Logger,
+	}
+
+	writerCtx.Images = images.New(images.Options{
+		Path:     path,
+		CacheDir: filepath.Join(path, cacheDir, imagesCacheDir),
+		Widths:   cfg.Images.Widths,
+		Quality:  cfg.Images.Quality,
+		Sizes:    cfg.Images.Sizes,
+		BasePath: model.BasePath(cfg.BaseURL),
+	})
+
+	if options.Minify {
+		writerCtx.Minifier = options.Minifier
+		if writerCtx.Minifier == nil {
+			writerCtx.Minifier = minify.New()
+		}
+	}
+
+	markdown, html, err := newParsers(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamp, fromEnv, err := buildTimestamp(path, cfg.ContentPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if fromEnv {
+		now = timestamp
 	}
 
 	b := Build{
-		Path:    path,
-		Parser:  parser.NewMarkdown(),
-		Builder: builder.New(&cfg),
-		Writer:  writer.New(writerCtx),
-		Types:   cfg.Types,
-		Options: options,
+		Path:        path,
+		Parser:      markdown,
+		Builder:     builder.New(&cfg),
+		Types:       cfg.Types,
+		Options:     options,
+		HTMLParser:  html,
+		Now:         now,
+		Timestamp:   timestamp,
+		targetFs:    targetFs,
+		archiveFs:   archiveFs,
+		preBuild:    cfg.Hooks.PreBuild,
+		postBuild:   cfg.Hooks.PostBuild,
+		contentDir:  cfg.ContentPath(path),
+		isContent:   fs.Renderable(cfg.RenderableExtensions()...),
+		dataDir:     cfg.DataPath(path),
+		rootDir:     cfg.RootPath(path),
+		themesDir:   cfg.ThemesPath(path),
+		theme:       cfg.Theme,
+		i18n:        cfg.I18n,
+		outputDir:   outputDir,
+		keepFiles:   cfg.Build.Keep,
+		precompress: cfg.Assets.Precompress,
+		cleanURLs:   cfg.Build.CleanURLs,
+		slug:        slugOptions(&cfg),
+		routes:      make(map[string][]string),
+		basePath:    model.BasePath(cfg.BaseURL),
+		baseURL:     strings.TrimSuffix(cfg.BaseURL, "/"),
+	}
+
+	if b.ignoreFilter, err = fs.Ignore(afero.NewOsFs(), b.contentDir); err != nil {
+		return nil, err
 	}
 
-	plugins := loadPlugins(&cfg, targetFs, outputDir)
+	if options.Compress && len(b.precompress) == 0 {
+		b.precompress = []string{compress.Gzip}
+	}
 
-	for _, key := range cfg.Plugins {
-		if _, exists := plugins[key]; !exists {
-			return nil, fmt.Errorf("plugin %s not found", key)
+	shortcodes, err := theme.Shortcodes(cfg.ThemesPath(path), cfg.Theme)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.shortcodes, err = shortcode.New(shortcodes, writerCtx.Images.Funcs()); err != nil {
+		return nil, err
+	}
+	b.shortcodesAfter = cfg.Markdown.Shortcodes == shortcode.After
+	b.allowDuplicates = cfg.Build.AllowDuplicateRoutes
+	b.slugFilenames = cfg.Slug.Filenames
+
+	if err := b.setOutputFormats(cfg.OutputFormats); err != nil {
+		return nil, err
+	}
+
+	// The render cache is stored inside the project on disk, so builds
+	// that don't write onto the disk mustn't leave cache files behind.
+	_, onDisk := targetFs.(*afero.OsFs)
+
+	if options.Incremental && !options.NoCache && !options.DryRun && onDisk {
+		if b.renderCache, err = newRenderCache(path, markdownOptions(&cfg)); err != nil {
+			return nil, err
 		}
-		b.Plugins = append(b.Plugins, plugins[key]())
 	}
 
-	for _, beforeHook := range cfg.Build.Before {
-		cmdParts := strings.Split(beforeHook, " ")
-		cmd := exec.Command(cmdParts[0], cmdParts[1:]...)
-		cmd.Dir = path
-		cmd.Stderr = os.Stderr
-		cmd.Stdout = os.Stdout
-		if err := cmd.Run(); err != nil {
+	if options.Incremental {
+		if b.incremental, err = newIncrementalBuild(targetFs, path, &cfg, outputDir, &options); err != nil {
 			return nil, err
 		}
+		writerCtx.KeepOutputDir = true
+		writerCtx.SkipPage = b.incremental.isUnchanged
+	}
+
+	writerCtx.Now = func() time.Time {
+		return b.Timestamp
+	}
+	writerCtx.PageWritten = b.countRenderedPage
+
+	if cfg.Timezone != "" {
+		if writerCtx.Location, err = time.LoadLocation(cfg.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	b.Writer = writer.New(writerCtx)
+
+	// Taxonomies process pages and register their terms like plugins, so
+	// that they're available to plugins in PreWrite.
+	for _, name := range cfg.Taxonomies {
+		b.Plugins = append(b.Plugins, taxonomy.New(taxonomy.Options{
+			Name:          name,
+			GeneratePages: true,
+			CleanURLs:     cfg.Build.CleanURLs,
+		}))
+	}
+
+	for _, key := range cfg.Plugins {
+		p, err := plugin.New(key, plugin.Config{
+			Project:   &cfg,
+			Settings:  cfg.PluginSettings(key),
+			Fs:        targetFs,
+			OutputDir: outputDir,
+			Preview:   options.IncludeDrafts || options.IncludeFuture,
+			Timestamp: timestamp,
+		})
+		if err != nil {
+			return nil, err
+		}
+		b.Plugins = append(b.Plugins, p)
+	}
+
+	if options.DryRun {
+		return &b, nil
+	}
+
+	if err := runHooks(path, cfg.Build.Before); err != nil {
+		return nil, err
 	}
 
-	if err := theme.RunBeforeHooks(path, cfg.Theme); err != nil {
+	if err := theme.RunBeforeHooks(cfg.ThemesPath(path), cfg.Theme); err != nil {
 		return nil, err
 	}
 
@@ -156,36 +470,67 @@ func NewBuild(targetFs afero.Fs, path string, options BuildOptions) (*Build, err
 // Run executes the build using the provided build context.
 //
 // The current build implementation runs the following steps:
-//	1. Read all files in the content directory and send them through a channel.
-//	2. Spawn workers reading from that channel.
-//	3. Process each received file:
-//		3.1. Read the file as a []byte
-//		3.2. Parse the []byte and convert it to a model.Page.
-//		3.3. Register the page in the builder's site model.
-//		3.4. Let each plugin process the page.
-//	4. Get the site model from the builder and render it as a website.
-//	5. Let each plugin finish its work, e.g. by writing a file.
+//  1. Read all files in the content directory and send them through a channel.
+//  2. Spawn Options.Parsers workers reading from that channel.
+//  3. Process each received file:
+//     3.1. Read the file as a []byte
+//     3.2. Parse the []byte and convert it to a model.Page.
+//     3.3. Let each plugin process the page.
+//     3.4. Register the page in the builder's site model.
+//  4. Get the site model from the builder and render it as a website.
+//  5. Let each plugin finish its work, e.g. by writing a file, and record
+//     all files of the output directory in its manifest.
+//  6. Check the internal links of all rendered pages.
+//  7. Pack the output directory into the archive if Options.ArchivePath
+//     is set.
+//
+// The preBuild hooks from the project configuration are executed before
+// these steps, and the postBuild hooks after a successful build. With
+// Options.DryRun, Run stops after the fourth step has built the site
+// model and doesn't run any hooks. With Options.Clean, the output
+// directory is removed before running the preBuild hooks.
+//
+// Plugins are invoked in the order they've been enabled in the project
+// configuration.
 func (b *Build) Run() error {
 	var (
 		files           = make(chan string)
+		streamErr       = make(chan error, 1)
 		errorCh         = make(chan error)
 		collectedErrors = make([]error, 0)
-		contentDir      = filepath.Join(b.Path, config.ContentDir)
+		contentDir      = b.contentDir
+		start           = time.Now()
 	)
 
-	go func() {
-		if err := fs.StreamFiles(contentDir, files, fs.MarkdownOnly, fs.NoUnderscores); err != nil {
-			errorCh <- err
+	if b.Options.Clean && !b.Options.DryRun {
+		if err := fs.RmdirExcept(b.targetFs, b.outputDir, b.keepFiles...); err != nil {
+			return err
+		}
+	}
+
+	if !b.Options.DryRun {
+		if err := runHooks(b.Path, b.preBuild); err != nil {
+			return err
 		}
+	}
+
+	go func() {
+		streamErr <- fs.StreamFilesOS(contentDir, files, b.isContent, fs.NoUnderscores, b.ignoreFilter)
 	}()
 
+	parsers := b.Options.Parsers
+	if parsers <= 0 {
+		parsers = runtime.GOMAXPROCS(0)
+	}
+
 	wg := sync.WaitGroup{}
-	wg.Add(parallelism)
+	wg.Add(parsers)
 
-	for i := 0; i < parallelism; i++ {
+	for i := 0; i < parsers; i++ {
 		go func() {
 			// Process the files received via the files channel.
 			for file := range files {
+				b.logger().Debug(style.None, "processing %s", file)
 				if err := b.processFile(contentDir, file); err != nil {
 					errorCh <- err
 				}
@@ -204,38 +549,176 @@ func (b *Build) Run() error {
 	}()
 
 	for err := range errorCh {
-		if errors.Is(err, fs.ErrStreaming) {
-			return err
-		}
 		collectedErrors = append(collectedErrors, err)
 	}
 
+	// The files channel has been closed at this point, so the streaming
+	// result is available. A streaming error takes precedence.
+	if err := <-streamErr; err != nil {
+		return err
+	}
+
 	if len(collectedErrors) > 0 {
+		// The files are processed concurrently, so the errors are sorted to
+		// list them in a stable order.
+		sort.Slice(collectedErrors, func(i, j int) bool {
+			return collectedErrors[i].Error() < collectedErrors[j].Error()
+		})
 		return fmt.Errorf("errors while processing files: %v", collectedErrors)
 	}
 
+	if b.renderCache != nil {
+		if err := b.renderCache.prune(); err != nil {
+			return err
+		}
+	}
+
+	if err := b.checkRoutes(); err != nil {
+		return err
+	}
+
 	site, err := b.Builder.Dispatch()
 	if err != nil {
 		return err
 	}
 
+	if site.Data, err = loadData(b.dataDir); err != nil {
+		return err
+	}
+
+	if b.Options.DryRun {
+		return b.finishStats(start)
+	}
+
+	if b.incremental != nil {
+		if err := b.removeStalePages(); err != nil {
+			return err
+		}
+	}
+
 	for _, plugin := range b.Plugins {
 		if err := plugin.PreWrite(&site); err != nil {
 			return err
 		}
 	}
 
-	if err := b.Writer.Write(site); err != nil {
+	if err := b.write(site, start); err != nil {
 		return err
 	}
 
-	for _, plugin := range b.Plugins {
-		if err := plugin.PostWrite(); err != nil {
+	if err := b.checkLinks(); err != nil {
+		return err
+	}
+
+	target := b.outputDir
+
+	if b.Options.ArchivePath != "" {
+		if err := writeArchive(b.targetFs, b.outputDir, b.archiveFs, b.Options.ArchivePath, b.Timestamp); err != nil {
 			return err
 		}
+		target = b.Options.ArchivePath
 	}
 
-	return nil
+	if b.incremental != nil {
+		if err := b.incremental.save(b.Path); err != nil {
+			return err
+		}
+	}
+
+	if err := b.finishStats(start); err != nil {
+		return err
+	}
+
+	b.logger().Info(style.HeavyCheckMark, "website written to %s", target)
+
+	return runHooks(b.Path, b.postBuild)
+}
+
+// write renders the site model, runs the PostWrite hooks of all plugins,
+// copies the files of the root directory into the output directory and
+// precompresses the output.
+// Afterwards, all files in the output directory are recorded in the
+// manifest, even if writing failed. Otherwise, the next build would
+// refuse to clear a partially written output directory.
+func (b *Build) write(site model.Site, start time.Time) error {
+	err := b.Writer.Write(site)
+
+	// The writer clears the output directory, so the resources of page
+	// bundles can only be copied afterwards.
+	if err == nil {
+		err = b.copyBundleResources()
+	}
+
+	if err == nil {
+		for _, plugin := range b.Plugins {
+			if err = plugin.PostWrite(); err != nil {
+				break
+			}
+		}
+	}
+
+	// Generated files take precedence, so that the root files are copied
+	// after the plugins have written their files.
+	if err == nil {
+		err = b.copyRootFiles(start)
+	}
+
+	if err == nil {
+		err = b.compressOutput()
+	}
+
+	if manifestErr := writeManifest(b.targetFs, b.outputDir); err == nil {
+		err = manifestErr
+	}
+
+	return err
+}
+
+// newParsers creates the parsers for the Markdown and the HTML content
+// files of a project with the given configuration.
+func newParsers(cfg *config.Config) (Parser, Parser, error) {
+	markdown, err := parser.NewMarkdown(markdownOptions(cfg))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	html, err := parser.NewHTML(parser.Options{
+		WordsPerMinute: cfg.Markdown.WordsPerMinute,
+		SummaryLength:  cfg.Markdown.SummaryLength,
+		Taxonomies:     cfg.Taxonomies,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return markdown, html, nil
+}
+
+// markdownOptions returns the options of the Markdown parser for the
+// given configuration.
+func markdownOptions(cfg *config.Config) parser.Options {
+	return parser.Options{
+		Highlight:      parser.HighlightOptions(cfg.Markdown.Highlight),
+		Extensions:     cfg.Markdown.Extensions,
+		TOC:            parser.TOCOptions(cfg.Markdown.TOC),
+		WordsPerMinute: cfg.Markdown.WordsPerMinute,
+		SummaryLength:  cfg.Markdown.SummaryLength,
+		Taxonomies:     cfg.Taxonomies,
+		Emoji:          cfg.Markdown.Emoji,
+		Smartypants:    cfg.Markdown.Smartypants,
+		Diagrams: parser.DiagramOptions{
+			Languages: cfg.Markdown.Diagrams.Languages,
+			Element:   cfg.Markdown.Diagrams.Element,
+		},
+	}
+}
+
+// slugOptions returns the slug options of the given configuration.
+func slugOptions(cfg *config.Config) model.SlugOptions {
+	return model.SlugOptions{
+		Lowercase:     cfg.Slug.Lowercase,
+		Transliterate: cfg.Slug.Transliterate,
+	}
 }
 
 func (b *Build) processFile(contentDir, file string) error {
@@ -244,27 +727,209 @@ func (b *Build) processFile(contentDir, file string) error {
 		return err
 	}
 
-	page, err := b.Parser.ParsePage(src)
+	content, outputs, err := b.renderShortcodes(src)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	var page model.Page
+
+	switch {
+	case fs.HTMLOnly(file):
+		page, err = b.HTMLParser.ParsePage(content)
+	case b.renderCache != nil:
+		page, err = b.renderCache.parse(b.Parser, content)
+	default:
+		page, err = b.Parser.ParsePage(content)
+	}
+
+	if err != nil {
+		// Without strict front matter, files with invalid front matter are
+		// skipped so that all other pages can be built.
+		if errors.Is(err, parser.ErrInvalidFrontMatter) && !b.Options.StrictFrontmatter {
+			b.addWarning(Warning{File: file, FrontMatterError: err.Error()})
+			return nil
+		}
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	if len(outputs) > 0 {
+		page.Content = shortcode.Restore(page.Content, outputs)
+		page.Summary = shortcode.Restore(page.Summary, outputs)
+	}
+
+	// Drafts and scheduled pages are skipped before registering the page,
+	// so that they don't appear in any list page or plugin output.
+	if page.Draft && !b.Options.IncludeDrafts {
+		b.countSkippedPage()
+		return nil
+	}
+
+	if page.Date.After(b.Now) && !b.Options.IncludeFuture {
+		b.countSkippedPage()
+		return nil
+	}
+
+	if missingFields := page.MissingFields(); len(missingFields) > 0 {
+		if b.Options.StrictFrontmatter {
+			return fmt.Errorf("%s: missing front matter fields: %s", file, strings.Join(missingFields, ", "))
+		}
+		b.addWarning(Warning{File: file, MissingFields: missingFields})
+	}
+
+	bundle, err := b.routePage(contentDir, file, &page)
 	if err != nil {
 		return err
 	}
 
-	// A page like /blog/coffee/making-espresso.md will have /blog/coffee as
-	// route and making-espresso as ID.
-	page.Route = filepath.ToSlash(filepath.Dir(file))
-	page.ID = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
-	page.Href = filepath.Join(page.Route, page.ID)
+	if bundle != nil {
+		bundle.outputDir = path.Join(page.Route, page.ID)
+		page.Content = rewriteBundleRefs(page.Content, bundle.outputDir)
+		page.Summary = rewriteBundleRefs(page.Summary, bundle.outputDir)
+		b.addBundle(bundle)
+	}
+
+	page.Href = model.PageHref(page.Route, page.ID, b.cleanURLs)
+
+	// A custom list page is rendered as the index of its directory. Its
+	// canonical URL depends on the pagination, so the writer derives it.
+	if page.IsCustomListPage() && !page.Hidden {
+		page.Href = model.ListPageHref(page.Route, b.cleanURLs)
+	} else if page.Canonical == "" && b.baseURL != "" {
+		page.Canonical = b.baseURL + page.Href
+	}
+
+	info, err := os.Stat(filepath.Join(contentDir, file))
+	if err != nil {
+		return err
+	}
+	page.Modified = info.ModTime()
+
+	if b.incremental != nil {
+		b.incremental.track(filepath.ToSlash(file), page.Href, src, page.Modified)
+	}
 
 	if err := b.setPageType(&page); err != nil {
 		return err
 	}
 
+	if err := b.setPageTemplate(file, &page); err != nil {
+		return err
+	}
+
+	b.setAlternates(&page)
+
+	// Plugins process the page before it is registered, so that changes
+	// made by a plugin are part of the site model.
+	for _, p := range b.Plugins {
+		if err := p.ProcessPage(&page); err != nil {
+			return err
+		}
+	}
+
 	if err := b.Builder.RegisterPage(page); err != nil {
 		return err
 	}
 
-	for _, plugin := range b.Plugins {
-		if err := plugin.ProcessPage(&page); err != nil {
+	b.addRoute(writer.OutputFile(page.Href, b.cleanURLs), file)
+
+	for _, alternate := range page.Alternates {
+		b.addRoute(alternate.Href, file)
+	}
+
+	return nil
+}
+
+// addRoute records that the given content file is rendered to the given
+// output file. Safe for concurrent usage.
+func (b *Build) addRoute(outputFile, file string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.routes[outputFile] = append(b.routes[outputFile], file)
+}
+
+// checkRoutes returns an error listing all output files that several
+// content files are rendered to. If duplicate routes are allowed, they are
+// recorded as warnings instead, and the page written last wins.
+func (b *Build) checkRoutes() error {
+	var duplicates []string
+
+	for outputFile, files := range b.routes {
+		if len(files) < 2 {
+			continue
+		}
+
+		sort.Strings(files)
+
+		if b.allowDuplicates {
+			b.addWarning(Warning{Page: outputFile, DuplicateFiles: files})
+			continue
+		}
+
+		duplicates = append(duplicates, fmt.Sprintf("%s is rendered from %s", outputFile, strings.Join(files, ", ")))
+	}
+
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	sort.Strings(duplicates)
+
+	return fmt.Errorf("%w\n%s", ErrDuplicateRoute, strings.Join(duplicates, "\n"))
+}
+
+// Warnings returns all warnings collected during the build, ordered by
+// the content file or page they refer to.
+func (b *Build) Warnings() []Warning {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	warnings := make([]Warning, len(b.warnings))
+	copy(warnings, b.warnings)
+
+	sort.Slice(warnings, func(i, j int) bool {
+		if warnings[i].File != warnings[j].File {
+			return warnings[i].File < warnings[j].File
+		}
+		if warnings[i].Page != warnings[j].Page {
+			return warnings[i].Page < warnings[j].Page
+		}
+		return warnings[i].RootFile < warnings[j].RootFile
+	})
+
+	return warnings
+}
+
+// logger returns the logger for printing the build progress.
+func (b *Build) logger() *out.Logger {
+	return loggerOrDefault(b.Options.Logger)
+}
+
+// loggerOrDefault returns the given logger, or the default logger of the
+// out package if it is nil.
+func loggerOrDefault(logger *out.Logger) *out.Logger {
+	if logger == nil {
+		return out.Default()
+	}
+	return logger
+}
+
+// addWarning records a warning. Safe for concurrent usage.
+func (b *Build) addWarning(warning Warning) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.warnings = append(b.warnings, warning)
+}
+
+// removeStalePages removes the rendered pages of all content files that
+// have been removed since the previous build.
+func (b *Build) removeStalePages() error {
+	for _, href := range b.incremental.removed() {
+		file := filepath.Join(b.outputDir, filepath.FromSlash(writer.OutputFile(href, b.cleanURLs)))
+
+		if err := b.targetFs.Remove(file); err != nil && !os.IsNotExist(err) {
 			return err
 		}
 	}
@@ -290,22 +955,186 @@ func (b *Build) setPageType(page *model.Page) error {
 	return nil
 }
 
-func outputDir(path string, options *BuildOptions) string {
+// renderShortcodes replaces the shortcodes in the contents of a content
+// file. If shortcodes are rendered after converting the Markdown content,
+// they're replaced with placeholders, and their outputs are returned so
+// that they can be inserted into the converted HTML.
+func (b *Build) renderShortcodes(src []byte) ([]byte, []string, error) {
+	if b.shortcodesAfter {
+		return b.shortcodes.Extract(src)
+	}
+
+	content, err := b.shortcodes.Render(src)
+	return content, nil, err
+}
+
+// routePage sets the route and the ID of the page read from the given
+// content file. If the file is the index file of a page bundle, the bundle
+// is returned.
+func (b *Build) routePage(contentDir, file string, page *model.Page) (*pageBundle, error) {
+	// A page like /blog/coffee/making-espresso.md will have /blog/coffee as
+	// route and making-espresso as ID.
+	page.Route = filepath.ToSlash(filepath.Dir(file))
+	page.ID = strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+	bundle, err := findBundle(contentDir, file, b.isContent, b.ignoreFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	// The index.md file of a page bundle like /blog/coffee/index.md isn't
+	// a custom list page but the page /blog/coffee.
+	if bundle != nil {
+		page.Route, page.ID = path.Dir(page.Route), path.Base(page.Route)
+	}
+
+	// The route is slugified before the language prefix is added, and the
+	// ID afterwards, since its suffix like .de is the language code.
+	if b.slugFilenames {
+		page.Route = model.SlugifyRoute(page.Route, b.slug)
+	}
+
+	if err := b.setPageLanguage(file, page); err != nil {
+		return nil, err
+	}
+
+	if b.slugFilenames && !page.IsCustomListPage() {
+		if id := model.Slugify(page.ID, b.slug); id != "" {
+			page.ID = id
+		}
+	}
+
+	// The slug only replaces the ID of pages, since the route of a custom
+	// list page is the route of all pages in its directory.
+	if page.Slug != "" && !page.IsCustomListPage() {
+		if page.ID = model.Slugify(page.Slug, b.slug); page.ID == "" || page.IsCustomListPage() {
+			return nil, fmt.Errorf("%s: invalid slug %q: must contain letters or digits and must not be index", file, page.Slug)
+		}
+	}
+
+	return bundle, nil
+}
+
+// setPageLanguage determines the language of a page on a multilingual
+// site and moves the page to the route of that language, like /de/about
+// for about.de.md. The language is read from the front matter or from a
+// filename suffix, and defaults to the default language.
+func (b *Build) setPageLanguage(file string, page *model.Page) error {
+	if !b.i18n.Enabled() {
+		return nil
+	}
+
+	if ext := path.Ext(page.ID); ext != "" {
+		code := strings.ToLower(ext[1:])
+
+		if _, exists := b.i18n.Languages[code]; exists {
+			page.ID = strings.TrimSuffix(page.ID, ext)
+			if page.Language == "" {
+				page.Language = code
+			}
+		}
+	}
+
+	if page.Language == "" {
+		page.Language = b.i18n.DefaultLanguage
+	}
+
+	if _, exists := b.i18n.Languages[page.Language]; !exists {
+		return fmt.Errorf("%s: language %s has not been declared", file, page.Language)
+	}
+
+	page.Route = path.Join(b.i18n.Route(page.Language), page.Route)
+
+	return nil
+}
+
+// setPageTemplate completes the name of the template selected in the
+// front matter of a page, like landing for landing.html, and makes sure
+// that the theme provides that template.
+func (b *Build) setPageTemplate(file string, page *model.Page) error {
+	if page.Template == "" {
+		return nil
+	}
+
+	if filepath.Ext(page.Template) == "" {
+		page.Template += ".html"
+	}
+
+	if _, err := theme.ResolveTemplate(b.themesDir, b.theme, page.Template); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	return nil
+}
+
+// setOutputFormats stores the output formats of the project and makes sure
+// that their templates exist.
+func (b *Build) setOutputFormats(formats map[string]*model.OutputFormat) error {
+	b.outputFormats = formats
+	b.formatNames = make([]string, 0, len(formats))
+
+	for name := range formats {
+		b.formatNames = append(b.formatNames, name)
+	}
+	sort.Strings(b.formatNames)
+
+	for _, name := range b.formatNames {
+		if _, err := theme.ResolveTemplate(b.themesDir, b.theme, formats[name].Template); err != nil {
+			return fmt.Errorf("output format %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// setAlternates adds the renderings of the page in all output formats that
+// include its route. Custom list pages are rendered like list pages and
+// therefore don't have any alternates.
+func (b *Build) setAlternates(page *model.Page) {
+	if page.IsCustomListPage() {
+		return
+	}
+
+	for _, name := range b.formatNames {
+		format := b.outputFormats[name]
+		if !format.Includes(page.Route) {
+			continue
+		}
+
+		mediaType := format.MediaType
+		if mediaType == "" {
+			mediaType = model.DefaultMediaType
+		}
+
+		page.Alternates = append(page.Alternates, model.Alternate{
+			Name:      name,
+			MediaType: mediaType,
+			Href:      model.AlternateHref(page.Href, format.Extension, b.cleanURLs),
+		})
+	}
+}
+
+func outputDir(path string, cfg *config.Config, options *BuildOptions) string {
 	if options.OutputDir != "" {
 		return options.OutputDir
 	}
 
-	return filepath.Join(path, config.OutputDir)
+	return cfg.OutputPath(path)
 }
 
-// loadPlugins returns a map of all available plugins. Each entry
-// is a function that returns a fully initialized plugin instance.
-func loadPlugins(cfg *config.Config, fs afero.Fs, outputDir string) map[string]func() Plugin {
+// bundleConfig merges the bundling configuration of the project into the
+// configuration of the theme. Bundling is enabled if either of them
+// enables it, and the file orders of the project take precedence.
+func bundleConfig(project, themeBundle theme.Bundle) theme.Bundle {
+	bundle := themeBundle
+	bundle.Enabled = project.Enabled || themeBundle.Enabled
 
-	plugins := map[string]func() Plugin{
-		"atom": func() Plugin { return atom.New(&cfg.Site.Meta, fs, outputDir) },
-		"tags": func() Plugin { return tags.New() },
+	if len(project.CSS) > 0 {
+		bundle.CSS = project.CSS
+	}
+	if len(project.JS) > 0 {
+		bundle.JS = project.JS
 	}
 
-	return plugins
+	return bundle
 }
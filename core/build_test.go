@@ -1,12 +1,37 @@
 package core_test
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
 	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
+	"github.com/verless/verless/config"
 	"github.com/verless/verless/core"
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/plugin"
+	"github.com/verless/verless/scss"
 	"github.com/verless/verless/test"
+	"github.com/verless/verless/writer"
 )
 
 const (
@@ -19,7 +44,7 @@ const (
 func TestRunFullBuild(t *testing.T) {
 	o := core.BuildOptions{
 		OutputDir: outTestPath,
-		Overwrite: true,
+		Force:     true,
 	}
 
 	memMapFs := afero.NewMemMapFs()
@@ -34,3 +59,3861 @@ func TestRunFullBuild(t *testing.T) {
 		log.Println(err)
 	}
 }
+
+// TestRunIncrementalBuild checks if an incremental build only renders the
+// pages whose content files or build inputs have changed.
+func TestRunIncrementalBuild(t *testing.T) {
+	tests := map[string]struct {
+		change            func(path string) error
+		changeOptions     func(options *core.BuildOptions)
+		expectedRewritten []string
+		expectedRemoved   []string
+	}{
+		"unchanged project": {
+			change: func(path string) error { return nil },
+		},
+		"modified content file": {
+			change: func(path string) error {
+				return ioutil.WriteFile(filepath.Join(path, "content", "tea.md"), []byte("---\nTitle: Green Tea\n---\n"), 0644)
+			},
+			expectedRewritten: []string{"tea"},
+		},
+		"touched content file": {
+			change: func(path string) error {
+				now := time.Now().Add(time.Hour)
+				return os.Chtimes(filepath.Join(path, "content", "tea.md"), now, now)
+			},
+		},
+		"removed content file": {
+			change: func(path string) error {
+				return os.Remove(filepath.Join(path, "content", "tea.md"))
+			},
+			expectedRemoved: []string{"tea"},
+		},
+		"modified configuration": {
+			change: func(path string) error {
+				return ioutil.WriteFile(filepath.Join(path, "verless.yml"), []byte("version: 1\ntheme: default\n"), 0644)
+			},
+			expectedRewritten: []string{"coffee", "tea"},
+		},
+		"minify toggled": {
+			change: func(path string) error { return nil },
+			changeOptions: func(options *core.BuildOptions) {
+				options.Minify = true
+			},
+			expectedRewritten: []string{"coffee", "tea"},
+		},
+		"overridden configuration": {
+			change: func(path string) error {
+				return os.Setenv("VERLESS_BASEURL", "https://example.com")
+			},
+			expectedRewritten: []string{"coffee", "tea"},
+		},
+	}
+
+	const outputDir = "/target"
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		dir, err := ioutil.TempDir("", "verless-build")
+		test.Ok(t, err)
+
+		path := filepath.Join(dir, "my-blog")
+
+		_, err = core.CreateProject(path, core.CreateProjectOptions{})
+		test.Ok(t, err)
+
+		for _, id := range []string{"coffee", "tea"} {
+			file := filepath.Join(path, "content", id+".md")
+			test.Ok(t, ioutil.WriteFile(file, []byte("---\nTitle: "+id+"\n---\n"), 0644))
+		}
+
+		memMapFs := afero.NewMemMapFs()
+		options := core.BuildOptions{
+			OutputDir:   outputDir,
+			Force:       true,
+			Incremental: true,
+		}
+
+		modTimes := make(map[string]time.Time)
+
+		for i := 0; i < 2; i++ {
+			if i == 1 {
+				test.Ok(t, testCase.change(path))
+				if testCase.changeOptions != nil {
+					testCase.changeOptions(&options)
+				}
+			}
+
+			build, err := core.NewBuild(memMapFs, path, options)
+			test.Ok(t, err)
+			test.Ok(t, build.Run())
+
+			if i == 1 {
+				break
+			}
+
+			for _, id := range []string{"coffee", "tea"} {
+				info, err := memMapFs.Stat(filepath.Join(outputDir, id, "index.html"))
+				test.Ok(t, err)
+				modTimes[id] = info.ModTime()
+			}
+		}
+
+		for id, modTime := range modTimes {
+			info, err := memMapFs.Stat(filepath.Join(outputDir, id, "index.html"))
+
+			if contains(testCase.expectedRemoved, id) {
+				test.Assert(t, os.IsNotExist(err), "%s should have been removed", id)
+				continue
+			}
+
+			test.Ok(t, err)
+			rewritten := !info.ModTime().Equal(modTime)
+			test.Equals(t, contains(testCase.expectedRewritten, id), rewritten)
+		}
+
+		_ = os.Unsetenv("VERLESS_BASEURL")
+		_ = os.RemoveAll(dir)
+	}
+}
+
+// TestRunIncrementalBuild_dependencies checks if an incremental build
+// renders pages again whose displayed linked pages have changed, and all
+// pages if the template may display any page.
+func TestRunIncrementalBuild_dependencies(t *testing.T) {
+	const (
+		nextTemplate  = "{{.Page.Title}} next={{with .Page.Next}}{{.Title}}{{end}}"
+		pagesTemplate = "{{.Page.Title}} {{range pages \"/blog\"}}{{.Title}} {{end}}"
+	)
+
+	tests := map[string]struct {
+		template          string
+		change            func(path string) error
+		expectedRewritten []string
+		expectedA         string
+	}{
+		"changed neighbour": {
+			template: nextTemplate,
+			change: func(path string) error {
+				return ioutil.WriteFile(filepath.Join(path, "content", "blog", "b.md"), []byte("---\nTitle: Bravo\nDate: 2020-10-02\n---\n"), 0644)
+			},
+			expectedRewritten: []string{"a", "b"},
+			expectedA:         "A next=Bravo",
+		},
+		"removed neighbour": {
+			template: nextTemplate,
+			change: func(path string) error {
+				return os.Remove(filepath.Join(path, "content", "blog", "b.md"))
+			},
+			expectedRewritten: []string{"a"},
+			expectedA:         "A next=C",
+		},
+		"unchanged neighbours": {
+			template: nextTemplate,
+			change: func(path string) error {
+				return ioutil.WriteFile(filepath.Join(path, "content", "blog", "a.md"), []byte("---\nTitle: Alpha\nDate: 2020-10-03\n---\n"), 0644)
+			},
+			expectedRewritten: []string{"a"},
+			expectedA:         "Alpha next=B",
+		},
+		"pages function": {
+			template: pagesTemplate,
+			change: func(path string) error {
+				return ioutil.WriteFile(filepath.Join(path, "content", "blog", "b.md"), []byte("---\nTitle: Bravo\nDate: 2020-10-02\n---\n"), 0644)
+			},
+			expectedRewritten: []string{"a", "b", "c"},
+			expectedA:         "A A Bravo C ",
+		},
+	}
+
+	const outputDir = "/target"
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		// The pages are sorted by date descending, so that A is followed
+		// by B and B is followed by C.
+		path := createTestProject(t, "", map[string]string{
+			"blog/a.md": "---\nTitle: A\nDate: 2020-10-03\n---\n",
+			"blog/b.md": "---\nTitle: B\nDate: 2020-10-02\n---\n",
+			"blog/c.md": "---\nTitle: C\nDate: 2020-10-01\n---\n",
+		})
+		test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte(testCase.template), 0644))
+
+		memMapFs := afero.NewMemMapFs()
+		modTimes := make(map[string]time.Time)
+
+		for i := 0; i < 2; i++ {
+			if i == 1 {
+				test.Ok(t, testCase.change(path))
+			}
+
+			build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+				OutputDir:          outputDir,
+				Force:              true,
+				RecompileTemplates: true,
+				Incremental:        true,
+			})
+			test.Ok(t, err)
+			test.Ok(t, build.Run())
+
+			if i == 1 {
+				break
+			}
+
+			for _, id := range []string{"a", "b", "c"} {
+				info, err := memMapFs.Stat(filepath.Join(outputDir, "blog", id, "index.html"))
+				test.Ok(t, err)
+				modTimes[id] = info.ModTime()
+			}
+		}
+
+		for id, modTime := range modTimes {
+			info, err := memMapFs.Stat(filepath.Join(outputDir, "blog", id, "index.html"))
+			if os.IsNotExist(err) {
+				continue
+			}
+			test.Ok(t, err)
+			test.Assert(t, contains(testCase.expectedRewritten, id) == !info.ModTime().Equal(modTime), "%s: expected rewritten to be %v", id, contains(testCase.expectedRewritten, id))
+		}
+
+		content, err := afero.ReadFile(memMapFs, filepath.Join(outputDir, "blog", "a", "index.html"))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedA, string(content))
+
+		_ = os.RemoveAll(filepath.Dir(path))
+	}
+}
+
+// TestRunIncrementalBuild_inMemory checks if an incremental build into an
+// in-memory filesystem doesn't store its build cache on disk, so that a
+// later build into the output directory on disk isn't affected by it.
+func TestRunIncrementalBuild_inMemory(t *testing.T) {
+	path := createTestProject(t, "", map[string]string{"coffee.md": "---\nTitle: Coffee\n---\n"})
+	defer os.RemoveAll(filepath.Dir(path))
+
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte("{{.Page.Title}}"), 0644))
+
+	// The output directory is outside the project, so that the build
+	// cache on disk is the only file written into the project.
+	outputDir := filepath.Join(filepath.Dir(path), "public")
+
+	build := func(targetFs afero.Fs) {
+		b, err := core.NewBuild(targetFs, path, core.BuildOptions{
+			OutputDir:          outputDir,
+			Force:              true,
+			RecompileTemplates: true,
+			Incremental:        true,
+		})
+		test.Ok(t, err)
+		test.Ok(t, b.Run())
+	}
+
+	osFs := afero.NewOsFs()
+	build(osFs)
+
+	cache, err := ioutil.ReadFile(filepath.Join(path, ".verless", "cache.json"))
+	test.Ok(t, err)
+
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "content", "coffee.md"), []byte("---\nTitle: Espresso\n---\n"), 0644))
+
+	memMapFs := afero.NewMemMapFs()
+	build(memMapFs)
+
+	onDisk, err := ioutil.ReadFile(filepath.Join(path, ".verless", "cache.json"))
+	test.Ok(t, err)
+	test.Equals(t, string(cache), string(onDisk))
+
+	exists, err := afero.Exists(memMapFs, filepath.Join(path, ".verless", "cache.json"))
+	test.Ok(t, err)
+	test.Assert(t, exists, "the build cache should be stored in the in-memory filesystem")
+
+	build(osFs)
+
+	content, err := ioutil.ReadFile(filepath.Join(outputDir, "coffee", "index.html"))
+	test.Ok(t, err)
+	test.Equals(t, "Espresso", string(content))
+}
+
+// contains determines whether a slice contains the given value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// TestRun_parsers checks if the build output is identical regardless of
+// the number of parsers.
+func TestRun_parsers(t *testing.T) {
+	path := createCorpus(t, 200)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	var expected map[string]string
+
+	for _, parsers := range []int{1, 2, 8, 32} {
+		t.Log(parsers, "parsers")
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			Parsers:            parsers,
+		})
+		test.Ok(t, err)
+		test.Ok(t, build.Run())
+
+		output := make(map[string]string)
+
+		test.Ok(t, afero.Walk(memMapFs, "/target", func(file string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			content, err := afero.ReadFile(memMapFs, file)
+			output[file] = string(content)
+			return err
+		}))
+
+		if expected == nil {
+			expected = output
+			continue
+		}
+
+		test.Equals(t, expected, output)
+	}
+}
+
+// BenchmarkRun measures the build performance with a single parser and
+// with one parser per CPU.
+func BenchmarkRun(b *testing.B) {
+	path := createCorpus(b, 1000)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	for _, parsers := range []int{1, runtime.GOMAXPROCS(0)} {
+		b.Run(fmt.Sprintf("parsers=%d", parsers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				build, err := core.NewBuild(afero.NewMemMapFs(), path, core.BuildOptions{
+					OutputDir: "/target",
+					Parsers:   parsers,
+				})
+				test.Ok(b, err)
+				test.Ok(b, build.Run())
+			}
+		})
+	}
+}
+
+// BenchmarkRun_renderCache measures the build performance without the
+// render cache and with a render cache populated by a previous build.
+func BenchmarkRun_renderCache(b *testing.B) {
+	path := createCorpus(b, 1000)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	for _, noCache := range []bool{true, false} {
+		b.Run(fmt.Sprintf("noCache=%t", noCache), func(b *testing.B) {
+			// The render cache is only used by incremental builds onto the
+			// disk.
+			options := core.BuildOptions{
+				OutputDir:   filepath.Join(filepath.Dir(path), "public"),
+				Force:       true,
+				Incremental: true,
+				NoCache:     noCache,
+			}
+
+			build, err := core.NewBuild(afero.NewOsFs(), path, options)
+			test.Ok(b, err)
+			test.Ok(b, build.Run())
+
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				build, err := core.NewBuild(afero.NewOsFs(), path, options)
+				test.Ok(b, err)
+				test.Ok(b, build.Run())
+			}
+		})
+	}
+}
+
+// createCorpus creates a project with n content files spread across a
+// few directories. Many pages share the same date and tags.
+func createCorpus(tb testing.TB, n int) string {
+	dir, err := ioutil.TempDir("", "verless-corpus")
+	test.Ok(tb, err)
+
+	path := filepath.Join(dir, "my-blog")
+
+	_, err = core.CreateProject(path, core.CreateProjectOptions{})
+	test.Ok(tb, err)
+
+	config := "version: 1\ntheme: default\nplugins:\n  - tags\n"
+	test.Ok(tb, ioutil.WriteFile(filepath.Join(path, "verless.yml"), []byte(config), 0644))
+
+	templates := filepath.Join(path, "themes", "default", "templates")
+	listPage := "{{range .Pages}}{{.Href}} {{.Title}}\n{{end}}"
+	test.Ok(tb, ioutil.WriteFile(filepath.Join(templates, "list-page.html"), []byte(listPage), 0644))
+	test.Ok(tb, ioutil.WriteFile(filepath.Join(templates, "page.html"), []byte("{{.Page.Title}}"), 0644))
+
+	for i := 0; i < n; i++ {
+		dir := filepath.Join(path, "content", fmt.Sprintf("section-%d", i%5))
+		test.Ok(tb, os.MkdirAll(dir, 0755))
+
+		page := fmt.Sprintf("---\nTitle: Page %d\nDate: 2020-10-%02d\nTags:\n  - tag-%d\n---\n# Page %d\n\nSome *content*.\n", i, i%28+1, i%3, i)
+		test.Ok(tb, ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("page-%d.md", i)), []byte(page), 0644))
+	}
+
+	return path
+}
+
+// TestRun_frontmatter checks if missing front matter fields are reported
+// as warnings and fail the build in strict mode.
+func TestRun_frontmatter(t *testing.T) {
+	tests := map[string]struct {
+		files            map[string]string
+		strict           bool
+		expectedWarnings []core.Warning
+		expectError      bool
+	}{
+		"complete front matter": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\n---\n",
+			},
+			expectedWarnings: []core.Warning{},
+		},
+		"no front matter": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\n---\n",
+				"tea.md":    "# Tea",
+			},
+			expectedWarnings: []core.Warning{
+				{File: filepath.FromSlash("/tea.md"), MissingFields: []string{"Title"}},
+			},
+		},
+		"partial front matter": {
+			files: map[string]string{
+				"blog/tea.md": "---\nDate: 2020-10-14\n---\n",
+			},
+			expectedWarnings: []core.Warning{
+				{File: filepath.FromSlash("/blog/tea.md"), MissingFields: []string{"Title"}},
+			},
+		},
+		"strict mode": {
+			files: map[string]string{
+				"tea.md": "---\nDate: 2020-10-14\n---\n",
+			},
+			strict:      true,
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "", testCase.files)
+
+		build, err := core.NewBuild(afero.NewMemMapFs(), path, core.BuildOptions{
+			OutputDir:         "/target",
+			StrictFrontmatter: testCase.strict,
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		if testCase.expectError {
+			test.Assert(t, err != nil, "build should fail in strict mode")
+			continue
+		}
+
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedWarnings, build.Warnings())
+	}
+}
+
+// TestRun_drafts checks if drafts are excluded from all output unless
+// drafts are included explicitly.
+func TestRun_drafts(t *testing.T) {
+	files := map[string]string{
+		"coffee.md": "---\nTitle: Coffee\nTags:\n  - black\n---\n",
+		"tea.md":    "---\nTitle: Tea\nDraft: true\nTags:\n  - green\n---\n",
+	}
+	config := "version: 1\nsite:\n  meta:\n    base: https://example.com\ntheme: default\nplugins:\n  - atom\n  - tags\n"
+
+	tests := map[string]struct {
+		includeDrafts bool
+	}{
+		"without drafts": {},
+		"with drafts": {
+			includeDrafts: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, config, files)
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			IncludeDrafts:      testCase.includeDrafts,
+		})
+		test.Ok(t, err)
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		exists, err := afero.Exists(memMapFs, "/target/tea/index.html")
+		test.Ok(t, err)
+		test.Equals(t, testCase.includeDrafts, exists)
+
+		exists, err = afero.Exists(memMapFs, "/target/tags/green/index.html")
+		test.Ok(t, err)
+		test.Equals(t, testCase.includeDrafts, exists)
+
+		for _, file := range []string{"/target/index.html", "/target/atom.xml"} {
+			content, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Assert(t, strings.Contains(string(content), "/coffee"), "%s should contain coffee", file)
+			test.Equals(t, testCase.includeDrafts, strings.Contains(string(content), "/tea"))
+		}
+	}
+}
+
+// createTestProject creates a new project with the given configuration
+// and content files inside a temporary directory. If config is empty, the
+// default configuration is used. The list page template lists the Hrefs
+// of all pages.
+func createTestProject(tb testing.TB, config string, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "verless-build")
+	test.Ok(tb, err)
+
+	path := filepath.Join(dir, "my-blog")
+
+	_, err = core.CreateProject(path, core.CreateProjectOptions{})
+	test.Ok(tb, err)
+
+	if config != "" {
+		test.Ok(tb, ioutil.WriteFile(filepath.Join(path, "verless.yml"), []byte(config), 0644))
+	}
+
+	listPage := []byte("{{range .Pages}}{{.Href}}\n{{end}}")
+	test.Ok(tb, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "list-page.html"), listPage, 0644))
+
+	for file, content := range files {
+		file = filepath.Join(path, "content", filepath.FromSlash(file))
+		test.Ok(tb, os.MkdirAll(filepath.Dir(file), 0755))
+		test.Ok(tb, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+
+	return path
+}
+
+// TestRun_future checks if pages dated after the build time are excluded
+// unless future pages are included explicitly.
+func TestRun_future(t *testing.T) {
+	files := map[string]string{
+		"past.md":         "---\nTitle: Past\nDate: 2020-10-01\n---\n",
+		"today.md":        "---\nTitle: Today\nDate: 2020-10-14T12:00:00Z\n---\n",
+		"scheduled.md":    "---\nTitle: Scheduled\nDate: 2020-10-14T12:00:01Z\n---\n",
+		"future-draft.md": "---\nTitle: Future Draft\nDate: 2020-12-01\nDraft: true\n---\n",
+	}
+
+	tests := map[string]struct {
+		includeFuture bool
+		includeDrafts bool
+		expected      []string
+	}{
+		"without future pages": {
+			expected: []string{"past", "today"},
+		},
+		"with future pages": {
+			includeFuture: true,
+			expected:      []string{"past", "today", "scheduled"},
+		},
+		"with drafts only": {
+			includeDrafts: true,
+			expected:      []string{"past", "today"},
+		},
+		"with future pages and drafts": {
+			includeFuture: true,
+			includeDrafts: true,
+			expected:      []string{"past", "today", "scheduled", "future-draft"},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "", files)
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			IncludeFuture:      testCase.includeFuture,
+			IncludeDrafts:      testCase.includeDrafts,
+		})
+		test.Ok(t, err)
+
+		build.Now = time.Date(2020, 10, 14, 12, 0, 0, 0, time.UTC)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		for file := range files {
+			id := strings.TrimSuffix(file, ".md")
+
+			exists, err := afero.Exists(memMapFs, filepath.Join("/target", id, "index.html"))
+			test.Ok(t, err)
+			test.Equals(t, contains(testCase.expected, id), exists)
+		}
+	}
+}
+
+// TestRun_pagination checks if list pages are split into several pages
+// that link to each other.
+func TestRun_pagination(t *testing.T) {
+	files := make(map[string]string)
+
+	for i := 1; i <= 25; i++ {
+		files[fmt.Sprintf("blog/post-%02d.md", i)] = fmt.Sprintf("---\nTitle: Post %d\nDate: 2020-10-%02d\n---\n", i, i)
+	}
+
+	tests := map[string]struct {
+		pageSize int
+		expected map[string]string
+	}{
+		"without pagination": {
+			expected: map[string]string{
+				"/target/blog/index.html": "1/1  \n25",
+			},
+		},
+		"with page size 10": {
+			pageSize: 10,
+			expected: map[string]string{
+				"/target/blog/index.html":        "1/3  /blog/page/2/\n10",
+				"/target/blog/page/2/index.html": "2/3 /blog/ /blog/page/3/\n10",
+				"/target/blog/page/3/index.html": "3/3 /blog/page/2/ \n5",
+			},
+		},
+		"with page size larger than the number of pages": {
+			pageSize: 30,
+			expected: map[string]string{
+				"/target/blog/index.html": "1/1  \n25",
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, fmt.Sprintf("version: 1\npagination:\n  pageSize: %d\n", testCase.pageSize), files)
+
+		listPage := []byte("{{.CurrentPage}}/{{.TotalPages}} {{.PrevURL}} {{.NextURL}}\n{{len .Pages}}")
+		test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "list-page.html"), listPage, 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		for file, expected := range testCase.expected {
+			content, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Equals(t, expected, string(content))
+		}
+
+		exists, err := afero.Exists(memMapFs, fmt.Sprintf("/target/blog/page/%d/index.html", len(testCase.expected)+1))
+		test.Ok(t, err)
+		test.Assert(t, !exists, "no further pages should exist")
+
+		// The pages themselves are never paginated.
+		exists, err = afero.Exists(memMapFs, "/target/blog/post-25/index.html")
+		test.Ok(t, err)
+		test.Assert(t, exists, "post-25 should exist")
+	}
+}
+
+// TestRun_tags checks if the tags plugin generates list pages for all
+// tags unless generating pages has been disabled.
+func TestRun_tags(t *testing.T) {
+	files := map[string]string{
+		"blog/espresso.md": "---\nTitle: Espresso\nTags:\n  - Making Coffee\n  - Espresso\n---\n",
+		"blog/moka-pot.md": "---\nTitle: Moka Pot\nTags:\n  - making coffee\n---\n",
+	}
+
+	tests := map[string]struct {
+		config   string
+		expected map[string]string
+	}{
+		"default configuration": {
+			config: "version: 1\nplugins:\n  - tags\n",
+			expected: map[string]string{
+				"/target/tags/index.html":               "making-coffee 2\nespresso 1\n",
+				"/target/tags/making-coffee/index.html": "/blog/espresso\n/blog/moka-pot\n",
+				"/target/tags/espresso/index.html":      "/blog/espresso\n",
+			},
+		},
+		"without generating pages": {
+			config:   "version: 1\nplugins:\n  - tags\npluginConfig:\n  tags:\n    generatePages: false\n",
+			expected: map[string]string{},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, testCase.config, files)
+
+		listPage := []byte("{{range .TagList}}{{.Slug}} {{.Count}}\n{{end}}{{range .Pages}}{{.Href}}\n{{end}}")
+		test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "list-page.html"), listPage, 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		for file, expected := range testCase.expected {
+			content, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Equals(t, expected, string(content))
+		}
+
+		exists, err := afero.Exists(memMapFs, "/target/tags")
+		test.Ok(t, err)
+		test.Equals(t, len(testCase.expected) > 0, exists)
+	}
+}
+
+// TestRun_cleanURLs checks if pages are rendered to <page>/index.html or
+// <page>.html depending on the clean URLs setting, and if all links and
+// the sitemap use the matching URLs.
+func TestRun_cleanURLs(t *testing.T) {
+	files := map[string]string{
+		"about.md":         "---\nTitle: About\n---\n",
+		"blog/espresso.md": "---\nTitle: Espresso\nTags:\n  - Coffee\n---\n",
+		"blog/moka-pot.md": "---\nTitle: Moka Pot\nTags:\n  - Coffee\n---\n",
+	}
+
+	config := "version: 1\nsite:\n  meta:\n    base: https://example.com\nplugins:\n  - tags\n  - sitemap\npagination:\n  pageSize: 1\n"
+
+	tests := map[string]struct {
+		cleanURLs       bool
+		expected        map[string]string
+		expectedSitemap []string
+	}{
+		"clean URLs": {
+			cleanURLs: true,
+			expected: map[string]string{
+				"/target/index.html":             "/about\n/page/2/",
+				"/target/blog/index.html":        "/blog/espresso\n/blog/page/2/",
+				"/target/blog/page/2/index.html": "/blog/moka-pot\n",
+				"/target/tags/index.html":        "/tags/coffee\n",
+				"/target/about/index.html":       "",
+			},
+			expectedSitemap: []string{
+				"https://example.com/",
+				"https://example.com/about",
+				"https://example.com/blog/espresso",
+				"https://example.com/tags/coffee",
+			},
+		},
+		".html files": {
+			cleanURLs: false,
+			expected: map[string]string{
+				"/target/index.html":             "/about.html\n/page/2/index.html",
+				"/target/blog/index.html":        "/blog/espresso.html\n/blog/page/2/index.html",
+				"/target/blog/page/2/index.html": "/blog/moka-pot.html\n",
+				"/target/tags/index.html":        "/tags/coffee/index.html\n",
+				"/target/about.html":             "",
+			},
+			expectedSitemap: []string{
+				"https://example.com/index.html",
+				"https://example.com/about.html",
+				"https://example.com/blog/espresso.html",
+				"https://example.com/tags/coffee/index.html",
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, fmt.Sprintf("%sbuild:\n  cleanURLs: %t\n", config, testCase.cleanURLs), files)
+
+		listPage := []byte("{{range .TagList}}{{.Href}}\n{{end}}{{range .Pages}}{{.Href}}\n{{end}}{{.NextURL}}")
+		test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "list-page.html"), listPage, 0644))
+		test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte{}, 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		for file, expected := range testCase.expected {
+			content, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Equals(t, expected, string(content))
+		}
+
+		exists, err := afero.Exists(memMapFs, "/target/about/index.html")
+		test.Ok(t, err)
+		test.Equals(t, testCase.cleanURLs, exists)
+
+		sitemap, err := afero.ReadFile(memMapFs, "/target/sitemap.xml")
+		test.Ok(t, err)
+
+		for _, loc := range testCase.expectedSitemap {
+			test.Assert(t, strings.Contains(string(sitemap), "<loc>"+loc+"</loc>"), "sitemap should contain %s", loc)
+		}
+	}
+}
+
+// TestRun_fingerprint checks if CSS files are renamed to filenames with
+// a hash of their content and if pages reference the renamed files.
+func TestRun_fingerprint(t *testing.T) {
+	const style = "body { color: #333; }"
+
+	hash := sha256.Sum256([]byte(style))
+	fingerprinted := "/assets/style." + hex.EncodeToString(hash[:])[:8] + ".css"
+
+	tests := map[string]struct {
+		fingerprint bool
+		expected    string
+	}{
+		"without fingerprinting": {
+			expected: "/assets/style.css",
+		},
+		"with fingerprinting": {
+			fingerprint: true,
+			expected:    fingerprinted,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		config := fmt.Sprintf("version: 1\nassets:\n  fingerprint: %t\n", testCase.fingerprint)
+		path := createTestProject(t, config, map[string]string{"about.md": "---\nTitle: About\n---\n"})
+
+		themePath := filepath.Join(path, "themes", "default")
+		test.Ok(t, ioutil.WriteFile(filepath.Join(themePath, "assets", "style.css"), []byte(style), 0644))
+
+		page := []byte(`<link href="/assets/style.css"> {{fingerprint "style.css"}}`)
+		test.Ok(t, ioutil.WriteFile(filepath.Join(themePath, "templates", "page.html"), page, 0644))
+
+		listPage := []byte(`{{fingerprint "/assets/style.css"}}`)
+		test.Ok(t, ioutil.WriteFile(filepath.Join(themePath, "templates", "list-page.html"), listPage, 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		content, err := afero.ReadFile(memMapFs, filepath.Join("/target", filepath.FromSlash(testCase.expected)))
+		test.Ok(t, err)
+		test.Equals(t, style, string(content))
+
+		exists, err := afero.Exists(memMapFs, "/target/assets/style.css")
+		test.Ok(t, err)
+		test.Equals(t, !testCase.fingerprint, exists)
+
+		content, err = afero.ReadFile(memMapFs, "/target/about/index.html")
+		test.Ok(t, err)
+		test.Equals(t, fmt.Sprintf(`<link href="%s"> %s`, testCase.expected, testCase.expected), string(content))
+
+		content, err = afero.ReadFile(memMapFs, "/target/index.html")
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, string(content))
+	}
+}
+
+// TestRun_fingerprint_unknownAsset checks if referencing an asset that
+// doesn't exist fails the build.
+func TestRun_fingerprint_unknownAsset(t *testing.T) {
+	path := createTestProject(t, "version: 1\nassets:\n  fingerprint: true\n", nil)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	listPage := []byte(`{{fingerprint "missing.css"}}`)
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "list-page.html"), listPage, 0644))
+
+	build, err := core.NewBuild(afero.NewMemMapFs(), path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+
+	err = build.Run()
+	test.Assert(t, errors.Is(err, writer.ErrUnknownAsset), "the build should fail with ErrUnknownAsset, got %v", err)
+}
+
+// TestRun_minify checks if pages and stylesheets are minified and if the
+// whitespace inside of code blocks is preserved.
+func TestRun_minify(t *testing.T) {
+	files := map[string]string{
+		"coffee.md": "---\nTitle: Coffee\n---\n# Coffee\n\n```go\nfunc main() {\n\tif true {\n\t\treturn\n\t}\n}\n```\n",
+	}
+
+	results := make(map[bool]map[string][]byte)
+
+	for _, minify := range []bool{false, true} {
+		t.Logf("minify: %t", minify)
+
+		path := createTestProject(t, "", files)
+
+		themePath := filepath.Join(path, "themes", "default")
+		page := []byte("<html>\n    <body>\n        {{.Page.Content}}\n    </body>\n</html>\n")
+		test.Ok(t, ioutil.WriteFile(filepath.Join(themePath, "templates", "page.html"), page, 0644))
+		style := []byte("/* Base */\nbody {\n    color: #333;\n}\n")
+		test.Ok(t, ioutil.WriteFile(filepath.Join(themePath, "assets", "style.css"), style, 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			Minify:             minify,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		results[minify] = make(map[string][]byte)
+
+		for _, file := range []string{"/target/coffee/index.html", "/target/assets/style.css"} {
+			content, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			results[minify][file] = content
+		}
+	}
+
+	for file, content := range results[true] {
+		original := results[false][file]
+		test.Assert(t, len(content) < len(original), "%s should be smaller after minification (%d >= %d bytes)", file, len(content), len(original))
+	}
+
+	test.Equals(t, "body{color:#333}", string(results[true]["/target/assets/style.css"]))
+
+	// The code block has to keep its indentation and line breaks.
+	codeBlock := func(page []byte) string {
+		start, end := strings.Index(string(page), "<pre"), strings.Index(string(page), "</pre>")
+		test.Assert(t, start >= 0 && end > start, "page should contain a code block: %s", page)
+		return string(page[start:end])
+	}
+
+	original := codeBlock(results[false]["/target/coffee/index.html"])
+	test.Assert(t, strings.Contains(original, "\n\t\t"), "code block should be indented: %s", original)
+	test.Equals(t, original, codeBlock(results[true]["/target/coffee/index.html"]))
+}
+
+// TestRun_robots checks if the robots plugin disallows indexing for
+// builds including drafts.
+func TestRun_robots(t *testing.T) {
+	config := "version: 1\nsite:\n  meta:\n    base: https://example.com\nplugins:\n  - sitemap\n  - robots\n"
+
+	tests := map[string]struct {
+		includeDrafts bool
+		expected      string
+	}{
+		"production build": {
+			expected: "User-agent: *\nDisallow:\n\nSitemap: https://example.com/sitemap.xml\n",
+		},
+		"build with drafts": {
+			includeDrafts: true,
+			expected:      "User-agent: *\nDisallow: /\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, config, map[string]string{
+			"coffee.md": "---\nTitle: Coffee\n---\n",
+		})
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			IncludeDrafts:      testCase.includeDrafts,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		content, err := afero.ReadFile(memMapFs, "/target/robots.txt")
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, string(content))
+	}
+}
+
+// TestRun_links checks if internal links to missing pages or files are
+// reported as warnings and fail the build in strict mode.
+func TestRun_links(t *testing.T) {
+	tests := map[string]struct {
+		files            map[string]string
+		strict           bool
+		expectedWarnings []core.Warning
+		expectedErr      error
+	}{
+		"valid links": {
+			files: map[string]string{
+				"coffee.md":        "---\nTitle: Coffee\n---\n[Espresso](/blog/espresso) and [tea](tea#green), see [top](#top).",
+				"tea.md":           "---\nTitle: Tea\n---\n[Blog](/blog/) and [style](/assets/style.css?v=1).",
+				"blog/espresso.md": "---\nTitle: Espresso\n---\n[Home](../) and [coffee](../coffee).",
+			},
+			expectedWarnings: []core.Warning{},
+		},
+		"external links": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\n---\n[Example](https://example.com/missing), [protocol-relative](//example.com/missing) and [mail](mailto:coffee@example.com).",
+			},
+			expectedWarnings: []core.Warning{},
+		},
+		"missing route": {
+			files: map[string]string{
+				"coffee.md":   "---\nTitle: Coffee\n---\n[Espresso](/blog/espresso) and again [Espresso](/blog/espresso).",
+				"blog/tea.md": "---\nTitle: Tea\n---\n![Cup](img/cup.png) and [coffee](/coffee).",
+			},
+			expectedWarnings: []core.Warning{
+				{Page: "/blog/tea/index.html", BrokenLinks: []string{"img/cup.png"}},
+				{Page: "/coffee/index.html", BrokenLinks: []string{"/blog/espresso"}},
+			},
+		},
+		"strict mode": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\n---\n[Espresso](/blog/espresso).",
+			},
+			strict:      true,
+			expectedErr: core.ErrBrokenLinks,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "", testCase.files)
+		test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte("{{.Page.Content}}"), 0644))
+
+		build, err := core.NewBuild(afero.NewMemMapFs(), path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			StrictLinks:        testCase.strict,
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		if testCase.expectedErr != nil {
+			test.ExpectedError(t, testCase.expectedErr, err)
+			test.Assert(t, strings.Contains(err.Error(), "/coffee/index.html -> /blog/espresso"), "error should contain the broken link")
+			continue
+		}
+
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedWarnings, build.Warnings())
+	}
+}
+
+// TestRun_baseURL checks if root-relative references are prefixed with
+// the path of the base URL while absolute URLs are left unchanged.
+func TestRun_baseURL(t *testing.T) {
+	config := "version: 1\nbaseURL: https://x.test/blog/\nplugins:\n  - atom\n  - sitemap\n"
+	pageTpl := `<link rel="canonical" href="{{absURL .Page.Href}}">
+<a href="/about">About</a>
+<a href='/'>Home</a>
+<a href="{{relURL "/about"}}">About</a>
+<img src="/assets/style.css">
+<a href="https://example.com/about">Example</a>
+<a href="//example.com/about">Example</a>
+<a href="#top">Top</a>`
+
+	path := createTestProject(t, config, map[string]string{
+		"about.md":  "---\nTitle: About\n---\n",
+		"coffee.md": "---\nTitle: Coffee\n---\n[About](/about)",
+	})
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte(pageTpl+"\n{{.Page.Content}}"), 0644))
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+		StrictLinks:        true,
+	})
+	test.Ok(t, err)
+
+	test.Ok(t, build.Run())
+	_ = os.RemoveAll(filepath.Dir(path))
+
+	content, err := afero.ReadFile(memMapFs, "/target/coffee/index.html")
+	test.Ok(t, err)
+
+	expected := `<link rel="canonical" href="https://x.test/blog/coffee">
+<a href="/blog/about">About</a>
+<a href='/blog/'>Home</a>
+<a href="/blog/about">About</a>
+<img src="/blog/assets/style.css">
+<a href="https://example.com/about">Example</a>
+<a href="//example.com/about">Example</a>
+<a href="#top">Top</a>
+<p><a href="/blog/about">About</a></p>
+`
+	test.Equals(t, expected, string(content))
+
+	for _, file := range []string{"/target/atom.xml", "/target/sitemap.xml"} {
+		content, err := afero.ReadFile(memMapFs, file)
+		test.Ok(t, err)
+		test.Assert(t, strings.Contains(string(content), "https://x.test/blog/coffee"), "%s should contain the page URL", file)
+	}
+}
+
+// TestRun_pageFuncs checks if templates can look up pages by their route
+// and list all pages of a section in the configured order.
+func TestRun_pageFuncs(t *testing.T) {
+	tests := map[string]struct {
+		config   string
+		file     string
+		expected string
+	}{
+		"clean URLs": {
+			config:   "version: 1\n",
+			file:     "/target/about/index.html",
+			expected: "/blog:Blog|/about:About|/blog/tea,/blog/coffee,/blog/beans/arabica,|",
+		},
+		"without clean URLs": {
+			config:   "version: 1\nbuild:\n  cleanURLs: false\n",
+			file:     "/target/about.html",
+			expected: "/blog/index.html:Blog|/about.html:About|/blog/tea.html,/blog/coffee.html,/blog/beans/arabica.html,|",
+		},
+	}
+
+	pageTpl := `{{with page "/blog"}}{{.Href}}:{{.Title}}{{end}}|` +
+		`{{with page "about.html"}}{{.Href}}:{{.Title}}{{end}}|` +
+		`{{range pages "/blog"}}{{.Href}},{{end}}|` +
+		`{{with page "/missing"}}missing{{end}}{{range pages "/missing"}}missing{{end}}`
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, testCase.config, map[string]string{
+			"about.md":              "---\nTitle: About\n---\n",
+			"blog/index.md":         "---\nTitle: Blog\n---\n",
+			"blog/coffee.md":        "---\nTitle: Coffee\nWeight: 2\n---\n",
+			"blog/tea.md":           "---\nTitle: Tea\nWeight: 1\n---\n",
+			"blog/beans/arabica.md": "---\nTitle: Arabica\nWeight: 3\n---\n",
+		})
+		test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte(pageTpl), 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		content, err := afero.ReadFile(memMapFs, testCase.file)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, string(content))
+	}
+}
+
+// TestRun_partials checks if page and list page templates can include the
+// partial templates of the theme.
+func TestRun_partials(t *testing.T) {
+	templates := map[string]string{
+		"partials/header.html":   `<header>{{.Meta.Title}}</header>`,
+		"partials/nav/main.html": `<nav>{{range .Nav.Items}}{{.Label}}{{end}}</nav>`,
+		"page.html":              `{{template "partials/header" .}}{{partial "nav/main" . | printf "%s"}}{{.Page.Title}}`,
+		"list-page.html":         `{{template "partials/header" .}}{{partial "nav/main" .}}list`,
+	}
+
+	config := "version: 1\nsite:\n  meta:\n    title: Coffee Blog\n  nav:\n    items:\n      - label: Blog\n        target: /blog\n"
+
+	path := createTestProject(t, config, map[string]string{
+		"coffee.md": "---\nTitle: Coffee\n---\n",
+	})
+
+	for file, content := range templates {
+		file = filepath.Join(path, "themes", "default", "templates", filepath.FromSlash(file))
+		test.Ok(t, os.MkdirAll(filepath.Dir(file), 0755))
+		test.Ok(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+
+	test.Ok(t, build.Run())
+	_ = os.RemoveAll(filepath.Dir(path))
+
+	expected := map[string]string{
+		"/target/coffee/index.html": "<header>Coffee Blog</header><nav>Blog</nav>Coffee",
+		"/target/index.html":        "<header>Coffee Blog</header><nav>Blog</nav>list",
+	}
+
+	for file, content := range expected {
+		actual, err := afero.ReadFile(memMapFs, file)
+		test.Ok(t, err)
+		test.Equals(t, content, string(actual))
+	}
+}
+
+// TestRun_templates checks if pages can select a template of the theme in
+// their front matter and if selecting a missing template fails the build.
+func TestRun_templates(t *testing.T) {
+	tests := map[string]struct {
+		files       map[string]string
+		expected    map[string]string
+		expectError bool
+	}{
+		"custom templates": {
+			files: map[string]string{
+				"index.md":  "---\nTitle: Home\nTemplate: landing\n---\n",
+				"about.md":  "---\nTitle: About\nLayout: landing.html\n---\n",
+				"coffee.md": "---\nTitle: Coffee\n---\n",
+			},
+			expected: map[string]string{
+				"/target/index.html":        "landing: Home",
+				"/target/about/index.html":  "landing: About",
+				"/target/coffee/index.html": "page: Coffee",
+			},
+		},
+		"missing template": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\nTemplate: espresso\n---\n",
+			},
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "", testCase.files)
+
+		templates := filepath.Join(path, "themes", "default", "templates")
+		test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "page.html"), []byte("page: {{.Page.Title}}"), 0644))
+		test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "landing.html"), []byte("landing: {{with .Page}}{{.Title}}{{else}}{{.Title}}{{end}}"), 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		if testCase.expectError {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), "espresso.html"), "the build should fail for the missing template")
+			continue
+		}
+		test.Ok(t, err)
+
+		for file, content := range testCase.expected {
+			actual, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Equals(t, content, string(actual))
+		}
+	}
+}
+
+// TestRun_data checks if the contents of all data files are available to
+// page and list page templates as .Site.Data.
+func TestRun_data(t *testing.T) {
+	tests := map[string]struct {
+		files       map[string]string
+		expected    map[string]string
+		expectError bool
+	}{
+		"data files": {
+			files: map[string]string{
+				"authors.yml":     "- name: Barista\n- name: Roaster\n",
+				"team/roles.json": `{"lead": "Barista"}`,
+				"site.toml":       "motto = \"Coffee first\"\n",
+				"notes.txt":       "ignored",
+			},
+			expected: map[string]string{
+				"/target/coffee/index.html": "Barista,Roaster,|Barista|Coffee first",
+				"/target/index.html":        "list: Coffee first",
+			},
+		},
+		"duplicate key": {
+			files: map[string]string{
+				"authors.yml":  "- name: Barista\n",
+				"authors.json": `[{"name": "Roaster"}]`,
+			},
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "", map[string]string{
+			"coffee.md": "---\nTitle: Coffee\n---\n",
+		})
+
+		for file, content := range testCase.files {
+			file = filepath.Join(path, config.DataDir, filepath.FromSlash(file))
+			test.Ok(t, os.MkdirAll(filepath.Dir(file), 0755))
+			test.Ok(t, ioutil.WriteFile(file, []byte(content), 0644))
+		}
+
+		templates := filepath.Join(path, "themes", "default", "templates")
+		pageTpl := `{{range .Site.Data.authors}}{{.name}},{{end}}|{{.Site.Data.team.roles.lead}}|{{.Site.Data.site.motto}}`
+		test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "page.html"), []byte(pageTpl), 0644))
+		test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "list-page.html"), []byte(`list: {{.Site.Data.site.motto}}`), 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		if testCase.expectError {
+			test.ExpectedError(t, core.ErrDuplicateData, err)
+			continue
+		}
+		test.Ok(t, err)
+
+		for file, content := range testCase.expected {
+			actual, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Equals(t, content, string(actual))
+		}
+	}
+}
+
+// TestRun_menus checks if the menus from the configuration are sorted by
+// weight and if the entry of the rendered page or its section is active.
+func TestRun_menus(t *testing.T) {
+	config := `version: 1
+menus:
+  main:
+    - name: Blog
+      url: /blog
+      weight: 2
+    - name: Home
+      url: /
+      weight: 1
+    - name: About
+      url: /about
+      weight: 3
+    - name: GitHub
+      url: https://github.com/verless/verless
+      weight: 4
+`
+	menuTpl := `{{range .Site.Menus.main}}{{.Name}}{{if .Active}}*{{end}},{{end}}`
+
+	path := createTestProject(t, config, map[string]string{
+		"about.md":       "---\nTitle: About\n---\n",
+		"blog/coffee.md": "---\nTitle: Coffee\n---\n",
+	})
+
+	templates := filepath.Join(path, "themes", "default", "templates")
+	test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "page.html"), []byte(menuTpl), 0644))
+	test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "list-page.html"), []byte(menuTpl), 0644))
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+
+	test.Ok(t, build.Run())
+	_ = os.RemoveAll(filepath.Dir(path))
+
+	expected := map[string]string{
+		"/target/index.html":             "Home*,Blog,About,GitHub,",
+		"/target/about/index.html":       "Home,Blog,About*,GitHub,",
+		"/target/blog/index.html":        "Home,Blog*,About,GitHub,",
+		"/target/blog/coffee/index.html": "Home,Blog*,About,GitHub,",
+	}
+
+	for file, content := range expected {
+		actual, err := afero.ReadFile(memMapFs, file)
+		test.Ok(t, err)
+		test.Equals(t, content, string(actual))
+	}
+}
+
+// TestRun_i18n checks if the content of a multilingual site is rendered
+// to a directory for each language and if translations are linked.
+func TestRun_i18n(t *testing.T) {
+	const (
+		config = `version: 1
+i18n:
+  defaultLanguage: en
+  defaultInSubdir: %t
+  languages:
+    en:
+      name: English
+    de:
+      name: Deutsch
+`
+		pageTpl     = `{{.Page.Language}}:{{range .Page.Translations}}{{.Language}}={{.Href}},{{end}}`
+		listPageTpl = `{{range .Site.Languages}}{{.Code}}={{.Href}},{{end}}|{{range .Pages}}{{.Href}},{{end}}`
+	)
+
+	files := map[string]string{
+		"about.md":          "---\nTitle: About\n---\n",
+		"about.de.md":       "---\nTitle: Über\n---\n",
+		"blog/coffee.md":    "---\nTitle: Coffee\n---\n",
+		"blog/coffee.de.md": "---\nTitle: Kaffee\n---\n",
+		"blog/tee.md":       "---\nTitle: Tee\nLanguage: de\n---\n",
+	}
+
+	tests := map[string]struct {
+		defaultInSubdir bool
+		expected        map[string]string
+	}{
+		"default language at root": {
+			expected: map[string]string{
+				"/target/index.html":                "en=/,de=/de,|/about,/blog/coffee,",
+				"/target/de/index.html":             "en=/,de=/de,|/de/about,/de/blog/coffee,/de/blog/tee,",
+				"/target/about/index.html":          "en:de=/de/about,",
+				"/target/de/about/index.html":       "de:en=/about,",
+				"/target/blog/coffee/index.html":    "en:de=/de/blog/coffee,",
+				"/target/de/blog/coffee/index.html": "de:en=/blog/coffee,",
+				"/target/de/blog/tee/index.html":    "de:",
+			},
+		},
+		"default language in subdirectory": {
+			defaultInSubdir: true,
+			expected: map[string]string{
+				"/target/index.html":                "en=/en,de=/de,|",
+				"/target/en/index.html":             "en=/en,de=/de,|/en/about,/en/blog/coffee,",
+				"/target/en/about/index.html":       "en:de=/de/about,",
+				"/target/de/about/index.html":       "de:en=/en/about,",
+				"/target/de/blog/coffee/index.html": "de:en=/en/blog/coffee,",
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, fmt.Sprintf(config, testCase.defaultInSubdir), files)
+
+		templates := filepath.Join(path, "themes", "default", "templates")
+		test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "page.html"), []byte(pageTpl), 0644))
+		test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "list-page.html"), []byte(listPageTpl), 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		for file, content := range testCase.expected {
+			actual, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Equals(t, content, string(actual))
+		}
+	}
+}
+
+// TestRun_shortcodes checks if shortcodes in the content are rendered
+// before or after converting the Markdown content, and if an unknown
+// shortcode fails the build.
+func TestRun_shortcodes(t *testing.T) {
+	const shortcodeTpl = `**{{.Get 0}}** by {{.Get "author"}}`
+
+	tests := map[string]struct {
+		mode          string
+		files         map[string]string
+		expected      string
+		expectedError string
+	}{
+		"before converting": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\n---\n{{< quote \"*Espresso*\" author=Barista >}}\n",
+			},
+			expected: "<p><em><strong>Espresso</strong></em> by Barista</p>\n",
+		},
+		"after converting": {
+			mode: "after",
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\n---\n{{< quote \"*Espresso*\" author=Barista >}}\n",
+			},
+			expected: "***Espresso*** by Barista\n",
+		},
+		"unknown shortcode": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\n---\nCoffee\n\nBy {{< author >}}\n",
+			},
+			expectedError: "coffee.md: line 6, column 4: author: unknown shortcode",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, fmt.Sprintf("version: 1\nmarkdown:\n  shortcodes: %q\n", testCase.mode), testCase.files)
+
+		shortcodes := filepath.Join(path, "themes", "default", "shortcodes")
+		test.Ok(t, os.MkdirAll(shortcodes, 0755))
+		test.Ok(t, ioutil.WriteFile(filepath.Join(shortcodes, "quote.html"), []byte(shortcodeTpl), 0644))
+		test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte("{{.Page.Content}}"), 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "expected error %q, got %v", testCase.expectedError, err)
+			continue
+		}
+		test.Ok(t, err)
+
+		actual, err := afero.ReadFile(memMapFs, "/target/coffee/index.html")
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, string(actual))
+	}
+}
+
+// TestRun_images checks if the variants of responsive images requested
+// by templates and shortcodes are written into the output directory.
+func TestRun_images(t *testing.T) {
+	const (
+		config = `version: 1
+images:
+  widths: [20, 40]
+markdown:
+  shortcodes: after
+`
+		pageTpl      = `{{imageTag "static/img/coffee.png" .Page.Title}}|{{.Page.Content}}`
+		shortcodeTpl = `{{with image (.Get 0)}}srcset="{{.Srcset}}"{{end}}`
+	)
+
+	path := createTestProject(t, config, map[string]string{
+		"coffee.md": "---\nTitle: Coffee\n---\n{{< figure static/img/coffee.png >}}\n",
+	})
+
+	img := image.NewRGBA(image.Rect(0, 0, 60, 30))
+	var b bytes.Buffer
+	test.Ok(t, png.Encode(&b, img))
+
+	test.Ok(t, os.MkdirAll(filepath.Join(path, "static", "img"), 0755))
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "static", "img", "coffee.png"), b.Bytes(), 0644))
+
+	shortcodes := filepath.Join(path, "themes", "default", "shortcodes")
+	test.Ok(t, os.MkdirAll(shortcodes, 0755))
+	test.Ok(t, ioutil.WriteFile(filepath.Join(shortcodes, "figure.html"), []byte(shortcodeTpl), 0644))
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte(pageTpl), 0644))
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+
+	test.Ok(t, build.Run())
+	_ = os.RemoveAll(filepath.Dir(path))
+
+	for _, file := range []string{"/target/static/img/coffee-20w.png", "/target/static/img/coffee-40w.png"} {
+		exists, err := afero.Exists(memMapFs, file)
+		test.Ok(t, err)
+		test.Assert(t, exists, "%s should exist", file)
+	}
+
+	actual, err := afero.ReadFile(memMapFs, "/target/coffee/index.html")
+	test.Ok(t, err)
+	test.Equals(t, `<img src="/static/img/coffee-40w.png" srcset="/static/img/coffee-20w.png 20w, /static/img/coffee-40w.png 40w" sizes="100vw" width="40" height="20" alt="Coffee">|srcset="/static/img/coffee-20w.png 20w, /static/img/coffee-40w.png 40w"
+`, string(actual))
+}
+
+// TestRun_logging checks if the build progress is printed according to
+// the level of the logger.
+func TestRun_logging(t *testing.T) {
+	files := map[string]string{
+		"coffee.md": "---\nTitle: Coffee\n---\n",
+	}
+
+	tests := map[string]struct {
+		level    out.Level
+		expected string
+	}{
+		"quiet": {
+			level: out.LevelError,
+		},
+		"default": {
+			level:    out.LevelInfo,
+			expected: "✔️ website written to /target\n",
+		},
+		"verbose": {
+			level: out.LevelDebug,
+			expected: "processing /coffee.md\n" +
+				"wrote /target/coffee/index.html\n" +
+				"wrote /target/index.html\n" +
+				"✔️ website written to /target\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "", files)
+
+		var stdout, stderr bytes.Buffer
+
+		build, err := core.NewBuild(afero.NewMemMapFs(), path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			Logger:             out.NewLogger(testCase.level, &stdout, &stderr),
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+		test.Ok(t, err)
+
+		test.Equals(t, testCase.expected, stdout.String())
+		test.Equals(t, "", stderr.String())
+	}
+}
+
+// TestRun_stats checks if the statistics of a build match the fixture
+// project and can be unmarshalled from their JSON representation.
+func TestRun_stats(t *testing.T) {
+	files := map[string]string{
+		"coffee.md":      "---\nTitle: Coffee\n---\n",
+		"blog/tea.md":    "---\nTitle: Tea\n---\n",
+		"blog/mate.md":   "---\nDate: 2020-10-01\n---\n",
+		"draft.md":       "---\nTitle: Draft\nDraft: true\n---\n",
+		"blog/future.md": "---\nTitle: Future\nDate: 2020-12-01\n---\n",
+	}
+
+	tests := map[string]struct {
+		dryRun   bool
+		expected core.BuildStats
+	}{
+		"build": {
+			// 3 pages as well as the root and blog list pages.
+			expected: core.BuildStats{Pages: 5, Skipped: 2, Warnings: 1},
+		},
+		"dry run": {
+			dryRun:   true,
+			expected: core.BuildStats{Pages: 0, Skipped: 2, Warnings: 1},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "", files)
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			DryRun:             testCase.dryRun,
+		})
+		test.Ok(t, err)
+
+		build.Now = time.Date(2020, 10, 14, 12, 0, 0, 0, time.UTC)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+		test.Ok(t, err)
+
+		b, err := json.Marshal(build.Stats())
+		test.Ok(t, err)
+
+		var stats core.BuildStats
+		test.Ok(t, json.Unmarshal(b, &stats))
+
+		test.Equals(t, testCase.expected.Pages, stats.Pages)
+		test.Equals(t, testCase.expected.Skipped, stats.Skipped)
+		test.Equals(t, testCase.expected.Warnings, stats.Warnings)
+		test.Assert(t, stats.ElapsedSeconds > 0, "elapsed time should be positive, got %v", stats.ElapsedSeconds)
+
+		var outputBytes int64
+
+		if !testCase.dryRun {
+			test.Ok(t, afero.Walk(memMapFs, "/target", func(file string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() && filepath.Base(file) != ".verless-manifest.json" {
+					outputBytes += info.Size()
+				}
+				return err
+			}))
+			test.Assert(t, outputBytes > 0, "output directory should not be empty")
+		}
+
+		test.Equals(t, outputBytes, stats.OutputBytes)
+	}
+}
+
+// TestRun_invalidFrontMatter checks if all files with invalid front matter
+// are reported along with the line of the problem, and if they are only
+// skipped unless the front matter is strict.
+func TestRun_invalidFrontMatter(t *testing.T) {
+	files := map[string]string{
+		"coffee.md":    "---\nTitle: Coffee\n---\n",
+		"tea.md":       "---\nTitle: Tea\nTags\nAuthor: Barista\n---\n",
+		"blog/mate.md": "---\nTitle: Mate\n  Author: Barista\n---\n",
+	}
+
+	expectedErrors := []string{
+		"/blog/mate.md: invalid front matter in line 3: mapping values are not allowed in this context",
+		"/tea.md: invalid front matter in line 4: could not find expected ':'",
+	}
+
+	tests := map[string]struct {
+		strict bool
+	}{
+		"skipping invalid files": {},
+		"strict front matter": {
+			strict: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "", files)
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			StrictFrontmatter:  testCase.strict,
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		if testCase.strict {
+			test.Assert(t, err != nil, "the build should fail")
+			for _, expected := range expectedErrors {
+				test.Assert(t, strings.Contains(err.Error(), expected), "expected error %q, got %v", expected, err)
+			}
+			continue
+		}
+		test.Ok(t, err)
+
+		var actualErrors []string
+		for _, warning := range build.Warnings() {
+			actualErrors = append(actualErrors, warning.File+": "+warning.FrontMatterError)
+		}
+		test.Equals(t, expectedErrors, actualErrors)
+
+		exists, err := afero.Exists(memMapFs, "/target/coffee/index.html")
+		test.Ok(t, err)
+		test.Assert(t, exists, "valid files should have been rendered")
+
+		exists, err = afero.Exists(memMapFs, "/target/tea/index.html")
+		test.Ok(t, err)
+		test.Assert(t, !exists, "files with invalid front matter should have been skipped")
+	}
+}
+
+// TestRun_canonical checks if the canonical URL of a page is taken from
+// its front matter or derived from the base URL and its route, and if
+// the sitemap and the Atom feed use it.
+func TestRun_canonical(t *testing.T) {
+	config := "version: 1\nbaseURL: https://x.test/blog/\nplugins:\n  - atom\n  - sitemap\n"
+
+	path := createTestProject(t, config, map[string]string{
+		"coffee.md": "---\nTitle: Coffee\n---\n",
+		"tea.md":    "---\nTitle: Tea\nCanonical: https://tea.test/tea\n---\n",
+	})
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte("{{.Page.Canonical}}"), 0644))
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+
+	test.Ok(t, build.Run())
+	_ = os.RemoveAll(filepath.Dir(path))
+
+	tests := map[string]struct {
+		file     string
+		expected string
+	}{
+		"derived canonical URL": {
+			file:     "/target/coffee/index.html",
+			expected: "https://x.test/blog/coffee",
+		},
+		"explicit canonical URL": {
+			file:     "/target/tea/index.html",
+			expected: "https://tea.test/tea",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		content, err := afero.ReadFile(memMapFs, testCase.file)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, string(content))
+
+		for _, file := range []string{"/target/atom.xml", "/target/sitemap.xml"} {
+			content, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Assert(t, strings.Contains(string(content), testCase.expected), "%s should contain %s", file, testCase.expected)
+		}
+	}
+
+	content, err := afero.ReadFile(memMapFs, "/target/sitemap.xml")
+	test.Ok(t, err)
+	test.Assert(t, !strings.Contains(string(content), "https://x.test/blog/tea"), "the sitemap should not contain the derived URL of a page with a canonical URL")
+}
+
+// TestRun_aliases checks if a redirect stub is written to each alias of a
+// page and if aliases colliding with other pages are reported.
+func TestRun_aliases(t *testing.T) {
+	tests := map[string]struct {
+		files         map[string]string
+		expectedStubs map[string]string
+		expectedError error
+	}{
+		"redirect stubs": {
+			files: map[string]string{
+				"coffee.md":     "---\nTitle: Coffee\nAliases:\n  - /old-coffee/\n  - /drinks/coffee.html\n---\n",
+				"blog/index.md": "---\nTitle: Blog\nAliases:\n  - /news\n---\n",
+			},
+			expectedStubs: map[string]string{
+				"/target/old-coffee/index.html": "/coffee",
+				"/target/drinks/coffee.html":    "/coffee",
+				"/target/news/index.html":       "/blog",
+			},
+		},
+		"alias of another page": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\nAliases:\n  - /tea/\n---\n",
+				"tea.md":    "---\nTitle: Tea\n---\n",
+			},
+			expectedError: writer.ErrAliasCollision,
+		},
+		"alias of a list page": {
+			files: map[string]string{
+				"coffee.md":   "---\nTitle: Coffee\nAliases:\n  - /blog\n---\n",
+				"blog/tea.md": "---\nTitle: Tea\n---\n",
+			},
+			expectedError: writer.ErrAliasCollision,
+		},
+		"duplicate alias": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\nAliases:\n  - /old/\n---\n",
+				"tea.md":    "---\nTitle: Tea\nAliases:\n  - /old\n---\n",
+			},
+			expectedError: writer.ErrAliasCollision,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		config := "version: 1\nbaseURL: https://example.com\n"
+		path := createTestProject(t, config, testCase.files)
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		if testCase.expectedError != nil {
+			test.Assert(t, errors.Is(err, testCase.expectedError), "expected %v, got %v", testCase.expectedError, err)
+			continue
+		}
+		test.Ok(t, err)
+
+		for file, target := range testCase.expectedStubs {
+			content, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Assert(t, strings.Contains(string(content), `content="0; url=`+target+`"`), "%s should redirect to %s, got %s", file, target, content)
+			test.Assert(t, strings.Contains(string(content), `href="https://example.com`+target+`"`), "%s should link to the canonical URL of %s", file, target)
+		}
+	}
+}
+
+// TestRun_taxonomies checks if the pages are grouped by the terms of all
+// configured taxonomies and if a list page is generated for each term
+// and each taxonomy.
+func TestRun_taxonomies(t *testing.T) {
+	config := "version: 1\ntaxonomies:\n  - category\n  - series\n"
+	listPageTpl := "{{range .TermList}}{{.Name}} {{.Href}} {{.Count}}\n{{end}}{{range .Pages}}{{.Href}}\n{{end}}"
+	pageTpl := `{{range .Site.Taxonomies.categories.Terms}}{{.Name}}:{{range .Pages}} {{.ID}}{{end}}
+{{end}}`
+
+	path := createTestProject(t, config, map[string]string{
+		"blog/espresso.md":   "---\nTitle: Espresso\nDate: 2020-10-01\nCategories:\n  - Coffee\nSeries: Brewing Basics\n---\n",
+		"blog/cappuccino.md": "---\nTitle: Cappuccino\nDate: 2020-10-02\nCategory: Coffee\nSeries:\n  - Brewing Basics\n---\n",
+		"blog/matcha.md":     "---\nTitle: Matcha\nDate: 2020-10-03\nCategories:\n  - Tea\n---\n",
+	})
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "list-page.html"), []byte(listPageTpl), 0644))
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte(pageTpl), 0644))
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+
+	test.Ok(t, build.Run())
+	_ = os.RemoveAll(filepath.Dir(path))
+
+	tests := map[string]struct {
+		file     string
+		expected string
+	}{
+		"category index": {
+			file:     "/target/categories/index.html",
+			expected: "Coffee /categories/coffee 2\nTea /categories/tea 1\n",
+		},
+		"category page": {
+			file:     "/target/categories/coffee/index.html",
+			expected: "/blog/cappuccino\n/blog/espresso\n",
+		},
+		"series index": {
+			file:     "/target/series/index.html",
+			expected: "Brewing Basics /series/brewing-basics 2\n",
+		},
+		"series page": {
+			file:     "/target/series/brewing-basics/index.html",
+			expected: "/blog/cappuccino\n/blog/espresso\n",
+		},
+		"terms in templates": {
+			file:     "/target/blog/matcha/index.html",
+			expected: "Coffee: cappuccino espresso\nTea: matcha\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		content, err := afero.ReadFile(memMapFs, testCase.file)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, string(content))
+	}
+}
+
+// TestRun_bundles checks if the index.md file of a directory without any
+// other Markdown files is rendered as a single page, and if the other
+// files of the directory are copied next to it and can be referenced by
+// their relative path.
+func TestRun_bundles(t *testing.T) {
+	files := map[string]string{
+		"blog/coffee/index.md":         "---\nTitle: Coffee\n---\n![Beans](beans.jpg)\n\n[Recipe](files/recipe.txt)",
+		"blog/coffee/beans.jpg":        "beans",
+		"blog/coffee/files/recipe.txt": "recipe",
+		"blog/coffee/_notes.txt":       "notes",
+		"blog/tea.md":                  "---\nTitle: Tea\n---\n",
+	}
+
+	tests := map[string]struct {
+		config       string
+		expectedPage string
+		expectedList string
+	}{
+		"clean URLs": {
+			config:       "version: 1\n",
+			expectedPage: "/target/blog/coffee/index.html",
+			expectedList: "/blog/coffee\n/blog/tea\n",
+		},
+		"without clean URLs": {
+			config:       "version: 1\nbuild:\n  cleanURLs: false\n",
+			expectedPage: "/target/blog/coffee.html",
+			expectedList: "/blog/coffee.html\n/blog/tea.html\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, testCase.config, files)
+		test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte("{{.Page.Content}}"), 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			StrictLinks:        true,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		content, err := afero.ReadFile(memMapFs, testCase.expectedPage)
+		test.Ok(t, err)
+		test.Equals(t, "<p><img src=\"/blog/coffee/beans.jpg\" alt=\"Beans\"></p>\n<p><a href=\"/blog/coffee/files/recipe.txt\">Recipe</a></p>\n", string(content))
+
+		expectedResources := map[string]string{
+			"/target/blog/coffee/beans.jpg":        "beans",
+			"/target/blog/coffee/files/recipe.txt": "recipe",
+		}
+
+		for file, expected := range expectedResources {
+			content, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Equals(t, expected, string(content))
+		}
+
+		exists, err := afero.Exists(memMapFs, "/target/blog/coffee/_notes.txt")
+		test.Ok(t, err)
+		test.Assert(t, !exists, "files starting with an underscore should not be copied")
+
+		list, err := afero.ReadFile(memMapFs, "/target/blog/index.html")
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedList, string(list))
+	}
+}
+
+// TestRun_rootFiles checks if the files of the root directory are copied
+// into the output directory preserving their structure, and if root files
+// colliding with generated files are skipped with a warning.
+func TestRun_rootFiles(t *testing.T) {
+	tests := map[string]struct {
+		config string
+		dir    string
+	}{
+		"default directory": {
+			dir: "root",
+		},
+		"configured directory": {
+			config: "version: 1\ndirs:\n  root: passthrough\n",
+			dir:    "passthrough",
+		},
+	}
+
+	rootFiles := map[string]string{
+		"favicon.ico":                "icon",
+		"_redirects":                 "/old /new 301",
+		".well-known/security.txt":   "Contact: mailto:security@example.com",
+		"downloads/menus/coffee.pdf": "pdf",
+		"coffee/index.html":          "collision",
+	}
+
+	expectedFiles := map[string]string{
+		"/target/favicon.ico":                "icon",
+		"/target/_redirects":                 "/old /new 301",
+		"/target/.well-known/security.txt":   "Contact: mailto:security@example.com",
+		"/target/downloads/menus/coffee.pdf": "pdf",
+		"/target/coffee/index.html":          "Coffee",
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, testCase.config, map[string]string{
+			"coffee.md": "---\nTitle: Coffee\n---\n",
+		})
+		test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte("{{.Page.Title}}"), 0644))
+
+		for file, content := range rootFiles {
+			file = filepath.Join(path, testCase.dir, filepath.FromSlash(file))
+			test.Ok(t, os.MkdirAll(filepath.Dir(file), 0755))
+			test.Ok(t, ioutil.WriteFile(file, []byte(content), 0644))
+		}
+
+		memMapFs := afero.NewMemMapFs()
+
+		// The incremental builds keep the output directory. They have to
+		// overwrite the root files copied by the previous build, but not
+		// the unchanged page that isn't rendered by the last build.
+		for _, incremental := range []bool{false, true, true} {
+			build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+				OutputDir:          "/target",
+				RecompileTemplates: true,
+				Incremental:        incremental,
+				Force:              true,
+			})
+			test.Ok(t, err)
+			test.Ok(t, build.Run())
+
+			test.Equals(t, []core.Warning{{RootFile: "/coffee/index.html"}}, build.Warnings())
+		}
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		for file, expected := range expectedFiles {
+			content, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Equals(t, expected, string(content))
+		}
+	}
+}
+
+// TestRun_assetBundles checks if the CSS and JavaScript files of the theme
+// are concatenated into bundles in the configured order, and if pages can
+// reference the bundles.
+func TestRun_assetBundles(t *testing.T) {
+	tests := map[string]struct {
+		config      string
+		themeConfig string
+		fingerprint bool
+		expectedCSS string
+		expectedJS  string
+		expectedErr error
+	}{
+		"bundling disabled": {},
+		"lexical order": {
+			config:      "version: 1\nassets:\n  bundle:\n    enabled: true\n",
+			expectedCSS: "html {}\nbody {}\n",
+			expectedJS:  "init();\n;\nmenu()\n",
+		},
+		"order declared by the project": {
+			config:      "version: 1\nassets:\n  bundle:\n    enabled: true\n    css: [style.css]\n    js: [menu.js, app.js]\n",
+			themeConfig: "bundle:\n  css: [reset.css, style.css]\n",
+			expectedCSS: "body {}\nhtml {}\n",
+			expectedJS:  "menu()\n;\ninit();\n",
+		},
+		"order declared by the theme": {
+			themeConfig: "bundle:\n  enabled: true\n  css: [style.css, reset.css]\n",
+			expectedCSS: "body {}\nhtml {}\n",
+			expectedJS:  "init();\n;\nmenu()\n",
+		},
+		"fingerprinted bundles": {
+			config:      "version: 1\nassets:\n  fingerprint: true\n  bundle:\n    enabled: true\n",
+			fingerprint: true,
+			expectedCSS: "html {}\nbody {}\n",
+			expectedJS:  "init();\n;\nmenu()\n",
+		},
+		"unknown file": {
+			config:      "version: 1\nassets:\n  bundle:\n    enabled: true\n    css: [print.css]\n",
+			expectedErr: writer.ErrUnknownAsset,
+		},
+	}
+
+	themeFiles := map[string]string{
+		"css/style.css":       "body {}",
+		"assets/reset.css":    "html {}\n",
+		"js/app.js":           "init();\n",
+		"assets/menu/menu.js": "menu()",
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, testCase.config, map[string]string{"about.md": "---\nTitle: About\n---\n"})
+		themePath := filepath.Join(path, "themes", "default")
+
+		for file, content := range themeFiles {
+			file = filepath.Join(themePath, filepath.FromSlash(file))
+			test.Ok(t, os.MkdirAll(filepath.Dir(file), 0755))
+			test.Ok(t, ioutil.WriteFile(file, []byte(content), 0644))
+		}
+		test.Ok(t, os.Remove(filepath.Join(themePath, "assets", "style.css")))
+
+		if testCase.themeConfig != "" {
+			test.Ok(t, ioutil.WriteFile(filepath.Join(themePath, "theme.yml"), []byte(testCase.themeConfig), 0644))
+		}
+
+		page := `{{bundle "css"}} {{bundle "js"}}`
+		if testCase.expectedCSS == "" {
+			page = "{{.Page.Title}}"
+		}
+		test.Ok(t, ioutil.WriteFile(filepath.Join(themePath, "templates", "page.html"), []byte(page), 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		if test.ExpectedError(t, testCase.expectedErr, err) != test.IsCorrectNil {
+			continue
+		}
+
+		if testCase.expectedCSS == "" {
+			exists, err := afero.Exists(memMapFs, "/target/assets/bundle.css")
+			test.Ok(t, err)
+			test.Assert(t, !exists, "no bundle should have been written")
+			continue
+		}
+
+		var hrefs []string
+
+		for _, bundle := range []struct{ ext, expected string }{{".css", testCase.expectedCSS}, {".js", testCase.expectedJS}} {
+			href := "/assets/bundle" + bundle.ext
+			if testCase.fingerprint {
+				hash := sha256.Sum256([]byte(bundle.expected))
+				href = "/assets/bundle." + hex.EncodeToString(hash[:])[:8] + bundle.ext
+			}
+			hrefs = append(hrefs, href)
+
+			content, err := afero.ReadFile(memMapFs, filepath.Join("/target", filepath.FromSlash(href)))
+			test.Ok(t, err)
+			test.Equals(t, bundle.expected, string(content))
+		}
+
+		content, err := afero.ReadFile(memMapFs, "/target/about/index.html")
+		test.Ok(t, err)
+		test.Equals(t, strings.Join(hrefs, " "), string(content))
+	}
+}
+
+// TestRun_themeAssets checks if the CSS and JavaScript files of the theme
+// are copied into the output directory, skipping files starting with an
+// underscore and template sources, and if pages can reference them.
+func TestRun_themeAssets(t *testing.T) {
+	themeFiles := map[string]string{
+		"css/style.css":        "body {}",
+		"css/_variables.css":   ":root {}",
+		"js/app.js":            "init();",
+		"js/vendor/menu.js":    "menu();",
+		"js/_draft.js":         "draft();",
+		"js/snippets/nav.html": "<nav></nav>",
+	}
+
+	expectedFiles := map[string]string{
+		"/target/css/style.css": "body {}",
+		"/target/js/app.js":     "init();",
+		"/target/js/menu.js":    "menu();",
+	}
+
+	skippedFiles := []string{
+		"/target/css/_variables.css",
+		"/target/js/_draft.js",
+		"/target/js/nav.html",
+		"/target/js/snippets/nav.html",
+	}
+
+	path := createTestProject(t, "", map[string]string{"about.md": "---\nTitle: About\n---\n"})
+	themePath := filepath.Join(path, "themes", "default")
+
+	for file, content := range themeFiles {
+		file = filepath.Join(themePath, filepath.FromSlash(file))
+		test.Ok(t, os.MkdirAll(filepath.Dir(file), 0755))
+		test.Ok(t, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+
+	page := []byte(`{{fingerprint "app.js"}} {{fingerprint "js/menu.js"}}`)
+	test.Ok(t, ioutil.WriteFile(filepath.Join(themePath, "templates", "page.html"), page, 0644))
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+
+	test.Ok(t, build.Run())
+	_ = os.RemoveAll(filepath.Dir(path))
+
+	for file, expected := range expectedFiles {
+		content, err := afero.ReadFile(memMapFs, file)
+		test.Ok(t, err)
+		test.Equals(t, expected, string(content))
+	}
+
+	for _, file := range skippedFiles {
+		exists, err := afero.Exists(memMapFs, file)
+		test.Ok(t, err)
+		test.Assert(t, !exists, "%s should not have been copied", file)
+	}
+
+	content, err := afero.ReadFile(memMapFs, "/target/about/index.html")
+	test.Ok(t, err)
+	test.Equals(t, "/js/app.js /js/menu.js", string(content))
+}
+
+// TestRun_scss checks if the SCSS files of a theme are compiled to CSS if
+// enabled in theme.yml, and if plain CSS files are left untouched.
+func TestRun_scss(t *testing.T) {
+	const (
+		style = "@import \"colors\";\n.nav {\n  color: $main;\n  a { &:hover { color: $accent; } }\n}\n"
+		plain = "body{margin:0}"
+	)
+
+	tests := map[string]struct {
+		themeConfig   string
+		style         string
+		expected      map[string]string
+		skipped       []string
+		expectedError error
+	}{
+		"compilation enabled": {
+			themeConfig: "scss:\n  enabled: true\n",
+			style:       style,
+			expected: map[string]string{
+				"/target/css/style.css": ".nav {\n  color: #333;\n}\n\n.nav a:hover {\n  color: blue;\n}\n",
+				"/target/css/plain.css": plain,
+			},
+			skipped: []string{"/target/css/style.scss", "/target/css/_colors.scss", "/target/css/colors.css"},
+		},
+		"compilation disabled": {
+			style: style,
+			expected: map[string]string{
+				"/target/css/style.scss": style,
+				"/target/css/plain.css":  plain,
+			},
+			skipped: []string{"/target/css/style.css"},
+		},
+		"invalid SCSS": {
+			themeConfig:   "scss:\n  enabled: true\n",
+			style:         ".nav {\n  color: $missing;\n}\n",
+			expectedError: scss.ErrInvalidSCSS,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "", map[string]string{"about.md": "---\nTitle: About\n---\n"})
+		themePath := filepath.Join(path, "themes", "default")
+
+		files := map[string]string{
+			"css/style.scss":   testCase.style,
+			"css/_colors.scss": "$main: #333;\n$accent: blue;\n",
+			"css/plain.css":    plain,
+			"theme.yml":        testCase.themeConfig,
+		}
+
+		for file, content := range files {
+			file = filepath.Join(themePath, filepath.FromSlash(file))
+			test.Ok(t, os.MkdirAll(filepath.Dir(file), 0755))
+			test.Ok(t, ioutil.WriteFile(file, []byte(content), 0644))
+		}
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			if err != nil {
+				test.Assert(t, strings.Contains(err.Error(), filepath.Join("css", "style.scss")+":2:"), "error should contain the file and line: %v", err)
+			}
+			continue
+		}
+
+		for file, expected := range testCase.expected {
+			content, err := afero.ReadFile(memMapFs, file)
+			test.Ok(t, err)
+			test.Equals(t, expected, string(content))
+		}
+
+		for _, file := range testCase.skipped {
+			exists, err := afero.Exists(memMapFs, file)
+			test.Ok(t, err)
+			test.Assert(t, !exists, "%s should not have been written", file)
+		}
+	}
+}
+
+// TestRun_clean checks if a build with the Clean option removes the output
+// directory before building, except for the kept paths, even if the build
+// is incremental and would keep the output directory otherwise.
+func TestRun_clean(t *testing.T) {
+	tests := map[string]struct {
+		clean         bool
+		expectedStale bool
+	}{
+		"without clean": {
+			expectedStale: true,
+		},
+		"with clean": {
+			clean: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "version: 1\nbuild:\n  keep: [CNAME]\n", map[string]string{"about.md": "---\nTitle: About\n---\n"})
+		memMapFs := afero.NewMemMapFs()
+
+		// The output directory has to be inside the project to be removed.
+		outputDir := filepath.Join(path, "target")
+
+		test.Ok(t, afero.WriteFile(memMapFs, filepath.Join(outputDir, "CNAME"), []byte("example.com"), 0644))
+		test.Ok(t, afero.WriteFile(memMapFs, filepath.Join(outputDir, "stale.html"), []byte("stale"), 0644))
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          outputDir,
+			RecompileTemplates: true,
+			Incremental:        true,
+			Force:              true,
+			Clean:              testCase.clean,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		exists, err := afero.Exists(memMapFs, filepath.Join(outputDir, "stale.html"))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedStale, exists)
+
+		for _, file := range []string{"CNAME", "about/index.html"} {
+			exists, err := afero.Exists(memMapFs, filepath.Join(outputDir, filepath.FromSlash(file)))
+			test.Ok(t, err)
+			test.Assert(t, exists, "%s should exist", file)
+		}
+	}
+}
+
+// TestRun_precompress checks if large text files are precompressed into
+// siblings that decompress to the original files, and if small files,
+// other file types and the manifest are left alone.
+func TestRun_precompress(t *testing.T) {
+	tests := map[string]struct {
+		config   string
+		compress bool
+		expected bool
+	}{
+		"without precompression": {},
+		"with the compress option": {
+			compress: true,
+			expected: true,
+		},
+		"with assets.precompress": {
+			config:   "version: 1\nassets:\n  precompress: [gzip]\n",
+			expected: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		files := map[string]string{
+			"coffee.md": "---\nTitle: Coffee\n---\n" + strings.Repeat("Espresso and milk.\n\n", 100),
+			"tea.md":    "---\nTitle: Tea\n---\n",
+		}
+
+		path := createTestProject(t, testCase.config, files)
+
+		themePath := filepath.Join(path, "themes", "default")
+		test.Ok(t, ioutil.WriteFile(filepath.Join(themePath, "templates", "page.html"), []byte("{{.Page.Content}}"), 0644))
+		test.Ok(t, ioutil.WriteFile(filepath.Join(themePath, "assets", "logo.png"), bytes.Repeat([]byte{0}, 2048), 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			Compress:           testCase.compress,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		compressed, err := afero.ReadFile(memMapFs, "/target/coffee/index.html.gz")
+		if !testCase.expected {
+			test.Assert(t, os.IsNotExist(err), "/target/coffee/index.html.gz shouldn't exist")
+			continue
+		}
+		test.Ok(t, err)
+
+		original, err := afero.ReadFile(memMapFs, "/target/coffee/index.html")
+		test.Ok(t, err)
+
+		r, err := gzip.NewReader(bytes.NewReader(compressed))
+		test.Ok(t, err)
+		decompressed, err := ioutil.ReadAll(r)
+		test.Ok(t, err)
+		test.Equals(t, original, decompressed)
+
+		for _, file := range []string{"/target/tea/index.html.gz", "/target/assets/logo.png.gz", "/target/.verless-manifest.json.gz"} {
+			_, err := memMapFs.Stat(file)
+			test.Assert(t, os.IsNotExist(err), "%s shouldn't exist", file)
+		}
+
+		manifest, err := afero.ReadFile(memMapFs, "/target/.verless-manifest.json")
+		test.Ok(t, err)
+		test.Assert(t, strings.Contains(string(manifest), `"coffee/index.html.gz"`), "manifest should list the compressed page: %s", manifest)
+	}
+}
+
+// TestRun_memMapFs checks if a build writes the complete website into the
+// given filesystem and doesn't touch the output directory on disk.
+func TestRun_memMapFs(t *testing.T) {
+	path := createTestProject(t, "", map[string]string{
+		"coffee.md":    "---\nTitle: Coffee\n---\n",
+		"blog/tea.md":  "---\nTitle: Tea\n---\n",
+		"blog/milk.md": "---\nTitle: Milk\n---\n",
+	})
+	defer func() {
+		_ = os.RemoveAll(filepath.Dir(path))
+	}()
+
+	memMapFs := afero.NewMemMapFs()
+	outputDir := filepath.Join(path, "target")
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{RecompileTemplates: true})
+	test.Ok(t, err)
+	test.Ok(t, build.Run())
+
+	var files []string
+
+	err = afero.Walk(memMapFs, outputDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			rel, err := filepath.Rel(outputDir, file)
+			test.Ok(t, err)
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	test.Ok(t, err)
+
+	expected := []string{
+		".verless-manifest.json",
+		"assets/style.css",
+		"blog/index.html",
+		"blog/milk/index.html",
+		"blog/tea/index.html",
+		"coffee/index.html",
+		"index.html",
+	}
+	test.Equals(t, expected, files)
+
+	index, err := afero.ReadFile(memMapFs, filepath.Join(outputDir, "index.html"))
+	test.Ok(t, err)
+	test.Equals(t, "/blog/milk\n/blog/tea\n/coffee\n", string(index))
+
+	blog, err := afero.ReadFile(memMapFs, filepath.Join(outputDir, "blog", "index.html"))
+	test.Ok(t, err)
+	test.Equals(t, "/blog/milk\n/blog/tea\n", string(blog))
+
+	_, err = os.Stat(outputDir)
+	test.Assert(t, os.IsNotExist(err), "the output directory shouldn't exist on disk")
+}
+
+// TestRun_archive checks if a build with an archive path writes an
+// archive whose entries match the files of a regular build, and if it
+// leaves the output directory untouched.
+func TestRun_archive(t *testing.T) {
+	tests := map[string]struct {
+		archivePath   string
+		expectedError error
+	}{
+		"zip archive": {
+			archivePath: "/dist/site.zip",
+		},
+		"tar.gz archive": {
+			archivePath: "/dist/site.tar.gz",
+		},
+		"tgz archive": {
+			archivePath: "/dist/site.tgz",
+		},
+		"unsupported archive": {
+			archivePath:   "/dist/site.rar",
+			expectedError: core.ErrUnsupportedArchive,
+		},
+	}
+
+	files := map[string]string{
+		"coffee.md":   "---\nTitle: Coffee\n---\n",
+		"blog/tea.md": "---\nTitle: Tea\n---\n",
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "", files)
+
+		expected := make(map[string]string)
+		dirFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(dirFs, path, core.BuildOptions{OutputDir: "/target", RecompileTemplates: true})
+		test.Ok(t, err)
+		test.Ok(t, build.Run())
+
+		err = afero.Walk(dirFs, "/target", func(file string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || filepath.Base(file) == ".verless-manifest.json" {
+				return err
+			}
+			content, err := afero.ReadFile(dirFs, file)
+			expected[filepath.ToSlash(strings.TrimPrefix(file, filepath.FromSlash("/target/")))] = string(content)
+			return err
+		})
+		test.Ok(t, err)
+
+		archiveFs := afero.NewMemMapFs()
+
+		build, err = core.NewBuild(archiveFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			ArchivePath:        testCase.archivePath,
+			RecompileTemplates: true,
+		})
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			_ = os.RemoveAll(filepath.Dir(path))
+			continue
+		}
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		exists, err := afero.Exists(archiveFs, "/target")
+		test.Ok(t, err)
+		test.Assert(t, !exists, "the output directory shouldn't have been written")
+
+		archive, err := afero.ReadFile(archiveFs, testCase.archivePath)
+		test.Ok(t, err)
+
+		entries := make(map[string]string)
+		modes := make(map[string]os.FileMode)
+
+		if strings.HasSuffix(testCase.archivePath, ".zip") {
+			zipReader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+			test.Ok(t, err)
+
+			for _, file := range zipReader.File {
+				modes[file.Name] = file.Mode()
+				if file.Mode().IsDir() {
+					continue
+				}
+				r, err := file.Open()
+				test.Ok(t, err)
+				content, err := ioutil.ReadAll(r)
+				test.Ok(t, err)
+				entries[file.Name] = string(content)
+			}
+		} else {
+			gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+			test.Ok(t, err)
+			tarReader := tar.NewReader(gzipReader)
+
+			for {
+				header, err := tarReader.Next()
+				if err == io.EOF {
+					break
+				}
+				test.Ok(t, err)
+				modes[header.Name] = header.FileInfo().Mode()
+				if header.Typeflag == tar.TypeDir {
+					continue
+				}
+				content, err := ioutil.ReadAll(tarReader)
+				test.Ok(t, err)
+				entries[header.Name] = string(content)
+			}
+		}
+
+		test.Equals(t, expected, entries)
+		test.Assert(t, modes["blog/"].IsDir(), "blog/ should be a directory entry")
+		test.Equals(t, os.FileMode(0644), modes["index.html"].Perm())
+	}
+}
+
+// TestRun_reproducible checks if building the same project twice yields
+// byte-identical output, including feeds, archives and the result of the
+// now template function, and if SOURCE_DATE_EPOCH sets the timestamp.
+func TestRun_reproducible(t *testing.T) {
+	config := `version: 1
+site:
+  meta:
+    base: https://example.com
+plugins:
+  - atom
+  - sitemap
+  - tags
+taxonomies:
+  - category
+menus:
+  main:
+    - name: Blog
+      url: /blog
+    - name: About
+      url: /about
+`
+	files := map[string]string{
+		"about.md":       "---\nTitle: About\n---\n",
+		"blog/coffee.md": "---\nTitle: Coffee\nTags: [coffee, drinks]\nCategory: [hot]\n---\n",
+		"blog/tea.md":    "---\nTitle: Tea\nTags: [tea, drinks]\nCategory: [hot]\n---\n",
+		"blog/milk.md":   "---\nTitle: Milk\nTags: [drinks]\nCategory: [cold]\n---\n",
+		"news/update.md": "---\nTitle: Update\n---\n",
+	}
+
+	tests := map[string]struct {
+		sourceDateEpoch string
+		expectedNow     string
+	}{
+		"content modification times": {},
+		"source date epoch": {
+			sourceDateEpoch: "1602676800",
+			expectedNow:     "2020-10-14T12:00:00Z",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		if testCase.sourceDateEpoch != "" {
+			test.Ok(t, os.Setenv(core.SourceDateEpochVar, testCase.sourceDateEpoch))
+		}
+
+		path := createTestProject(t, config, files)
+
+		templates := filepath.Join(path, "themes", "default", "templates")
+		page := []byte(`{{.Page.Title}} {{now | dateFormat "rfc3339"}} {{range .Site.Menus.main}}{{.Name}},{{end}}`)
+		test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "page.html"), page, 0644))
+
+		var builds []map[string]string
+
+		for i := 0; i < 2; i++ {
+			memMapFs := afero.NewMemMapFs()
+
+			build, err := core.NewBuild(memMapFs, path, core.BuildOptions{OutputDir: "/target", RecompileTemplates: true, Parsers: 4})
+			test.Ok(t, err)
+			test.Ok(t, build.Run())
+
+			archiveBuild, err := core.NewBuild(memMapFs, path, core.BuildOptions{OutputDir: "/target", RecompileTemplates: true, ArchivePath: "/site.zip"})
+			test.Ok(t, err)
+			test.Ok(t, archiveBuild.Run())
+
+			hashes := make(map[string]string)
+
+			for _, dir := range []string{"/target", "/site.zip"} {
+				err = afero.Walk(memMapFs, dir, func(file string, info os.FileInfo, err error) error {
+					if err != nil || info.IsDir() {
+						return err
+					}
+					content, err := afero.ReadFile(memMapFs, file)
+					sum := sha256.Sum256(content)
+					hashes[file] = hex.EncodeToString(sum[:])
+					return err
+				})
+				test.Ok(t, err)
+			}
+
+			for _, file := range []string{"/target/atom.xml", "/target/sitemap.xml", "/target/tags/drinks/index.html", "/target/categories/index.html"} {
+				_, exists := hashes[file]
+				test.Assert(t, exists, "%s should have been written", file)
+			}
+
+			if testCase.expectedNow != "" {
+				coffee, err := afero.ReadFile(memMapFs, "/target/blog/coffee/index.html")
+				test.Ok(t, err)
+				test.Equals(t, "Coffee "+testCase.expectedNow+" Blog,About,", string(coffee))
+			}
+
+			builds = append(builds, hashes)
+
+			// The builds run in different seconds, so that any output depending
+			// on the current time differs.
+			if i == 0 {
+				time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+			}
+		}
+
+		_ = os.RemoveAll(filepath.Dir(path))
+		test.Ok(t, os.Unsetenv(core.SourceDateEpochVar))
+
+		test.Equals(t, builds[0], builds[1])
+	}
+}
+
+// TestRun_markdownExtensions checks if content files with any of the
+// Markdown extensions are rendered to a route without their extension,
+// and if the extensions can be configured.
+func TestRun_markdownExtensions(t *testing.T) {
+	files := map[string]string{
+		"coffee.md":                    "---\nTitle: Coffee\n---\n",
+		"blog/tea.markdown":            "---\nTitle: Tea\n---\n",
+		"blog/milk.mdown":              "---\nTitle: Milk\n---\n",
+		"blog/espresso/index.markdown": "---\nTitle: Espresso\n---\n",
+		"blog/espresso/beans.txt":      "beans",
+		"blog/notes.txt":               "notes",
+	}
+
+	tests := map[string]struct {
+		config   string
+		expected []string
+		excluded []string
+	}{
+		"default extensions": {
+			config: "version: 1\n",
+			expected: []string{
+				"/target/coffee/index.html",
+				"/target/blog/tea/index.html",
+				"/target/blog/milk/index.html",
+				"/target/blog/espresso/index.html",
+				"/target/blog/espresso/beans.txt",
+			},
+			excluded: []string{"/target/blog/notes/index.html", "/target/blog/notes.txt"},
+		},
+		"configured extensions": {
+			config: "version: 1\nmarkdown:\n  fileExtensions: [.markdown]\n",
+			expected: []string{
+				"/target/blog/tea/index.html",
+				"/target/blog/espresso/index.html",
+			},
+			excluded: []string{"/target/coffee/index.html", "/target/blog/milk/index.html"},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, testCase.config, files)
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir: "/target",
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		for _, file := range testCase.expected {
+			exists, err := afero.Exists(memMapFs, file)
+			test.Ok(t, err)
+			test.Assert(t, exists, "%s should exist", file)
+		}
+
+		for _, file := range testCase.excluded {
+			exists, err := afero.Exists(memMapFs, file)
+			test.Ok(t, err)
+			test.Assert(t, !exists, "%s shouldn't exist", file)
+		}
+	}
+}
+
+// TestRun_htmlPages checks if HTML content files are rendered through the
+// theme with their content passed through as-is, and if they are routed
+// like Markdown files.
+func TestRun_htmlPages(t *testing.T) {
+	files := map[string]string{
+		"blog/espresso.html":    "---\nTitle: Espresso\n---\n<section class=\"custom\">*Not* Markdown</section>\n",
+		"blog/latte/index.html": "+++\nTitle = \"Latte\"\n+++\n<img src=\"latte.jpg\">\n",
+		"blog/latte/latte.jpg":  "latte",
+		"blog/tea.md":           "---\nTitle: Tea\n---\n*Tea*",
+	}
+
+	path := createTestProject(t, "version: 1\n", files)
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte("<h1>{{.Page.Title}}</h1>{{.Page.Content}}"), 0644))
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+
+	test.Ok(t, build.Run())
+	_ = os.RemoveAll(filepath.Dir(path))
+
+	expected := map[string]string{
+		"/target/blog/espresso/index.html": "<h1>Espresso</h1><section class=\"custom\">*Not* Markdown</section>\n",
+		"/target/blog/latte/index.html":    "<h1>Latte</h1><img src=\"/blog/latte/latte.jpg\">\n",
+		"/target/blog/latte/latte.jpg":     "latte",
+		"/target/blog/tea/index.html":      "<h1>Tea</h1><p><em>Tea</em></p>\n",
+		"/target/blog/index.html":          "/blog/espresso\n/blog/latte\n/blog/tea\n",
+	}
+
+	for file, content := range expected {
+		actual, err := afero.ReadFile(memMapFs, file)
+		test.Ok(t, err)
+		test.Equals(t, content, string(actual))
+	}
+}
+
+// TestRun_diagrams checks if the diagram script is only inserted into
+// pages containing a diagram.
+func TestRun_diagrams(t *testing.T) {
+	files := map[string]string{
+		"espresso.md": "---\nTitle: Espresso\n---\n```mermaid\ngraph TD;\n```",
+		"tea.md":      "---\nTitle: Tea\n---\n```text\ngraph TD;\n```",
+	}
+
+	config := "version: 1\nmarkdown:\n  highlight:\n    enabled: false\n  diagrams:\n    script: <script src=\"/js/mermaid.js\"></script>\n"
+
+	path := createTestProject(t, config, files)
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte("<body>{{.Page.Content}}</body>"), 0644))
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+
+	test.Ok(t, build.Run())
+	_ = os.RemoveAll(filepath.Dir(path))
+
+	expected := map[string]string{
+		"/target/espresso/index.html": "<body><pre class=\"mermaid\">graph TD;\n</pre>\n<script src=\"/js/mermaid.js\"></script></body>",
+		"/target/tea/index.html":      "<body><pre><code class=\"language-text\">graph TD;\n</code></pre>\n</body>",
+	}
+
+	for file, content := range expected {
+		actual, err := afero.ReadFile(memMapFs, file)
+		test.Ok(t, err)
+		test.Equals(t, content, string(actual))
+	}
+}
+
+// TestRun_duplicateRoutes checks if the build fails for content files
+// rendered to the same page, naming all of those files, and if duplicate
+// routes are only reported as a warning if they're allowed.
+func TestRun_duplicateRoutes(t *testing.T) {
+	tests := map[string]struct {
+		config           string
+		files            map[string]string
+		expectedError    error
+		expectedMessage  string
+		expectedWarnings []core.Warning
+	}{
+		"page and list page": {
+			config: "version: 1\n",
+			files: map[string]string{
+				"about.md":       "---\nTitle: About\n---\n",
+				"about/index.md": "---\nTitle: About\n---\n",
+			},
+			expectedError:   core.ErrDuplicateRoute,
+			expectedMessage: filepath.FromSlash("/about/index.html is rendered from /about.md, /about/index.md"),
+		},
+		"Markdown and HTML page": {
+			config: "version: 1\nbuild:\n  cleanURLs: false\n",
+			files: map[string]string{
+				"blog/coffee.md":   "---\nTitle: Coffee\n---\n",
+				"blog/coffee.html": "---\nTitle: Coffee\n---\n",
+			},
+			expectedError:   core.ErrDuplicateRoute,
+			expectedMessage: filepath.FromSlash("/blog/coffee.html is rendered from /blog/coffee.html, /blog/coffee.md"),
+		},
+		"unique routes": {
+			config: "version: 1\n",
+			files: map[string]string{
+				"blog.md":        "---\nTitle: Blog\n---\n",
+				"blog/coffee.md": "---\nTitle: Coffee\n---\n",
+				"coffee.md":      "---\nTitle: Coffee\n---\n",
+			},
+			expectedWarnings: []core.Warning{},
+		},
+		"allowed duplicate routes": {
+			config: "version: 1\nbuild:\n  allowDuplicateRoutes: true\n",
+			files: map[string]string{
+				"about.md":       "---\nTitle: About\n---\n",
+				"about/index.md": "---\nTitle: About\n---\n",
+			},
+			expectedWarnings: []core.Warning{
+				{
+					Page:           "/about/index.html",
+					DuplicateFiles: []string{filepath.FromSlash("/about.md"), filepath.FromSlash("/about/index.md")},
+				},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, testCase.config, testCase.files)
+
+		build, err := core.NewBuild(afero.NewMemMapFs(), path, core.BuildOptions{
+			OutputDir: "/target",
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		if test.ExpectedError(t, testCase.expectedError, err) == test.IsCorrectNil {
+			test.Equals(t, testCase.expectedWarnings, build.Warnings())
+			continue
+		}
+
+		test.Assert(t, strings.Contains(err.Error(), testCase.expectedMessage), "unexpected error: %v", err)
+	}
+}
+
+// TestRun_slugs checks if the Slug front matter field replaces the
+// filename in the output path and the Href of a page.
+func TestRun_slugs(t *testing.T) {
+	files := map[string]string{
+		"blog/2023-01-my-long-title.md": "---\nTitle: Intro\nSlug: intro\n---\n",
+		"blog/latte/index.md":           "---\nTitle: Latte\nSlug: Latte Art!\n---\n![Latte](latte.jpg)",
+		"blog/latte/latte.jpg":          "latte",
+		"blog/tea.md":                   "---\nTitle: Tea\n---\n",
+	}
+
+	path := createTestProject(t, "version: 1\n", files)
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte("{{.Page.Href}} {{.Page.Content}}"), 0644))
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+
+	test.Ok(t, build.Run())
+	_ = os.RemoveAll(filepath.Dir(path))
+
+	expected := map[string]string{
+		"/target/blog/intro/index.html":     "/blog/intro ",
+		"/target/blog/latte-art/index.html": "/blog/latte-art <p><img src=\"/blog/latte-art/latte.jpg\" alt=\"Latte\"></p>\n",
+		"/target/blog/latte-art/latte.jpg":  "latte",
+		"/target/blog/tea/index.html":       "/blog/tea ",
+		"/target/blog/index.html":           "/blog/intro\n/blog/latte-art\n/blog/tea\n",
+	}
+
+	for file, content := range expected {
+		actual, err := afero.ReadFile(memMapFs, file)
+		test.Ok(t, err)
+		test.Equals(t, content, string(actual))
+	}
+
+	exists, err := afero.Exists(memMapFs, "/target/blog/2023-01-my-long-title/index.html")
+	test.Ok(t, err)
+	test.Assert(t, !exists, "the page shouldn't be rendered to its filename")
+}
+
+// TestRun_slugCollisions checks if the build fails for a slug that is
+// the route of another page and for slugs that can't be used as ID.
+func TestRun_slugCollisions(t *testing.T) {
+	tests := map[string]struct {
+		files           map[string]string
+		expectedError   error
+		expectedMessage string
+	}{
+		"slug of another page": {
+			files: map[string]string{
+				"blog/intro.md":      "---\nTitle: Intro\n---\n",
+				"blog/first-post.md": "---\nTitle: First post\nSlug: Intro\n---\n",
+			},
+			expectedError:   core.ErrDuplicateRoute,
+			expectedMessage: filepath.FromSlash("/blog/intro/index.html is rendered from /blog/first-post.md, /blog/intro.md"),
+		},
+		"slug without letters": {
+			files: map[string]string{
+				"blog/first-post.md": "---\nTitle: First post\nSlug: \"!!!\"\n---\n",
+			},
+			expectedMessage: `invalid slug "!!!"`,
+		},
+		"index slug": {
+			files: map[string]string{
+				"blog/first-post.md": "---\nTitle: First post\nSlug: Index\n---\n",
+			},
+			expectedMessage: `invalid slug "Index"`,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "version: 1\n", testCase.files)
+
+		build, err := core.NewBuild(afero.NewMemMapFs(), path, core.BuildOptions{
+			OutputDir: "/target",
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		test.Assert(t, err != nil, "expected an error")
+		if testCase.expectedError != nil {
+			test.Assert(t, errors.Is(err, testCase.expectedError), "expected %v, got %v", testCase.expectedError, err)
+		}
+		test.Assert(t, strings.Contains(err.Error(), testCase.expectedMessage), "unexpected error: %v", err)
+	}
+}
+
+// TestRun_slugFilenames checks if the route segments derived from the
+// names of content files and directories are slugified if configured.
+func TestRun_slugFilenames(t *testing.T) {
+	files := map[string]string{
+		"Blog Posts/Café au Lait.md":     "---\nTitle: Café au Lait\n---\n",
+		"Blog Posts/Crème_Brûlée.md":     "---\nTitle: Crème brûlée\n---\n",
+		"Blog Posts/index.md":            "---\nTitle: Blog\n---\n",
+		"Blog Posts/Straße/index.md":     "---\nTitle: Straße\n---\n",
+		"Blog Posts/Espresso Tips.md":    "---\nTitle: Espresso\nSlug: Ristretto & Lungo\n---\n",
+		"Blog Posts/release-1.2 (rc).md": "---\nTitle: Release\n---\n",
+	}
+
+	tests := map[string]struct {
+		config           string
+		expected         []string
+		expectedListPage string
+	}{
+		"filenames aren't slugified by default": {
+			config: "version: 1\n",
+			expected: []string{
+				"/Blog Posts/Café au Lait",
+				"/Blog Posts/Crème_Brûlée",
+				"/Blog Posts/Straße",
+				"/Blog Posts/ristretto-lungo",
+				"/Blog Posts/release-1.2 (rc)",
+			},
+			expectedListPage: "/target/Blog Posts/index.html",
+		},
+		"slugified filenames": {
+			config: "version: 1\nslug:\n  filenames: true\n",
+			expected: []string{
+				"/blog-posts/cafe-au-lait",
+				"/blog-posts/creme-brulee",
+				"/blog-posts/strasse",
+				"/blog-posts/ristretto-lungo",
+				"/blog-posts/release-1-2-rc",
+			},
+			expectedListPage: "/target/blog-posts/index.html",
+		},
+		"slugified filenames without lowercasing": {
+			config: "version: 1\nslug:\n  filenames: true\n  lowercase: false\n",
+			expected: []string{
+				"/Blog-Posts/Cafe-au-Lait",
+				"/Blog-Posts/Creme-Brulee",
+				"/Blog-Posts/Strasse",
+				"/Blog-Posts/Ristretto-Lungo",
+				"/Blog-Posts/release-1-2-rc",
+			},
+			expectedListPage: "/target/Blog-Posts/index.html",
+		},
+		"slugified filenames without transliteration": {
+			config: "version: 1\nslug:\n  filenames: true\n  transliterate: false\n",
+			expected: []string{
+				"/blog-posts/café-au-lait",
+				"/blog-posts/crème-brûlée",
+				"/blog-posts/straße",
+				"/blog-posts/ristretto-lungo",
+				"/blog-posts/release-1-2-rc",
+			},
+			expectedListPage: "/target/blog-posts/index.html",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, testCase.config, files)
+		test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), []byte("{{.Page.Href}}"), 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		for _, href := range testCase.expected {
+			actual, err := afero.ReadFile(memMapFs, "/target"+href+"/index.html")
+			test.Ok(t, err)
+			test.Equals(t, href, string(actual))
+		}
+
+		exists, err := afero.Exists(memMapFs, testCase.expectedListPage)
+		test.Ok(t, err)
+		test.Assert(t, exists, "%s doesn't exist", testCase.expectedListPage)
+	}
+}
+
+// TestRun_slugFilenamesLanguages checks if the language suffix of a
+// filename is removed before slugifying the filename.
+func TestRun_slugFilenamesLanguages(t *testing.T) {
+	files := map[string]string{
+		"Über uns.de.md": "---\nTitle: Über uns\n---\n",
+		"About Us.md":    "---\nTitle: About us\n---\n",
+	}
+
+	config := "version: 1\nslug:\n  filenames: true\ni18n:\n  defaultLanguage: en\n  languages:\n    en:\n      name: English\n    de:\n      name: Deutsch\n"
+
+	path := createTestProject(t, config, files)
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir: "/target",
+	})
+	test.Ok(t, err)
+
+	test.Ok(t, build.Run())
+	_ = os.RemoveAll(filepath.Dir(path))
+
+	for _, file := range []string{"/target/about-us/index.html", "/target/de/uber-uns/index.html"} {
+		exists, err := afero.Exists(memMapFs, file)
+		test.Ok(t, err)
+		test.Assert(t, exists, "%s doesn't exist", file)
+	}
+}
+
+// TestRun_outputFormats checks if pages are additionally rendered in all
+// output formats including their section and if the alternates of a page
+// are available to its templates.
+func TestRun_outputFormats(t *testing.T) {
+	formats := `outputFormats:
+  amp:
+    template: page.amp.html
+    extension: amp.html
+  text:
+    template: page.txt
+    mediaType: text/plain
+    extension: txt
+    sections:
+      - /blog
+`
+
+	tests := map[string]struct {
+		config        string
+		expected      map[string]string
+		expectedNone  []string
+		expectedError string
+	}{
+		"clean URLs": {
+			config: "version: 1\n" + formats,
+			expected: map[string]string{
+				"/target/blog/coffee/index.html":     `page: Coffee <link rel="alternate" type="text/html" href="/blog/coffee/index.amp.html"> <link rel="alternate" type="text/plain" href="/blog/coffee/index.txt">`,
+				"/target/blog/coffee/index.amp.html": "amp: Coffee",
+				"/target/blog/coffee/index.txt":      "text: Coffee\n",
+				"/target/about/index.html":           `page: About <link rel="alternate" type="text/html" href="/about/index.amp.html">`,
+				"/target/about/index.amp.html":       "amp: About",
+			},
+			expectedNone: []string{
+				"/target/about/index.txt",
+				"/target/blog/index.amp.html",
+			},
+		},
+		"without clean URLs": {
+			config: "version: 1\nbuild:\n  cleanURLs: false\n" + formats,
+			expected: map[string]string{
+				"/target/blog/coffee.html":     `page: Coffee <link rel="alternate" type="text/html" href="/blog/coffee.amp.html"> <link rel="alternate" type="text/plain" href="/blog/coffee.txt">`,
+				"/target/blog/coffee.amp.html": "amp: Coffee",
+				"/target/blog/coffee.txt":      "text: Coffee\n",
+				"/target/about.html":           `page: About <link rel="alternate" type="text/html" href="/about.amp.html">`,
+				"/target/about.amp.html":       "amp: About",
+			},
+			expectedNone: []string{
+				"/target/about.txt",
+			},
+		},
+		"missing template": {
+			config:        "version: 1\noutputFormats:\n  json:\n    template: page.json\n    extension: json\n",
+			expectedError: "output format json",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, testCase.config, map[string]string{
+			"blog/index.md":  "---\nTitle: Blog\n---\n",
+			"blog/coffee.md": "---\nTitle: Coffee\n---\n",
+			"about.md":       "---\nTitle: About\n---\n",
+		})
+
+		templates := filepath.Join(path, "themes", "default", "templates")
+		test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "page.html"), []byte(`page: {{.Page.Title}}{{range .Page.Alternates}} <link rel="alternate" type="{{.MediaType}}" href="{{.Href}}">{{end}}`), 0644))
+		test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "page.amp.html"), []byte("amp: {{.Page.Title}}"), 0644))
+		test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "page.txt"), []byte("text: {{.Page.Title}}\n"), 0644))
+
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+			Minify:             true,
+		})
+		if testCase.expectedError != "" {
+			_ = os.RemoveAll(filepath.Dir(path))
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "expected an error for %s, got %v", testCase.expectedError, err)
+			continue
+		}
+		test.Ok(t, err)
+
+		err = build.Run()
+		_ = os.RemoveAll(filepath.Dir(path))
+		test.Ok(t, err)
+
+		for file, content := range testCase.expected {
+			actual, err := afero.ReadFile(memMapFs, filepath.FromSlash(file))
+			test.Ok(t, err)
+			test.Equals(t, content, string(actual))
+		}
+
+		for _, file := range testCase.expectedNone {
+			exists, err := afero.Exists(memMapFs, filepath.FromSlash(file))
+			test.Ok(t, err)
+			test.Assert(t, !exists, "%s should not be rendered", file)
+		}
+	}
+}
+
+// countingParser is a parser counting its invocations, which are passed
+// to the wrapped parser.
+type countingParser struct {
+	parser core.Parser
+	calls  int
+	mutex  sync.Mutex
+}
+
+func (c *countingParser) ParsePage(src []byte) (model.Page, error) {
+	c.mutex.Lock()
+	c.calls++
+	c.mutex.Unlock()
+
+	return c.parser.ParsePage(src)
+}
+
+// TestRun_renderCache checks if a build reuses the pages parsed by the
+// previous build, unless the file or the Markdown options have changed
+// or the cache has been disabled.
+func TestRun_renderCache(t *testing.T) {
+	config := "version: 1\ntypes:\n  drink:\n    template: page.html\n"
+
+	path := createTestProject(t, config, map[string]string{
+		"coffee.md": "---\nTitle: Coffee\nType: drink\n---\n# Coffee\n\n\"Espresso\" -- *strong*.\n",
+		"tea.md":    "---\nTitle: Tea\n---\nGreen.\n",
+		"milk.html": "<p>Milk</p>",
+	})
+	defer func() {
+		_ = os.RemoveAll(filepath.Dir(path))
+	}()
+
+	template := []byte("{{.Page.Title}} {{with .Page.Type}}{{.Template}} {{end}}{{.Page.Content}}")
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), template, 0644))
+
+	var expected []byte
+
+	tests := []struct {
+		name           string
+		change         func()
+		noCache        bool
+		dryRun         bool
+		inMemory       bool
+		notIncremental bool
+		expected       int
+		content        string
+	}{
+		{name: "empty cache", expected: 2},
+		{name: "unchanged files", expected: 0},
+		{
+			name: "changed file",
+			change: func() {
+				test.Ok(t, ioutil.WriteFile(filepath.Join(path, "content", "tea.md"), []byte("---\nTitle: Tea\n---\nBlack.\n"), 0644))
+			},
+			expected: 1,
+		},
+		{
+			name: "changed Markdown options",
+			change: func() {
+				test.Ok(t, ioutil.WriteFile(filepath.Join(path, "verless.yml"), []byte(config+"markdown:\n  smartypants: true\n"), 0644))
+			},
+			expected: 2,
+			content:  "Coffee page.html <h1 id=\"coffee\">Coffee</h1>\n<p>&ldquo;Espresso&rdquo; &ndash; <em>strong</em>.</p>\n",
+		},
+		{name: "dry run", dryRun: true, expected: 2},
+		{name: "in-memory target", inMemory: true, expected: 2},
+		{name: "full build", notIncremental: true, expected: 2},
+		{name: "disabled cache", noCache: true, expected: 2},
+	}
+
+	// The output directory is outside the project, so that only the
+	// caches are written into the project.
+	outputDir := filepath.Join(filepath.Dir(path), "public")
+
+	for _, testCase := range tests {
+		t.Log(testCase.name)
+
+		if testCase.change != nil {
+			testCase.change()
+		}
+
+		targetFs := afero.NewOsFs()
+		if testCase.inMemory {
+			targetFs = afero.NewMemMapFs()
+		}
+
+		build, err := core.NewBuild(targetFs, path, core.BuildOptions{
+			OutputDir:          outputDir,
+			Force:              true,
+			RecompileTemplates: true,
+			Incremental:        !testCase.notIncremental,
+			NoCache:            testCase.noCache,
+			DryRun:             testCase.dryRun,
+		})
+		test.Ok(t, err)
+
+		spy := &countingParser{parser: build.Parser}
+		build.Parser = spy
+
+		test.Ok(t, build.Run())
+		test.Equals(t, testCase.expected, spy.calls)
+
+		if testCase.dryRun {
+			continue
+		}
+
+		content, err := afero.ReadFile(targetFs, filepath.Join(outputDir, "coffee", "index.html"))
+		test.Ok(t, err)
+
+		// Cached pages must be rendered exactly like freshly parsed pages.
+		if testCase.content != "" {
+			expected = []byte(testCase.content)
+		}
+		if expected != nil {
+			test.Equals(t, string(expected), string(content))
+		}
+		expected = content
+	}
+
+	// The entries of the previous versions of tea.md have been removed.
+	entries, err := ioutil.ReadDir(filepath.Join(path, ".verless", "renders"))
+	test.Ok(t, err)
+	test.Equals(t, 2, len(entries))
+}
+
+// TestRun_ignoreFiles checks if content files and bundle resources matched
+// by the .verlessignore files are skipped, and if negated patterns let
+// pass files that have been matched by a previous pattern.
+func TestRun_ignoreFiles(t *testing.T) {
+	path := createTestProject(t, "", map[string]string{
+		".verlessignore":             "scratch/\nnotes.md\n!blog/notes.md\n*.psd\n",
+		"blog/.verlessignore":        "draft-*.md\n",
+		"scratch/ideas.md":           "---\nTitle: Ideas\n---\n",
+		"notes.md":                   "---\nTitle: Notes\n---\n",
+		"blog/notes.md":              "---\nTitle: Blog notes\n---\n",
+		"blog/tea.md":                "---\nTitle: Tea\n---\n",
+		"blog/draft-milk.md":         "---\nTitle: Milk\n---\n",
+		"blog/coffee/index.md":       "---\nTitle: Coffee\n---\n",
+		"blog/coffee/draft-beans.md": "---\nTitle: Beans\n---\n",
+		"blog/coffee/beans.jpg":      "beans",
+		"blog/coffee/beans.psd":      "beans",
+	})
+	defer func() {
+		_ = os.RemoveAll(filepath.Dir(path))
+	}()
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		OutputDir:          "/target",
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+	test.Ok(t, build.Run())
+
+	files := map[string]bool{
+		"/target/scratch/ideas/index.html":           false,
+		"/target/notes/index.html":                   false,
+		"/target/blog/notes/index.html":              true,
+		"/target/blog/tea/index.html":                true,
+		"/target/blog/draft-milk/index.html":         false,
+		"/target/blog/coffee/index.html":             true,
+		"/target/blog/coffee/draft-beans.md":         false,
+		"/target/blog/coffee/beans.jpg":              true,
+		"/target/blog/coffee/beans.psd":              false,
+		"/target/blog/coffee/.verlessignore":         false,
+		"/target/blog/coffee/draft-beans/index.html": false,
+	}
+
+	for file, expected := range files {
+		exists, err := afero.Exists(memMapFs, file)
+		test.Ok(t, err)
+		test.Assert(t, exists == expected, "%s: expected exists to be %v", file, expected)
+	}
+
+	list, err := afero.ReadFile(memMapFs, "/target/blog/index.html")
+	test.Ok(t, err)
+	test.Equals(t, "/blog/coffee\n/blog/notes\n/blog/tea\n", string(list))
+}
+
+// TestRun_dirs checks if the project directories configured in the dirs
+// section are used for creating and building a project.
+func TestRun_dirs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verless-build")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "my-blog")
+
+	_, err = core.CreateProject(path, core.CreateProjectOptions{
+		Dirs: config.Dirs{Content: "posts", Output: "public", Themes: "looks"},
+	})
+	test.Ok(t, err)
+
+	for _, dir := range []string{"posts", filepath.Join("looks", "default", "templates")} {
+		exists, err := afero.DirExists(afero.NewOsFs(), filepath.Join(path, dir))
+		test.Ok(t, err)
+		test.Assert(t, exists, "%s should exist", dir)
+	}
+
+	test.Ok(t, core.CreateFile(path, "coffee", core.CreateFileOptions{}))
+	test.Ok(t, core.CreateTheme(core.CreateThemeOptions{Project: path}, "dark"))
+
+	exists, err := afero.Exists(afero.NewOsFs(), filepath.Join(path, "looks", "dark", "theme.yml"))
+	test.Ok(t, err)
+	test.Assert(t, exists, "the dark theme should exist in the themes directory")
+
+	memMapFs := afero.NewMemMapFs()
+
+	build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+		RecompileTemplates: true,
+	})
+	test.Ok(t, err)
+	test.Ok(t, build.Run())
+
+	for _, file := range []string{"index.html", filepath.Join("coffee", "index.html"), filepath.Join("assets", "style.css")} {
+		exists, err := afero.Exists(memMapFs, filepath.Join(path, "public", file))
+		test.Ok(t, err)
+		test.Assert(t, exists, "%s should exist in the output directory", file)
+	}
+}
+
+// TestRun_outputDir checks if a build only clears an output directory
+// that contains nothing but the files of the previous build, unless the
+// build is forced, and never clears directories outside the project.
+func TestRun_outputDir(t *testing.T) {
+	tests := map[string]struct {
+		outsideProject bool
+		unexpected     string
+		force          bool
+		expectedErr    error
+		expectedFiles  map[string]bool
+	}{
+		"rebuild": {
+			expectedFiles: map[string]bool{"coffee/index.html": false, "tea/index.html": true},
+		},
+		"unexpected file": {
+			unexpected:    "notes.txt",
+			expectedErr:   core.ErrCannotOverwrite,
+			expectedFiles: map[string]bool{"coffee/index.html": true, "notes.txt": true},
+		},
+		"unexpected file with force": {
+			unexpected:    "notes.txt",
+			force:         true,
+			expectedFiles: map[string]bool{"coffee/index.html": false, "notes.txt": false, "tea/index.html": true},
+		},
+		"outside the project": {
+			outsideProject: true,
+			expectedErr:    core.ErrCannotOverwrite,
+			expectedFiles:  map[string]bool{"coffee/index.html": true},
+		},
+		"outside the project with force": {
+			outsideProject: true,
+			force:          true,
+			expectedFiles:  map[string]bool{"coffee/index.html": true, "tea/index.html": true},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, "", map[string]string{
+			"coffee.md": "---\nTitle: Coffee\n---\n",
+		})
+		memMapFs := afero.NewMemMapFs()
+
+		outputDir := filepath.Join(path, "target")
+		if testCase.outsideProject {
+			outputDir = filepath.Join(filepath.Dir(path), "public")
+		}
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{OutputDir: outputDir})
+		test.Ok(t, err)
+		test.Ok(t, build.Run())
+
+		if testCase.unexpected != "" {
+			test.Ok(t, afero.WriteFile(memMapFs, filepath.Join(outputDir, testCase.unexpected), []byte{}, 0644))
+		}
+
+		// The next build renders tea instead of coffee, so that files
+		// which haven't been removed are still present.
+		test.Ok(t, os.Rename(filepath.Join(path, "content", "coffee.md"), filepath.Join(path, "content", "tea.md")))
+
+		build, err = core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir: outputDir,
+			Force:     testCase.force,
+		})
+		if err == nil {
+			err = build.Run()
+		}
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		if testCase.expectedErr != nil {
+			test.ExpectedError(t, testCase.expectedErr, err)
+		} else {
+			test.Ok(t, err)
+		}
+
+		for file, expected := range testCase.expectedFiles {
+			exists, err := afero.Exists(memMapFs, filepath.Join(outputDir, filepath.FromSlash(file)))
+			test.Ok(t, err)
+			test.Assert(t, exists == expected, "%s: existence of %s should be %v", name, file, expected)
+		}
+	}
+}
+
+// TestRun_hooks checks if the preBuild and postBuild hooks are executed
+// in order before and after the build, if a failing hook fails the build
+// and if no hooks are executed in a dry run.
+func TestRun_hooks(t *testing.T) {
+	tests := map[string]struct {
+		config        string
+		dryRun        bool
+		expectError   bool
+		expectedFiles map[string]bool
+	}{
+		"pre and post hooks": {
+			config: `version: 1
+hooks:
+  preBuild:
+    - mkdir generated
+    - cp content/coffee.md content/tea.md
+  postBuild:
+    - cp target/tea/index.html generated/tea.html
+    - echo done
+`,
+			expectedFiles: map[string]bool{"generated/tea.html": true, "target/tea/index.html": true},
+		},
+		"failing pre hook": {
+			config: `version: 1
+hooks:
+  preBuild:
+    - "false"
+    - mkdir generated
+`,
+			expectError:   true,
+			expectedFiles: map[string]bool{"generated": false, "target": false},
+		},
+		"failing post hook": {
+			config: `version: 1
+hooks:
+  postBuild:
+    - "false"
+    - mkdir generated
+`,
+			expectError:   true,
+			expectedFiles: map[string]bool{"generated": false, "target/coffee/index.html": true},
+		},
+		"dry run": {
+			config: `version: 1
+hooks:
+  preBuild:
+    - mkdir generated
+  postBuild:
+    - mkdir published
+`,
+			dryRun:        true,
+			expectedFiles: map[string]bool{"generated": false, "published": false, "target": false},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, testCase.config, map[string]string{
+			"coffee.md": "---\nTitle: Coffee\n---\n",
+		})
+
+		build, err := core.NewBuild(afero.NewOsFs(), path, core.BuildOptions{
+			OutputDir: filepath.Join(path, "target"),
+			DryRun:    testCase.dryRun,
+		})
+		test.Ok(t, err)
+
+		err = build.Run()
+		if testCase.expectError {
+			test.Assert(t, err != nil, "%s: the build should fail", name)
+		} else {
+			test.Ok(t, err)
+		}
+
+		for file, expected := range testCase.expectedFiles {
+			exists, err := afero.Exists(afero.NewOsFs(), filepath.Join(path, filepath.FromSlash(file)))
+			test.Ok(t, err)
+			test.Assert(t, exists == expected, "%s: existence of %s should be %v", name, file, expected)
+		}
+
+		_ = os.RemoveAll(filepath.Dir(path))
+	}
+}
+
+// taggerPlugin is a custom plugin that adds a tag to every page.
+type taggerPlugin struct {
+	tag       string
+	processed int
+	mutex     sync.Mutex
+	calls     []string
+}
+
+func (t *taggerPlugin) ProcessPage(page *model.Page) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	page.Tags = append(page.Tags, t.tag)
+	t.processed++
+
+	return nil
+}
+
+func (t *taggerPlugin) PreWrite(_ *model.Site) error {
+	t.calls = append(t.calls, "PreWrite")
+	return nil
+}
+
+func (t *taggerPlugin) PostWrite() error {
+	t.calls = append(t.calls, "PostWrite")
+	return nil
+}
+
+// taggers contains the tagger plugins created by the registered factory.
+var taggers []*taggerPlugin
+
+// init registers the tagger plugin. Registering it in the tests would
+// fail if the tests are run multiple times.
+func init() {
+	plugin.Register("tagger", func(cfg plugin.Config) (plugin.Plugin, error) {
+		tag, ok := cfg.Settings["tag"].(string)
+		if !ok {
+			return nil, fmt.Errorf("tag has to be set")
+		}
+		t := &taggerPlugin{tag: tag}
+		taggers = append(taggers, t)
+		return t, nil
+	})
+}
+
+// TestRun_customPlugin checks if a registered custom plugin is created
+// with its settings and runs before the plugins enabled after it.
+func TestRun_customPlugin(t *testing.T) {
+	files := map[string]string{
+		"coffee.md": "---\nTitle: Coffee\n---\n",
+		"tea.md":    "---\nTitle: Tea\n---\n",
+	}
+
+	tests := map[string]struct {
+		config      string
+		expectError bool
+	}{
+		"custom plugin with settings": {
+			config: "version: 1\nplugins:\n  - tagger\n  - tags\npluginConfig:\n  tagger:\n    tag: Hot Drinks\n",
+		},
+		"custom plugin without settings": {
+			config:      "version: 1\nplugins:\n  - tagger\n",
+			expectError: true,
+		},
+		"unknown plugin": {
+			config:      "version: 1\nplugins:\n  - unknown\n",
+			expectError: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		taggers = nil
+
+		path := createTestProject(t, testCase.config, files)
+		memMapFs := afero.NewMemMapFs()
+
+		build, err := core.NewBuild(memMapFs, path, core.BuildOptions{
+			OutputDir:          "/target",
+			RecompileTemplates: true,
+		})
+		if testCase.expectError {
+			_ = os.RemoveAll(filepath.Dir(path))
+			test.Assert(t, err != nil, "creating the build should fail")
+			continue
+		}
+		test.Ok(t, err)
+
+		test.Ok(t, build.Run())
+		_ = os.RemoveAll(filepath.Dir(path))
+
+		test.Equals(t, 1, len(taggers))
+		test.Equals(t, len(files), taggers[0].processed)
+		test.Equals(t, []string{"PreWrite", "PostWrite"}, taggers[0].calls)
+
+		// The tags plugin runs after the tagger plugin and therefore has
+		// to know the added tag.
+		content, err := afero.ReadFile(memMapFs, "/target/tags/hot-drinks/index.html")
+		test.Ok(t, err)
+		test.Equals(t, "/coffee\n/tea\n", string(content))
+	}
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/verless/verless/fs"
+)
+
+const (
+	// bundleIndexName is the name of the Markdown file without extension
+	// that turns a content directory into a page bundle, like index.md.
+	bundleIndexName = "index"
+)
+
+var (
+	// errSection stops walking a directory as soon as it turns out to be
+	// a section instead of a page bundle.
+	errSection = errors.New("directory contains Markdown files")
+
+	// relativeRefPattern matches href and src attributes whose values
+	// don't start with a slash or a #. The attribute and the opening quote
+	// are captured separately from the value.
+	relativeRefPattern = regexp.MustCompile(`(?i)(\s(?:href|src)\s*=\s*["'])([^"'/#][^"']*)`)
+)
+
+// pageBundle is a content directory like /blog/coffee whose index.md file
+// is rendered as a single page. All other files inside the directory are
+// resources of that page and are copied next to it.
+type pageBundle struct {
+	// dir is the directory inside the content directory.
+	dir string
+	// resources contains the paths of all resources relative to dir.
+	resources []string
+	// outputDir is the directory the resources are copied to, relative
+	// to the output directory. This is the URL of the page.
+	outputDir string
+}
+
+// findBundle returns the page bundle whose index.md file is the given
+// content file. If the file is no index.md file or its directory contains
+// other content files, the directory is a section with a custom list
+// page and findBundle returns nil. The content directory itself is never
+// a page bundle. isContent reports whether a file is a content file, so
+// that index.markdown or index.html is an index file as well. Files that
+// don't pass filter, like ignored files, are neither content files nor
+// resources.
+func findBundle(contentDir, file string, isContent, filter func(file string) bool) (*pageBundle, error) {
+	var (
+		dir      = filepath.ToSlash(filepath.Dir(file))
+		base     = filepath.Base(file)
+		isBundle = strings.TrimSuffix(base, filepath.Ext(base)) == bundleIndexName
+	)
+
+	if !isBundle || !isContent(base) || dir == "/" || dir == "." {
+		return nil, nil
+	}
+
+	bundle := pageBundle{dir: dir}
+	root := filepath.Join(contentDir, filepath.FromSlash(dir))
+
+	err := filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if info.IsDir() || rel == base || !fs.NoUnderscores("/"+rel) {
+			return nil
+		}
+
+		if info.Name() == fs.IgnoreFile || !filter(path.Join(dir, rel)) {
+			return nil
+		}
+
+		if isContent(rel) {
+			return errSection
+		}
+
+		bundle.resources = append(bundle.resources, rel)
+		return nil
+	})
+
+	if err == errSection {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &bundle, nil
+}
+
+// addBundle records a page bundle whose resources have to be copied into
+// the output directory. Safe for concurrent usage.
+func (b *Build) addBundle(bundle *pageBundle) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.bundles = append(b.bundles, bundle)
+}
+
+// copyBundleResources copies the resources of all page bundles into the
+// output directories of their pages.
+func (b *Build) copyBundleResources() error {
+	for _, bundle := range b.bundles {
+		for _, resource := range bundle.resources {
+			content, err := ioutil.ReadFile(filepath.Join(b.contentDir, filepath.FromSlash(bundle.dir), filepath.FromSlash(resource)))
+			if err != nil {
+				return err
+			}
+
+			dest := filepath.Join(b.outputDir, filepath.FromSlash(bundle.outputDir), filepath.FromSlash(resource))
+
+			if err := b.targetFs.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+				return err
+			}
+
+			if err := fs.WriteFileAtomic(b.targetFs, dest, content, 0644); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// rewriteBundleRefs resolves all relative references inside href and src
+// attributes against the given directory, turning photo.jpg into a root-
+// relative path like /blog/coffee/photo.jpg. This way, the resources of
+// a page bundle are found regardless of where the content is rendered,
+// e.g. in a list page. External URLs are left unchanged.
+func rewriteBundleRefs(html, dir string) string {
+	base := &url.URL{Path: strings.TrimSuffix(dir, "/") + "/"}
+
+	return relativeRefPattern.ReplaceAllStringFunc(html, func(match string) string {
+		groups := relativeRefPattern.FindStringSubmatch(match)
+
+		ref, err := url.Parse(groups[2])
+		if err != nil || ref.Scheme != "" || ref.Host != "" {
+			return match
+		}
+
+		return groups[1] + base.ResolveReference(ref).String()
+	})
+}
@@ -0,0 +1,395 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/config"
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/theme"
+)
+
+const (
+	// cacheDir is the directory inside the project that contains the
+	// build cache used for incremental builds.
+	cacheDir string = ".verless"
+	// cacheFile is the filename of the build cache.
+	cacheFile string = "cache.json"
+	// imagesCacheDir is the directory inside cacheDir that contains the
+	// variants of responsive images.
+	imagesCacheDir string = "images"
+	// rendersCacheDir is the directory inside cacheDir that contains the
+	// parsed Markdown files.
+	rendersCacheDir string = "renders"
+	// cacheVersion is the format version of the build cache. Caches with
+	// another version are discarded.
+	cacheVersion int = 2
+)
+
+// cacheEntry represents a content file recorded in the build cache.
+type cacheEntry struct {
+	Hash    string    `json:"hash"`
+	ModTime time.Time `json:"modTime"`
+	Href    string    `json:"href"`
+	// Dependencies contains the linked pages displayed by the page, like
+	// Next:/blog/coffee. The page has to be rendered again if they change.
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+// buildCache represents the manifest of a build. It contains all content
+// files along with their hashes and a fingerprint of the build inputs
+// that affect all pages, like the project configuration, the active
+// theme and the output directory. RootFiles lists the files copied from the root directory
+// into the output directory.
+type buildCache struct {
+	Version     int                   `json:"version"`
+	Fingerprint string                `json:"fingerprint"`
+	Files       map[string]cacheEntry `json:"files"`
+	RootFiles   []string              `json:"rootFiles,omitempty"`
+}
+
+// incrementalBuild keeps track of the files processed by an incremental
+// build and compares them to the previous build. The build cache is
+// stored on the filesystem the website is written to, so that the cache
+// of a build into an in-memory filesystem like the one of verless serve
+// never describes the output directory on disk.
+type incrementalBuild struct {
+	targetFs  afero.Fs
+	previous  buildCache
+	current   buildCache
+	unchanged map[string]bool
+	// files maps the Hrefs of all pages to their content files.
+	files map[string]string
+	mutex *sync.Mutex
+}
+
+// newIncrementalBuild loads the build cache from the previous build of
+// the project into targetFs. If the cache doesn't exist or the fingerprint
+// doesn't match, all pages are considered as changed.
+func newIncrementalBuild(targetFs afero.Fs, path string, cfg *config.Config, outputDir string, options *BuildOptions) (*incrementalBuild, error) {
+	fingerprint, err := buildFingerprint(path, cfg, outputDir, options)
+	if err != nil {
+		return nil, err
+	}
+
+	ib := incrementalBuild{
+		targetFs: targetFs,
+		current: buildCache{
+			Version:     cacheVersion,
+			Fingerprint: fingerprint,
+			Files:       make(map[string]cacheEntry),
+		},
+		unchanged: make(map[string]bool),
+		files:     make(map[string]string),
+		mutex:     &sync.Mutex{},
+	}
+
+	previous, err := readCache(targetFs, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if previous.Version == cacheVersion && previous.Fingerprint == fingerprint {
+		ib.previous = previous
+	}
+
+	return &ib, nil
+}
+
+// track records a content file for the current build and marks its page
+// as unchanged if the file is unchanged since the previous build. Safe
+// for concurrent usage.
+func (ib *incrementalBuild) track(file, href string, src []byte, modTime time.Time) {
+	hash := sha256.Sum256(src)
+
+	entry := cacheEntry{
+		Hash:    hex.EncodeToString(hash[:]),
+		ModTime: modTime,
+		Href:    href,
+	}
+
+	ib.mutex.Lock()
+	defer ib.mutex.Unlock()
+
+	ib.current.Files[file] = entry
+	ib.files[href] = file
+
+	// Files whose modification time has changed without changing their
+	// content, e.g. after a checkout, don't have to be rendered again.
+	if previous, exists := ib.previous.Files[file]; exists {
+		if previous.Hash == entry.Hash && previous.Href == entry.Href {
+			ib.unchanged[href] = true
+		}
+	}
+}
+
+// isUnchanged reports whether the page with the given Href is unchanged
+// since the previous build. This requires the page to display the same
+// linked pages as in the previous build, like Next:/blog/coffee, and
+// none of them may have changed either. The dependencies are recorded
+// for the next build.
+func (ib *incrementalBuild) isUnchanged(href string, dependencies []string) bool {
+	ib.mutex.Lock()
+	defer ib.mutex.Unlock()
+
+	file, tracked := ib.files[href]
+	if !tracked {
+		return false
+	}
+
+	entry := ib.current.Files[file]
+	entry.Dependencies = dependencies
+	ib.current.Files[file] = entry
+
+	if !ib.unchanged[href] || !equalStrings(ib.previous.Files[file].Dependencies, dependencies) {
+		return false
+	}
+
+	for _, dependency := range dependencies {
+		// Missing links like the Prev link of the first page are recorded
+		// without an Href.
+		linked := dependency[strings.Index(dependency, ":")+1:]
+		if linked != "" && !ib.unchanged[linked] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// equalStrings reports whether both slices contain the same values in
+// the same order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// trackRootFile records a file copied from the root directory for the
+// current build.
+func (ib *incrementalBuild) trackRootFile(file string) {
+	ib.mutex.Lock()
+	defer ib.mutex.Unlock()
+
+	ib.current.RootFiles = append(ib.current.RootFiles, file)
+}
+
+// isStaleRootFile reports whether a file in the output directory that
+// hasn't been written by the current build can be overwritten by the
+// root file with the same path. Unchanged pages aren't rendered again,
+// so this is only the case if the previous build has copied the file
+// from the root directory as well, or if the previous cache has been
+// discarded and all pages are rendered.
+func (ib *incrementalBuild) isStaleRootFile(file string) bool {
+	ib.mutex.Lock()
+	defer ib.mutex.Unlock()
+
+	if ib.previous.Version != cacheVersion {
+		return true
+	}
+
+	for _, rootFile := range ib.previous.RootFiles {
+		if rootFile == file {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removed returns the Hrefs of all pages that existed in the previous
+// build but whose content files have been removed since then.
+func (ib *incrementalBuild) removed() []string {
+	var hrefs []string
+
+	for file, entry := range ib.previous.Files {
+		if _, exists := ib.current.Files[file]; !exists {
+			hrefs = append(hrefs, entry.Href)
+		}
+	}
+
+	return hrefs
+}
+
+// save writes the build cache of the current build into the project on
+// the target filesystem.
+func (ib *incrementalBuild) save(path string) error {
+	if err := ib.targetFs.MkdirAll(filepath.Join(path, cacheDir), 0755); err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(ib.current, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return fs.WriteFileAtomic(ib.targetFs, filepath.Join(path, cacheDir, cacheFile), b, 0644)
+}
+
+// readCache reads the build cache of the project from the given target
+// filesystem. If there is no cache, an empty cache is returned.
+func readCache(targetFs afero.Fs, path string) (buildCache, error) {
+	var cache buildCache
+
+	b, err := afero.ReadFile(targetFs, filepath.Join(path, cacheDir, cacheFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cache, nil
+		}
+		return cache, err
+	}
+
+	// A corrupt cache is not an error, it just causes a full build.
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return buildCache{}, nil
+	}
+
+	return cache, nil
+}
+
+// outputOptions contains the build options that affect the rendered
+// pages, along with the absolute output directory they're written to.
+type outputOptions struct {
+	OutputDir     string
+	Env           string
+	Minify        bool
+	Compress      bool
+	IncludeDrafts bool
+	IncludeFuture bool
+}
+
+// buildFingerprint computes a hash over the project configuration, the
+// data files, all files of the active theme and the build options that
+// affect the output. If any of them changes, all pages have to be
+// rendered again. Since the output directory is part of the fingerprint,
+// building into another directory renders all pages as well.
+func buildFingerprint(path string, cfg *config.Config, outputDir string, options *BuildOptions) (string, error) {
+	hash := sha256.New()
+
+	absOutputDir, err := filepath.Abs(outputDir)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(outputOptions{
+		OutputDir:     absOutputDir,
+		Env:           options.Env,
+		Minify:        options.Minify,
+		Compress:      options.Compress,
+		IncludeDrafts: options.IncludeDrafts,
+		IncludeFuture: options.IncludeFuture,
+	})
+	if err != nil {
+		return "", err
+	}
+	_, _ = hash.Write(b)
+
+	// The resolved settings include the settings of the environment and
+	// those overridden by environment variables like VERLESS_BASEURL,
+	// which aren't part of the configuration files. Maps are printed with
+	// sorted keys.
+	_, _ = fmt.Fprintf(hash, "%v", cfg.Settings())
+
+	// The configuration may be stored in any format supported by viper.
+	configFiles, err := filepath.Glob(filepath.Join(path, config.Filename+".*"))
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range configFiles {
+		if err := hashFile(hash, file); err != nil {
+			return "", err
+		}
+	}
+
+	if err := hashDir(hash, cfg.DataPath(path)); err != nil {
+		return "", err
+	}
+
+	// Templates may be inherited from parent themes, so the parent themes
+	// have to be considered as well.
+	var (
+		themesDir = cfg.ThemesPath(path)
+		visited   = make(map[string]bool)
+	)
+
+	for current := cfg.Theme; current != "" && !visited[current]; {
+		visited[current] = true
+		_, _ = io.WriteString(hash, current)
+
+		if err := hashDir(hash, theme.Path(themesDir, current)); err != nil {
+			return "", err
+		}
+
+		cfg, err := theme.GetConfig(themesDir, current)
+		if err != nil {
+			return "", err
+		}
+		current = cfg.Parent
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// hashDir writes the relative paths and contents of all files inside the
+// given directory into hash. A missing directory is ignored.
+func hashDir(hash io.Writer, dir string) error {
+	var files []string
+
+	err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			files = append(files, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(files)
+
+	for _, file := range files {
+		rel, _ := filepath.Rel(dir, file)
+		_, _ = io.WriteString(hash, filepath.ToSlash(rel))
+
+		if err := hashFile(hash, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hashFile writes the contents of the given file into hash.
+func hashFile(hash io.Writer, file string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(hash, f)
+	return err
+}
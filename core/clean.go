@@ -0,0 +1,69 @@
+package core
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/config"
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/out/style"
+)
+
+// CleanOptions represents options for removing the output directory of
+// a project.
+type CleanOptions struct {
+	// OutputDir overrides the output directory of the project.
+	OutputDir string
+	// Env is the environment whose configuration file is merged into the
+	// project configuration, like BuildOptions.Env.
+	Env string
+	// Force removes the output directory even if it contains files not
+	// produced by verless.
+	Force bool
+	// Logger prints the removed directory. If it is nil, the default
+	// logger of the out package is used.
+	Logger *out.Logger
+}
+
+// Clean removes the contents of the output directory of the project at
+// the given path, leaving the project itself untouched. The output
+// directory itself and the paths listed in build.keep are kept.
+//
+// Clean uses the same safety checks as a build clearing the output
+// directory: The output directory has to be inside the project, and it
+// may only contain files produced by verless unless options.Force or
+// build.overwrite are set.
+func Clean(targetFs afero.Fs, path string, options CleanOptions) error {
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return fmt.Errorf("%s: %w", path, ErrProjectNotExists)
+	}
+
+	cfg, err := config.FromFileEnv(path, config.Filename, options.Env)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Version == "" {
+		return ErrMissingVersionKey
+	}
+
+	outputDir := outputDir(path, &cfg, &BuildOptions{OutputDir: options.OutputDir})
+
+	clear, err := checkOutputDir(targetFs, path, outputDir, options.Force || cfg.Build.Overwrite, cfg.Build.Keep)
+	if err != nil {
+		return err
+	}
+	if !clear {
+		return fmt.Errorf("%s is not inside the project directory: %w", outputDir, ErrCannotOverwrite)
+	}
+
+	if err := fs.RmdirExcept(targetFs, outputDir, cfg.Build.Keep...); err != nil {
+		return err
+	}
+
+	loggerOrDefault(options.Logger).Info(style.HeavyCheckMark, "cleared %s", outputDir)
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package core_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/core"
+	"github.com/verless/verless/test"
+)
+
+// TestClean checks if Clean removes the output directory of a project
+// while keeping the project itself and the configured paths, and if it
+// refuses to remove output directories that aren't safe to remove.
+func TestClean(t *testing.T) {
+	tests := map[string]struct {
+		config        string
+		outputDir     string
+		force         bool
+		extraFiles    []string
+		expectedKept  []string
+		expectedError error
+	}{
+		"built output directory": {},
+		"kept files": {
+			config:       "version: 1\nbuild:\n  keep: [CNAME, .well-known]\n",
+			extraFiles:   []string{"target/CNAME", "target/.well-known/security.txt"},
+			expectedKept: []string{"target/CNAME", "target/.well-known/security.txt"},
+		},
+		"unexpected files": {
+			extraFiles:    []string{"target/notes.txt"},
+			expectedKept:  []string{"target/notes.txt", "target/index.html"},
+			expectedError: core.ErrCannotOverwrite,
+		},
+		"unexpected files with force": {
+			extraFiles: []string{"target/notes.txt"},
+			force:      true,
+		},
+		"output directory outside of the project": {
+			outputDir:     "../public",
+			force:         true,
+			extraFiles:    []string{"../public/index.html"},
+			expectedKept:  []string{"../public/index.html"},
+			expectedError: core.ErrCannotOverwrite,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		path := createTestProject(t, testCase.config, map[string]string{"about.md": "---\nTitle: About\n---\n"})
+		osFs := afero.NewOsFs()
+
+		build, err := core.NewBuild(osFs, path, core.BuildOptions{})
+		test.Ok(t, err)
+		test.Ok(t, build.Run())
+
+		for _, file := range testCase.extraFiles {
+			file = filepath.Join(path, filepath.FromSlash(file))
+			test.Ok(t, os.MkdirAll(filepath.Dir(file), 0755))
+			test.Ok(t, ioutil.WriteFile(file, []byte("extra"), 0644))
+		}
+
+		outputDir := testCase.outputDir
+		if outputDir != "" {
+			outputDir = filepath.Join(path, filepath.FromSlash(outputDir))
+		}
+
+		err = core.Clean(osFs, path, core.CleanOptions{OutputDir: outputDir, Force: testCase.force})
+		test.ExpectedError(t, testCase.expectedError, err)
+
+		// The project itself always remains untouched.
+		for _, file := range []string{"verless.yml", "content/about.md", "themes/default/templates/page.html"} {
+			_, err := os.Stat(filepath.Join(path, filepath.FromSlash(file)))
+			test.Ok(t, err)
+		}
+
+		for _, file := range testCase.expectedKept {
+			_, err := os.Stat(filepath.Join(path, filepath.FromSlash(file)))
+			test.Ok(t, err)
+		}
+
+		if testCase.expectedError == nil {
+			_, err = os.Stat(filepath.Join(path, "target", "index.html"))
+			test.Assert(t, os.IsNotExist(err), "target/index.html should have been removed")
+		}
+
+		_ = os.RemoveAll(filepath.Dir(path))
+	}
+}
+
+// TestClean_missingProject checks if Clean reports a missing project.
+func TestClean_missingProject(t *testing.T) {
+	err := core.Clean(afero.NewOsFs(), filepath.Join(os.TempDir(), "verless-missing-project"), core.CleanOptions{})
+	test.ExpectedError(t, core.ErrProjectNotExists, err)
+}
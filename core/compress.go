@@ -0,0 +1,27 @@
+package core
+
+import (
+	"path/filepath"
+
+	"github.com/verless/verless/compress"
+)
+
+// compressOutput writes compressed siblings of all text files inside the
+// output directory using the configured formats. The manifest and the
+// kept paths aren't part of the website and are therefore ignored.
+func (b *Build) compressOutput() error {
+	if len(b.precompress) == 0 {
+		return nil
+	}
+
+	return compress.Dir(b.targetFs, b.outputDir, b.precompress, func(file string) bool {
+		rel, err := filepath.Rel(b.outputDir, file)
+		if err != nil {
+			return true
+		}
+
+		rel = filepath.ToSlash(rel)
+
+		return rel == outputManifest || isKept(rel, b.keepFiles)
+	})
+}
@@ -1,17 +1,22 @@
 package core
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
-	"path"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/spf13/afero"
+	"github.com/spf13/viper"
 	. "github.com/verless/verless/config"
 	"github.com/verless/verless/fs"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/out/style"
 	"github.com/verless/verless/theme"
 )
 
@@ -25,156 +30,418 @@ var (
 	// ErrThemeExists states that the specified theme already exists.
 	ErrThemeExists = errors.New("theme already exists, remove it first")
 
+	// ErrThemeNotExists states that the specified theme doesn't exist.
+	ErrThemeNotExists = errors.New("theme doesn't exist")
+
 	// ErrFileExist states that the specified file already exists.
-	ErrFileExists = errors.New("file already exists")
+	ErrFileExists = fs.ErrFileExists
 )
 
 // CreateProjectOptions represents options for creating a project.
 type CreateProjectOptions struct {
 	Overwrite bool
+	// DryRun only determines the changes that would be made without
+	// actually touching the filesystem.
+	DryRun bool
+	// Fs is the filesystem the project is created in. Defaults to the
+	// OS filesystem if nil.
+	Fs afero.Fs
+	// CleanupOnError removes all partially created directories and files
+	// if creating the project fails.
+	CleanupOnError bool
+	// WithJS additionally creates a JavaScript directory containing a
+	// script that is included by the default theme.
+	WithJS bool
+	// Dirs overrides the default project directories. The overrides are
+	// stored in the project configuration.
+	Dirs Dirs
+	// Logger prints the created project, and each created directory and
+	// file at debug level. If it is nil, the default logger is used.
+	Logger *out.Logger
 }
 
-// CreateFileOptions represents project path for creating file.
+// CreateFileOptions represents options for creating a content file.
 type CreateFileOptions struct {
-	Project string
+	// Overwrite allows CreateFile to replace an existing file.
+	Overwrite bool
+	// Fs is the filesystem the file is created in. Defaults to the OS
+	// filesystem if nil.
+	Fs afero.Fs
+	// Logger prints the created file. If it is nil, the default logger is
+	// used.
+	Logger *out.Logger
+}
+
+// ProjectPlan lists all filesystem changes made by CreateProject.
+type ProjectPlan struct {
+	// Remove contains all existing paths that will be removed.
+	Remove []string
+	// Dirs contains all directories that will be created.
+	Dirs []string
+	// Files contains all files that will be written.
+	Files []string
 }
 
 // CreateProject creates a new verless project. If the specified project
 // path already exists, CreateProject returns an error unless --overwrite
 // has been used.
-func CreateProject(path string, options CreateProjectOptions) error {
-	if !fs.IsSafeToRemove(afero.NewOsFs(), path, options.Overwrite) {
+//
+// CreateProject returns a plan containing all changes made to the
+// filesystem. If options.DryRun is set, the plan is returned without
+// making those changes.
+func CreateProject(path string, options CreateProjectOptions) (ProjectPlan, error) {
+	targetFs := options.Fs
+	if targetFs == nil {
+		targetFs = afero.NewOsFs()
+	}
+
+	if !fs.IsSafeToRemove(targetFs, path, options.Overwrite) {
+		return ProjectPlan{}, ErrProjectExists
+	}
+
+	dirs, files := projectLayout(path, options.Dirs, options.WithJS)
+
+	return scaffoldProject(targetFs, path, dirs, files, options)
+}
+
+// CreateProjectFromArchive creates a new verless project from a project
+// skeleton provided as tar.gz or zip archive. Just like CreateProject, it
+// returns an error if the project path already exists unless --overwrite
+// has been used.
+//
+// All archive entries are validated before anything is extracted. If
+// options.DryRun is set, only the archive is validated.
+func CreateProjectFromArchive(path string, archive io.Reader, options CreateProjectOptions) error {
+	targetFs := options.Fs
+	if targetFs == nil {
+		targetFs = afero.NewOsFs()
+	}
+
+	if !fs.IsSafeToRemove(targetFs, path, options.Overwrite) {
 		return ErrProjectExists
 	}
 
-	if path != "." {
-		if err := os.RemoveAll(path); err != nil {
-			return err
-		}
-	} else {
-		err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
-			// RemoveAll removes nested directory in first iteration which causes
-			// os.PathError saying "no such file or directory" for next recursion of
-			// WalkFunc.
-			if os.IsNotExist(err) {
-				return nil
-			}
-			if path != "." {
-				if info.IsDir() {
-					// Remove nested non-empty directories as os.Remove() only removes
-					// files and empty directories
-					return os.RemoveAll(path)
-				} else {
-					return os.Remove(path)
-				}
-			}
-			return nil
-		})
-		if err != nil {
-			return errors.New("Cannot remove existing files from current directory")
+	dirs, files, err := readArchive(path, archive)
+	if err != nil {
+		return err
+	}
+
+	_, err = scaffoldProject(targetFs, path, dirs, files, options)
+	return err
+}
+
+// scaffoldProject creates a project consisting of the given directories
+// and files inside path, replacing any existing files.
+func scaffoldProject(targetFs afero.Fs, path string, dirs []string, files map[string][]byte, options CreateProjectOptions) (ProjectPlan, error) {
+	plan, err := planProject(targetFs, path, dirs, files)
+	if err != nil {
+		return ProjectPlan{}, err
+	}
+
+	if options.DryRun {
+		return plan, nil
+	}
+
+	// The current directory itself can't be removed, so only its entries
+	// are removed in that case. planProject has already collected them.
+	for _, p := range plan.Remove {
+		if err := targetFs.RemoveAll(p); err != nil {
+			return plan, fmt.Errorf("removing %s: %w", p, err)
 		}
 	}
 
-	dirs := []string{
-		filepath.Join(path, ContentDir),
-		theme.TemplatePath(path, theme.Default),
-		theme.CssPath(path, theme.Default),
+	logger := loggerOrDefault(options.Logger)
+
+	if err := createProject(targetFs, logger, dirs, files); err != nil {
+		if options.CleanupOnError {
+			cleanupProject(targetFs, path, plan)
+		}
+		return plan, err
 	}
 
+	logger.Info(style.HeavyCheckMark, "created project %s", path)
+
+	return plan, nil
+}
+
+// createProject creates all directories and files of a new project.
+func createProject(targetFs afero.Fs, logger *out.Logger, dirs []string, files map[string][]byte) error {
 	for _, dir := range dirs {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return err
+		if err := targetFs.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", dir, err)
 		}
+		logger.Debug(style.None, "created %s", dir)
+	}
+
+	return createFiles(targetFs, logger, files)
+}
+
+// cleanupProject removes all directories and files listed in the plan
+// that might have been created. For the current directory, only the
+// top-level entries of the project are removed.
+func cleanupProject(targetFs afero.Fs, path string, plan ProjectPlan) {
+	if path != "." {
+		_ = targetFs.RemoveAll(path)
+		return
 	}
 
+	for _, p := range append(plan.Dirs, plan.Files...) {
+		entry := strings.SplitN(filepath.ToSlash(filepath.Clean(p)), "/", 2)[0]
+		_ = targetFs.RemoveAll(entry)
+	}
+}
+
+// projectLayout returns all directories and files of a new project.
+// If withJS is true, a script is added to the default theme.
+func projectLayout(path string, projectDirs Dirs, withJS bool) ([]string, map[string][]byte) {
+	cfg := Config{Dirs: projectDirs}
+	themesDir := cfg.ThemesPath(path)
+
+	dirs := []string{
+		cfg.ContentPath(path),
+		theme.TemplatePath(themesDir, theme.Default),
+		theme.AssetsPath(themesDir, theme.Default),
+	}
+
+	listPageTpl := defaultTpl
+
 	files := map[string][]byte{
-		filepath.Join(path, "verless.yml"):                                             defaultConfig,
-		filepath.Join(path, ".gitignore"):                                              defaultGitignore,
-		filepath.Join(theme.TemplatePath(path, theme.Default), theme.ListPageTemplate): defaultTpl,
-		filepath.Join(theme.TemplatePath(path, theme.Default), theme.PageTemplate):     {},
-		filepath.Join(theme.AssetsPath(path, theme.Default), "style.css"):              defaultCss,
+		filepath.Join(path, "verless.yml"):                                              projectConfigFile(projectDirs),
+		filepath.Join(path, ".gitignore"):                                               defaultGitignore,
+		filepath.Join(theme.TemplatePath(themesDir, theme.Default), theme.PageTemplate): {},
+		filepath.Join(theme.AssetsPath(themesDir, theme.Default), "style.css"):          defaultCss,
+	}
+
+	if withJS {
+		dirs = append(dirs, theme.JsPath(themesDir, theme.Default))
+		files[filepath.Join(theme.JsPath(themesDir, theme.Default), "main.js")] = defaultJs
+		listPageTpl = bytes.Replace(defaultTpl, []byte("</head>"), []byte(defaultScriptTag+"\n    </head>"), 1)
+	}
+
+	files[filepath.Join(theme.TemplatePath(themesDir, theme.Default), theme.ListPageTemplate)] = listPageTpl
+
+	return dirs, files
+}
+
+// projectConfigFile returns the configuration file of a new project,
+// including all overridden directories.
+func projectConfigFile(dirs Dirs) []byte {
+	if dirs == (Dirs{}) {
+		return defaultConfig
+	}
+
+	var buf bytes.Buffer
+	buf.Write(defaultConfig)
+	buf.WriteString("dirs:\n")
+
+	for _, dir := range []struct{ key, value string }{
+		{"content", dirs.Content},
+		{"output", dirs.Output},
+		{"themes", dirs.Themes},
+		{"data", dirs.Data},
+	} {
+		if dir.value != "" {
+			fmt.Fprintf(&buf, "  %s: %s\n", dir.key, dir.value)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// projectConfig returns the configuration of the project with the given
+// path on targetFs. If the project doesn't have a configuration file, the
+// default configuration is returned.
+func projectConfig(targetFs afero.Fs, path string) (Config, error) {
+	cfg, err := FromFs(targetFs, path, Filename)
+
+	var notFound viper.ConfigFileNotFoundError
+	if errors.As(err, &notFound) {
+		return Config{}, nil
+	}
+
+	return cfg, err
+}
+
+// planProject determines the changes needed for creating a project
+// with the given directories and files.
+func planProject(targetFs afero.Fs, path string, dirs []string, files map[string][]byte) (ProjectPlan, error) {
+	plan := ProjectPlan{
+		Remove: []string{},
+		Dirs:   dirs,
+		Files:  make([]string, 0, len(files)),
 	}
 
-	return createFiles(files)
+	if path != "." {
+		if _, err := targetFs.Stat(path); err == nil {
+			plan.Remove = append(plan.Remove, path)
+		}
+	} else {
+		entries, err := afero.ReadDir(targetFs, path)
+		if err != nil {
+			return plan, err
+		}
+		for _, entry := range entries {
+			plan.Remove = append(plan.Remove, entry.Name())
+		}
+	}
+
+	for file := range files {
+		plan.Files = append(plan.Files, file)
+	}
+	sort.Strings(plan.Files)
+
+	return plan, nil
 }
 
 // CreateThemeOptions represents project path for creating new theme.
 type CreateThemeOptions struct {
 	Project string
+	// From is the name of an existing theme that the new theme will be
+	// copied from. If empty, an empty theme is created.
+	From string
+	// Logger prints the created theme, and each created directory and
+	// file at debug level. If it is nil, the default logger is used.
+	Logger *out.Logger
 }
 
 // CreateTheme creates a new theme with the specified name inside the
 // given path. Returns an error if it already exists, unless --overwrite
 // has been used.
+//
+// If options.From is set, all files of that theme are copied into the
+// new theme instead.
 func CreateTheme(options CreateThemeOptions, name string) error {
 	if _, err := os.Stat(options.Project); os.IsNotExist(err) {
 		return ErrProjectNotExists
 	}
 
-	if theme.Exists(options.Project, name) {
+	cfg, err := projectConfig(afero.NewOsFs(), options.Project)
+	if err != nil {
+		return err
+	}
+
+	var (
+		themesDir = cfg.ThemesPath(options.Project)
+		logger    = loggerOrDefault(options.Logger)
+	)
+
+	if theme.Exists(themesDir, name) {
 		return ErrThemeExists
 	}
 
+	if options.From != "" {
+		if !theme.Exists(themesDir, options.From) {
+			return fmt.Errorf("%s: %w", options.From, ErrThemeNotExists)
+		}
+
+		src := theme.Path(themesDir, options.From)
+		dst := theme.Path(themesDir, name)
+
+		if err := fs.CopyDir(afero.NewOsFs(), src, dst); err != nil {
+			return err
+		}
+
+		logger.Info(style.HeavyCheckMark, "created theme %s from %s", name, options.From)
+		return nil
+	}
+
 	dirs := []string{
-		theme.TemplatePath(options.Project, name),
-		theme.CssPath(options.Project, name),
-		theme.JsPath(options.Project, name),
+		theme.TemplatePath(themesDir, name),
+		theme.CssPath(themesDir, name),
+		theme.JsPath(themesDir, name),
 	}
 
 	for _, dir := range dirs {
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return err
 		}
+		logger.Debug(style.None, "created %s", dir)
 	}
 
 	files := map[string][]byte{
-		filepath.Join(theme.TemplatePath(options.Project, name), theme.ListPageTemplate): {},
-		filepath.Join(theme.TemplatePath(options.Project, name), theme.PageTemplate):     {},
-		filepath.Join(theme.Path(options.Project, name), "theme.yml"):                    defaultThemeConfig,
+		filepath.Join(theme.TemplatePath(themesDir, name), theme.ListPageTemplate): {},
+		filepath.Join(theme.TemplatePath(themesDir, name), theme.PageTemplate):     {},
+		filepath.Join(theme.Path(themesDir, name), "theme.yml"):                    defaultThemeConfig,
+	}
+
+	if err := createFiles(afero.NewOsFs(), logger, files); err != nil {
+		return err
 	}
 
-	return createFiles(files)
+	logger.Info(style.HeavyCheckMark, "created theme %s", name)
+
+	return nil
 }
 
-// CreateFile creates a file with specified path under content directory.
-func CreateFile(filePath string, options CreateFileOptions) error {
+// CreateFile creates a new Markdown file for the given route inside the
+// content directory of the project, e.g. content/blog/my-post.md for the
+// route blog/my-post. Missing parent directories are created.
+//
+// The file contains a front matter template with the current time as
+// date. If the file already exists, CreateFile returns an error unless
+// options.Overwrite is set.
+func CreateFile(project, route string, options CreateFileOptions) error {
+	targetFs := options.Fs
+	if targetFs == nil {
+		targetFs = afero.NewOsFs()
+	}
 
-	if _, err := os.Stat(options.Project); os.IsNotExist(err) {
+	if exists, _ := afero.DirExists(targetFs, project); !exists {
 		return ErrProjectNotExists
 	}
 
-	contentPath := filepath.Join(options.Project, ContentDir, filePath)
+	cfg, err := projectConfig(targetFs, project)
+	if err != nil {
+		return err
+	}
+
+	contentDir := cfg.ContentPath(project)
+	// Routes like blog/post.markdown keep their Markdown extension, all
+	// other routes get the first Markdown extension, which usually is .md.
+	exts := cfg.MarkdownExtensions()
+	file := filepath.Join(contentDir, filepath.FromSlash(route))
 
-	if _, err := os.Stat(path.Dir(contentPath)); os.IsNotExist(err) {
-		return fmt.Errorf("no such dir %s exist, create it first", path.Dir(contentPath))
+	if !fs.Extensions(exts...)(file) {
+		file += exts[0]
 	}
 
-	if _, err := os.Stat(contentPath); !os.IsNotExist(err) {
-		return ErrFileExists
+	if rel, err := filepath.Rel(contentDir, file); err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("route %s points outside of the content directory", route)
 	}
 
-	defaultContentTemplate :=
-		`---
-Title:
-Description:
-Date: %s
----
-`
+	if exists, _ := afero.Exists(targetFs, file); exists && !options.Overwrite {
+		return fmt.Errorf("%s: %w", file, ErrFileExists)
+	}
 
-	content := fmt.Sprintf(defaultContentTemplate, time.Now().Format("2006-01-02"))
-	if err := ioutil.WriteFile(contentPath, []byte(content), 0755); err != nil {
-		return err
+	if err := targetFs.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(file), err)
 	}
 
-	return nil
+	content := fmt.Sprintf(defaultContent, time.Now().Format(time.RFC3339))
 
+	if err := fs.WriteFileAtomic(targetFs, file, []byte(content), 0644); err != nil {
+		return fmt.Errorf("creating %s: %w", file, err)
+	}
+
+	loggerOrDefault(options.Logger).Info(style.HeavyCheckMark, "created %s", file)
+
+	return nil
 }
 
-func createFiles(files map[string][]byte) error {
-	for path, content := range files {
-		if err := ioutil.WriteFile(path, content, 0755); err != nil {
-			return err
+// createFiles writes all files to the filesystem, using the map keys
+// as file paths. The files are written in lexical order.
+func createFiles(targetFs afero.Fs, logger *out.Logger, files map[string][]byte) error {
+	paths := make([]string, 0, len(files))
+	for path := range files {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		if err := fs.WriteFileAtomic(targetFs, path, files[path], 0755); err != nil {
+			return fmt.Errorf("creating %s: %w", path, err)
 		}
+		logger.Debug(style.None, "wrote %s", path)
 	}
 	return nil
 }
@@ -0,0 +1,589 @@
+package core_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/core"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/test"
+)
+
+// TestCreateProject checks if CreateProject creates the expected project
+// layout and removes existing files when overwriting the project.
+func TestCreateProject(t *testing.T) {
+	tests := map[string]struct {
+		path      string
+		existing  []string
+		overwrite bool
+	}{
+		"new project": {
+			path: "my-blog",
+		},
+		"existing project with overwrite": {
+			path:      "my-blog",
+			existing:  []string{"my-blog/content/stale.md"},
+			overwrite: true,
+		},
+		"current directory with overwrite": {
+			path:      ".",
+			existing:  []string{"content/stale.md"},
+			overwrite: true,
+		},
+	}
+
+	// expected maps the files of the project to a snippet of their content.
+	expected := map[string]string{
+		"verless.yml": "version: 1",
+		".gitignore":  "generated/",
+		"themes/default/templates/list-page.html": "{{.Meta.Title}}",
+		"themes/default/templates/page.html":      "",
+		"themes/default/assets/style.css":         "font-family",
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+
+		for _, file := range testCase.existing {
+			test.Ok(t, memMapFs.MkdirAll(filepath.Dir(file), 0755))
+			test.Ok(t, afero.WriteFile(memMapFs, file, []byte{}, 0644))
+		}
+
+		_, err := core.CreateProject(testCase.path, core.CreateProjectOptions{
+			Overwrite: testCase.overwrite,
+			Fs:        memMapFs,
+		})
+		test.Ok(t, err)
+
+		for file, snippet := range expected {
+			content, err := afero.ReadFile(memMapFs, filepath.Join(testCase.path, file))
+			test.Ok(t, err)
+			test.Assert(t, strings.Contains(string(content), snippet), "%s should contain %s", file, snippet)
+		}
+
+		isDir, err := afero.IsDir(memMapFs, filepath.Join(testCase.path, "content"))
+		test.Ok(t, err)
+		test.Assert(t, isDir, "content directory should exist")
+
+		for _, file := range testCase.existing {
+			exists, err := afero.Exists(memMapFs, file)
+			test.Ok(t, err)
+			test.Assert(t, !exists, "%s should have been removed", file)
+		}
+	}
+}
+
+// TestCreateProject_withJS checks if CreateProject only creates a script
+// for the default theme if WithJS is set.
+func TestCreateProject_withJS(t *testing.T) {
+	tests := map[string]struct {
+		withJS bool
+	}{
+		"without JavaScript": {},
+		"with JavaScript": {
+			withJS: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+
+		_, err := core.CreateProject("my-blog", core.CreateProjectOptions{
+			Fs:     memMapFs,
+			WithJS: testCase.withJS,
+		})
+		test.Ok(t, err)
+
+		isDir, _ := afero.DirExists(memMapFs, "my-blog/themes/default/js")
+		test.Equals(t, testCase.withJS, isDir)
+
+		exists, err := afero.Exists(memMapFs, "my-blog/themes/default/js/main.js")
+		test.Ok(t, err)
+		test.Equals(t, testCase.withJS, exists)
+
+		tpl, err := afero.ReadFile(memMapFs, "my-blog/themes/default/templates/list-page.html")
+		test.Ok(t, err)
+		test.Equals(t, testCase.withJS, strings.Contains(string(tpl), `<script src="/js/main.js">`))
+	}
+}
+
+// errWriteFailed is returned by failingFs when writing a file.
+var errWriteFailed = errors.New("write failed")
+
+// failingFs is a filesystem that fails to write a particular file while
+// all other operations succeed.
+type failingFs struct {
+	afero.Fs
+	file string
+}
+
+// OpenFile fails for the configured file if it is opened for writing.
+// This includes temporary files used for writing the file atomically.
+func (f failingFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if strings.Contains(filepath.Base(name), f.file) && flag&(os.O_WRONLY|os.O_RDWR) != 0 {
+		return nil, errWriteFailed
+	}
+	return f.Fs.OpenFile(name, flag, perm)
+}
+
+// TestCreateProject_cleanupOnError checks if CreateProject returns an
+// error describing the failed step and removes the partially created
+// project if CleanupOnError is set.
+func TestCreateProject_cleanupOnError(t *testing.T) {
+	tests := map[string]struct {
+		path           string
+		cleanupOnError bool
+		expectedExists []string
+	}{
+		"without cleanup": {
+			path:           "my-blog",
+			expectedExists: []string{"my-blog/content", "my-blog/themes"},
+		},
+		"with cleanup": {
+			path:           "my-blog",
+			cleanupOnError: true,
+		},
+		"current directory with cleanup": {
+			path:           ".",
+			cleanupOnError: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+
+		_, err := core.CreateProject(testCase.path, core.CreateProjectOptions{
+			Fs:             failingFs{Fs: memMapFs, file: "style.css"},
+			CleanupOnError: testCase.cleanupOnError,
+		})
+		test.Assert(t, errors.Is(err, errWriteFailed), "expected %v, got %v", errWriteFailed, err)
+		test.Assert(t, strings.Contains(err.Error(), "style.css"), "%v should name the failed file", err)
+
+		entries, err := afero.ReadDir(memMapFs, testCase.path)
+		if len(testCase.expectedExists) == 0 {
+			test.Assert(t, err != nil || len(entries) == 0, "%s should have been cleaned up", testCase.path)
+			continue
+		}
+
+		for _, path := range testCase.expectedExists {
+			exists, err := afero.Exists(memMapFs, path)
+			test.Ok(t, err)
+			test.Assert(t, exists, "%s should exist", path)
+		}
+	}
+}
+
+// TestCreateProject_dryRun checks if a dry run of CreateProject leaves the
+// filesystem untouched and returns the same plan as an actual run.
+func TestCreateProject_dryRun(t *testing.T) {
+	tests := map[string]struct {
+		existing       []string
+		overwrite      bool
+		expectedRemove int
+		expectedError  error
+	}{
+		"new project": {},
+		"existing project without overwrite": {
+			existing:      []string{"verless.yml"},
+			expectedError: core.ErrProjectExists,
+		},
+		"existing project with overwrite": {
+			existing:       []string{"verless.yml"},
+			overwrite:      true,
+			expectedRemove: 1,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		dir, err := ioutil.TempDir("", "verless-create")
+		test.Ok(t, err)
+
+		path := filepath.Join(dir, "my-blog")
+
+		for _, file := range testCase.existing {
+			test.Ok(t, os.MkdirAll(path, 0755))
+			test.Ok(t, ioutil.WriteFile(filepath.Join(path, file), []byte{}, 0644))
+		}
+
+		options := core.CreateProjectOptions{
+			Overwrite: testCase.overwrite,
+			DryRun:    true,
+		}
+
+		plan, err := core.CreateProject(path, options)
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			_ = os.RemoveAll(dir)
+			continue
+		}
+
+		test.Equals(t, testCase.expectedRemove, len(plan.Remove))
+
+		for _, file := range plan.Files {
+			info, err := os.Stat(file)
+			written := err == nil && info.Size() > 0
+			test.Assert(t, !written, "%s should not have been written", file)
+		}
+
+		options.DryRun = false
+
+		actual, err := core.CreateProject(path, options)
+		test.Ok(t, err)
+		test.Equals(t, plan, actual)
+
+		for _, file := range actual.Files {
+			_, err := os.Stat(file)
+			test.Ok(t, err)
+		}
+
+		_ = os.RemoveAll(dir)
+	}
+}
+
+// TestCreateProject_logging checks if CreateProject prints the created
+// project and, in verbose mode, each created directory and file.
+func TestCreateProject_logging(t *testing.T) {
+	tests := map[string]struct {
+		level   out.Level
+		info    bool
+		verbose bool
+	}{
+		"quiet": {
+			level: out.LevelError,
+		},
+		"default": {
+			level: out.LevelInfo,
+			info:  true,
+		},
+		"verbose": {
+			level:   out.LevelDebug,
+			info:    true,
+			verbose: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		var stdout, stderr bytes.Buffer
+
+		plan, err := core.CreateProject("my-blog", core.CreateProjectOptions{
+			Fs:     afero.NewMemMapFs(),
+			Logger: out.NewLogger(testCase.level, &stdout, &stderr),
+		})
+		test.Ok(t, err)
+
+		var expected strings.Builder
+
+		if testCase.verbose {
+			files := append([]string(nil), plan.Files...)
+			sort.Strings(files)
+
+			for _, dir := range plan.Dirs {
+				expected.WriteString("created " + dir + "\n")
+			}
+			for _, file := range files {
+				expected.WriteString("wrote " + file + "\n")
+			}
+		}
+		if testCase.info {
+			expected.WriteString("✔️ created project my-blog\n")
+		}
+
+		test.Equals(t, expected.String(), stdout.String())
+		test.Equals(t, "", stderr.String())
+	}
+}
+
+// TestCreateProjectFromArchive checks if CreateProjectFromArchive extracts
+// tar.gz and zip archives and rejects illegal archive entries.
+func TestCreateProjectFromArchive(t *testing.T) {
+	skeleton := map[string]string{
+		"verless.yml": "version: 1",
+		"themes/default/templates/list-page.html": "{{.Meta.Title}}",
+	}
+
+	tests := map[string]struct {
+		archive       func(t *testing.T, files map[string]string) []byte
+		files         map[string]string
+		existing      bool
+		expectedError error
+	}{
+		"zip archive": {
+			archive: zipArchive,
+			files:   skeleton,
+		},
+		"tar.gz archive": {
+			archive: tarGzArchive,
+			files:   skeleton,
+		},
+		"path traversal": {
+			archive: zipArchive,
+			files: map[string]string{
+				"verless.yml":      "version: 1",
+				"../../etc/passwd": "root",
+			},
+			expectedError: core.ErrIllegalArchivePath,
+		},
+		"absolute path": {
+			archive: tarGzArchive,
+			files: map[string]string{
+				"/etc/passwd": "root",
+			},
+			expectedError: core.ErrIllegalArchivePath,
+		},
+		"unsupported archive": {
+			archive: func(t *testing.T, files map[string]string) []byte {
+				return []byte("version: 1")
+			},
+			expectedError: core.ErrUnsupportedArchive,
+		},
+		"existing project": {
+			archive:       zipArchive,
+			files:         skeleton,
+			existing:      true,
+			expectedError: core.ErrProjectExists,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+
+		if testCase.existing {
+			test.Ok(t, afero.WriteFile(memMapFs, "my-blog/verless.yml", []byte{}, 0644))
+		}
+
+		archive := bytes.NewReader(testCase.archive(t, testCase.files))
+
+		err := core.CreateProjectFromArchive("my-blog", archive, core.CreateProjectOptions{
+			Fs: memMapFs,
+		})
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			if !testCase.existing {
+				exists, err := afero.Exists(memMapFs, "my-blog")
+				test.Ok(t, err)
+				test.Assert(t, !exists, "nothing should have been extracted")
+			}
+			continue
+		}
+
+		for file, expected := range testCase.files {
+			content, err := afero.ReadFile(memMapFs, filepath.Join("my-blog", file))
+			test.Ok(t, err)
+			test.Equals(t, expected, string(content))
+		}
+	}
+}
+
+// zipArchive creates a zip archive containing the given files.
+func zipArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+
+	for name, content := range files {
+		w, err := zipWriter.Create(name)
+		test.Ok(t, err)
+		_, err = w.Write([]byte(content))
+		test.Ok(t, err)
+	}
+
+	test.Ok(t, zipWriter.Close())
+	return buf.Bytes()
+}
+
+// tarGzArchive creates a tar.gz archive containing the given files.
+func tarGzArchive(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for name, content := range files {
+		test.Ok(t, tarWriter.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tarWriter.Write([]byte(content))
+		test.Ok(t, err)
+	}
+
+	test.Ok(t, tarWriter.Close())
+	test.Ok(t, gzipWriter.Close())
+	return buf.Bytes()
+}
+
+// TestCreateTheme_from checks if CreateTheme copies all files of an
+// existing theme into the new theme.
+func TestCreateTheme_from(t *testing.T) {
+	source := map[string]string{
+		"theme.yml":                "version: 1",
+		"templates/page.html":      "{{.Page.Title}}",
+		"templates/list-page.html": "{{range .Pages}}{{end}}",
+		"css/style.css":            "body {}",
+		"js/main.js":               "init();",
+	}
+
+	tests := map[string]struct {
+		from          string
+		expectedError error
+	}{
+		"existing theme": {
+			from: "default",
+		},
+		"missing theme": {
+			from:          "dark",
+			expectedError: core.ErrThemeNotExists,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		dir, err := ioutil.TempDir("", "verless-theme")
+		test.Ok(t, err)
+
+		for file, content := range source {
+			path := filepath.Join(dir, "themes", "default", filepath.FromSlash(file))
+			test.Ok(t, os.MkdirAll(filepath.Dir(path), 0755))
+			test.Ok(t, ioutil.WriteFile(path, []byte(content), 0644))
+		}
+
+		err = core.CreateTheme(core.CreateThemeOptions{
+			Project: dir,
+			From:    testCase.from,
+		}, "fork")
+
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			_ = os.RemoveAll(dir)
+			continue
+		}
+
+		for file, expected := range source {
+			content, err := ioutil.ReadFile(filepath.Join(dir, "themes", "fork", filepath.FromSlash(file)))
+			test.Ok(t, err)
+			test.Equals(t, []byte(expected), content)
+		}
+
+		_ = os.RemoveAll(dir)
+	}
+}
+
+// TestCreateFile checks if CreateFile creates a content file with a front
+// matter template and respects the overwrite flag.
+func TestCreateFile(t *testing.T) {
+	tests := map[string]struct {
+		route         string
+		config        string
+		existing      bool
+		overwrite     bool
+		expectedFile  string
+		expectedError error
+	}{
+		"top-level route": {
+			route:        "about",
+			expectedFile: "my-blog/content/about.md",
+		},
+		"nested route": {
+			route:        "blog/2020/coffee",
+			expectedFile: "my-blog/content/blog/2020/coffee.md",
+		},
+		"route with file extension": {
+			route:        "blog/coffee.md",
+			expectedFile: "my-blog/content/blog/coffee.md",
+		},
+		"configured content directory": {
+			route:        "blog/coffee",
+			config:       "version: 1\ndirs:\n  content: posts\n",
+			expectedFile: "my-blog/posts/blog/coffee.md",
+		},
+		"existing file without overwrite": {
+			route:         "blog/coffee",
+			existing:      true,
+			expectedFile:  "my-blog/content/blog/coffee.md",
+			expectedError: core.ErrFileExists,
+		},
+		"existing file with overwrite": {
+			route:        "blog/coffee",
+			existing:     true,
+			overwrite:    true,
+			expectedFile: "my-blog/content/blog/coffee.md",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+		test.Ok(t, memMapFs.MkdirAll("my-blog", 0755))
+
+		if testCase.config != "" {
+			test.Ok(t, afero.WriteFile(memMapFs, "my-blog/verless.yml", []byte(testCase.config), 0644))
+		}
+
+		if testCase.existing {
+			test.Ok(t, afero.WriteFile(memMapFs, testCase.expectedFile, []byte("existing"), 0644))
+		}
+
+		err := core.CreateFile("my-blog", testCase.route, core.CreateFileOptions{
+			Overwrite: testCase.overwrite,
+			Fs:        memMapFs,
+		})
+
+		content, readErr := afero.ReadFile(memMapFs, testCase.expectedFile)
+		test.Ok(t, readErr)
+
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			test.Equals(t, "existing", string(content))
+			continue
+		}
+
+		lines := strings.Split(string(content), "\n")
+		test.Equals(t, "---", lines[0])
+		test.Equals(t, "Title:", lines[1])
+		test.Assert(t, strings.Contains(string(content), "\nDraft: false\n"), "front matter should contain Draft")
+		test.Assert(t, strings.Contains(string(content), "\nType:\n"), "front matter should contain Type")
+
+		date := strings.TrimPrefix(lines[3], "Date: ")
+		parsed, err := time.Parse(time.RFC3339, date)
+		test.Ok(t, err)
+		test.Assert(t, time.Since(parsed) < time.Minute, "date %s should be the current time", date)
+	}
+}
+
+// TestCreateFile_invalid checks if CreateFile rejects missing projects and
+// routes pointing outside of the content directory.
+func TestCreateFile_invalid(t *testing.T) {
+	memMapFs := afero.NewMemMapFs()
+	test.Ok(t, memMapFs.MkdirAll("my-blog", 0755))
+
+	err := core.CreateFile("other-blog", "about", core.CreateFileOptions{Fs: memMapFs})
+	test.Assert(t, errors.Is(err, core.ErrProjectNotExists), "expected %v, got %v", core.ErrProjectNotExists, err)
+
+	err = core.CreateFile("my-blog", "../verless", core.CreateFileOptions{Fs: memMapFs})
+	test.Assert(t, err != nil, "route outside of the content directory should be rejected")
+
+	exists, err := afero.Exists(memMapFs, "my-blog/verless.md")
+	test.Ok(t, err)
+	test.Assert(t, !exists, "file outside of the content directory should not exist")
+}
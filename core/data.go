@@ -0,0 +1,130 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// ErrDuplicateData states that two data files or a data file and a
+	// directory inside the data directory result in the same key, like
+	// authors.yml and authors.json.
+	ErrDuplicateData = errors.New("duplicate data key")
+)
+
+// dataDecoders maps the extensions of supported data files to functions
+// decoding their contents.
+var dataDecoders = map[string]func(b []byte) (interface{}, error){
+	".yml":  decodeYAML,
+	".yaml": decodeYAML,
+	".json": decodeJSON,
+	".toml": decodeTOML,
+}
+
+// loadData reads all data files inside the data directory and returns
+// their contents keyed by their filename without extension. Directories
+// inside the data directory result in nested maps, so that the data file
+// data/team/authors.yml is available as Data.team.authors. Files with an
+// unsupported extension are ignored. If the data directory doesn't exist,
+// loadData returns an empty map.
+func loadData(dir string) (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return data, nil
+		}
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		var (
+			file  = filepath.Join(dir, entry.Name())
+			key   = strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+			value interface{}
+		)
+
+		if entry.IsDir() {
+			key = entry.Name()
+			if value, err = loadData(file); err != nil {
+				return nil, err
+			}
+		} else {
+			decode, ok := dataDecoders[strings.ToLower(filepath.Ext(entry.Name()))]
+			if !ok {
+				continue
+			}
+
+			b, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+
+			if value, err = decode(b); err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+		}
+
+		if _, exists := data[key]; exists {
+			return nil, fmt.Errorf("%s: %w %s", file, ErrDuplicateData, key)
+		}
+
+		data[key] = value
+	}
+
+	return data, nil
+}
+
+func decodeYAML(b []byte) (interface{}, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(b, &value); err != nil {
+		return nil, err
+	}
+	return stringKeys(value), nil
+}
+
+func decodeJSON(b []byte) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+func decodeTOML(b []byte) (interface{}, error) {
+	tree, err := toml.LoadBytes(b)
+	if err != nil {
+		return nil, err
+	}
+	return tree.ToMap(), nil
+}
+
+// stringKeys converts the map[interface{}]interface{} values produced by
+// the YAML decoder to map[string]interface{}, so that YAML data can be
+// used exactly like JSON and TOML data.
+func stringKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = stringKeys(item)
+		}
+		return m
+	case []interface{}:
+		for i, item := range v {
+			v[i] = stringKeys(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
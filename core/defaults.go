@@ -62,5 +62,22 @@ build:
     # - Another command there
 `)
 
-	defaultGitignore = []byte(`generated/`)
+	defaultJs = []byte(`document.addEventListener("DOMContentLoaded", function () {
+    console.log("Welcome to your new verless project!");
+});
+`)
+
+	defaultScriptTag = `    <script src="/js/main.js"></script>`
+
+	defaultGitignore = []byte(`generated/
+.verless/`)
+
+	defaultContent = `---
+Title:
+Description:
+Date: %s
+Draft: false
+Type:
+---
+`
 )
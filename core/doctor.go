@@ -0,0 +1,382 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/verless/verless/config"
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/theme"
+	"github.com/verless/verless/writer"
+)
+
+// Severity indicates how serious a problem found by Doctor is.
+type Severity string
+
+const (
+	// SeverityError states that the problem prevents building the project.
+	SeverityError Severity = "error"
+	// SeverityWarning states that the project can be built, but probably
+	// not as intended.
+	SeverityWarning Severity = "warning"
+)
+
+// Diagnostic represents a problem of a project found by Doctor.
+type Diagnostic struct {
+	Severity Severity
+	Message  string
+}
+
+// Doctor checks the health of the project at the given path and returns
+// all problems it finds. It checks if
+//
+//  1. the project configuration can be parsed and is valid,
+//  2. the configured theme exists and is valid,
+//  3. the content directory exists and contains Markdown files,
+//  4. all templates of the theme can be parsed and only include existing
+//     templates and partials, and the templates of all page types exist.
+//
+// The returned error is only non-nil if the project can't be checked at
+// all, e.g. because it doesn't exist.
+func Doctor(path string) ([]Diagnostic, error) {
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s: %w", path, ErrProjectNotExists)
+	}
+
+	var d doctor
+
+	cfg := d.checkConfig(path)
+
+	if cfg.Theme == "" {
+		cfg.Theme = theme.Default
+	}
+
+	validTheme := d.checkTheme(path, &cfg)
+	d.checkContent(path, &cfg)
+
+	if validTheme {
+		if err := d.checkTemplates(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return d.diagnostics, nil
+}
+
+// doctor collects the diagnostics of the individual checks.
+type doctor struct {
+	diagnostics []Diagnostic
+}
+
+func (d *doctor) errorf(format string, a ...interface{}) {
+	d.diagnostics = append(d.diagnostics, Diagnostic{Severity: SeverityError, Message: fmt.Sprintf(format, a...)})
+}
+
+func (d *doctor) warnf(format string, a ...interface{}) {
+	d.diagnostics = append(d.diagnostics, Diagnostic{Severity: SeverityWarning, Message: fmt.Sprintf(format, a...)})
+}
+
+// checkConfig reads and validates the project configuration. If it can't
+// be read, the default configuration is returned so that the remaining
+// checks can use the default directories.
+func (d *doctor) checkConfig(path string) config.Config {
+	configFiles, err := filepath.Glob(filepath.Join(path, config.Filename+".*"))
+	if err != nil || len(configFiles) == 0 {
+		d.errorf("missing %s.yml, is %s a verless project?", config.Filename, path)
+		return config.Config{}
+	}
+
+	cfg, err := config.FromFile(path, config.Filename)
+	if err != nil {
+		d.errorf("cannot parse %s.yml: %s", config.Filename, err)
+		return config.Config{}
+	}
+
+	if cfg.Version == "" {
+		d.errorf("%s", ErrMissingVersionKey)
+	}
+
+	if err := config.Validate(cfg); err != nil {
+		var validationErr *config.ValidationError
+		if !errors.As(err, &validationErr) {
+			d.errorf("%s", err)
+			return cfg
+		}
+		for _, message := range validationErr.Messages {
+			// A missing version has already been reported.
+			if !strings.HasPrefix(message, "version:") {
+				d.errorf("%s.yml: %s", config.Filename, message)
+			}
+		}
+	}
+
+	return cfg
+}
+
+// checkTheme reports whether the configured theme is valid.
+func (d *doctor) checkTheme(path string, cfg *config.Config) bool {
+	if err := theme.Validate(cfg.ThemesPath(path), cfg.Theme); err != nil {
+		d.errorf("%s", err)
+		return false
+	}
+
+	return true
+}
+
+// checkContent checks if the content directory contains Markdown files.
+func (d *doctor) checkContent(path string, cfg *config.Config) {
+	contentDir := cfg.ContentPath(path)
+
+	if info, err := os.Stat(contentDir); err != nil || !info.IsDir() {
+		d.errorf("content directory %s doesn't exist", contentDir)
+		return
+	}
+
+	var (
+		markdownFiles int
+		isMarkdown    = fs.Extensions(cfg.MarkdownExtensions()...)
+	)
+
+	_ = filepath.Walk(contentDir, func(file string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() && isMarkdown(file) {
+			markdownFiles++
+		}
+		return nil
+	})
+
+	if markdownFiles == 0 {
+		d.warnf("content directory %s doesn't contain any Markdown files", contentDir)
+	}
+}
+
+// checkTemplates parses all templates of the theme and its parent themes
+// and reports syntax errors as well as references to missing templates.
+func (d *doctor) checkTemplates(path string, cfg *config.Config) error {
+	themesDir := cfg.ThemesPath(path)
+
+	partials, err := theme.Partials(themesDir, cfg.Theme)
+	if err != nil {
+		return err
+	}
+
+	files, err := templateFiles(themesDir, cfg.Theme)
+	if err != nil {
+		return err
+	}
+
+	var (
+		funcs   = writer.Funcs()
+		parsed  = make(map[string]*template.Template)
+		defined = make(map[string]bool)
+	)
+
+	for name := range partials {
+		defined[name] = true
+	}
+
+	for _, file := range files {
+		text, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		tpl, err := template.New(filepath.Base(file)).Funcs(funcs).Parse(string(text))
+		if err != nil {
+			d.errorf("%s: %s", relPath(path, file), err)
+			continue
+		}
+		parsed[file] = tpl
+	}
+
+	// Templates defined inside partials are available in all templates.
+	for _, file := range partials {
+		if tpl, ok := parsed[file]; ok {
+			for _, t := range tpl.Templates() {
+				defined[t.Name()] = true
+			}
+		}
+	}
+
+	for _, file := range files {
+		tpl, ok := parsed[file]
+		if !ok {
+			continue
+		}
+
+		for _, t := range tpl.Templates() {
+			if t.Tree == nil {
+				continue
+			}
+
+			walkTemplate(t.Tree.Root, func(node parse.Node) {
+				switch node := node.(type) {
+				case *parse.TemplateNode:
+					if !defined[node.Name] && tpl.Lookup(node.Name) == nil {
+						d.errorf("%s: template %q doesn't exist", relPath(path, file), node.Name)
+					}
+				case *parse.CommandNode:
+					if name, ok := partialCall(node); ok && partials[filepath.ToSlash(filepath.Join(theme.PartialsDir, name))] == "" {
+						d.errorf("%s: partial %q doesn't exist", relPath(path, file), name)
+					}
+				}
+			})
+		}
+	}
+
+	types := make([]string, 0, len(cfg.Types))
+	for name := range cfg.Types {
+		types = append(types, name)
+	}
+	sort.Strings(types)
+
+	for _, name := range types {
+		t := cfg.Types[name]
+		if t == nil || t.Template == "" {
+			continue
+		}
+		if _, err := theme.ResolveTemplate(themesDir, cfg.Theme, t.Template); err != nil {
+			d.errorf("types.%s.template: %s", name, err)
+		}
+	}
+
+	formats := make([]string, 0, len(cfg.OutputFormats))
+	for name := range cfg.OutputFormats {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+
+	for _, name := range formats {
+		f := cfg.OutputFormats[name]
+		if f == nil || f.Template == "" {
+			continue
+		}
+		if _, err := theme.ResolveTemplate(themesDir, cfg.Theme, f.Template); err != nil {
+			d.errorf("outputFormats.%s.template: %s", name, err)
+		}
+	}
+
+	return nil
+}
+
+// templateFiles returns all template files of the given theme and its
+// parent themes in lexical order.
+func templateFiles(themesDir, name string) ([]string, error) {
+	var (
+		files   []string
+		visited = make(map[string]bool)
+	)
+
+	for current := name; current != "" && !visited[current]; {
+		visited[current] = true
+
+		err := filepath.Walk(theme.TemplatePath(themesDir, current), func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if info.Mode().IsRegular() {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		cfg, err := theme.GetConfig(themesDir, current)
+		if err != nil {
+			return nil, err
+		}
+		current = cfg.Parent
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// walkTemplate invokes fn for the given node and all nodes below it.
+func walkTemplate(node parse.Node, fn func(node parse.Node)) {
+	if node == nil {
+		return
+	}
+
+	fn(node)
+
+	switch node := node.(type) {
+	case *parse.ListNode:
+		for _, n := range node.Nodes {
+			walkTemplate(n, fn)
+		}
+	case *parse.ActionNode:
+		walkTemplate(node.Pipe, fn)
+	case *parse.PipeNode:
+		for _, cmd := range node.Cmds {
+			walkTemplate(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range node.Args {
+			walkTemplate(arg, fn)
+		}
+	case *parse.IfNode:
+		walkBranch(&node.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&node.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&node.BranchNode, fn)
+	case *parse.TemplateNode:
+		if node.Pipe != nil {
+			walkTemplate(node.Pipe, fn)
+		}
+	}
+}
+
+// walkBranch walks the pipeline and both lists of an if, range or with
+// action. The else list is nil if there is no else branch.
+func walkBranch(node *parse.BranchNode, fn func(node parse.Node)) {
+	walkTemplate(node.Pipe, fn)
+	walkTemplate(node.List, fn)
+
+	if node.ElseList != nil {
+		walkTemplate(node.ElseList, fn)
+	}
+}
+
+// partialCall returns the name of the partial if the command calls the
+// partial function with a string literal, like partial "header" .
+func partialCall(cmd *parse.CommandNode) (string, bool) {
+	if len(cmd.Args) < 2 {
+		return "", false
+	}
+
+	ident, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok || ident.Ident != "partial" {
+		return "", false
+	}
+
+	name, ok := cmd.Args[1].(*parse.StringNode)
+	if !ok {
+		return "", false
+	}
+
+	return name.Text, true
+}
+
+// relPath returns the path of file relative to the project path, or file
+// itself if it isn't inside the project.
+func relPath(path, file string) string {
+	rel, err := filepath.Rel(path, file)
+	if err != nil {
+		return file
+	}
+	return filepath.ToSlash(rel)
+}
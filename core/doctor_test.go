@@ -0,0 +1,105 @@
+package core_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/verless/verless/core"
+	"github.com/verless/verless/test"
+)
+
+// TestDoctor checks if Doctor reports the problems of broken projects and
+// doesn't report anything for a healthy project.
+func TestDoctor(t *testing.T) {
+	tests := map[string]struct {
+		config   string
+		files    map[string]string
+		setup    func(path string)
+		expected []core.Diagnostic
+	}{
+		"healthy project": {
+			files: map[string]string{"coffee.md": "---\nTitle: Coffee\n---\n"},
+		},
+		"invalid configuration": {
+			config: "plugns:\n  - atom\n",
+			files:  map[string]string{"coffee.md": ""},
+			expected: []core.Diagnostic{
+				{Severity: core.SeverityError, Message: "missing `version` key"},
+				{Severity: core.SeverityError, Message: "verless.yml: plugns: unknown key, did you mean plugins?"},
+			},
+		},
+		"missing theme": {
+			config: "version: 1\ntheme: dark\n",
+			files:  map[string]string{"coffee.md": ""},
+			expected: []core.Diagnostic{
+				{Severity: core.SeverityError, Message: "invalid theme dark"},
+			},
+		},
+		"missing content directory": {
+			setup: func(path string) {
+				test.Ok(t, os.RemoveAll(filepath.Join(path, "content")))
+			},
+			expected: []core.Diagnostic{
+				{Severity: core.SeverityError, Message: "content directory"},
+			},
+		},
+		"no Markdown files": {
+			expected: []core.Diagnostic{
+				{Severity: core.SeverityWarning, Message: "doesn't contain any Markdown files"},
+			},
+		},
+		"broken templates": {
+			config: "version: 1\ntypes:\n  note:\n    template: note.html\noutputFormats:\n  amp:\n    template: page.amp.html\n    extension: amp.html\n",
+			files:  map[string]string{"coffee.md": ""},
+			setup: func(path string) {
+				templates := filepath.Join(path, "themes", "default", "templates")
+				page := `{{define "title"}}{{.Page.Title}}{{end}}{{template "title" .}}{{template "partials/header" .}}{{if .Page}}{{partial "footer" .}}{{end}}`
+				test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "page.html"), []byte(page), 0644))
+				test.Ok(t, ioutil.WriteFile(filepath.Join(templates, "list-page.html"), []byte("{{range .Pages}}"), 0644))
+			},
+			expected: []core.Diagnostic{
+				{Severity: core.SeverityError, Message: "themes/default/templates/list-page.html: template: list-page.html:1: unexpected EOF"},
+				{Severity: core.SeverityError, Message: `themes/default/templates/page.html: template "partials/header" doesn't exist`},
+				{Severity: core.SeverityError, Message: `themes/default/templates/page.html: partial "footer" doesn't exist`},
+				{Severity: core.SeverityError, Message: "types.note.template: note.html in theme default: template not found"},
+				{Severity: core.SeverityError, Message: "outputFormats.amp.template: page.amp.html in theme default: template not found"},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		config := testCase.config
+		if config == "" {
+			config = "version: 1\n"
+		}
+
+		path := createTestProject(t, config, testCase.files)
+
+		if testCase.setup != nil {
+			testCase.setup(path)
+		}
+
+		diagnostics, err := core.Doctor(path)
+		_ = os.RemoveAll(filepath.Dir(path))
+		test.Ok(t, err)
+
+		test.Equals(t, len(testCase.expected), len(diagnostics))
+
+		for i, expected := range testCase.expected {
+			test.Equals(t, expected.Severity, diagnostics[i].Severity)
+			test.Assert(t, strings.Contains(diagnostics[i].Message, expected.Message), "expected %q to contain %q", diagnostics[i].Message, expected.Message)
+		}
+	}
+}
+
+// TestDoctor_missingProject checks if Doctor fails for a missing project.
+func TestDoctor_missingProject(t *testing.T) {
+	_, err := core.Doctor(filepath.Join(os.TempDir(), "verless-missing-project"))
+	test.Assert(t, errors.Is(err, core.ErrProjectNotExists), "expected %v, got %v", core.ErrProjectNotExists, err)
+}
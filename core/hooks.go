@@ -0,0 +1,31 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// runHooks executes the given commands one after another inside the
+// directory dir. The output of the commands is streamed to stdout and
+// stderr. If a command fails, the remaining commands are not executed.
+func runHooks(dir string, commands []string) error {
+	for _, command := range commands {
+		parts := strings.Fields(command)
+		if len(parts) == 0 {
+			continue
+		}
+
+		cmd := exec.Command(parts[0], parts[1:]...)
+		cmd.Dir = dir
+		cmd.Stderr = os.Stderr
+		cmd.Stdout = os.Stdout
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("hook %s: %w", command, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,961 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"github.com/verless/verless/config"
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/out/style"
+	"github.com/verless/verless/parser"
+	"github.com/verless/verless/theme"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ImportHugo imports a Hugo site.
+	ImportHugo string = "hugo"
+	// ImportJekyll imports a Jekyll site.
+	ImportJekyll string = "jekyll"
+
+	// importPostsDir is the directory inside the content directory the
+	// posts of a Jekyll site are imported into.
+	importPostsDir string = "blog"
+)
+
+var (
+	// ErrSiteNotExists states that the site to import doesn't exist.
+	ErrSiteNotExists = errors.New("site to import doesn't exist")
+
+	// ErrUnknownSite states that the generator of the site to import
+	// can't be detected.
+	ErrUnknownSite = errors.New("neither a Hugo nor a Jekyll site, use --from to specify the generator")
+
+	// ErrInvalidGenerator states that the generator to import from isn't
+	// supported.
+	ErrInvalidGenerator = errors.New("unsupported generator, must be hugo or jekyll")
+
+	// ErrImportIntoSite states that the project would be created inside
+	// the imported site or the other way around, which could modify the
+	// imported site.
+	ErrImportIntoSite = errors.New("the project and the imported site must not contain each other")
+
+	// jekyllPostName matches the names of Jekyll posts like
+	// 2020-10-14-making-espresso and captures the date and the title.
+	jekyllPostName = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+
+	// liquidRaw matches {% raw %} blocks, whose contents are no Liquid.
+	liquidRaw = regexp.MustCompile(`(?s)\{%-?\s*raw\s*-?%\}(.*?)\{%-?\s*endraw\s*-?%\}`)
+	// liquidHighlight matches {% highlight %} blocks and captures the
+	// language and the code.
+	liquidHighlight = regexp.MustCompile(`(?s)\{%-?\s*highlight\s+([\w+#-]+)[^%]*-?%\}\r?\n?(.*?)\{%-?\s*endhighlight\s*-?%\}`)
+	// liquidPostURL matches {% post_url %} tags and captures the post.
+	liquidPostURL = regexp.MustCompile(`\{%-?\s*post_url\s+(\S+?)\s*-?%\}`)
+	// liquidMarkup matches all Liquid tags and output markup.
+	liquidMarkup = regexp.MustCompile(`(?s)\{%-?\s*(\w*).*?-?%\}|\{\{.*?\}\}`)
+
+	// hugoShortcode matches Hugo shortcodes like {{< youtube id >}} or
+	// {{% notice %}} and captures the delimiter and the arguments.
+	hugoShortcode = regexp.MustCompile(`(?s)\{\{([<%])(.*?)[>%]\}\}`)
+
+	// importedKeys maps the lowercase front matter keys of Hugo and Jekyll
+	// to the corresponding front matter keys of verless.
+	importedKeys = map[string]string{
+		"title":         "Title",
+		"author":        "Author",
+		"date":          "Date",
+		"publishdate":   "Date",
+		"description":   "Description",
+		"summary":       "Summary",
+		"excerpt":       "Summary",
+		"tags":          "Tags",
+		"categories":    "Categories",
+		"category":      "Categories",
+		"image":         "Img",
+		"img":           "Img",
+		"slug":          "Slug",
+		"aliases":       "Aliases",
+		"redirect_from": "Aliases",
+		"canonical_url": "Canonical",
+		"canonicalurl":  "Canonical",
+		"weight":        "Weight",
+		"draft":         "Draft",
+	}
+
+	// importedKeyOrder is the order of the keys in imported front matter.
+	importedKeyOrder = []string{
+		"Title", "Author", "Date", "Description", "Summary", "Tags", "Categories",
+		"Img", "Slug", "Aliases", "Canonical", "Weight", "Draft",
+	}
+
+	// importedDateFormats contains the date formats used by Hugo and
+	// Jekyll, like 2020-10-14 08:15:00 +0200.
+	importedDateFormats = []string{
+		time.RFC3339,
+		"2006-01-02 15:04:05 -0700",
+		"2006-01-02 15:04:05 -07:00",
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+
+	// defaultLayouts contains the Hugo and Jekyll layouts that correspond
+	// to the default templates of verless.
+	defaultLayouts = map[string]bool{"default": true, "page": true, "post": true, "single": true, "list": true}
+)
+
+// ImportOptions represents options for importing a site.
+type ImportOptions struct {
+	// From is the generator of the imported site, either ImportHugo or
+	// ImportJekyll. If it is empty, the generator is detected.
+	From string
+	// Overwrite allows replacing an existing project.
+	Overwrite bool
+	// Logger prints the created project and each written file at debug
+	// level. If it is nil, the default logger is used.
+	Logger *out.Logger
+}
+
+// ImportWarning represents a construct of the imported site that can't
+// be converted automatically.
+type ImportWarning struct {
+	// File is the path of the file inside the imported site, like
+	// _posts/2020-10-14-coffee.md.
+	File    string
+	Message string
+}
+
+// Import creates a new verless project at path from the Hugo or Jekyll
+// site at source, which is only read. The content files are converted as
+// follows:
+//
+//  1. The front matter keys are mapped to verless keys, e.g. title to
+//     Title and redirect_from to Aliases. Other keys are removed.
+//  2. Jekyll posts like _posts/2020-10-14-coffee.md become pages like
+//     blog/coffee.md dated from their filename, and drafts become pages
+//     with Draft set.
+//  3. Hugo files like _index.md become index.md files.
+//  4. {% highlight %} blocks and {% post_url %} tags are converted, and
+//     Hugo shortcodes are kept for shortcode templates of the theme.
+//
+// Static files are copied into the root directory and data files into
+// the data directory. Import returns a warning for each construct that
+// can't be converted, like Liquid tags, layouts and shortcodes.
+func Import(source, path string, options ImportOptions) ([]ImportWarning, error) {
+	if info, err := os.Stat(source); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s: %w", source, ErrSiteNotExists)
+	}
+
+	if contains(source, path) || contains(path, source) {
+		return nil, ErrImportIntoSite
+	}
+
+	from := options.From
+	if from == "" {
+		from = detectGenerator(source)
+	}
+
+	i := siteImporter{
+		source: source,
+		files:  make(map[string][]byte),
+		posts:  make(map[string]string),
+	}
+
+	var err error
+
+	switch from {
+	case ImportJekyll:
+		err = i.importJekyll()
+	case ImportHugo:
+		err = i.importHugo()
+	case "":
+		return nil, fmt.Errorf("%s: %w", source, ErrUnknownSite)
+	default:
+		return nil, fmt.Errorf("%s: %w", from, ErrInvalidGenerator)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := CreateProject(path, CreateProjectOptions{
+		Overwrite:      options.Overwrite,
+		CleanupOnError: true,
+		Logger:         options.Logger,
+	}); err != nil {
+		return nil, err
+	}
+
+	var (
+		dirs  []string
+		files = make(map[string][]byte, len(i.files)+1)
+	)
+
+	for file, content := range i.files {
+		file = filepath.Join(path, filepath.FromSlash(file))
+		dirs = append(dirs, filepath.Dir(file))
+		files[file] = content
+	}
+
+	files[filepath.Join(path, config.Filename+".yml")] = i.projectConfig()
+
+	if err := createProject(afero.NewOsFs(), loggerOrDefault(options.Logger), dirs, files); err != nil {
+		return nil, err
+	}
+
+	loggerOrDefault(options.Logger).Info(style.HeavyCheckMark, "imported %d files from %s", len(i.files), source)
+
+	return i.warnings, nil
+}
+
+// contains reports whether the path inner is the same as or inside the
+// directory outer.
+func contains(outer, inner string) bool {
+	outer, err := filepath.Abs(outer)
+	if err != nil {
+		return false
+	}
+
+	inner, err = filepath.Abs(inner)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(outer, inner)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// detectGenerator returns the generator of the site at source, or an
+// empty string if it can't be detected.
+func detectGenerator(source string) string {
+	exists := func(names ...string) bool {
+		for _, name := range names {
+			if _, err := os.Stat(filepath.Join(source, name)); err == nil {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case exists("_config.yml", "_config.yaml", "_config.toml", "_posts"):
+		return ImportJekyll
+	case exists("hugo.toml", "hugo.yaml", "hugo.json", "config.toml", "config.yaml", "config.yml", "config.json", "archetypes"):
+		return ImportHugo
+	}
+
+	return ""
+}
+
+// siteImporter converts the files of a Hugo or Jekyll site.
+type siteImporter struct {
+	source string
+	// files maps the paths of the converted files relative to the project,
+	// like content/blog/coffee.md, to their contents.
+	files    map[string][]byte
+	warnings []ImportWarning
+	// posts maps the names of Jekyll posts like 2020-10-14-coffee to their
+	// Href, so that {% post_url %} tags can be converted.
+	posts map[string]string
+	// meta contains the site metadata read from the site configuration.
+	meta    yaml.MapSlice
+	baseURL string
+	// hasTags and hasCategories report whether any page has tags or
+	// categories, which require the tags plugin or a taxonomy.
+	hasTags       bool
+	hasCategories bool
+}
+
+func (i *siteImporter) warnf(file, format string, a ...interface{}) {
+	i.warnings = append(i.warnings, ImportWarning{File: file, Message: fmt.Sprintf(format, a...)})
+}
+
+// sourceFiles returns the paths of all files inside the given directory
+// of the site relative to the site, like _posts/2020-10-14-coffee.md.
+func (i *siteImporter) sourceFiles(dir string) ([]string, error) {
+	var files []string
+
+	root := filepath.Join(i.source, dir)
+
+	if _, err := os.Stat(root); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	err := filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(i.source, file)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+
+	return files, err
+}
+
+// readConfig reads the site configuration from the first existing file
+// with one of the given names, which don't have an extension.
+func (i *siteImporter) readConfig(names ...string) (*viper.Viper, error) {
+	for _, name := range names {
+		v := viper.New()
+		v.AddConfigPath(i.source)
+		v.SetConfigName(name)
+
+		err := v.ReadInConfig()
+
+		var notFound viper.ConfigFileNotFoundError
+		if errors.As(err, &notFound) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("reading the site configuration: %w", err)
+		}
+
+		return v, nil
+	}
+
+	return viper.New(), nil
+}
+
+// readMeta reads the site metadata from the given configuration keys.
+func (i *siteImporter) readMeta(v *viper.Viper, title, description, author string) {
+	for _, field := range []struct{ key, value string }{
+		{"title", v.GetString(title)},
+		{"description", v.GetString(description)},
+		{"author", v.GetString(author)},
+	} {
+		if field.value != "" {
+			i.meta = append(i.meta, yaml.MapItem{Key: field.key, Value: field.value})
+		}
+	}
+}
+
+// projectConfig returns the configuration file of the imported project,
+// containing the site metadata, the tags plugin if any page has tags and
+// the category taxonomy if any page has categories.
+func (i *siteImporter) projectConfig() []byte {
+	cfg := yaml.MapSlice{{Key: "version", Value: 1}}
+
+	if i.baseURL != "" {
+		cfg = append(cfg, yaml.MapItem{Key: "baseURL", Value: i.baseURL})
+	}
+
+	if len(i.meta) > 0 {
+		cfg = append(cfg, yaml.MapItem{Key: "site", Value: yaml.MapSlice{{Key: "meta", Value: i.meta}}})
+	}
+
+	cfg = append(cfg, yaml.MapItem{Key: "theme", Value: theme.Default})
+
+	if i.hasTags {
+		cfg = append(cfg, yaml.MapItem{Key: "plugins", Value: []string{"tags"}})
+	}
+
+	if i.hasCategories {
+		cfg = append(cfg, yaml.MapItem{Key: "taxonomies", Value: []string{"category"}})
+	}
+
+	// Marshalling maps, slices and strings can't fail.
+	file, _ := yaml.Marshal(cfg)
+
+	return file
+}
+
+// importJekyll converts the posts, drafts and pages of a Jekyll site. Like
+// Jekyll, only files with front matter are treated as pages, all other
+// files are static files.
+func (i *siteImporter) importJekyll() error {
+	v, err := i.readConfig("_config")
+	if err != nil {
+		return err
+	}
+
+	author := "author"
+	if v.IsSet("author.name") {
+		author = "author.name"
+	}
+	i.readMeta(v, "title", "description", author)
+
+	if url := v.GetString("url"); url != "" {
+		i.baseURL = strings.TrimSuffix(url, "/") + v.GetString("baseurl")
+	}
+
+	files, err := i.sourceFiles(".")
+	if err != nil {
+		return err
+	}
+
+	// The routes of all posts have to be known before converting the
+	// {% post_url %} tags.
+	for _, file := range files {
+		if !strings.HasPrefix(file, "_posts/") || !isContentFile(file) {
+			continue
+		}
+
+		if route, _, ok := jekyllPostRoute(file); ok {
+			// A post can be referenced with or without its subdirectory.
+			name := strings.TrimSuffix(strings.TrimPrefix(file, "_posts/"), path.Ext(file))
+			i.posts[name] = "/" + route
+			i.posts[path.Base(name)] = "/" + route
+		}
+	}
+
+	skipped := make(map[string]bool)
+
+	for _, file := range files {
+		var (
+			segments = strings.Split(file, "/")
+			dir      = segments[0]
+		)
+
+		switch {
+		case dir == "_posts" || dir == "_drafts":
+			if err := i.importJekyllPost(file, dir == "_drafts"); err != nil {
+				return err
+			}
+		case dir == "_data":
+			if err := i.copyFile(file, path.Join(config.DataDir, strings.TrimPrefix(file, "_data/"))); err != nil {
+				return err
+			}
+		case dir == "_layouts" || dir == "_includes":
+			if !skipped[dir] {
+				i.warnf(dir, "layouts and includes aren't imported, create templates for the verless theme instead")
+				skipped["_layouts"], skipped["_includes"] = true, true
+			}
+		case dir == "_plugins":
+			if !skipped[dir] {
+				i.warnf(dir, "Jekyll plugins aren't supported")
+				skipped[dir] = true
+			}
+		case len(segments) > 1 && strings.HasPrefix(dir, "_") && dir != "_site" && dir != "_sass":
+			if !skipped[dir] {
+				i.warnf(dir, "collections aren't imported, move the files into the content directory")
+				skipped[dir] = true
+			}
+		case isHidden(segments) || dir == "node_modules" || dir == "vendor" || file == "Gemfile" || file == "Gemfile.lock":
+		default:
+			if err := i.importJekyllPage(file); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// isHidden reports whether any segment of a path starts with a dot or an
+// underscore, which Jekyll doesn't publish.
+func isHidden(segments []string) bool {
+	for _, segment := range segments {
+		if strings.HasPrefix(segment, ".") || strings.HasPrefix(segment, "_") {
+			return true
+		}
+	}
+	return false
+}
+
+// importJekyllPost converts a post like _posts/2020-10-14-coffee.md into
+// a page like blog/coffee.md, dated from the filename unless it has a
+// date in its front matter.
+func (i *siteImporter) importJekyllPost(file string, draft bool) error {
+	// Like Jekyll, other files inside the posts directory are ignored.
+	if !isContentFile(file) {
+		return nil
+	}
+
+	route, date, ok := jekyllPostRoute(file)
+	if !ok && !draft {
+		i.warnf(file, "posts have to be named like 2020-10-14-title%s, the file has been skipped", path.Ext(file))
+		return nil
+	}
+
+	c, err := i.readContentFile(file)
+	if err != nil {
+		return err
+	}
+
+	if c.values == nil {
+		c.values = make(map[string]interface{})
+	}
+
+	if _, exists := c.values["date"]; !exists && date != "" {
+		c.values["date"] = date
+	}
+
+	if draft {
+		c.values["draft"] = true
+	}
+
+	target := path.Join(config.ContentDir, route+path.Ext(file))
+
+	return i.writeContentFile(file, target, c.values, i.convertLiquid(file, c.content, c.line))
+}
+
+// jekyllPostRoute returns the route of a Jekyll post or draft like
+// _posts/coffee/2020-10-14-espresso.md inside the content directory, like
+// blog/coffee/espresso, along with the date from the filename. The
+// returned bool is false if the filename doesn't contain a date.
+func jekyllPostRoute(file string) (string, string, bool) {
+	var (
+		name = strings.TrimSuffix(path.Base(file), path.Ext(file))
+		dir  = strings.SplitN(path.Dir(file), "/", 2)
+	)
+
+	// Posts inside subdirectories like _posts/coffee keep the directory.
+	route := importPostsDir
+	if len(dir) == 2 {
+		route = path.Join(route, dir[1])
+	}
+
+	match := jekyllPostName.FindStringSubmatch(name)
+	if match == nil {
+		return path.Join(route, name), "", false
+	}
+
+	return path.Join(route, match[2]), match[1], true
+}
+
+// importJekyllPage converts a page of a Jekyll site if it has front
+// matter, and copies it into the root directory otherwise.
+func (i *siteImporter) importJekyllPage(file string) error {
+	if !isContentFile(file) {
+		return i.copyFile(file, path.Join(config.RootDir, file))
+	}
+
+	c, err := i.readContentFile(file)
+	if err != nil {
+		return err
+	}
+
+	if c.values == nil {
+		return i.copyFile(file, path.Join(config.RootDir, file))
+	}
+
+	return i.writeContentFile(file, path.Join(config.ContentDir, file), c.values, i.convertLiquid(file, c.content, c.line))
+}
+
+// importHugo converts the content of a Hugo site and copies its static
+// and data files.
+func (i *siteImporter) importHugo() error {
+	v, err := i.readConfig("hugo", "config")
+	if err != nil {
+		return err
+	}
+
+	author := "params.author"
+	if !v.IsSet(author) {
+		author = "author"
+	}
+	i.readMeta(v, "title", "params.description", author)
+	i.baseURL = v.GetString("baseURL")
+
+	dirs := map[string]string{
+		"contentDir": config.ContentDir,
+		"staticDir":  config.StaticDir,
+		"dataDir":    config.DataDir,
+	}
+	for key := range dirs {
+		if v.IsSet(key) {
+			dirs[key] = v.GetString(key)
+		}
+	}
+
+	for _, dir := range []string{"layouts", "themes"} {
+		if _, err := os.Stat(filepath.Join(i.source, dir)); err == nil {
+			i.warnf(dir, "layouts and themes aren't imported, create templates for the verless theme instead")
+			break
+		}
+	}
+
+	content, err := i.sourceFiles(dirs["contentDir"])
+	if err != nil {
+		return err
+	}
+
+	for _, file := range content {
+		if err := i.importHugoFile(file, strings.TrimPrefix(file, dirs["contentDir"]+"/")); err != nil {
+			return err
+		}
+	}
+
+	for _, dir := range []struct{ source, target string }{
+		{dirs["staticDir"], config.RootDir},
+		{dirs["dataDir"], config.DataDir},
+	} {
+		files, err := i.sourceFiles(dir.source)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if err := i.copyFile(file, path.Join(dir.target, strings.TrimPrefix(file, dir.source+"/"))); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// importHugoFile converts a file inside the content directory of a Hugo
+// site, whose path inside the content directory is route. Files other
+// than content files are copied, so that page bundles keep their
+// resources.
+func (i *siteImporter) importHugoFile(file, route string) error {
+	switch path.Ext(file) {
+	case ".adoc", ".asciidoc", ".org", ".pandoc", ".pdc", ".rst":
+		i.warnf(file, "only Markdown and HTML content is supported, the file has been skipped")
+		return nil
+	}
+
+	if !isContentFile(file) {
+		return i.copyFile(file, path.Join(config.ContentDir, route))
+	}
+
+	c, err := i.readContentFile(file)
+	if err != nil {
+		return err
+	}
+
+	if c.values == nil {
+		c.values = make(map[string]interface{})
+	}
+
+	// A _index.md file contains the content of a list page.
+	if name := path.Base(route); strings.HasPrefix(name, "_index.") {
+		route = path.Join(path.Dir(route), strings.TrimPrefix(name, "_"))
+	}
+
+	return i.writeContentFile(file, path.Join(config.ContentDir, route), c.values, i.convertShortcodes(file, c.content, c.line))
+}
+
+// isContentFile reports whether the given file is a Markdown or HTML file
+// with one of the default extensions.
+var isContentFile = fs.Renderable()
+
+// contentFile is a content file of the imported site.
+type contentFile struct {
+	// values contains the front matter, which is nil if there is none.
+	values  map[string]interface{}
+	content string
+	// line is the line of the file the content starts at.
+	line int
+}
+
+// readContentFile reads the front matter and the content of the given
+// Markdown or HTML file inside the site.
+func (i *siteImporter) readContentFile(file string) (contentFile, error) {
+	src, err := ioutil.ReadFile(filepath.Join(i.source, filepath.FromSlash(file)))
+	if err != nil {
+		return contentFile{}, err
+	}
+
+	values, content, err := parser.ReadFrontMatter(src)
+	if err != nil {
+		return contentFile{}, fmt.Errorf("%s: %w", file, err)
+	}
+
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+
+	c := contentFile{
+		values:  values,
+		content: string(content),
+		line:    1 + bytes.Count(src[:len(src)-len(content)], []byte("\n")),
+	}
+
+	// A file without front matter has no values.
+	if len(content) == len(src) {
+		c.values = nil
+	}
+
+	return c, nil
+}
+
+// copyFile copies a file of the site to the given path in the project.
+func (i *siteImporter) copyFile(file, target string) error {
+	content, err := ioutil.ReadFile(filepath.Join(i.source, filepath.FromSlash(file)))
+	if err != nil {
+		return err
+	}
+
+	i.files[target] = content
+	return nil
+}
+
+// writeContentFile records the content file at the given path in the
+// project, consisting of the converted front matter and the content.
+func (i *siteImporter) writeContentFile(file, target string, values map[string]interface{}, content string) error {
+	frontMatter, err := yaml.Marshal(i.convertFrontMatter(file, target, values))
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("---\n")
+	buf.Write(frontMatter)
+	buf.WriteString("---\n")
+	buf.WriteString(content)
+
+	i.files[target] = buf.Bytes()
+	return nil
+}
+
+// convertFrontMatter maps the front matter keys of Hugo and Jekyll to the
+// front matter keys of verless, see importedKeys. The content file is
+// written to target.
+func (i *siteImporter) convertFrontMatter(file, target string, values map[string]interface{}) yaml.MapSlice {
+	var (
+		converted = make(map[string]interface{})
+		removed   []string
+	)
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		lower := strings.ToLower(key)
+
+		switch lower {
+		case "layout":
+			if layout, _ := value.(string); !defaultLayouts[layout] {
+				i.warnf(file, "layout %v isn't imported, create a template and set Template instead", value)
+			}
+			continue
+		case "published":
+			if published, ok := value.(bool); ok && !published {
+				converted["Draft"] = true
+			}
+			continue
+		case "permalink", "url":
+			// The old URL redirects to the new page unless it's the same.
+			if permalink, ok := value.(string); ok && strings.Trim(permalink, "/") != strings.Trim(importedHref(target), "/") {
+				converted["Aliases"] = append(stringList(converted["Aliases"], false), permalink)
+			}
+			continue
+		case "lastmod":
+			// verless uses the modification time of the content file.
+			continue
+		}
+
+		verlessKey, exists := importedKeys[lower]
+		if !exists {
+			removed = append(removed, key)
+			continue
+		}
+
+		switch verlessKey {
+		case "Date":
+			if _, exists := converted["Date"]; exists && lower == "publishdate" {
+				continue
+			}
+			date, ok := importedDate(value)
+			if !ok {
+				i.warnf(file, "date %v can't be parsed and has been removed", value)
+				continue
+			}
+			converted["Date"] = date
+		case "Tags", "Categories":
+			// Jekyll separates several terms in a string with spaces.
+			terms := stringList(value, lower != "category")
+			converted[verlessKey] = append(stringList(converted[verlessKey], false), terms...)
+			i.hasTags = i.hasTags || verlessKey == "Tags" && len(terms) > 0
+			i.hasCategories = i.hasCategories || verlessKey == "Categories" && len(terms) > 0
+		case "Aliases":
+			converted["Aliases"] = append(stringList(converted["Aliases"], false), stringList(value, false)...)
+		case "Draft":
+			if draft, ok := value.(bool); ok && draft {
+				converted["Draft"] = true
+			}
+		default:
+			converted[verlessKey] = value
+		}
+	}
+
+	if len(removed) > 0 {
+		i.warnf(file, "front matter keys without verless counterpart have been removed: %s", strings.Join(removed, ", "))
+	}
+
+	slice := make(yaml.MapSlice, 0, len(converted))
+
+	for _, key := range importedKeyOrder {
+		if value, exists := converted[key]; exists {
+			slice = append(slice, yaml.MapItem{Key: key, Value: value})
+		}
+	}
+
+	return slice
+}
+
+// importedHref returns the Href of an imported content file with clean
+// URLs, like /blog/coffee for content/blog/coffee.md.
+func importedHref(target string) string {
+	route := strings.TrimPrefix(target, config.ContentDir)
+	route = strings.TrimSuffix(route, path.Ext(route))
+
+	return path.Join("/", strings.TrimSuffix(route, "/index"))
+}
+
+// importedDate converts a date of Hugo or Jekyll front matter into a date
+// like 2020-10-14 or an RFC 3339 timestamp.
+func importedDate(value interface{}) (string, bool) {
+	text, ok := value.(string)
+	if !ok {
+		return "", false
+	}
+
+	for _, format := range importedDateFormats {
+		date, err := time.Parse(format, text)
+		if err != nil {
+			continue
+		}
+		if format == "2006-01-02" {
+			return text, true
+		}
+		return date.Format(time.RFC3339), true
+	}
+
+	return "", false
+}
+
+// stringList converts a front matter value into a list of strings. If
+// split is set, a single string is split into its words.
+func stringList(value interface{}, split bool) []string {
+	switch v := value.(type) {
+	case string:
+		if split {
+			return strings.Fields(v)
+		}
+		return []string{v}
+	case []string:
+		return v
+	case []interface{}:
+		list := make([]string, 0, len(v))
+		for _, item := range v {
+			list = append(list, fmt.Sprint(item))
+		}
+		return list
+	}
+
+	return nil
+}
+
+// convertLiquid converts the {% highlight %} blocks and {% post_url %}
+// tags in the content of a Jekyll page into Markdown and removes the
+// {% raw %} tags. All other Liquid markup outside of {% raw %} blocks is
+// kept and reported. line is the line of the file the content starts at.
+func (i *siteImporter) convertLiquid(file, content string, line int) string {
+	var (
+		result strings.Builder
+		offset int
+	)
+
+	for _, raw := range liquidRaw.FindAllStringSubmatchIndex(content, -1) {
+		result.WriteString(i.convertLiquidMarkup(file, content[offset:raw[0]], line+strings.Count(content[:offset], "\n")))
+		result.WriteString(content[raw[2]:raw[3]])
+		offset = raw[1]
+	}
+
+	result.WriteString(i.convertLiquidMarkup(file, content[offset:], line+strings.Count(content[:offset], "\n")))
+
+	return result.String()
+}
+
+// convertLiquidMarkup converts the Liquid markup in a part of the content
+// that starts at the given line, see convertLiquid.
+func (i *siteImporter) convertLiquidMarkup(file, content string, line int) string {
+	for _, match := range liquidMarkup.FindAllStringSubmatchIndex(content, -1) {
+		if match[2] >= 0 {
+			switch content[match[2]:match[3]] {
+			case "highlight", "endhighlight", "post_url":
+				continue
+			}
+		}
+
+		i.warnf(file, "line %d: Liquid markup %s can't be converted", line+strings.Count(content[:match[0]], "\n"), content[match[0]:match[1]])
+	}
+
+	content = liquidHighlight.ReplaceAllStringFunc(content, func(block string) string {
+		match := liquidHighlight.FindStringSubmatch(block)
+		code := match[2]
+		if !strings.HasSuffix(code, "\n") {
+			code += "\n"
+		}
+		return "```" + match[1] + "\n" + code + "```"
+	})
+
+	return liquidPostURL.ReplaceAllStringFunc(content, func(tag string) string {
+		post := liquidPostURL.FindStringSubmatch(tag)[1]
+		if href, exists := i.posts[post]; exists {
+			return href
+		}
+		i.warnf(file, "post_url %s refers to a missing post", post)
+		return tag
+	})
+}
+
+// convertShortcodes converts the Hugo shortcodes like {{% notice %}} in
+// the content of a Hugo page into verless shortcodes like {{< notice >}}.
+// Each shortcode is reported once, since the theme has to provide its
+// template. line is the line of the file the content starts at.
+func (i *siteImporter) convertShortcodes(file, content string, line int) string {
+	var (
+		result   strings.Builder
+		offset   int
+		reported = make(map[string]bool)
+	)
+
+	for _, match := range hugoShortcode.FindAllStringSubmatchIndex(content, -1) {
+		var (
+			args   = content[match[4]:match[5]]
+			fields = strings.Fields(args)
+			at     = line + strings.Count(content[:match[0]], "\n")
+		)
+
+		switch {
+		case len(fields) == 0 || strings.HasPrefix(fields[0], "/*"):
+		case strings.HasPrefix(fields[0], "/"):
+			i.warnf(file, "line %d: closing shortcode %s can't be converted, since verless shortcodes have no inner content", at, content[match[0]:match[1]])
+		case !reported[fields[0]]:
+			template := path.Join(config.ThemesDir, theme.Default, theme.ShortcodesDir, fields[0]+".html")
+			i.warnf(file, "line %d: shortcode %s requires a template %s", at, fields[0], template)
+			reported[fields[0]] = true
+		}
+
+		result.WriteString(content[offset:match[0]])
+		result.WriteString("{{<" + args + ">}}")
+		offset = match[1]
+	}
+
+	result.WriteString(content[offset:])
+
+	return result.String()
+}
@@ -0,0 +1,233 @@
+package core_test
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/core"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/test"
+)
+
+// createTestSite creates a site to import consisting of the given files,
+// whose paths are relative to the site directory. It returns the site
+// directory and the path of the project to import into.
+func createTestSite(tb testing.TB, files map[string]string) (string, string) {
+	dir, err := ioutil.TempDir("", "verless-import")
+	test.Ok(tb, err)
+
+	site := filepath.Join(dir, "site")
+
+	for file, content := range files {
+		file = filepath.Join(site, filepath.FromSlash(file))
+		test.Ok(tb, os.MkdirAll(filepath.Dir(file), 0755))
+		test.Ok(tb, ioutil.WriteFile(file, []byte(content), 0644))
+	}
+
+	test.Ok(tb, os.MkdirAll(site, 0755))
+
+	return site, filepath.Join(dir, "project")
+}
+
+// readProjectFiles returns the contents of the files inside the directory
+// dir of the project, keyed by their slash-separated path relative to
+// the project.
+func readProjectFiles(tb testing.TB, project, dir string) map[string]string {
+	files := make(map[string]string)
+
+	err := filepath.Walk(filepath.Join(project, dir), func(file string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(project, file)
+		if err != nil {
+			return err
+		}
+
+		files[filepath.ToSlash(rel)] = string(content)
+		return nil
+	})
+	test.Ok(tb, err)
+
+	return files
+}
+
+// TestImport_jekyll checks if a Jekyll site is converted into a project
+// that can be built and if unconvertible constructs are reported.
+func TestImport_jekyll(t *testing.T) {
+	site, project := createTestSite(t, map[string]string{
+		"_config.yml":           "title: Coffee Blog\ndescription: All about coffee\nauthor:\n  name: Barista\nurl: https://example.com/\nbaseurl: /blog\n",
+		"Gemfile":               "source 'https://rubygems.org'\n",
+		"README.md":             "# Readme\n",
+		"_data/menu.yml":        "- Espresso\n",
+		"_layouts/default.html": "<html></html>",
+		"_drafts/cold-brew.md":  "---\ntitle: Cold brew\n---\nCold.\n",
+		"_posts/2020-10-14-making-espresso.md": "---\nlayout: post\ntitle: Making Espresso\ntags: coffee espresso\ncategories: [Guides]\ncomments: true\n---\n" +
+			"Read [latte]({% post_url 2020-10-15-latte %}) first.\n\n{% highlight go %}\nfmt.Println(\"coffee\")\n{% endhighlight %}\n\n{% include note.html %}\n\n{% raw %}{{ not liquid }}{% endraw %}\n",
+		"_posts/2020-10-15-latte.markdown": "---\ntitle: Latte\ndate: 2020-10-15 08:15:00 +0200\npermalink: /latte.html\n---\nMilk.\n",
+		"_posts/invalid.md":                "---\ntitle: Invalid\n---\n",
+		"about.md":                         "---\nlayout: page\ntitle: About\npermalink: /about/\n---\nAbout {{ site.title }}.\n",
+		"assets/img/beans.jpg":             "beans",
+	})
+	defer func() {
+		_ = os.RemoveAll(filepath.Dir(site))
+	}()
+
+	var logs bytes.Buffer
+
+	warnings, err := core.Import(site, project, core.ImportOptions{
+		Logger: out.NewLogger(out.LevelInfo, &logs, &logs),
+	})
+	test.Ok(t, err)
+
+	test.Equals(t, []core.ImportWarning{
+		{File: "_layouts", Message: "layouts and includes aren't imported, create templates for the verless theme instead"},
+		{File: "_posts/2020-10-14-making-espresso.md", Message: "line 14: Liquid markup {% include note.html %} can't be converted"},
+		{File: "_posts/2020-10-14-making-espresso.md", Message: "front matter keys without verless counterpart have been removed: comments"},
+		{File: "_posts/invalid.md", Message: "posts have to be named like 2020-10-14-title.md, the file has been skipped"},
+		{File: "about.md", Message: "line 6: Liquid markup {{ site.title }} can't be converted"},
+	}, warnings)
+
+	test.Equals(t, map[string]string{
+		"content/about.md":            "---\nTitle: About\n---\nAbout {{ site.title }}.\n",
+		"content/blog/cold-brew.md":   "---\nTitle: Cold brew\nDraft: true\n---\nCold.\n",
+		"content/blog/latte.markdown": "---\nTitle: Latte\nDate: \"2020-10-15T08:15:00+02:00\"\nAliases:\n- /latte.html\n---\nMilk.\n",
+		"content/blog/making-espresso.md": "---\nTitle: Making Espresso\nDate: \"2020-10-14\"\nTags:\n- coffee\n- espresso\nCategories:\n- Guides\n---\n" +
+			"Read [latte](/blog/latte) first.\n\n```go\nfmt.Println(\"coffee\")\n```\n\n{% include note.html %}\n\n{{ not liquid }}\n",
+	}, readProjectFiles(t, project, "content"))
+
+	test.Equals(t, map[string]string{
+		"root/README.md":            "# Readme\n",
+		"root/assets/img/beans.jpg": "beans",
+	}, readProjectFiles(t, project, "root"))
+
+	test.Equals(t, map[string]string{
+		"data/menu.yml": "- Espresso\n",
+	}, readProjectFiles(t, project, "data"))
+
+	config, err := ioutil.ReadFile(filepath.Join(project, "verless.yml"))
+	test.Ok(t, err)
+	test.Equals(t, "version: 1\nbaseURL: https://example.com/blog\nsite:\n  meta:\n    title: Coffee Blog\n    description: All about coffee\n    author: Barista\n"+
+		"theme: default\nplugins:\n- tags\ntaxonomies:\n- category\n", string(config))
+
+	// The source directory must remain untouched.
+	source := readProjectFiles(t, site, ".")
+	test.Equals(t, 11, len(source))
+	test.Equals(t, "---\nlayout: page\ntitle: About\npermalink: /about/\n---\nAbout {{ site.title }}.\n", source["about.md"])
+
+	build, err := core.NewBuild(afero.NewMemMapFs(), project, core.BuildOptions{OutputDir: "/target"})
+	test.Ok(t, err)
+	test.Ok(t, build.Run())
+}
+
+// TestImport_hugo checks if a Hugo site is converted into a project and
+// if shortcodes are rewritten for verless shortcode templates.
+func TestImport_hugo(t *testing.T) {
+	site, project := createTestSite(t, map[string]string{
+		"config.toml":       "title = \"Tea Blog\"\nbaseURL = \"https://tea.example.com/\"\n[params]\n  description = \"All about tea\"\n",
+		"content/_index.md": "+++\ntitle = \"Tea\"\n+++\nWelcome.\n",
+		"content/posts/green.md": "+++\ntitle = \"Green tea\"\ndate = 2020-10-14\ntags = [\"green\"]\naliases = [\"/old/green/\"]\ntoc = true\n+++\n" +
+			"{{< youtube abc >}}\n\n{{% notice info %}}\nHot!\n{{% /notice %}}\n\n{{< youtube def >}}\n",
+		"content/posts/bundle/index.md": "---\ntitle: Bundle\n---\n![Leaf](leaf.jpg)\n",
+		"content/posts/bundle/leaf.jpg": "leaf",
+		"content/notes.rst":             "Notes\n=====\n",
+		"static/favicon.ico":            "ico",
+		"layouts/index.html":            "<html></html>",
+	})
+	defer func() {
+		_ = os.RemoveAll(filepath.Dir(site))
+	}()
+
+	var logs bytes.Buffer
+
+	warnings, err := core.Import(site, project, core.ImportOptions{
+		Logger: out.NewLogger(out.LevelInfo, &logs, &logs),
+	})
+	test.Ok(t, err)
+
+	test.Equals(t, []core.ImportWarning{
+		{File: "layouts", Message: "layouts and themes aren't imported, create templates for the verless theme instead"},
+		{File: "content/notes.rst", Message: "only Markdown and HTML content is supported, the file has been skipped"},
+		{File: "content/posts/green.md", Message: "line 8: shortcode youtube requires a template themes/default/shortcodes/youtube.html"},
+		{File: "content/posts/green.md", Message: "line 10: shortcode notice requires a template themes/default/shortcodes/notice.html"},
+		{File: "content/posts/green.md", Message: "line 12: closing shortcode {{% /notice %}} can't be converted, since verless shortcodes have no inner content"},
+		{File: "content/posts/green.md", Message: "front matter keys without verless counterpart have been removed: toc"},
+	}, warnings)
+
+	test.Equals(t, map[string]string{
+		"content/index.md":              "---\nTitle: Tea\n---\nWelcome.\n",
+		"content/posts/bundle/index.md": "---\nTitle: Bundle\n---\n![Leaf](leaf.jpg)\n",
+		"content/posts/bundle/leaf.jpg": "leaf",
+		"content/posts/green.md": "---\nTitle: Green tea\nDate: \"2020-10-14\"\nTags:\n- green\nAliases:\n- /old/green/\n---\n" +
+			"{{< youtube abc >}}\n\n{{< notice info >}}\nHot!\n{{< /notice >}}\n\n{{< youtube def >}}\n",
+	}, readProjectFiles(t, project, "content"))
+
+	test.Equals(t, map[string]string{
+		"root/favicon.ico": "ico",
+	}, readProjectFiles(t, project, "root"))
+}
+
+// TestImport_errors checks if Import rejects sites it can't import before
+// creating the project.
+func TestImport_errors(t *testing.T) {
+	tests := map[string]struct {
+		files    map[string]string
+		from     string
+		source   string
+		project  string
+		expected error
+	}{
+		"missing site": {
+			source:   "missing",
+			expected: core.ErrSiteNotExists,
+		},
+		"unknown site": {
+			files:    map[string]string{"index.md": "# Index\n"},
+			expected: core.ErrUnknownSite,
+		},
+		"invalid generator": {
+			files:    map[string]string{"index.md": "# Index\n"},
+			from:     "gatsby",
+			expected: core.ErrInvalidGenerator,
+		},
+		"project inside site": {
+			files:    map[string]string{"_config.yml": "title: Coffee\n"},
+			project:  "project",
+			expected: core.ErrImportIntoSite,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		site, project := createTestSite(t, testCase.files)
+
+		source := site
+		if testCase.source != "" {
+			source = filepath.Join(site, testCase.source)
+		}
+
+		if testCase.project != "" {
+			project = filepath.Join(site, testCase.project)
+		}
+
+		_, err := core.Import(source, project, core.ImportOptions{From: testCase.from})
+		test.Assert(t, errors.Is(err, testCase.expected), "expected %v, got %v", testCase.expected, err)
+
+		_, statErr := os.Stat(project)
+		test.Assert(t, os.IsNotExist(statErr), "expected %s not to be created", project)
+
+		_ = os.RemoveAll(filepath.Dir(site))
+	}
+}
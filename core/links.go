@@ -0,0 +1,174 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	// ErrBrokenLinks states that rendered pages contain internal links to
+	// files that don't exist in the output directory.
+	ErrBrokenLinks = errors.New("broken internal links")
+
+	// linkAttrPattern matches the values of href and src attributes.
+	linkAttrPattern = regexp.MustCompile(`(?i)\s(?:href|src)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+)
+
+// checkLinks verifies that all internal links in the rendered pages point
+// to existing files. Broken links are recorded as warnings or fail the
+// build if Options.StrictLinks is set.
+func (b *Build) checkLinks() error {
+	var pages []string
+
+	err := afero.Walk(b.targetFs, b.outputDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.EqualFold(filepath.Ext(file), ".html") {
+			pages = append(pages, file)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Strings(pages)
+
+	var broken []string
+
+	for _, file := range pages {
+		content, err := afero.ReadFile(b.targetFs, file)
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(b.outputDir, file)
+		page := "/" + filepath.ToSlash(rel)
+
+		links := b.brokenLinks(page, string(content))
+		if len(links) == 0 {
+			continue
+		}
+
+		if b.Options.StrictLinks {
+			for _, link := range links {
+				broken = append(broken, page+" -> "+link)
+			}
+			continue
+		}
+
+		b.addWarning(Warning{Page: page, BrokenLinks: links})
+	}
+
+	if len(broken) > 0 {
+		return fmt.Errorf("%w: %s", ErrBrokenLinks, strings.Join(broken, ", "))
+	}
+
+	return nil
+}
+
+// brokenLinks returns all internal links in the content of the given page
+// whose targets don't exist. Each broken link is only returned once.
+func (b *Build) brokenLinks(page, content string) []string {
+	var (
+		links   []string
+		checked = make(map[string]bool)
+	)
+
+	for _, match := range linkAttrPattern.FindAllStringSubmatch(content, -1) {
+		link := html.UnescapeString(match[1] + match[2])
+
+		if checked[link] {
+			continue
+		}
+		checked[link] = true
+
+		targets := resolveLink(page, link, b.basePath, b.cleanURLs)
+		if len(targets) == 0 {
+			continue
+		}
+
+		if !b.anyOutputExists(targets) {
+			links = append(links, link)
+		}
+	}
+
+	return links
+}
+
+// resolveLink resolves a link found in the given page to absolute paths
+// inside the website. External URLs, links without a path like anchors
+// and unparseable links are not internal and yield no paths.
+//
+// With clean URLs, a page like /coffee/index.html may be served as
+// /coffee or /coffee/, so a relative link may refer to two targets. The
+// base path is removed from root-relative links.
+func resolveLink(page, link, basePath string, cleanURLs bool) []string {
+	if strings.HasPrefix(link, "//") {
+		return nil
+	}
+
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || u.Scheme != "" || u.Path == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(u.Path, "/") {
+		if basePath != "" && (u.Path == basePath || strings.HasPrefix(u.Path, basePath+"/")) {
+			return []string{"/" + strings.TrimPrefix(u.Path[len(basePath):], "/")}
+		}
+		return []string{u.Path}
+	}
+
+	dirs := []string{path.Dir(page)}
+	if cleanURLs && path.Base(page) == "index.html" && path.Dir(page) != "/" {
+		dirs = append(dirs, path.Dir(path.Dir(page)))
+	}
+
+	var targets []string
+
+	for _, dir := range dirs {
+		target := path.Join(dir, u.Path)
+		if strings.HasSuffix(u.Path, "/") {
+			target += "/"
+		}
+		targets = append(targets, target)
+	}
+
+	return targets
+}
+
+// anyOutputExists reports whether at least one of the given targets
+// exists.
+func (b *Build) anyOutputExists(targets []string) bool {
+	for _, target := range targets {
+		if b.outputExists(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// outputExists reports whether the given path inside the website exists
+// as a file or as a directory containing an index.html file.
+func (b *Build) outputExists(target string) bool {
+	file := filepath.Join(b.outputDir, filepath.FromSlash(target))
+
+	if info, err := b.targetFs.Stat(file); err == nil && !info.IsDir() {
+		return true
+	}
+
+	exists, _ := afero.Exists(b.targetFs, filepath.Join(file, "index.html"))
+	return exists
+}
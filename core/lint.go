@@ -0,0 +1,247 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/config"
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/parser"
+	"github.com/verless/verless/writer"
+)
+
+const (
+	// DefaultDraftAge is the period in which drafts are reported by Lint
+	// if no other period has been configured.
+	DefaultDraftAge = 7 * 24 * time.Hour
+)
+
+var (
+	// imageSrcPattern matches the src attributes of img elements, whose
+	// value is captured.
+	imageSrcPattern = regexp.MustCompile(`(?i)<img\s[^>]*?\bsrc\s*=\s*["']([^"']*)["']`)
+)
+
+// LintOptions configure Lint.
+type LintOptions struct {
+	// DraftAge is the period in which modified drafts are reported, since
+	// they probably are about to be published. If it is 0,
+	// DefaultDraftAge is used.
+	DraftAge time.Duration
+	// Now is the time the modification times of drafts are compared to.
+	// If it is zero, the current time is used.
+	Now time.Time
+}
+
+// LintIssue represents a problem of a content file found by Lint.
+type LintIssue struct {
+	// File is the path of the content file relative to the content
+	// directory, like blog/coffee.md.
+	File     string
+	Severity Severity
+	Message  string
+}
+
+// Lint checks all content files of the project at the given path for
+// problems and returns them ordered by file. It reports
+//
+//  1. front matter that can't be parsed or contains fields of the wrong
+//     type, like a Date that isn't a date,
+//  2. pages without a title,
+//  3. pages rendered to the same route as another page,
+//  4. relative image references to files that don't exist,
+//  5. drafts modified within LintOptions.DraftAge, as a warning.
+//
+// All problems except for drafts are errors. The returned error is only
+// non-nil if the content can't be checked at all.
+func Lint(path string, options LintOptions) ([]LintIssue, error) {
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s: %w", path, ErrProjectNotExists)
+	}
+
+	cfg, err := config.FromFile(path, config.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if options.DraftAge == 0 {
+		options.DraftAge = DefaultDraftAge
+	}
+
+	if options.Now.IsZero() {
+		options.Now = time.Now()
+	}
+
+	markdown, html, err := newParsers(&cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// The pages are routed exactly like during a build.
+	b := Build{
+		Parser:        markdown,
+		HTMLParser:    html,
+		contentDir:    cfg.ContentPath(path),
+		isContent:     fs.Renderable(cfg.RenderableExtensions()...),
+		i18n:          cfg.I18n,
+		cleanURLs:     cfg.Build.CleanURLs,
+		slug:          slugOptions(&cfg),
+		slugFilenames: cfg.Slug.Filenames,
+	}
+
+	if b.ignoreFilter, err = fs.Ignore(afero.NewOsFs(), b.contentDir); err != nil {
+		return nil, err
+	}
+
+	l := linter{
+		build:   &b,
+		options: options,
+		routes:  make(map[string]string),
+	}
+
+	files := make(chan string)
+	streamErr := make(chan error, 1)
+
+	go func() {
+		streamErr <- fs.StreamFilesOS(b.contentDir, files, b.isContent, fs.NoUnderscores, b.ignoreFilter)
+	}()
+
+	var sorted []string
+
+	for file := range files {
+		sorted = append(sorted, file)
+	}
+
+	if err := <-streamErr; err != nil {
+		return nil, err
+	}
+
+	// Duplicate routes are reported for the latter file, so the files are
+	// checked in a deterministic order.
+	sort.Strings(sorted)
+
+	for _, file := range sorted {
+		if err := l.lintFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	return l.issues, nil
+}
+
+// linter collects the issues of all content files.
+type linter struct {
+	build   *Build
+	options LintOptions
+	// routes maps the output files of all checked pages to the content
+	// file rendered to that output file.
+	routes map[string]string
+	issues []LintIssue
+}
+
+func (l *linter) errorf(file, format string, a ...interface{}) {
+	l.issues = append(l.issues, LintIssue{File: file, Severity: SeverityError, Message: fmt.Sprintf(format, a...)})
+}
+
+func (l *linter) warnf(file, format string, a ...interface{}) {
+	l.issues = append(l.issues, LintIssue{File: file, Severity: SeverityWarning, Message: fmt.Sprintf(format, a...)})
+}
+
+// lintFile runs all checks for the given content file, whose path like
+// /blog/coffee.md is relative to the content directory.
+func (l *linter) lintFile(contentFile string) error {
+	var (
+		native = filepath.Join(l.build.contentDir, contentFile)
+		file   = strings.TrimPrefix(filepath.ToSlash(contentFile), "/")
+	)
+
+	src, err := ioutil.ReadFile(native)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(native)
+	if err != nil {
+		return err
+	}
+
+	pageParser := l.build.Parser
+	if fs.HTMLOnly(file) {
+		pageParser = l.build.HTMLParser
+	}
+
+	page, err := pageParser.ParsePage(src)
+	if errors.Is(err, parser.ErrInvalidFrontMatter) {
+		l.errorf(file, "%s", err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	for _, field := range page.MissingFields() {
+		l.errorf(file, "missing front matter field %s", field)
+	}
+
+	l.checkRoute(contentFile, file, &page)
+	l.checkImages(file, &page)
+
+	if page.Draft && l.options.Now.Sub(info.ModTime()) < l.options.DraftAge {
+		l.warnf(file, "Draft is still set although the file has been modified recently")
+	}
+
+	return nil
+}
+
+// checkRoute reports a page that is rendered to the same output file as
+// a previously checked page, which would overwrite that page.
+func (l *linter) checkRoute(contentFile, file string, page *model.Page) {
+	if _, err := l.build.routePage(l.build.contentDir, contentFile, page); err != nil {
+		l.errorf(file, "%s", strings.TrimPrefix(err.Error(), contentFile+": "))
+		return
+	}
+
+	href := model.PageHref(page.Route, page.ID, l.build.cleanURLs)
+	if page.IsCustomListPage() && !page.Hidden {
+		href = model.ListPageHref(page.Route, l.build.cleanURLs)
+	}
+
+	outputFile := writer.OutputFile(href, l.build.cleanURLs)
+
+	if other, exists := l.routes[outputFile]; exists {
+		l.errorf(file, "route %s is already used by %s", href, other)
+		return
+	}
+
+	l.routes[outputFile] = file
+}
+
+// checkImages reports relative image references whose files don't exist
+// next to the content file.
+func (l *linter) checkImages(file string, page *model.Page) {
+	dir := path.Dir(file)
+
+	for _, match := range imageSrcPattern.FindAllStringSubmatch(page.Content, -1) {
+		ref, err := url.Parse(match[1])
+		if err != nil || ref.Scheme != "" || ref.Host != "" || ref.Path == "" || strings.HasPrefix(ref.Path, "/") {
+			continue
+		}
+
+		image := filepath.Join(l.build.contentDir, filepath.FromSlash(path.Join(dir, ref.Path)))
+
+		if _, err := os.Stat(image); err != nil {
+			l.errorf(file, "image %s doesn't exist", ref.Path)
+		}
+	}
+}
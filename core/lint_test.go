@@ -0,0 +1,121 @@
+package core_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/verless/verless/core"
+	"github.com/verless/verless/test"
+)
+
+// TestLint checks if Lint reports each kind of content problem for the
+// affected file and nothing for valid content.
+func TestLint(t *testing.T) {
+	tests := map[string]struct {
+		config   string
+		files    map[string]string
+		now      time.Time
+		expected []core.LintIssue
+	}{
+		"valid content": {
+			files: map[string]string{
+				"blog/coffee.md":          "---\nTitle: Coffee\n---\n![Beans](/img/beans.jpg) ![Logo](https://example.com/logo.png)",
+				"blog/espresso/index.md":  "---\nTitle: Espresso\n---\n![Crema](crema.jpg)",
+				"blog/espresso/crema.jpg": "crema",
+			},
+		},
+		"missing title": {
+			files: map[string]string{
+				"coffee.md": "---\nAuthor: Barista\n---\n",
+			},
+			expected: []core.LintIssue{
+				{File: "coffee.md", Severity: core.SeverityError, Message: "missing front matter field Title"},
+			},
+		},
+		"duplicate routes": {
+			files: map[string]string{
+				"blog.md":          "---\nTitle: Blog\n---\n",
+				"blog/index.md":    "---\nTitle: Blog\n---\n",
+				"blog/coffee.md":   "---\nTitle: Coffee\n---\n",
+				"blog/coffee.html": "---\nTitle: Coffee\n---\n",
+			},
+			expected: []core.LintIssue{
+				{File: "blog/coffee.md", Severity: core.SeverityError, Message: "route /blog/coffee is already used by blog/coffee.html"},
+				{File: "blog/index.md", Severity: core.SeverityError, Message: "route /blog is already used by blog.md"},
+			},
+		},
+		"broken image references": {
+			files: map[string]string{
+				"blog/coffee/index.md":  "---\nTitle: Coffee\n---\n![Beans](beans.jpg) ![Cup](images/cup.png?v=1)",
+				"blog/coffee/beans.jpg": "beans",
+			},
+			expected: []core.LintIssue{
+				{File: "blog/coffee/index.md", Severity: core.SeverityError, Message: "image images/cup.png doesn't exist"},
+			},
+		},
+		"recently modified draft": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\nDraft: true\n---\n",
+			},
+			expected: []core.LintIssue{
+				{File: "coffee.md", Severity: core.SeverityWarning, Message: "Draft is still set although the file has been modified recently"},
+			},
+		},
+		"old draft": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\nDraft: true\n---\n",
+			},
+			now: time.Now().Add(30 * 24 * time.Hour),
+		},
+		"wrong field types": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\nDate: yesterday\nWeight: high\n---\n",
+			},
+			expected: []core.LintIssue{
+				{File: "coffee.md", Severity: core.SeverityError, Message: "invalid front matter: Date must be a date like 2020-10-14, got yesterday, Weight must be an integer, got high"},
+			},
+		},
+		"invalid front matter": {
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\n  Author: Barista\n---\n",
+			},
+			expected: []core.LintIssue{
+				{File: "coffee.md", Severity: core.SeverityError, Message: "invalid front matter in line 3: mapping values are not allowed in this context"},
+			},
+		},
+		"undeclared language": {
+			config: "version: 1\ni18n:\n  defaultLanguage: en\n  languages:\n    en:\n      name: English\n",
+			files: map[string]string{
+				"coffee.md": "---\nTitle: Coffee\nLanguage: fr\n---\n",
+			},
+			expected: []core.LintIssue{
+				{File: "coffee.md", Severity: core.SeverityError, Message: "language fr has not been declared"},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		config := testCase.config
+		if config == "" {
+			config = "version: 1\n"
+		}
+
+		path := createTestProject(t, config, testCase.files)
+
+		issues, err := core.Lint(path, core.LintOptions{Now: testCase.now})
+		_ = os.RemoveAll(filepath.Dir(path))
+		test.Ok(t, err)
+
+		test.Equals(t, testCase.expected, issues)
+	}
+}
+
+// TestLint_missingProject checks if Lint fails for a non-existing project.
+func TestLint_missingProject(t *testing.T) {
+	_, err := core.Lint(filepath.Join(os.TempDir(), "verless-no-such-project"), core.LintOptions{})
+	test.ExpectedError(t, core.ErrProjectNotExists, err)
+}
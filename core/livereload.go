@@ -0,0 +1,158 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// liveReloadPath is the URL path of the endpoint that notifies the
+	// browser about re-builds using server-sent events.
+	liveReloadPath string = "/_verless/livereload"
+	// liveReloadScript is injected into all served HTML pages. It reloads
+	// the page when the server reports a re-build.
+	liveReloadScript string = `<script>new EventSource("` + liveReloadPath + `").onmessage = function () { location.reload(); };</script>`
+)
+
+// liveReload keeps track of all connected browsers and notifies them
+// after re-builds.
+type liveReload struct {
+	clients map[chan struct{}]bool
+	mutex   sync.Mutex
+}
+
+// newLiveReload creates a new liveReload instance without any clients.
+func newLiveReload() *liveReload {
+	l := liveReload{
+		clients: make(map[chan struct{}]bool),
+	}
+
+	return &l
+}
+
+// ServeHTTP streams a server-sent event to the browser for each rebuild
+// until the browser disconnects.
+func (l *liveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	client := make(chan struct{}, 1)
+
+	l.mutex.Lock()
+	l.clients[client] = true
+	l.mutex.Unlock()
+
+	defer func() {
+		l.mutex.Lock()
+		delete(l.clients, client)
+		l.mutex.Unlock()
+	}()
+
+	// Send a comment so that the browser knows the connection is open.
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-client:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// notify tells all connected browsers to reload the page. Safe for
+// concurrent usage.
+func (l *liveReload) notify() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	for client := range l.clients {
+		// A client that hasn't received the previous notification yet
+		// will reload anyway.
+		select {
+		case client <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// inject wraps the given handler and injects the live reload script into
+// all HTML responses.
+func (l *liveReload) inject(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := bufferedResponse{
+			header: make(http.Header),
+			status: http.StatusOK,
+		}
+
+		next.ServeHTTP(&res, r)
+
+		body := res.body.Bytes()
+
+		isHTML := strings.HasPrefix(res.header.Get("Content-Type"), "text/html")
+
+		if isHTML && res.status == http.StatusOK && r.Method == http.MethodGet {
+			body = injectScript(body, liveReloadScript)
+			res.header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+
+		for key, values := range res.header {
+			w.Header()[key] = values
+		}
+
+		w.WriteHeader(res.status)
+		_, _ = w.Write(body)
+	})
+}
+
+// injectScript inserts the script before the closing body tag of the
+// given HTML document. If there is no body tag, the script is appended.
+func injectScript(html []byte, script string) []byte {
+	i := bytes.LastIndex(bytes.ToLower(html), []byte("</body>"))
+	if i < 0 {
+		return append(html, script...)
+	}
+
+	injected := make([]byte, 0, len(html)+len(script))
+	injected = append(injected, html[:i]...)
+	injected = append(injected, script...)
+	injected = append(injected, html[i:]...)
+
+	return injected
+}
+
+// bufferedResponse is a http.ResponseWriter that buffers the response,
+// so that it can be modified before sending it to the client.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header returns the response headers.
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+// Write writes p into the buffer.
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// WriteHeader stores the status code.
+func (b *bufferedResponse) WriteHeader(status int) {
+	b.status = status
+}
@@ -0,0 +1,202 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/fs"
+)
+
+const (
+	// outputManifest is the file inside the output directory that lists
+	// all files produced by the previous build.
+	outputManifest string = ".verless-manifest.json"
+	// maxReportedFiles is the maximum number of unexpected files listed
+	// in the error returned by checkOutputDir.
+	maxReportedFiles int = 5
+)
+
+// manifest represents the files written into the output directory by a
+// build. The paths are relative to the output directory.
+type manifest struct {
+	Files []string `json:"files"`
+}
+
+// checkOutputDir determines whether the output directory may be cleared
+// before writing the website. It returns false if the output directory
+// has to be kept as it is, and an error if it may neither be cleared nor
+// be written to.
+//
+// A directory that doesn't exist or is empty can always be used. Inside
+// the project root, the output directory is cleared if it only contains
+// files listed in the manifest of the previous build or kept paths, or if
+// force is set. Directories outside the project root and the project root
+// itself are never cleared. If force is set, the website is written into
+// them without removing the existing files.
+func checkOutputDir(targetFs afero.Fs, path, outputDir string, force bool, keep []string) (bool, error) {
+	if fs.IsSafeToRemove(targetFs, outputDir, false) {
+		return true, nil
+	}
+
+	// The filesystem root is never safe to remove, not even with force.
+	if !fs.IsSafeToRemove(targetFs, outputDir, true) {
+		return false, fmt.Errorf("%s: %w", outputDir, ErrCannotOverwrite)
+	}
+
+	if !isInsideProject(path, outputDir) {
+		if force {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s is not inside the project directory: %w", outputDir, ErrCannotOverwrite)
+	}
+
+	if force {
+		return true, nil
+	}
+
+	unexpected, err := unexpectedFiles(targetFs, outputDir, keep)
+	if err != nil {
+		return false, err
+	}
+
+	if len(unexpected) > 0 {
+		if len(unexpected) > maxReportedFiles {
+			unexpected = append(unexpected[:maxReportedFiles], "...")
+		}
+		return false, fmt.Errorf("%s contains files not produced by verless (%s): %w",
+			outputDir, strings.Join(unexpected, ", "), ErrCannotOverwrite)
+	}
+
+	return true, nil
+}
+
+// isInsideProject reports whether dir is a subdirectory of the project
+// directory. The project directory itself isn't considered to be inside.
+// If one of the paths can't be resolved, isInsideProject returns false.
+func isInsideProject(path, dir string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return false
+	}
+
+	rel, err := filepath.Rel(absPath, absDir)
+	if err != nil {
+		return false
+	}
+
+	return rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// unexpectedFiles returns all files inside the output directory that are
+// neither listed in the manifest of the previous build nor inside one of
+// the kept paths, sorted by path.
+func unexpectedFiles(targetFs afero.Fs, outputDir string, keep []string) ([]string, error) {
+	produced := make(map[string]bool)
+
+	b, err := afero.ReadFile(targetFs, filepath.Join(outputDir, outputManifest))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if err == nil {
+		var m manifest
+		// Without a readable manifest, no file is known to be produced
+		// by verless.
+		if err := json.Unmarshal(b, &m); err == nil {
+			for _, file := range m.Files {
+				produced[file] = true
+			}
+		}
+	}
+
+	files, err := outputFiles(targetFs, outputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var unexpected []string
+
+	for _, file := range files {
+		if !produced[file] && !isKept(file, keep) {
+			unexpected = append(unexpected, file)
+		}
+	}
+
+	return unexpected, nil
+}
+
+// isKept reports whether a file relative to the output directory is one
+// of the kept paths or inside one of them.
+func isKept(file string, keep []string) bool {
+	for _, path := range keep {
+		path = strings.Trim(filepath.ToSlash(filepath.Clean(path)), "/")
+		if file == path || strings.HasPrefix(file, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeManifest records all files inside the output directory in the
+// manifest, so that the next build is allowed to clear the directory.
+// If nothing has been written, no manifest is created.
+func writeManifest(targetFs afero.Fs, outputDir string) error {
+	if exists, _ := afero.DirExists(targetFs, outputDir); !exists {
+		return nil
+	}
+
+	files, err := outputFiles(targetFs, outputDir)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.MarshalIndent(manifest{Files: files}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return fs.WriteFileAtomic(targetFs, filepath.Join(outputDir, outputManifest), b, 0644)
+}
+
+// outputFiles returns the paths of all files inside the output directory
+// except for the manifest, relative to the output directory and sorted.
+func outputFiles(targetFs afero.Fs, outputDir string) ([]string, error) {
+	files := make([]string, 0)
+
+	err := afero.Walk(targetFs, outputDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(outputDir, file)
+		if err != nil {
+			return err
+		}
+
+		if rel = filepath.ToSlash(rel); rel != outputManifest {
+			files = append(files, rel)
+		}
+
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	sort.Strings(files)
+
+	return files, nil
+}
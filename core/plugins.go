@@ -0,0 +1,76 @@
+package core
+
+import (
+	"github.com/verless/verless/config"
+	"github.com/verless/verless/plugin"
+	"github.com/verless/verless/plugin/atom"
+	"github.com/verless/verless/plugin/related"
+	"github.com/verless/verless/plugin/robots"
+	"github.com/verless/verless/plugin/search"
+	"github.com/verless/verless/plugin/sitemap"
+	"github.com/verless/verless/plugin/tags"
+)
+
+// init registers all built-in plugins.
+func init() {
+	plugin.Register("atom", func(cfg plugin.Config) (plugin.Plugin, error) {
+		settings := cfg.Project.PluginConfig.Atom
+		options := atom.Options{
+			OutputPath: settings.OutputPath,
+			ItemLimit:  settings.ItemLimit,
+			Title:      settings.Title,
+			Author:     settings.Author,
+			Section:    settings.Section,
+			RSS:        settings.RSS,
+			Created:    cfg.Timestamp,
+		}
+		return atom.New(&cfg.Project.Site.Meta, options, cfg.Fs, cfg.OutputDir), nil
+	})
+
+	plugin.Register("related", func(cfg plugin.Config) (plugin.Plugin, error) {
+		options := cfg.Project.PluginConfig.Related
+		return related.New(options.Limit, options.SameSection), nil
+	})
+
+	plugin.Register("robots", func(cfg plugin.Config) (plugin.Plugin, error) {
+		options := robots.Options{
+			Rules:   cfg.Project.PluginConfig.Robots.Rules,
+			Sitemap: hasPlugin(cfg.Project, "sitemap"),
+			Preview: cfg.Preview,
+		}
+		return robots.New(&cfg.Project.Site.Meta, options, cfg.Fs, cfg.OutputDir), nil
+	})
+
+	plugin.Register("search", func(cfg plugin.Config) (plugin.Plugin, error) {
+		settings := cfg.Project.PluginConfig.Search
+		options := search.Options{
+			OutputPath:    settings.OutputPath,
+			Fields:        settings.Fields,
+			ExcerptLength: settings.ExcerptLength,
+		}
+		return search.New(options, cfg.Fs, cfg.OutputDir), nil
+	})
+
+	plugin.Register("sitemap", func(cfg plugin.Config) (plugin.Plugin, error) {
+		options := sitemap.Options{
+			ChangeFreq: cfg.Project.PluginConfig.Sitemap.ChangeFreq,
+			Priority:   cfg.Project.PluginConfig.Sitemap.Priority,
+			CleanURLs:  cfg.Project.Build.CleanURLs,
+		}
+		return sitemap.New(&cfg.Project.Site.Meta, options, cfg.Fs, cfg.OutputDir), nil
+	})
+
+	plugin.Register("tags", func(cfg plugin.Config) (plugin.Plugin, error) {
+		return tags.New(cfg.Project.PluginConfig.Tags.GeneratePages, cfg.Project.Build.CleanURLs), nil
+	})
+}
+
+// hasPlugin reports whether the plugin with the given key is enabled.
+func hasPlugin(cfg *config.Config, key string) bool {
+	for _, p := range cfg.Plugins {
+		if p == key {
+			return true
+		}
+	}
+	return false
+}
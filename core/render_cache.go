@@ -0,0 +1,170 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/config"
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/parser"
+)
+
+// cachedPage represents a parsed Markdown file stored in the render
+// cache. The unexported fields of model.Page aren't serialized, so they
+// are stored separately.
+type cachedPage struct {
+	Page          model.Page `json:"page"`
+	Related       []string   `json:"related,omitempty"`
+	Type          string     `json:"type,omitempty"`
+	MissingFields []string   `json:"missingFields,omitempty"`
+}
+
+// renderCache stores the pages parsed from Markdown files inside the
+// project, so that unchanged files don't have to be converted again.
+// Each entry is keyed by the hash of the file and of the options of the
+// Markdown parser, so that entries are invalidated as soon as either of
+// them changes.
+type renderCache struct {
+	dir         string
+	fingerprint string
+	used        map[string]bool
+	mutex       *sync.Mutex
+}
+
+// newRenderCache creates a render cache for the project at the given
+// path whose Markdown files are parsed with the given options.
+func newRenderCache(path string, options parser.Options) (*renderCache, error) {
+	b, err := json.Marshal(options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Another verless version might render the same file differently.
+	hash := sha256.New()
+	_, _ = fmt.Fprintf(hash, "%d\n%s\n%s", cacheVersion, config.GitTag, b)
+
+	rc := renderCache{
+		dir:         filepath.Join(path, cacheDir, rendersCacheDir),
+		fingerprint: hex.EncodeToString(hash.Sum(nil)),
+		used:        make(map[string]bool),
+		mutex:       &sync.Mutex{},
+	}
+
+	return &rc, nil
+}
+
+// parse returns the cached page for the given source. If there is no
+// cached page, src is parsed using pageParser and the page is stored in
+// the cache. Safe for concurrent usage.
+func (rc *renderCache) parse(pageParser Parser, src []byte) (model.Page, error) {
+	key := rc.key(src)
+
+	rc.mutex.Lock()
+	rc.used[key] = true
+	rc.mutex.Unlock()
+
+	if page, ok := rc.read(key); ok {
+		return page, nil
+	}
+
+	page, err := pageParser.ParsePage(src)
+	if err != nil {
+		return page, err
+	}
+
+	return page, rc.write(key, page)
+}
+
+// key returns the cache key for the given source.
+func (rc *renderCache) key(src []byte) string {
+	hash := sha256.New()
+	_, _ = hash.Write([]byte(rc.fingerprint))
+	_, _ = hash.Write(src)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// read returns the cached page with the given key. A missing or corrupt
+// entry is reported as a cache miss.
+func (rc *renderCache) read(key string) (model.Page, bool) {
+	b, err := ioutil.ReadFile(rc.file(key))
+	if err != nil {
+		return model.Page{}, false
+	}
+
+	var cached cachedPage
+	if err := json.Unmarshal(b, &cached); err != nil {
+		return model.Page{}, false
+	}
+
+	page := cached.Page
+
+	for _, related := range cached.Related {
+		page.AddProvidedRelated(related)
+	}
+	page.SetProvidedType(cached.Type)
+	for _, field := range cached.MissingFields {
+		page.AddMissingField(field)
+	}
+
+	return page, true
+}
+
+// write stores the given page in the cache.
+func (rc *renderCache) write(key string, page model.Page) error {
+	b, err := json.Marshal(cachedPage{
+		Page:          page,
+		Related:       page.ProvidedRelated(),
+		Type:          page.ProvidedType(),
+		MissingFields: page.MissingFields(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(rc.dir, 0755); err != nil {
+		return err
+	}
+
+	return fs.WriteFileAtomic(afero.NewOsFs(), rc.file(key), b, 0644)
+}
+
+// prune removes all entries that haven't been used since the render
+// cache has been created, e.g. the entries of changed or removed files.
+func (rc *renderCache) prune() error {
+	files, err := ioutil.ReadDir(rc.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	for _, file := range files {
+		if rc.used[strings.TrimSuffix(file.Name(), ".json")] {
+			continue
+		}
+		if err := os.Remove(filepath.Join(rc.dir, file.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// file returns the path of the entry with the given key.
+func (rc *renderCache) file(key string) string {
+	return filepath.Join(rc.dir, key+".json")
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/fs"
+)
+
+// copyRootFiles copies all files inside the root directory like
+// favicon.ico or _redirects verbatim into the output directory,
+// preserving their directory structure.
+//
+// Files generated by the build since the given start time take
+// precedence: A root file with the same path isn't copied but recorded
+// as warning. Older files, e.g. copies left by a previous build, are
+// overwritten. For incremental builds, older files are only overwritten
+// if they have been copied from the root directory before.
+func (b *Build) copyRootFiles(start time.Time) error {
+	osFs := afero.NewOsFs()
+
+	if exists, err := afero.DirExists(osFs, b.rootDir); err != nil || !exists {
+		return err
+	}
+
+	return fs.CopyDirBetween(osFs, b.rootDir, b.targetFs, b.outputDir, func(file string) bool {
+		rootFile := filepath.ToSlash(file)
+
+		info, err := b.targetFs.Stat(filepath.Join(b.outputDir, file))
+		stale := err == nil && info.ModTime().Before(start) &&
+			(b.incremental == nil || b.incremental.isStaleRootFile(rootFile))
+
+		if err != nil || stale {
+			if b.incremental != nil {
+				b.incremental.trackRootFile(rootFile)
+			}
+			return true
+		}
+
+		b.addWarning(Warning{RootFile: rootFile})
+		return false
+	})
+}
@@ -1,27 +1,41 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
-	"path/filepath"
+	"strconv"
 	"sync"
+	"syscall"
 
 	"github.com/spf13/afero"
 	"github.com/verless/verless/config"
+	"github.com/verless/verless/model"
 	"github.com/verless/verless/theme"
 )
 
+// maxPort is the highest valid TCP port.
+const maxPort = 65535
+
+var (
+	// ErrInvalidPort states that the port to listen on is out of range.
+	ErrInvalidPort = errors.New("port must be between 0 and 65535")
+)
+
 // ServeOptions represents options for running a verless listenAndServe command.
 type ServeOptions struct {
 	// BuildOptions stores all options for re-builds when watching the site.
 	BuildOptions
-	// Port specifies the port to run the server at.
-	Port uint16
-	// IP specifies the IP to listen on in combination with the port.
-	IP net.IP
-	// Watch enables automatic re-builds when a file changes.
+	// Host specifies the host name or IP to listen on. If it is empty,
+	// the server listens on all network interfaces.
+	Host string
+	// Port specifies the port to run the server at. If it is 0, a free
+	// port is chosen automatically.
+	Port int
+	// Watch enables automatic re-builds when a file changes. The served
+	// pages are reloaded in the browser after each re-build.
 	Watch bool
 }
 
@@ -29,114 +43,175 @@ type ServeOptions struct {
 // It can build the project automatically if ServeOptions.Build is true and
 // even watch the whole project directory for changes if ServeOptions.Watch is true.
 func Serve(path string, options ServeOptions) error {
-	// First check if the passed path is a verless project (valid verless cfg).
-	cfg, err := config.FromFile(path, config.Filename)
+	s, err := newServer(path, options)
 	if err != nil {
 		return err
 	}
+	defer s.close()
 
-	targetFiles := outputDir(path, &options.BuildOptions)
-
-	// If yes, build it if requested to do so.
-	options.BuildOptions.RecompileTemplates = options.Watch
-	options.Overwrite = true
-
-	memMapFs := afero.NewMemMapFs()
-
-	done := make(chan bool)
-	rebuildCh := make(chan string)
-
-	// Only watch if needed.
-	if options.Watch {
-		if err := watch(watchContext{
-			IgnorePaths: []string{
-				targetFiles,
-				filepath.Join(path, config.StaticDir, config.GeneratedDir),
-				theme.GeneratedPath(path, cfg.Theme),
-			},
-			Path:      path,
-			ChangedCh: rebuildCh,
-			StopCh:    done,
-		}); err != nil {
-			return err
-		}
-	}
-
-	var initialBuild sync.WaitGroup
-
-	// Start rebuild goroutine.
-	// If watch is not enabled, it's still used for the initial build.
-	go func() {
-		isFirst := true
-		initialBuild.Add(1)
-
-		for {
-			select {
-			case _, ok := <-rebuildCh:
-				if !ok {
-					return
-				}
-				log.Println("rebuilding project")
-
-				build, err := NewBuild(memMapFs, path, options.BuildOptions)
-				if err != nil {
-					log.Println("rebuild error:", err.Error())
-				}
-
-				if err := build.Run(); err != nil {
-					log.Println("rebuild error:", err.Error())
-				}
-
-				if isFirst {
-					initialBuild.Done()
-					isFirst = false
-				}
-			case _, ok := <-done:
-				// Stops the goroutine if requested to.
-				// Triggers on closing of the done channel.
-				if !ok {
-					return
-				}
-			}
-		}
-	}()
-
-	// Trigger and wait for the initial rebuild.
-	rebuildCh <- path
-	initialBuild.Wait()
-
-	// Stop rebuilding goroutine if not watching.
-	if !options.Watch {
-		done <- true
-		close(done)
+	listener, err := listen(options.Host, options.Port)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("serving project on %s%s\n", serverURL(options.Host, listener.Addr()), s.basePath)
+
+	return http.Serve(listener, s.handler())
+}
+
+// server builds a verless project into an in-memory filesystem and
+// rebuilds it on changes if watching is enabled.
+type server struct {
+	path       string
+	options    ServeOptions
+	fs         afero.Fs
+	outputDir  string
+	basePath   string
+	liveReload *liveReload
+	stopCh     chan bool
+	stopOnce   sync.Once
+}
+
+// newServer builds the project for the first time and starts watching
+// the project if ServeOptions.Watch is true.
+func newServer(path string, options ServeOptions) (*server, error) {
+	// First check if the passed path is a verless project (valid verless cfg).
+	cfg, err := config.FromFileEnv(path, config.Filename, options.Env)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Theme == "" {
+		cfg.Theme = theme.Default
+	}
+
+	options.RecompileTemplates = options.Watch
+	options.Force = true
+	// Only re-render the pages affected by a change.
+	options.Incremental = options.Incremental || options.Watch
+
+	s := server{
+		path:       path,
+		options:    options,
+		fs:         afero.NewMemMapFs(),
+		outputDir:  outputDir(path, &cfg, &options.BuildOptions),
+		basePath:   model.BasePath(cfg.BaseURL),
+		liveReload: newLiveReload(),
+		stopCh:     make(chan bool),
+	}
+
+	if err := s.build(); err != nil {
+		return nil, err
 	}
 
 	// If the target folder doesn't exist, return an error.
-	if _, err := memMapFs.Stat(targetFiles); err != nil {
+	if _, err := s.fs.Stat(s.outputDir); err != nil {
+		return nil, err
+	}
+
+	if !options.Watch {
+		return &s, nil
+	}
+
+	changedCh := make(chan string)
+
+	if err := watch(watchContext{
+		IgnorePaths: watchIgnorePaths(path, &cfg, s.outputDir),
+		Path:        path,
+		ChangedCh:   changedCh,
+		StopCh:      s.stopCh,
+	}); err != nil {
+		return nil, err
+	}
+
+	go rebuildOnChange(changedCh, s.stopCh, s.rebuild)
+
+	return &s, nil
+}
+
+// build builds the project into the server's filesystem.
+func (s *server) build() error {
+	build, err := NewBuild(s.fs, s.path, s.options.BuildOptions)
+	if err != nil {
 		return err
 	}
 
-	err = listenAndServe(memMapFs, targetFiles, options.IP, options.Port)
+	// The postBuild hooks may deploy the website, which is not desired
+	// for a preview rendered into memory.
+	build.postBuild = nil
+
+	return build.Run()
+}
+
+// rebuild rebuilds the project after a change and notifies all connected
+// browsers.
+func (s *server) rebuild() {
+	log.Println("rebuilding project")
+
+	if err := s.build(); err != nil {
+		log.Println("rebuild error:", err.Error())
+		return
+	}
+
+	s.liveReload.notify()
+}
+
+// handler returns a handler serving the built project. If watching is
+// enabled, a live reload script is injected into all HTML pages.
+func (s *server) handler() http.Handler {
+	httpFs := afero.NewHttpFs(s.fs)
+	fileServer := http.FileServer(httpFs.Dir(s.outputDir))
+
+	if s.basePath != "" {
+		fileServer = http.StripPrefix(s.basePath, fileServer)
+	}
+
+	if !s.options.Watch {
+		return fileServer
+	}
 
-	// Stop building goroutine just to be sure.
-	done <- true
-	close(done)
+	mux := http.NewServeMux()
+	mux.Handle(liveReloadPath, s.liveReload)
+	mux.Handle("/", s.liveReload.inject(fileServer))
 
-	return err
+	return mux
 }
 
-// listenAndServe starts a file server serving the built project.
-func listenAndServe(fs afero.Fs, path string, ip net.IP, port uint16) error {
-	addr := fmt.Sprintf("%v:%v", ip, port)
-	log.Printf("serving project on %s\n", addr)
+// close stops watching the project.
+func (s *server) close() {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+}
+
+// listen announces on the given host and port. If the port is already
+// in use, the returned error suggests using another port.
+func listen(host string, port int) (net.Listener, error) {
+	if port < 0 || port > maxPort {
+		return nil, fmt.Errorf("%d: %w", port, ErrInvalidPort)
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
 
-	if ip.To4() == nil {
-		addr = fmt.Sprintf("[%v]:%v", ip, port)
+	listener, err := net.Listen("tcp", addr)
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return nil, fmt.Errorf("port %d is already in use, choose another one using --port: %w", port, err)
 	}
 
-	httpFs := afero.NewHttpFs(fs)
-	server := http.FileServer(httpFs.Dir(path))
-	http.Handle("/", server)
+	return listener, err
+}
+
+// serverURL returns the URL for accessing the server listening on addr.
+// It uses the configured host name instead of the resolved IP address.
+func serverURL(host string, addr net.Addr) string {
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return "http://" + addr.String()
+	}
+
+	if host == "" {
+		host = "localhost"
+	}
 
-	return http.ListenAndServe(addr, server)
+	return "http://" + net.JoinHostPort(host, port)
 }
@@ -0,0 +1,168 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/verless/verless/test"
+)
+
+// TestServer_liveReload checks if the server rebuilds the project when a
+// content file changes and notifies connected browsers.
+func TestServer_liveReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verless-serve")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "my-blog")
+
+	_, err = CreateProject(path, CreateProjectOptions{})
+	test.Ok(t, err)
+
+	pageTpl := []byte("<html><body>{{.Page.Title}}</body></html>")
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), pageTpl, 0644))
+
+	file := filepath.Join(path, "content", "coffee.md")
+	test.Ok(t, ioutil.WriteFile(file, []byte("---\nTitle: Espresso\n---\n"), 0644))
+
+	debounceInterval = 10 * time.Millisecond
+
+	s, err := newServer(path, ServeOptions{Watch: true})
+	test.Ok(t, err)
+	defer s.close()
+
+	ts := httptest.NewServer(s.handler())
+	defer ts.Close()
+
+	get := func(url string) string {
+		res, err := http.Get(ts.URL + url)
+		test.Ok(t, err)
+		defer res.Body.Close()
+
+		body, err := ioutil.ReadAll(res.Body)
+		test.Ok(t, err)
+		return string(body)
+	}
+
+	test.Equals(t, "<html><body>Espresso"+liveReloadScript+"</body></html>", get("/coffee/"))
+
+	// Connect to the live reload endpoint like a browser would do.
+	res, err := http.Get(ts.URL + liveReloadPath)
+	test.Ok(t, err)
+	defer res.Body.Close()
+
+	events := make(chan string)
+
+	go func() {
+		scanner := bufio.NewScanner(res.Body)
+		for scanner.Scan() {
+			if strings.HasPrefix(scanner.Text(), "data:") {
+				events <- scanner.Text()
+			}
+		}
+	}()
+
+	// Write the file several times, which should only cause one rebuild.
+	for _, title := range []string{"Lungo", "Ristretto", "Filter"} {
+		test.Ok(t, ioutil.WriteFile(file, []byte("---\nTitle: "+title+"\n---\n"), 0644))
+		time.Sleep(time.Millisecond)
+	}
+
+	select {
+	case event := <-events:
+		test.Equals(t, "data: reload", event)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the browser should have been notified about the rebuild")
+	}
+
+	test.Equals(t, "<html><body>Filter"+liveReloadScript+"</body></html>", get("/coffee/"))
+
+	// Other files are served without modification.
+	test.Equals(t, string(defaultCss), get("/assets/style.css"))
+}
+
+// TestInjectScript checks if injectScript inserts the script before the
+// closing body tag.
+func TestInjectScript(t *testing.T) {
+	tests := map[string]struct {
+		html     string
+		expected string
+	}{
+		"document with body": {
+			html:     "<html><body><p>Coffee</p></body></html>",
+			expected: "<html><body><p>Coffee</p><script></script></body></html>",
+		},
+		"document with uppercase body": {
+			html:     "<HTML><BODY>Coffee</BODY></HTML>",
+			expected: "<HTML><BODY>Coffee<script></script></BODY></HTML>",
+		},
+		"document without body": {
+			html:     "<p>Coffee</p>",
+			expected: "<p>Coffee</p><script></script>",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		test.Equals(t, testCase.expected, string(injectScript([]byte(testCase.html), "<script></script>")))
+	}
+}
+
+// TestListen checks if the server can listen on a free port chosen by the
+// operating system, reports a port that is already in use and rejects
+// ports out of range.
+func TestListen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verless-serve")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "my-blog")
+
+	_, err = CreateProject(path, CreateProjectOptions{})
+	test.Ok(t, err)
+
+	s, err := newServer(path, ServeOptions{})
+	test.Ok(t, err)
+	defer s.close()
+
+	listener, err := listen("localhost", 0)
+	test.Ok(t, err)
+	defer listener.Close()
+
+	port := listener.Addr().(*net.TCPAddr).Port
+	test.Assert(t, port != 0, "the actual port should be known")
+
+	url := serverURL("localhost", listener.Addr())
+	test.Equals(t, fmt.Sprintf("http://localhost:%d", port), url)
+
+	go func() {
+		_ = http.Serve(listener, s.handler())
+	}()
+
+	res, err := http.Get(url + "/")
+	test.Ok(t, err)
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	test.Ok(t, err)
+	test.Equals(t, http.StatusOK, res.StatusCode)
+	test.Assert(t, strings.Contains(string(body), "Your verless Project"), "the index page should be served")
+
+	_, err = listen("localhost", port)
+	test.ExpectedError(t, syscall.EADDRINUSE, err)
+	test.Assert(t, strings.Contains(err.Error(), "--port"), "the error should suggest using --port")
+
+	_, err = listen("localhost", 65536)
+	test.ExpectedError(t, ErrInvalidPort, err)
+}
@@ -0,0 +1,94 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// BuildStats summarizes a finished build, e.g. for parsing the result
+// of a build in a CI pipeline.
+type BuildStats struct {
+	// Pages is the number of rendered pages, including list pages and
+	// their paginated pages. Pages skipped by an incremental build don't
+	// count as rendered.
+	Pages int `json:"pages"`
+	// Skipped is the number of excluded drafts and pages dated in the
+	// future.
+	Skipped int `json:"skipped"`
+	// Warnings is the number of warnings, see Build.Warnings.
+	Warnings int `json:"warnings"`
+	// OutputBytes is the total size of all files in the output directory.
+	OutputBytes int64 `json:"outputBytes"`
+	// ElapsedSeconds is the duration of the build in seconds.
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+}
+
+// Stats returns the statistics of the build. They are complete as soon
+// as Run has returned successfully.
+func (b *Build) Stats() BuildStats {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	stats := b.stats
+	stats.Warnings = len(b.warnings)
+
+	return stats
+}
+
+// countRenderedPage counts a page written by the writer.
+func (b *Build) countRenderedPage(string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.stats.Pages++
+}
+
+// countSkippedPage counts a draft or future page excluded from the build.
+func (b *Build) countSkippedPage() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.stats.Skipped++
+}
+
+// finishStats records the size of the output directory and the duration
+// of a build that has started at the given time.
+func (b *Build) finishStats(start time.Time) error {
+	var size int64
+
+	if !b.Options.DryRun {
+		var err error
+		if size, err = outputSize(b.targetFs, b.outputDir); err != nil {
+			return err
+		}
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.stats.OutputBytes = size
+	b.stats.ElapsedSeconds = time.Since(start).Seconds()
+
+	return nil
+}
+
+// outputSize returns the total size of all files inside the output
+// directory except for the manifest.
+func outputSize(targetFs afero.Fs, outputDir string) (int64, error) {
+	var size int64
+
+	err := afero.Walk(targetFs, outputDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && file != filepath.Join(outputDir, outputManifest) {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size, err
+}
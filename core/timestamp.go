@@ -0,0 +1,68 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/verless/verless/config"
+)
+
+// SourceDateEpochVar is the environment variable that sets the build
+// timestamp as Unix timestamp, as specified by the Reproducible Builds
+// project: https://reproducible-builds.org/specs/source-date-epoch
+const SourceDateEpochVar string = "SOURCE_DATE_EPOCH"
+
+var (
+	// ErrInvalidSourceDateEpoch states that SOURCE_DATE_EPOCH isn't a
+	// Unix timestamp.
+	ErrInvalidSourceDateEpoch = errors.New("SOURCE_DATE_EPOCH has to be a Unix timestamp")
+)
+
+// buildTimestamp returns the timestamp recorded in the generated files
+// of the project at the given path, and whether it has been set using
+// SOURCE_DATE_EPOCH. Without SOURCE_DATE_EPOCH, it is the latest
+// modification time of the configuration files and the content files.
+// The timestamp is truncated to seconds, which is the precision of the
+// modification times inside archives.
+func buildTimestamp(path, contentDir string) (time.Time, bool, error) {
+	if value := strings.TrimSpace(os.Getenv(SourceDateEpochVar)); value != "" {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("%q: %w", value, ErrInvalidSourceDateEpoch)
+		}
+		return time.Unix(seconds, 0).UTC(), true, nil
+	}
+
+	var latest time.Time
+
+	configFiles, err := filepath.Glob(filepath.Join(path, config.Filename+".*"))
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	for _, file := range configFiles {
+		if info, err := os.Stat(file); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	err = filepath.Walk(contentDir, func(file string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return time.Time{}, false, err
+	}
+
+	return latest.Truncate(time.Second).UTC(), false, nil
+}
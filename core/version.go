@@ -1,7 +1,11 @@
 package core
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"runtime"
 
 	"github.com/verless/verless/config"
 )
@@ -9,22 +13,61 @@ import (
 var (
 	// format is the default format string for printing the version.
 	format = `verless version %s
-Git tag: %s
-Git commit: %s`
+Git commit: %s
+Build date: %s
+Go version: %s
+`
 )
 
 // VersionOptions represents options for the version command.
 type VersionOptions struct {
 	// Quiet only prints the plain version number.
 	Quiet bool
+	// JSON prints the version information as JSON object.
+	JSON bool
+	// Output is the writer the version information is printed to. If it
+	// is nil, os.Stdout is used.
+	Output io.Writer
+}
+
+// VersionInfo contains the build metadata of the verless binary. Except
+// for GoVersion, the values are injected using -ldflags at build time.
+type VersionInfo struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+}
+
+// Version returns the build metadata of the running verless binary.
+func Version() VersionInfo {
+	return VersionInfo{
+		Version:   config.GitTag,
+		GitCommit: config.GitCommit,
+		BuildDate: config.BuildDate,
+		GoVersion: runtime.Version(),
+	}
 }
 
 // RunVersion prints verless version information.
 func RunVersion(options VersionOptions) error {
-	if options.Quiet {
-		fmt.Printf("%s", config.GitTag)
+	w := options.Output
+	if w == nil {
+		w = os.Stdout
+	}
+
+	info := Version()
+
+	switch {
+	case options.JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	case options.Quiet:
+		_, err := fmt.Fprintln(w, info.Version)
+		return err
 	}
 
-	fmt.Printf(format, config.GitTag, config.GitTag, config.GitCommit)
-	return nil
+	_, err := fmt.Fprintf(w, format, info.Version, info.GitCommit, info.BuildDate, info.GoVersion)
+	return err
 }
@@ -0,0 +1,79 @@
+package core_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/verless/verless/config"
+	"github.com/verless/verless/core"
+	"github.com/verless/verless/test"
+)
+
+// TestRunVersion checks if RunVersion prints the build metadata injected
+// using -ldflags in all output formats.
+func TestRunVersion(t *testing.T) {
+	// Simulate the values injected by -ldflags.
+	defer func(tag, commit, date string) {
+		config.GitTag, config.GitCommit, config.BuildDate = tag, commit, date
+	}(config.GitTag, config.GitCommit, config.BuildDate)
+
+	config.GitTag = "v1.2.3"
+	config.GitCommit = "abc1234"
+	config.BuildDate = "2020-10-14T12:00:00Z"
+
+	tests := map[string]struct {
+		options  core.VersionOptions
+		expected string
+	}{
+		"text": {
+			expected: "verless version v1.2.3\nGit commit: abc1234\nBuild date: 2020-10-14T12:00:00Z\nGo version: " + runtime.Version() + "\n",
+		},
+		"quiet": {
+			options:  core.VersionOptions{Quiet: true},
+			expected: "v1.2.3\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		var b bytes.Buffer
+		testCase.options.Output = &b
+
+		test.Ok(t, core.RunVersion(testCase.options))
+		test.Equals(t, testCase.expected, b.String())
+	}
+}
+
+// TestRunVersion_json checks if the JSON output of RunVersion can be
+// unmarshalled into a VersionInfo with all fields set.
+func TestRunVersion_json(t *testing.T) {
+	defer func(tag, commit, date string) {
+		config.GitTag, config.GitCommit, config.BuildDate = tag, commit, date
+	}(config.GitTag, config.GitCommit, config.BuildDate)
+
+	config.GitTag = "v1.2.3"
+	config.GitCommit = "abc1234"
+	config.BuildDate = "2020-10-14T12:00:00Z"
+
+	var b bytes.Buffer
+
+	test.Ok(t, core.RunVersion(core.VersionOptions{JSON: true, Output: &b}))
+
+	var info core.VersionInfo
+
+	decoder := json.NewDecoder(strings.NewReader(b.String()))
+	decoder.DisallowUnknownFields()
+
+	test.Ok(t, decoder.Decode(&info))
+	test.Equals(t, core.VersionInfo{
+		Version:   "v1.2.3",
+		GitCommit: "abc1234",
+		BuildDate: "2020-10-14T12:00:00Z",
+		GoVersion: runtime.Version(),
+	}, info)
+	test.Assert(t, info.GoVersion != "", "the Go version should not be empty")
+}
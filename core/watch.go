@@ -9,8 +9,17 @@ import (
 	"time"
 
 	"github.com/radovskyb/watcher"
+	"github.com/spf13/afero"
+	"github.com/verless/verless/config"
+	"github.com/verless/verless/out/style"
+	"github.com/verless/verless/theme"
 )
 
+// debounceInterval is the time to wait for further file changes before
+// rebuilding the project, so that saving several files at once only
+// causes a single rebuild.
+var debounceInterval = 200 * time.Millisecond
+
 // watchContext provides all components required for serving an already built project.
 type watchContext struct {
 	Path        string
@@ -25,7 +34,7 @@ type watchContext struct {
 // watchContext.IgnorePath can be used to ignore a path inside the given watchContext.Path.
 func watch(ctx watchContext) error {
 	w := watcher.New()
-	w.FilterOps(watcher.Write)
+	w.FilterOps(watcher.Write, watcher.Create, watcher.Remove, watcher.Rename, watcher.Move)
 
 	go func() {
 	watcherLoop:
@@ -62,6 +71,7 @@ func watch(ctx watchContext) error {
 			case _, ok := <-ctx.StopCh:
 				if !ok {
 					w.Close()
+					return
 				}
 			}
 		}
@@ -83,3 +93,105 @@ func watch(ctx watchContext) error {
 
 	return err
 }
+
+// watchIgnorePaths returns the paths inside a project that are written by
+// a build itself and therefore must not trigger a rebuild.
+func watchIgnorePaths(path string, cfg *config.Config, outputDir string) []string {
+	themeName := cfg.Theme
+	if themeName == "" {
+		themeName = theme.Default
+	}
+
+	return []string{
+		outputDir,
+		filepath.Join(path, cacheDir),
+		filepath.Join(path, config.StaticDir, config.GeneratedDir),
+		theme.GeneratedPath(cfg.ThemesPath(path), themeName),
+	}
+}
+
+// rebuildOnChange invokes rebuild once no further changes have been
+// received for debounceInterval. It returns when changedCh or stopCh is
+// closed.
+func rebuildOnChange(changedCh <-chan string, stopCh <-chan bool, rebuild func()) {
+	var debounce <-chan time.Time
+
+	for {
+		select {
+		case _, ok := <-changedCh:
+			if !ok {
+				return
+			}
+			debounce = time.After(debounceInterval)
+
+		case <-debounce:
+			debounce = nil
+			rebuild()
+
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// BuildAndWatch builds the project and rebuilds it into the same output
+// directory whenever a file of the project changes, e.g. a content file,
+// a template or the project configuration. This is useful for serving the
+// website using another web server.
+//
+// Rebuilds are incremental, and rebuild errors are printed instead of
+// being returned. BuildAndWatch only returns an error if the initial build
+// fails. Otherwise, it blocks until stopCh is closed.
+func BuildAndWatch(targetFs afero.Fs, path string, options BuildOptions, stopCh <-chan bool) error {
+	cfg, err := config.FromFileEnv(path, config.Filename, options.Env)
+	if err != nil {
+		return err
+	}
+
+	options.RecompileTemplates = true
+	options.Incremental = true
+
+	logger := loggerOrDefault(options.Logger)
+
+	build := func() error {
+		b, err := NewBuild(targetFs, path, options)
+		if err != nil {
+			return err
+		}
+		return b.Run()
+	}
+
+	if err := build(); err != nil {
+		return err
+	}
+
+	// Only the initial build removes the output directory, otherwise the
+	// rebuilds wouldn't be incremental anymore.
+	options.Clean = false
+
+	changedCh := make(chan string)
+
+	if err := watch(watchContext{
+		IgnorePaths: watchIgnorePaths(path, &cfg, outputDir(path, &cfg, &options)),
+		Path:        path,
+		ChangedCh:   changedCh,
+		StopCh:      stopCh,
+	}); err != nil {
+		return err
+	}
+
+	logger.Info(style.Bulb, "watching %s for changes", path)
+
+	rebuildOnChange(changedCh, stopCh, func() {
+		start := time.Now()
+
+		if err := build(); err != nil {
+			logger.Error(style.X, "rebuild error: %s", err)
+			return
+		}
+
+		logger.Info(style.HeavyCheckMark, "rebuilt project in %s", time.Since(start).Round(time.Millisecond))
+	})
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/test"
+)
+
+// TestBuildAndWatch checks if BuildAndWatch rebuilds the project into the
+// output directory when a content file changes.
+func TestBuildAndWatch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verless-watch")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "my-blog")
+
+	_, err = CreateProject(path, CreateProjectOptions{})
+	test.Ok(t, err)
+
+	pageTpl := []byte("{{.Page.Title}}")
+	test.Ok(t, ioutil.WriteFile(filepath.Join(path, "themes", "default", "templates", "page.html"), pageTpl, 0644))
+
+	file := filepath.Join(path, "content", "coffee.md")
+	test.Ok(t, ioutil.WriteFile(file, []byte("---\nTitle: Espresso\n---\n"), 0644))
+
+	debounceInterval = 10 * time.Millisecond
+
+	var (
+		stopCh   = make(chan bool)
+		errCh    = make(chan error, 1)
+		output   = filepath.Join(path, "target", "coffee", "index.html")
+		logs     bytes.Buffer
+		logger   = out.NewLogger(out.LevelInfo, &logs, &logs)
+		deadline = time.Now().Add(10 * time.Second)
+	)
+
+	go func() {
+		errCh <- BuildAndWatch(afero.NewOsFs(), path, BuildOptions{Logger: logger}, stopCh)
+	}()
+
+	// Wait for the initial build.
+	for {
+		if content, err := ioutil.ReadFile(output); err == nil && string(content) == "Espresso" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the project should have been built")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The watcher might not have been started yet, so the file is written
+	// until the change has been picked up.
+	for {
+		test.Ok(t, ioutil.WriteFile(file, []byte("---\nTitle: Filter\n---\n"), 0644))
+		time.Sleep(50 * time.Millisecond)
+
+		if content, err := ioutil.ReadFile(output); err == nil && string(content) == "Filter" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the page should have been regenerated")
+		}
+	}
+
+	close(stopCh)
+
+	select {
+	case err := <-errCh:
+		test.Ok(t, err)
+		test.Assert(t, strings.Contains(logs.String(), "rebuilt project in"), "the rebuild should have been logged, got %q", logs.String())
+	case <-time.After(10 * time.Second):
+		t.Fatal("BuildAndWatch should have returned after stopping")
+	}
+}
@@ -0,0 +1,117 @@
+package fs
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// globstar matches any number of directories inside a glob pattern.
+	globstar = "**"
+)
+
+// Glob returns a filter that only lets pass files matching the given
+// shell-style glob pattern.
+//
+// A pattern without a slash is matched against the file name, so that
+// *.md lets pass all Markdown files in any directory. A pattern with a
+// slash is matched against the entire relative path like posts/2023-*.md,
+// where ** matches zero or more directories.
+//
+// Glob returns an error if the pattern is malformed.
+func Glob(pattern string) (func(file string) bool, error) {
+	pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "/")
+
+	segments := strings.Split(pattern, "/")
+
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("glob %s: %w", pattern, err)
+		}
+	}
+
+	if len(segments) == 1 {
+		return func(file string) bool {
+			matched, _ := path.Match(pattern, path.Base(filepath.ToSlash(file)))
+			return matched
+		}, nil
+	}
+
+	return func(file string) bool {
+		file = strings.TrimPrefix(filepath.ToSlash(file), "/")
+		return matchSegments(segments, strings.Split(file, "/"))
+	}, nil
+}
+
+// matchSegments reports whether the path segments match the pattern
+// segments, taking globstars into account.
+func matchSegments(pattern, segments []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == globstar {
+			for i := 0; i <= len(segments); i++ {
+				if matchSegments(pattern[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(pattern[0], segments[0]); !matched {
+			return false
+		}
+
+		pattern, segments = pattern[1:], segments[1:]
+	}
+
+	return len(segments) == 0
+}
+
+// Renderable returns a filter that only lets pass content files rendered
+// as pages, which are files with one of the given extensions like .md.
+// Without any extensions, files with one of the MarkdownExtensions and
+// HTML files are let pass.
+func Renderable(exts ...string) func(file string) bool {
+	if len(exts) == 0 {
+		exts = append(MarkdownExtensions[:len(MarkdownExtensions):len(MarkdownExtensions)], ".html")
+	}
+	return Extensions(exts...)
+}
+
+// Not returns a filter that only lets pass files that don't pass the
+// given filter, so that Not(HTMLOnly) lets pass all but HTML files.
+func Not(filter func(file string) bool) func(file string) bool {
+	return func(file string) bool {
+		return !filter(file)
+	}
+}
+
+// And returns a filter that only lets pass files passing all of the
+// given filters. Without any filters, all files are let pass.
+func And(filters ...func(file string) bool) func(file string) bool {
+	return func(file string) bool {
+		for _, filter := range filters {
+			if !filter(file) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or returns a filter that lets pass files passing at least one of the
+// given filters. Without any filters, no files are let pass.
+func Or(filters ...func(file string) bool) func(file string) bool {
+	return func(file string) bool {
+		for _, filter := range filters {
+			if filter(file) {
+				return true
+			}
+		}
+		return false
+	}
+}
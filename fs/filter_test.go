@@ -0,0 +1,127 @@
+package fs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/verless/verless/test"
+)
+
+// TestGlob checks if the filters returned by Glob only let pass files
+// that match the glob pattern.
+func TestGlob(t *testing.T) {
+	tests := map[string]struct {
+		pattern       string
+		files         map[string]bool
+		expectedError bool
+	}{
+		"Markdown files": {
+			pattern: "*.md",
+			files: map[string]bool{
+				"/index.md":            true,
+				"/blog/coffee.md":      true,
+				"/blog/img/coffee.jpg": false,
+			},
+		},
+		"files starting with an underscore": {
+			pattern: "_*",
+			files: map[string]bool{
+				"/_draft.md":      true,
+				"/blog/_draft.md": true,
+				"/blog/coffee.md": false,
+			},
+		},
+		"nested pattern": {
+			pattern: "posts/2023-*.md",
+			files: map[string]bool{
+				"/posts/2023-01-coffee.md":     true,
+				"/posts/2022-12-coffee.md":     false,
+				"/old/posts/2023-01-coffee.md": false,
+			},
+		},
+		"nested pattern with globstar": {
+			pattern: "blog/**/*.md",
+			files: map[string]bool{
+				"/blog/coffee.md":          true,
+				"/blog/roasting/beans.md":  true,
+				"/blog/roasting/beans.jpg": false,
+				"/about.md":                false,
+			},
+		},
+		"invalid pattern": {
+			pattern:       "[*.md",
+			expectedError: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		filter, err := Glob(testCase.pattern)
+		if testCase.expectedError {
+			test.Assert(t, err != nil, "invalid pattern should yield an error")
+			continue
+		}
+		test.Ok(t, err)
+
+		for file, expected := range testCase.files {
+			test.Equals(t, expected, filter(filepath.FromSlash(file)))
+		}
+	}
+}
+
+// TestCombinators checks if Not, And and Or combine the results of their
+// filters according to their truth tables.
+func TestCombinators(t *testing.T) {
+	var (
+		markdown = MarkdownOnly
+		partial  = Not(NoUnderscores)
+		files    = []string{"/blog/coffee.md", "/blog/_draft.md", "/blog/beans.jpg", "/blog/_beans.jpg"}
+	)
+
+	tests := map[string]struct {
+		filter   func(file string) bool
+		expected []bool
+	}{
+		"not": {
+			filter:   Not(markdown),
+			expected: []bool{false, false, true, true},
+		},
+		"and": {
+			filter:   And(markdown, Not(partial)),
+			expected: []bool{true, false, false, false},
+		},
+		"or": {
+			filter:   Or(markdown, partial),
+			expected: []bool{true, true, false, true},
+		},
+		"not and": {
+			filter:   Not(And(markdown, partial)),
+			expected: []bool{true, false, true, true},
+		},
+		"not or": {
+			filter:   Not(Or(markdown, partial)),
+			expected: []bool{false, false, true, false},
+		},
+		"and without filters": {
+			filter:   And(),
+			expected: []bool{true, true, true, true},
+		},
+		"or without filters": {
+			filter:   Or(),
+			expected: []bool{false, false, false, false},
+		},
+		"single filters": {
+			filter:   And(Or(markdown)),
+			expected: []bool{true, true, false, false},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		for i, file := range files {
+			test.Assert(t, testCase.filter(filepath.FromSlash(file)) == testCase.expected[i], "%s: expected %v", file, testCase.expected[i])
+		}
+	}
+}
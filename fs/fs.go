@@ -2,19 +2,30 @@
 package fs
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
 
 	"github.com/spf13/afero"
 )
 
+// MarkdownExtensions contains the file extensions recognized as Markdown
+// unless the project configures other extensions.
+var MarkdownExtensions = []string{".md", ".markdown", ".mdown"}
+
 var (
-	// MarkdownOnly is a filter that only lets pass Markdown files.
-	MarkdownOnly = func(file string) bool {
-		return filepath.Ext(file) == ".md"
-	}
+	// MarkdownOnly is a filter that only lets pass Markdown files with
+	// one of the MarkdownExtensions.
+	MarkdownOnly = Extensions(MarkdownExtensions...)
+
+	// HTMLOnly is a filter that only lets pass HTML files.
+	HTMLOnly = Extensions(".html")
 
 	// NoUnderscores is a predefined filter that doesn't let pass
 	// files starting with an underscore.
@@ -22,80 +33,210 @@ var (
 		filename := filepath.Base(file)
 		return !strings.HasPrefix(filename, "_")
 	}
-
-	// ErrStreaming is returned from StreamFiles.
-	ErrStreaming error = nil
 )
 
-// StreamFiles sends all relative file paths inside a given path that
-// match the given filters through the files channel.
-func StreamFiles(path string, files chan<- string, filters ...func(file string) bool) error {
+var (
+	// ErrFileExists states that a file already exists and won't be
+	// overwritten.
+	ErrFileExists = errors.New("file already exists")
+)
 
-	if _, err := os.Stat(path); err != nil {
-		if os.IsNotExist(err) {
-			close(files)
-			return nil
+// Extensions returns a filter that only lets pass files with one of the
+// given extensions like .md. The extensions are compared ignoring case.
+func Extensions(exts ...string) func(file string) bool {
+	return func(file string) bool {
+		ext := filepath.Ext(file)
+		for _, e := range exts {
+			if strings.EqualFold(ext, e) {
+				return true
+			}
 		}
-		return err
+		return false
 	}
+}
 
-	// Convert to absolute path so that it does not make a difference if
-	// the paths are in different formats. e.g. one "example/" and the
-	// other one "./example"
-	path, err := filepath.Abs(path)
-	if err != nil {
-		return err
+// MaxDepth returns a filter that only lets pass files nested at most n
+// directories deep. The depth is counted relative to the walked path:
+// A file directly inside that path has depth 0, a file inside one of
+// its sub-directories has depth 1, and so on.
+func MaxDepth(n int) func(file string) bool {
+	return func(file string) bool {
+		rel := strings.TrimPrefix(filepath.ToSlash(file), "/")
+		return strings.Count(rel, "/") <= n
 	}
+}
 
-	ErrStreaming = filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
-		if err != nil {
+// MkdirAll creates one or more directories inside the given path.
+func MkdirAll(path string, dirs ...string) error {
+	for _, dir := range dirs {
+		if err := os.MkdirAll(filepath.Join(path, dir), 0755); err != nil {
 			return err
 		}
-		if info.IsDir() {
-			return nil
+	}
+
+	return nil
+}
+
+// Rmdir removes an entire directory along with its contents. If the
+// directory does not exist, nothing happens.
+func Rmdir(fs afero.Fs, path string) error {
+	if _, err := fs.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	return fs.RemoveAll(path)
+}
+
+// RmdirExcept removes the contents of a directory except for the given
+// paths, which are relative to the directory. Kept directories retain
+// their entire contents, and the parent directories of all kept paths are
+// preserved as well. The directory itself is always kept, even if there
+// are no paths to keep. If the directory does not exist, nothing happens.
+func RmdirExcept(fs afero.Fs, path string, keep ...string) error {
+	if _, err := fs.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	var (
+		kept    = make(map[string]bool)
+		parents = make(map[string]bool)
+	)
+
+	for _, file := range keep {
+		file = filepath.Clean(filepath.FromSlash(file))
+		kept[file] = true
+
+		for dir := filepath.Dir(file); dir != "." && dir != string(filepath.Separator); dir = filepath.Dir(dir) {
+			parents[dir] = true
 		}
-		for _, filter := range filters {
-			if !filter(file) {
-				return nil
+	}
+
+	return removeExcept(fs, path, ".", kept, parents)
+}
+
+// removeExcept removes all entries of the directory dir, which is relative
+// to path, except for the kept paths. The parent directories of kept paths
+// are processed recursively.
+func removeExcept(fs afero.Fs, path, dir string, kept, parents map[string]bool) error {
+	entries, err := afero.ReadDir(fs, filepath.Join(path, dir))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		file := filepath.Join(dir, entry.Name())
+
+		switch {
+		case kept[file]:
+			continue
+		case parents[file] && entry.IsDir():
+			if err := removeExcept(fs, path, file, kept, parents); err != nil {
+				return err
+			}
+		default:
+			if err := fs.RemoveAll(filepath.Join(path, file)); err != nil {
+				return err
 			}
 		}
+	}
 
-		// Convert to absolute path so that it does not make a difference if
-		// the paths are in different formats. e.g. one "example/" and the
-		// other one "./example"
-		file, err = filepath.Abs(file)
-		if err != nil {
-			return err
-		}
+	return nil
+}
 
-		files <- file[len(path):]
+// WriteFileAtomic writes data to the file with the given path. Instead
+// of writing the file directly, the data is written to a temporary file
+// in the same directory which is then renamed to path. This ensures that
+// the file either contains the entire data or remains unchanged.
+func WriteFileAtomic(fs afero.Fs, path string, data []byte, perm os.FileMode) error {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
 
-		return nil
-	})
+	tmp, err := afero.TempFile(fs, dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
 
-	close(files)
-	return ErrStreaming
-}
+	tmpName := tmp.Name()
 
-// MkdirAll creates one or more directories inside the given path.
-func MkdirAll(path string, dirs ...string) error {
-	for _, dir := range dirs {
-		if err := os.MkdirAll(filepath.Join(path, dir), 0755); err != nil {
-			return err
+	// The temporary file is removed if any of the following steps fails.
+	// After a successful rename, there's nothing to remove anymore.
+	ok := false
+	defer func() {
+		if !ok {
+			_ = fs.Remove(tmpName)
 		}
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if err := fs.Chmod(tmpName, perm); err != nil {
+		return err
+	}
+
+	if err := rename(fs, tmpName, path); err != nil {
+		return err
 	}
 
+	ok = true
 	return nil
 }
 
-// Rmdir removes an entire directory along with its contents. If the
-// directory does not exist, nothing happens.
-func Rmdir(fs afero.Fs, path string) error {
-	if _, err := fs.Stat(path); !os.IsNotExist(err) {
+// rename renames oldname to newname, replacing newname if it exists.
+//
+// On Windows, renaming a file to an existing file may fail depending on
+// the filesystem. In this case, the existing file is removed first.
+func rename(fs afero.Fs, oldname, newname string) error {
+	err := fs.Rename(oldname, newname)
+	if err == nil || runtime.GOOS != "windows" {
 		return err
 	}
 
-	return fs.RemoveAll(path)
+	if _, statErr := fs.Stat(newname); statErr != nil {
+		return err
+	}
+
+	if err := fs.Remove(newname); err != nil {
+		return err
+	}
+
+	return fs.Rename(oldname, newname)
+}
+
+// DirSize returns the total size of all regular files inside the given
+// directory in bytes. Directories and symbolic links are not counted.
+// If the directory does not exist, DirSize returns 0.
+func DirSize(fs afero.Fs, path string) (int64, error) {
+	if _, err := fs.Stat(path); os.IsNotExist(err) {
+		return 0, nil
+	}
+
+	var size int64
+
+	err := afero.Walk(fs, path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+
+	return size, err
 }
 
 // CopyFromOS copies a given directory from the OS filesystem into
@@ -103,14 +244,20 @@ func Rmdir(fs afero.Fs, path string) error {
 //
 // If fileOnly is set to true, files will be copied directly into the
 // destination directory without their directory structure inside src.
-func CopyFromOS(targetFs afero.Fs, src, dest string, fileOnly bool) error {
+// Only files matching all filters are copied.
+func CopyFromOS(targetFs afero.Fs, src, dest string, fileOnly bool, filters ...func(file string) bool) error {
 	var (
-		files = make(chan string)
-		err   error
+		ctx, cancel = context.WithCancel(context.Background())
+		files       = make(chan string)
+		streamErr   = make(chan error, 1)
 	)
 
+	// Canceling the context stops the streaming goroutine if the copy
+	// process returns early.
+	defer cancel()
+
 	go func() {
-		err = StreamFiles(src, files)
+		streamErr <- StreamFilesContext(ctx, afero.NewOsFs(), src, files, filters...)
 	}()
 
 	for file := range files {
@@ -141,14 +288,148 @@ func CopyFromOS(targetFs afero.Fs, src, dest string, fileOnly bool) error {
 			return err
 		}
 	}
-	return err
+
+	return <-streamErr
 }
 
-// IsSafeToRemove determines if a directory can be removed safely.
+// CopyDir recursively copies the src directory including all files and
+// sub-directories to dst inside the given filesystem. dst is created if
+// it doesn't exist yet. All files and directories keep their mode bits.
+//
+// Optional filters can be used to exclude files, see StreamFiles.
+func CopyDir(fs afero.Fs, src, dst string, filters ...func(file string) bool) error {
+	return CopyDirBetween(fs, src, fs, dst, filters...)
+}
+
+// CopyDirBetween works like CopyDir but copies the src directory inside
+// srcFs to dst inside another filesystem dstFs.
+func CopyDirBetween(srcFs afero.Fs, src string, dstFs afero.Fs, dst string, filters ...func(file string) bool) error {
+	return afero.Walk(srcFs, src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			if err := dstFs.MkdirAll(target, info.Mode().Perm()); err != nil {
+				return err
+			}
+			return dstFs.Chmod(target, info.Mode().Perm())
+		}
+
+		for _, filter := range filters {
+			if !filter(string(filepath.Separator) + rel) {
+				return nil
+			}
+		}
+
+		return copyFile(srcFs, path, dstFs, target, info.Mode().Perm())
+	})
+}
+
+// CopyFile copies the src file to dst inside the given filesystem. All
+// parent directories of dst are created if they don't exist, and dst
+// keeps the mode bits of src.
+//
+// If dst already exists, CopyFile returns ErrFileExists unless it is
+// allowed to overwrite dst.
+func CopyFile(fs afero.Fs, src, dst string, overwrite bool) error {
+	info, err := fs.Stat(src)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fs.Stat(dst); err == nil && !overwrite {
+		return fmt.Errorf("%s: %w", dst, ErrFileExists)
+	} else if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := fs.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	return copyFile(fs, src, fs, dst, info.Mode().Perm())
+}
+
+// copyFile copies the contents of src inside srcFs to dst inside dstFs
+// and applies the given mode to dst. An existing dst file will be
+// truncated.
+func copyFile(srcFs afero.Fs, src string, dstFs afero.Fs, dst string, mode os.FileMode) error {
+	srcFile, err := srcFs.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = srcFile.Close()
+	}()
+
+	dstFile, err := dstFs.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		_ = dstFile.Close()
+		return err
+	}
+
+	if err := dstFile.Close(); err != nil {
+		return err
+	}
+
+	// Apply the mode explicitly since OpenFile is subject to the umask.
+	return dstFs.Chmod(dst, mode)
+}
+
+// IsSafeToRemove determines if a path can be removed safely, meaning
+// that no user data will be lost. This is the case if one of these
+// conditions is met:
+//
+//  1. The path doesn't exist.
+//  2. The path is an empty directory, e.g. "." in a new directory.
+//  3. force is set to true.
+//
+// Regardless of these conditions, the filesystem root like / or C:\
+// is never safe to remove. If path can't be inspected for some reason,
+// it isn't considered safe to remove either.
 func IsSafeToRemove(targetFs afero.Fs, path string, force bool) bool {
+	if isFilesystemRoot(path) {
+		return false
+	}
+
+	info, err := targetFs.Stat(path)
+	if os.IsNotExist(err) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+
 	if force {
 		return true
 	}
-	_, err := targetFs.Stat(path)
-	return os.IsNotExist(err)
+
+	if !info.IsDir() {
+		return false
+	}
+
+	isEmpty, err := afero.IsEmpty(targetFs, path)
+	return err == nil && isEmpty
+}
+
+// isFilesystemRoot reports whether path is the root of the filesystem
+// or a volume, for example / on Unix or C:\ on Windows.
+func isFilesystemRoot(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		// If the path can't be resolved, err on the side of caution.
+		return true
+	}
+	return filepath.Dir(abs) == abs
 }
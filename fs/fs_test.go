@@ -0,0 +1,472 @@
+package fs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/test"
+)
+
+// testFile represents a file used for filesystem tests.
+type testFile struct {
+	content string
+	mode    os.FileMode
+}
+
+// TestCopyDir checks if CopyDir copies an entire directory tree while
+// preserving the file contents and permissions.
+func TestCopyDir(t *testing.T) {
+	tests := map[string]struct {
+		files    map[string]testFile
+		filters  []func(file string) bool
+		expected map[string]testFile
+	}{
+		"nested directory": {
+			files: map[string]testFile{
+				"/src/style.css":         {content: "body {}", mode: 0644},
+				"/src/js/main.js":        {content: "init();", mode: 0600},
+				"/src/js/vendor/deps.sh": {content: "#!/bin/sh", mode: 0755},
+			},
+			expected: map[string]testFile{
+				"/dst/style.css":         {content: "body {}", mode: 0644},
+				"/dst/js/main.js":        {content: "init();", mode: 0600},
+				"/dst/js/vendor/deps.sh": {content: "#!/bin/sh", mode: 0755},
+			},
+		},
+		"excluding files with underscores": {
+			files: map[string]testFile{
+				"/src/style.css":    {content: "body {}", mode: 0644},
+				"/src/_partial.css": {content: "main {}", mode: 0644},
+			},
+			filters: []func(file string) bool{NoUnderscores},
+			expected: map[string]testFile{
+				"/dst/style.css": {content: "body {}", mode: 0644},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+		for path, file := range testCase.files {
+			test.Ok(t, memMapFs.MkdirAll(filepath.Dir(path), 0755))
+			test.Ok(t, afero.WriteFile(memMapFs, path, []byte(file.content), file.mode))
+		}
+
+		test.Ok(t, CopyDir(memMapFs, "/src", "/dst", testCase.filters...))
+
+		copied := 0
+
+		test.Ok(t, afero.Walk(memMapFs, "/dst", func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			copied++
+
+			expected, exists := testCase.expected[filepath.ToSlash(path)]
+			test.Assert(t, exists, "%s should not have been copied", path)
+
+			content, err := afero.ReadFile(memMapFs, path)
+			test.Ok(t, err)
+			test.Equals(t, expected.content, string(content))
+			test.Equals(t, expected.mode, info.Mode().Perm())
+
+			return nil
+		}))
+
+		test.Equals(t, len(testCase.expected), copied)
+	}
+}
+
+// TestCopyDirBetween checks if CopyDirBetween copies a nested directory
+// from one filesystem into another one.
+func TestCopyDirBetween(t *testing.T) {
+	files := map[string]string{
+		"/root/favicon.ico":              "icon",
+		"/root/.well-known/security.txt": "Contact: mailto:security@example.com",
+		"/root/downloads/menus/menu.pdf": "pdf",
+	}
+
+	srcFs, dstFs := afero.NewMemMapFs(), afero.NewMemMapFs()
+
+	for path, content := range files {
+		test.Ok(t, srcFs.MkdirAll(filepath.Dir(path), 0755))
+		test.Ok(t, afero.WriteFile(srcFs, path, []byte(content), 0644))
+	}
+
+	test.Ok(t, CopyDirBetween(srcFs, "/root", dstFs, "/target"))
+
+	for path, expected := range files {
+		path = filepath.Join("/target", strings.TrimPrefix(path, "/root"))
+
+		content, err := afero.ReadFile(dstFs, path)
+		test.Ok(t, err)
+		test.Equals(t, expected, string(content))
+
+		exists, err := afero.Exists(srcFs, path)
+		test.Ok(t, err)
+		test.Assert(t, !exists, "%s should not have been written to the source filesystem", path)
+	}
+}
+
+// TestCopyFile checks if CopyFile copies a single file and respects the
+// overwrite flag.
+func TestCopyFile(t *testing.T) {
+	tests := map[string]struct {
+		existing      string
+		overwrite     bool
+		missingSrc    bool
+		expected      string
+		expectedError error
+	}{
+		"new file": {
+			expected: "body {}",
+		},
+		"existing file without overwrite": {
+			existing:      "main {}",
+			expected:      "main {}",
+			expectedError: ErrFileExists,
+		},
+		"existing file with overwrite": {
+			existing:  "main {}",
+			overwrite: true,
+			expected:  "body {}",
+		},
+		"missing source file": {
+			missingSrc:    true,
+			expectedError: os.ErrNotExist,
+		},
+	}
+
+	const (
+		src = "/theme/css/style.css"
+		dst = "/target/assets/css/style.css"
+	)
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+
+		if !testCase.missingSrc {
+			test.Ok(t, afero.WriteFile(memMapFs, src, []byte("body {}"), 0600))
+		}
+		if testCase.existing != "" {
+			test.Ok(t, afero.WriteFile(memMapFs, dst, []byte(testCase.existing), 0644))
+		}
+
+		err := CopyFile(memMapFs, src, dst, testCase.overwrite)
+		if test.ExpectedError(t, testCase.expectedError, err) == test.IsWrongErr || testCase.missingSrc {
+			continue
+		}
+
+		content, err := afero.ReadFile(memMapFs, dst)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, string(content))
+
+		if testCase.expectedError == nil {
+			info, err := memMapFs.Stat(dst)
+			test.Ok(t, err)
+			test.Equals(t, os.FileMode(0600), info.Mode().Perm())
+		}
+	}
+}
+
+// TestIsSafeToRemove checks if IsSafeToRemove only considers paths safe
+// to remove that don't contain any data or may be overwritten.
+func TestIsSafeToRemove(t *testing.T) {
+	tests := map[string]struct {
+		path     string
+		files    []string
+		force    bool
+		expected bool
+	}{
+		"current directory which is empty": {
+			path:     ".",
+			expected: true,
+		},
+		"current directory with files": {
+			path:     ".",
+			files:    []string{"content/index.md"},
+			expected: false,
+		},
+		"current directory with files and force": {
+			path:     ".",
+			files:    []string{"content/index.md"},
+			force:    true,
+			expected: true,
+		},
+		"filesystem root": {
+			path:     string(filepath.Separator),
+			expected: false,
+		},
+		"filesystem root with force": {
+			path:     string(filepath.Separator),
+			force:    true,
+			expected: false,
+		},
+		"non-existing path": {
+			path:     "my-blog",
+			expected: true,
+		},
+		"populated directory": {
+			path:     "my-blog",
+			files:    []string{"my-blog/verless.yml"},
+			expected: false,
+		},
+		"populated directory with force": {
+			path:     "my-blog",
+			files:    []string{"my-blog/verless.yml"},
+			force:    true,
+			expected: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		dir, err := ioutil.TempDir("", "verless-fs")
+		test.Ok(t, err)
+
+		// Relative paths like "." are resolved against the temporary
+		// directory, so the actual working directory stays untouched.
+		baseFs := afero.NewBasePathFs(afero.NewOsFs(), dir)
+
+		for _, file := range testCase.files {
+			test.Ok(t, baseFs.MkdirAll(filepath.Dir(file), 0755))
+			test.Ok(t, afero.WriteFile(baseFs, file, []byte{}, 0644))
+		}
+
+		isSafe := IsSafeToRemove(baseFs, testCase.path, testCase.force)
+		test.Equals(t, testCase.expected, isSafe)
+
+		_ = os.RemoveAll(dir)
+	}
+}
+
+// TestRmdirExcept checks if RmdirExcept removes the contents of a
+// directory except for the kept paths and their parent directories, and
+// if the directory itself is kept.
+func TestRmdirExcept(t *testing.T) {
+	tests := map[string]struct {
+		files    []string
+		keep     []string
+		expected []string
+	}{
+		"top-level files": {
+			files:    []string{"/target/CNAME", "/target/.nojekyll", "/target/index.html", "/target/blog/index.html"},
+			keep:     []string{"CNAME", ".nojekyll"},
+			expected: []string{"/target", "/target/.nojekyll", "/target/CNAME"},
+		},
+		"nested file": {
+			files:    []string{"/target/.well-known/security.txt", "/target/.well-known/other.txt", "/target/index.html"},
+			keep:     []string{".well-known/security.txt"},
+			expected: []string{"/target", "/target/.well-known", "/target/.well-known/security.txt"},
+		},
+		"directory": {
+			files:    []string{"/target/downloads/a.zip", "/target/downloads/old/b.zip", "/target/index.html"},
+			keep:     []string{"downloads"},
+			expected: []string{"/target", "/target/downloads", "/target/downloads/a.zip", "/target/downloads/old", "/target/downloads/old/b.zip"},
+		},
+		"missing kept path": {
+			files:    []string{"/target/index.html"},
+			keep:     []string{"CNAME"},
+			expected: []string{"/target"},
+		},
+		"nothing to keep": {
+			files:    []string{"/target/CNAME", "/target/blog/index.html"},
+			expected: []string{"/target"},
+		},
+		"non-existing directory": {
+			keep:     []string{"CNAME"},
+			expected: []string{},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+
+		for _, file := range testCase.files {
+			test.Ok(t, memMapFs.MkdirAll(filepath.Dir(file), 0755))
+			test.Ok(t, afero.WriteFile(memMapFs, file, []byte{}, 0644))
+		}
+
+		test.Ok(t, RmdirExcept(memMapFs, "/target", testCase.keep...))
+
+		remaining := []string{}
+
+		_ = afero.Walk(memMapFs, "/target", func(path string, _ os.FileInfo, err error) error {
+			if err == nil {
+				remaining = append(remaining, filepath.ToSlash(path))
+			}
+			return nil
+		})
+
+		test.Equals(t, testCase.expected, remaining)
+	}
+}
+
+// TestDirSize checks if DirSize sums up the sizes of all files inside a
+// directory tree.
+func TestDirSize(t *testing.T) {
+	tests := map[string]struct {
+		files    map[string]int
+		path     string
+		expected int64
+	}{
+		"nested directory": {
+			files: map[string]int{
+				"/target/index.html":          120,
+				"/target/blog/index.html":     80,
+				"/target/blog/coffee/a.html":  42,
+				"/target/assets/css/main.css": 1024,
+			},
+			path:     "/target",
+			expected: 1266,
+		},
+		"sub-directory": {
+			files: map[string]int{
+				"/target/index.html":      120,
+				"/target/blog/index.html": 80,
+			},
+			path:     "/target/blog",
+			expected: 80,
+		},
+		"empty files": {
+			files: map[string]int{
+				"/target/index.html": 0,
+			},
+			path:     "/target",
+			expected: 0,
+		},
+		"non-existing directory": {
+			path:     "/target",
+			expected: 0,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+		for path, size := range testCase.files {
+			test.Ok(t, afero.WriteFile(memMapFs, path, make([]byte, size), 0644))
+		}
+
+		size, err := DirSize(memMapFs, testCase.path)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, size)
+	}
+}
+
+// TestDirSize_symlinks checks if DirSize ignores symbolic links.
+func TestDirSize_symlinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verless-fs")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "index.html")
+	test.Ok(t, ioutil.WriteFile(file, make([]byte, 100), 0644))
+
+	if err := os.Symlink(file, filepath.Join(dir, "link.html")); err != nil {
+		t.Skip("symbolic links are not supported:", err)
+	}
+
+	size, err := DirSize(afero.NewOsFs(), dir)
+	test.Ok(t, err)
+	test.Equals(t, int64(100), size)
+}
+
+// errWriteFailed is returned by files of failingWriteFs.
+var errWriteFailed = errors.New("write failed")
+
+// failingWriteFs is a filesystem whose files can be opened but not be
+// written to.
+type failingWriteFs struct {
+	afero.Fs
+}
+
+// OpenFile returns a file that fails to write.
+func (f failingWriteFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	file, err := f.Fs.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	return failingFile{File: file}, nil
+}
+
+// failingFile is a file that fails to write.
+type failingFile struct {
+	afero.File
+}
+
+// Write always returns errWriteFailed.
+func (f failingFile) Write(p []byte) (int, error) {
+	return 0, errWriteFailed
+}
+
+// TestWriteFileAtomic checks if WriteFileAtomic writes or replaces a file
+// and leaves the original file untouched if writing fails.
+func TestWriteFileAtomic(t *testing.T) {
+	tests := map[string]struct {
+		existing      string
+		failing       bool
+		expected      string
+		expectedError error
+	}{
+		"new file": {
+			expected: "<html></html>",
+		},
+		"existing file": {
+			existing: "<html>old</html>",
+			expected: "<html></html>",
+		},
+		"failing write": {
+			existing:      "<html>old</html>",
+			failing:       true,
+			expected:      "<html>old</html>",
+			expectedError: errWriteFailed,
+		},
+	}
+
+	const path = "/target/blog/index.html"
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+		test.Ok(t, memMapFs.MkdirAll(filepath.Dir(path), 0755))
+
+		if testCase.existing != "" {
+			test.Ok(t, afero.WriteFile(memMapFs, path, []byte(testCase.existing), 0644))
+		}
+
+		var targetFs afero.Fs = memMapFs
+		if testCase.failing {
+			targetFs = failingWriteFs{Fs: memMapFs}
+		}
+
+		err := WriteFileAtomic(targetFs, path, []byte("<html></html>"), 0644)
+		test.ExpectedError(t, testCase.expectedError, err)
+
+		content, err := afero.ReadFile(memMapFs, path)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, string(content))
+
+		info, err := memMapFs.Stat(path)
+		test.Ok(t, err)
+		test.Equals(t, os.FileMode(0644), info.Mode().Perm())
+
+		entries, err := afero.ReadDir(memMapFs, filepath.Dir(path))
+		test.Ok(t, err)
+		test.Equals(t, 1, len(entries))
+	}
+}
@@ -0,0 +1,178 @@
+package fs
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	// IgnoreFile is the name of the files listing the files to ignore
+	// inside their directory.
+	IgnoreFile = ".verlessignore"
+)
+
+// ignoreRule represents a single pattern of an ignore file.
+type ignoreRule struct {
+	segments []string
+	// anchored patterns contain a slash and match paths relative to the
+	// directory of their ignore file. Other patterns match the names of
+	// files and directories at any depth.
+	anchored bool
+	negate   bool
+	dirOnly  bool
+}
+
+// match reports whether the rule matches the given path segments, which
+// are relative to the directory of the ignore file.
+func (r ignoreRule) match(segments []string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored {
+		return matchSegments(r.segments, segments)
+	}
+
+	matched, _ := path.Match(r.segments[0], segments[len(segments)-1])
+	return matched
+}
+
+// Ignore returns a filter that doesn't let pass files matched by the
+// IgnoreFile files inside the given path or any of its directories.
+//
+// Ignore files use the gitignore syntax: Each line is a pattern like
+// drafts/ or *.tmp, and a pattern prefixed with ! lets pass files that
+// have been matched by a previous pattern. A pattern in an ignore file
+// of a subdirectory takes precedence over patterns of its parents. As
+// with gitignore, files inside an ignored directory can't be let pass.
+//
+// Ignore returns an error if an ignore file can't be read or contains a
+// malformed pattern.
+func Ignore(fs afero.Fs, path string) (func(file string) bool, error) {
+	rules := make(map[string][]ignoreRule)
+
+	err := afero.Walk(fs, path, func(file string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || info.Name() != IgnoreFile {
+			return nil
+		}
+
+		dirRules, err := readIgnoreFile(fs, file)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(path, filepath.Dir(file))
+		if err != nil {
+			return err
+		}
+		rules[ignoreDir(strings.Split(filepath.ToSlash(rel), "/"))] = dirRules
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func(file string) bool {
+		segments := strings.Split(strings.TrimPrefix(filepath.ToSlash(file), "/"), "/")
+
+		// A file is ignored if it or one of its parent directories is.
+		for i := 1; i <= len(segments); i++ {
+			if isIgnored(rules, segments[:i], i < len(segments)) {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// isIgnored reports whether the last matching rule of all ignore files
+// above the given path ignores the path.
+func isIgnored(rules map[string][]ignoreRule, segments []string, isDir bool) bool {
+	ignored := false
+
+	for i := 0; i < len(segments); i++ {
+		for _, rule := range rules[ignoreDir(segments[:i])] {
+			if rule.match(segments[i:], isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+
+	return ignored
+}
+
+// ignoreDir returns the key of the directory with the given segments.
+func ignoreDir(segments []string) string {
+	dir := path.Join(segments...)
+	if dir == "." {
+		return ""
+	}
+	return dir
+}
+
+// readIgnoreFile reads the rules of the given ignore file.
+func readIgnoreFile(fs afero.Fs, file string) ([]ignoreRule, error) {
+	f, err := fs.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var (
+		rules   []ignoreRule
+		scanner = bufio.NewScanner(f)
+	)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+
+		switch {
+		case strings.HasPrefix(line, "!"):
+			rule.negate = true
+			line = line[1:]
+		case strings.HasPrefix(line, `\#`), strings.HasPrefix(line, `\!`):
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+
+		rule.anchored = strings.Contains(line, "/")
+		rule.segments = strings.Split(strings.TrimPrefix(line, "/"), "/")
+
+		for _, segment := range rule.segments {
+			if _, err := path.Match(segment, ""); err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", file, n, err)
+			}
+		}
+
+		if line != "" {
+			rules = append(rules, rule)
+		}
+	}
+
+	return rules, scanner.Err()
+}
@@ -0,0 +1,117 @@
+package fs
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/test"
+)
+
+// TestIgnore checks if the filters returned by Ignore don't let pass the
+// files matched by the ignore files.
+func TestIgnore(t *testing.T) {
+	tests := map[string]struct {
+		ignoreFiles   map[string]string
+		files         map[string]bool
+		expectedError bool
+	}{
+		"no ignore file": {
+			files: map[string]bool{
+				"/index.md":       true,
+				"/blog/coffee.md": true,
+			},
+		},
+		"subdirectory and file": {
+			ignoreFiles: map[string]string{
+				"/content/.verlessignore": "# Scratch files\nscratch/\n/blog/notes.md\n",
+			},
+			files: map[string]bool{
+				"/scratch/todo.md":        false,
+				"/blog/scratch/ideas.md":  false,
+				"/blog/notes.md":          false,
+				"/blog/coffee.md":         true,
+				"/news/blog/notes.md":     true,
+				"/scratch.md":             true,
+				"/blog/notes.md.tmp/a.md": true,
+			},
+		},
+		"negation": {
+			ignoreFiles: map[string]string{
+				"/content/.verlessignore": "*.draft.md\n!keep.draft.md\nprivate/\n!private/public.md\n",
+			},
+			files: map[string]bool{
+				"/coffee.draft.md":      false,
+				"/blog/tea.draft.md":    false,
+				"/blog/keep.draft.md":   true,
+				"/blog/coffee.md":       true,
+				"/private/public.md":    false,
+				"/private/secret.md":    false,
+				"/blog/private-blog.md": true,
+			},
+		},
+		"nested ignore files": {
+			ignoreFiles: map[string]string{
+				"/content/.verlessignore":      "*.tmp.md\n",
+				"/content/blog/.verlessignore": "!coffee.tmp.md\n/drafts/\n",
+			},
+			files: map[string]bool{
+				"/tea.tmp.md":          false,
+				"/blog/tea.tmp.md":     false,
+				"/blog/coffee.tmp.md":  true,
+				"/coffee.tmp.md":       false,
+				"/blog/drafts/tea.md":  false,
+				"/drafts/tea.md":       true,
+				"/blog/2020/drafts.md": true,
+			},
+		},
+		"globstar": {
+			ignoreFiles: map[string]string{
+				"/content/.verlessignore": "blog/**/*.tmp\n",
+			},
+			files: map[string]bool{
+				"/blog/coffee.tmp":       false,
+				"/blog/2020/10/tea.tmp":  false,
+				"/blog/2020/10/tea.md":   true,
+				"/news/2020/10/tea.tmp":  true,
+				"/blog/2020/10/tmp/a.md": true,
+			},
+		},
+		"invalid pattern": {
+			ignoreFiles: map[string]string{
+				"/content/blog/.verlessignore": "[*.md\n",
+			},
+			expectedError: true,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+		test.Ok(t, memMapFs.MkdirAll("/content", 0755))
+
+		for file, content := range testCase.ignoreFiles {
+			test.Ok(t, afero.WriteFile(memMapFs, file, []byte(content), 0644))
+		}
+
+		filter, err := Ignore(memMapFs, "/content")
+		if testCase.expectedError {
+			test.Assert(t, err != nil, "invalid pattern should yield an error")
+			continue
+		}
+		test.Ok(t, err)
+
+		for file, expected := range testCase.files {
+			test.Assert(t, filter(filepath.FromSlash(file)) == expected, "%s: expected %v", file, expected)
+		}
+	}
+}
+
+// TestIgnore_missingDir checks if Ignore lets pass all files if the given
+// directory doesn't exist.
+func TestIgnore_missingDir(t *testing.T) {
+	filter, err := Ignore(afero.NewMemMapFs(), "/content")
+	test.Ok(t, err)
+	test.Equals(t, true, filter("/index.md"))
+}
@@ -0,0 +1,214 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	// ErrSymlinkCycle indicates that a symbolic link points to one of
+	// its parent directories and following it would loop forever.
+	ErrSymlinkCycle = errors.New("symlink cycle detected")
+)
+
+// StreamFilesOptions represents options for streaming files.
+type StreamFilesOptions struct {
+	// FollowSymlinks walks into symbolically linked directories
+	// instead of skipping them.
+	FollowSymlinks bool
+	// OnFile is invoked with the path of each file passing all filters
+	// right before the file is sent through the channel, e.g. to render
+	// a progress indicator. It must not block, since the walk waits for
+	// it to return. If it is nil, it is ignored.
+	OnFile func(file string)
+}
+
+// StreamFiles sends all relative file paths inside a given path that
+// match the given filters through the files channel.
+//
+// Each filter receives the file path relative to the walked path, in
+// the same form as it is sent through the channel, e.g. /blog/coffee.md.
+// Dangling symbolic links are skipped.
+func StreamFiles(fs afero.Fs, path string, files chan<- string, filters ...func(file string) bool) error {
+	return StreamFilesContext(context.Background(), fs, path, files, filters...)
+}
+
+// StreamFilesOS works like StreamFiles but uses the OS filesystem.
+func StreamFilesOS(path string, files chan<- string, filters ...func(file string) bool) error {
+	return StreamFiles(afero.NewOsFs(), path, files, filters...)
+}
+
+// StreamFilesContext works like StreamFiles but stops walking the path
+// as soon as the given context is canceled. In that case, the files
+// channel gets closed and the context's error is returned.
+func StreamFilesContext(ctx context.Context, fs afero.Fs, path string, files chan<- string, filters ...func(file string) bool) error {
+	return streamFiles(ctx, fs, path, StreamFilesOptions{}, files, filters)
+}
+
+// StreamFilesWith works like StreamFiles but takes additional options.
+//
+// If options.FollowSymlinks is set, symbolically linked directories are
+// walked as if they were regular directories. A symlink pointing to one
+// of its parent directories results in ErrSymlinkCycle. If options.OnFile
+// is set, it is invoked for each file sent through the channel.
+func StreamFilesWith(fs afero.Fs, path string, options StreamFilesOptions, files chan<- string, filters ...func(file string) bool) error {
+	return streamFiles(context.Background(), fs, path, options, files, filters)
+}
+
+func streamFiles(ctx context.Context, fs afero.Fs, path string, options StreamFilesOptions, files chan<- string, filters []func(file string) bool) error {
+	defer close(files)
+
+	info, err := fs.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	s := streamer{
+		ctx:     ctx,
+		fs:      fs,
+		root:    path,
+		options: options,
+		files:   files,
+		filters: filters,
+	}
+
+	return s.walk(path, info, nil)
+}
+
+// streamer walks a directory and sends all files through its channel.
+type streamer struct {
+	ctx     context.Context
+	fs      afero.Fs
+	root    string
+	options StreamFilesOptions
+	files   chan<- string
+	filters []func(file string) bool
+}
+
+// walk walks the given path recursively. ancestors holds the file info
+// of all directories above path and is used to detect symlink cycles.
+func (s *streamer) walk(path string, info os.FileInfo, ancestors []os.FileInfo) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return s.emit(path)
+	}
+
+	for _, ancestor := range ancestors {
+		if os.SameFile(ancestor, info) {
+			return fmt.Errorf("%s: %w", path, ErrSymlinkCycle)
+		}
+	}
+
+	names, err := readDirNames(s.fs, path)
+	if err != nil {
+		return err
+	}
+
+	// Force a copy so that sibling directories don't share the slice.
+	ancestors = append(ancestors[:len(ancestors):len(ancestors)], info)
+
+	for _, name := range names {
+		child := filepath.Join(path, name)
+
+		childInfo, err := lstatIfPossible(s.fs, child)
+		if err != nil {
+			return err
+		}
+
+		if childInfo.Mode()&os.ModeSymlink != 0 {
+			target, err := s.fs.Stat(child)
+			// Dangling symlinks don't point to anything that could be
+			// streamed, so they're skipped instead of failing the walk.
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if target.IsDir() && !s.options.FollowSymlinks {
+				continue
+			}
+			childInfo = target
+		}
+
+		if err := s.walk(child, childInfo, ancestors); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// emit sends the given file through the channel if it passes all
+// filters.
+func (s *streamer) emit(file string) error {
+	// Determine the path relative to the walked path, so that it does
+	// not make a difference if the paths are in different formats. e.g.
+	// one "example/" and the other one "./example"
+	rel, err := filepath.Rel(s.root, file)
+	if err != nil {
+		return err
+	}
+	rel = string(filepath.Separator) + rel
+
+	for _, filter := range s.filters {
+		if !filter(rel) {
+			return nil
+		}
+	}
+
+	if s.options.OnFile != nil {
+		s.options.OnFile(rel)
+	}
+
+	// Don't block forever if the consumer stopped reading from the
+	// channel after canceling the context.
+	select {
+	case s.files <- rel:
+	case <-s.ctx.Done():
+		return s.ctx.Err()
+	}
+
+	return nil
+}
+
+// readDirNames returns the sorted names of all directory entries.
+func readDirNames(fs afero.Fs, path string) ([]string, error) {
+	dir, err := fs.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = dir.Close()
+	}()
+
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// lstatIfPossible uses Lstat if the filesystem supports it, so that
+// symbolic links can be detected. Otherwise, it falls back to Stat.
+func lstatIfPossible(fs afero.Fs, path string) (os.FileInfo, error) {
+	if lstater, ok := fs.(afero.Lstater); ok {
+		info, _, err := lstater.LstatIfPossible(path)
+		return info, err
+	}
+	return fs.Stat(path)
+}
@@ -0,0 +1,495 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/test"
+)
+
+const (
+	// testFileCount is the number of files created for streaming tests.
+	testFileCount = 500
+)
+
+// TestStreamFiles checks if StreamFiles emits the relative paths of all
+// files inside a nested directory structure.
+func TestStreamFiles(t *testing.T) {
+	tests := map[string]struct {
+		path     string
+		files    []string
+		filters  []func(file string) bool
+		expected []string
+	}{
+		"nested directories": {
+			path: "/project/content",
+			files: []string{
+				"/project/content/index.md",
+				"/project/content/blog/coffee.md",
+				"/project/content/blog/roasting/beans.md",
+			},
+			expected: []string{
+				"/blog/coffee.md",
+				"/blog/roasting/beans.md",
+				"/index.md",
+			},
+		},
+		"relative path with filters": {
+			path: "project/content/",
+			files: []string{
+				"project/content/index.md",
+				"project/content/_draft.md",
+				"project/content/blog/image.jpg",
+				"project/content/blog/coffee.md",
+			},
+			filters: []func(file string) bool{MarkdownOnly, NoUnderscores},
+			expected: []string{
+				"/blog/coffee.md",
+				"/index.md",
+			},
+		},
+		"markdown extensions": {
+			path: "/project/content",
+			files: []string{
+				"/project/content/index.md",
+				"/project/content/blog/coffee.markdown",
+				"/project/content/blog/tea.mdown",
+				"/project/content/blog/notes.txt",
+				"/project/content/blog/image.jpg",
+			},
+			filters: []func(file string) bool{MarkdownOnly},
+			expected: []string{
+				"/blog/coffee.markdown",
+				"/blog/tea.mdown",
+				"/index.md",
+			},
+		},
+		"configured extensions": {
+			path: "/project/content",
+			files: []string{
+				"/project/content/index.md",
+				"/project/content/blog/coffee.markdown",
+				"/project/content/blog/notes.txt",
+			},
+			filters: []func(file string) bool{Extensions(".markdown", ".txt")},
+			expected: []string{
+				"/blog/coffee.markdown",
+				"/blog/notes.txt",
+			},
+		},
+		"renderable files": {
+			path: "/project/content",
+			files: []string{
+				"/project/content/index.md",
+				"/project/content/about.html",
+				"/project/content/blog/coffee.markdown",
+				"/project/content/blog/tea.mdown",
+				"/project/content/blog/notes.txt",
+				"/project/content/blog/style.css",
+				"/project/content/blog/latte/index.HTML",
+				"/project/content/blog/latte/latte.jpg",
+			},
+			filters: []func(file string) bool{Renderable()},
+			expected: []string{
+				"/about.html",
+				"/blog/coffee.markdown",
+				"/blog/latte/index.HTML",
+				"/blog/tea.mdown",
+				"/index.md",
+			},
+		},
+		"configured renderable extensions": {
+			path: "/project/content",
+			files: []string{
+				"/project/content/index.md",
+				"/project/content/about.html",
+				"/project/content/blog/coffee.markdown",
+				"/project/content/blog/notes.txt",
+			},
+			filters: []func(file string) bool{Renderable(".markdown", ".html")},
+			expected: []string{
+				"/about.html",
+				"/blog/coffee.markdown",
+			},
+		},
+		"non-existing path": {
+			path:     "/project/content",
+			expected: []string{},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+		for _, file := range testCase.files {
+			test.Ok(t, afero.WriteFile(memMapFs, file, []byte{}, 0644))
+		}
+
+		streamed, err := collectFiles(memMapFs, testCase.path, testCase.filters...)
+		test.Ok(t, err)
+
+		test.Equals(t, toNative(testCase.expected), streamed)
+	}
+}
+
+// TestMaxDepth checks if the MaxDepth filter excludes all files nested
+// deeper than the given depth.
+func TestMaxDepth(t *testing.T) {
+	files := []string{
+		"/content/index.md",
+		"/content/blog/coffee.md",
+		"/content/blog/roasting/beans.md",
+	}
+
+	tests := map[string]struct {
+		depth    int
+		expected []string
+	}{
+		"depth 0": {
+			depth:    0,
+			expected: []string{"/index.md"},
+		},
+		"depth 1": {
+			depth:    1,
+			expected: []string{"/blog/coffee.md", "/index.md"},
+		},
+		"depth 2": {
+			depth:    2,
+			expected: []string{"/blog/coffee.md", "/blog/roasting/beans.md", "/index.md"},
+		},
+	}
+
+	memMapFs := afero.NewMemMapFs()
+	for _, file := range files {
+		test.Ok(t, afero.WriteFile(memMapFs, file, []byte{}, 0644))
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		streamed, err := collectFiles(memMapFs, "/content", MaxDepth(testCase.depth))
+		test.Ok(t, err)
+
+		test.Equals(t, toNative(testCase.expected), streamed)
+	}
+}
+
+// TestStreamFilesContext checks if StreamFilesContext stops walking
+// the directory and closes the channel once the context is canceled.
+func TestStreamFilesContext(t *testing.T) {
+	tests := map[string]struct {
+		cancelAfter   int
+		expectedError error
+	}{
+		"cancel before walking": {
+			cancelAfter:   0,
+			expectedError: context.Canceled,
+		},
+		"cancel mid-walk": {
+			cancelAfter:   testFileCount / 2,
+			expectedError: context.Canceled,
+		},
+		"no cancellation": {
+			cancelAfter: -1,
+		},
+	}
+
+	dir := createTestFiles(t, testFileCount)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+			files       = make(chan string)
+			errCh       = make(chan error)
+			received    = 0
+		)
+
+		if testCase.cancelAfter == 0 {
+			cancel()
+		}
+
+		go func() {
+			errCh <- StreamFilesContext(ctx, afero.NewOsFs(), dir, files)
+		}()
+
+		for range files {
+			received++
+			if received == testCase.cancelAfter {
+				cancel()
+				// The walk must be able to finish even though nobody
+				// reads from the channel anymore.
+				break
+			}
+		}
+
+		err := <-errCh
+		test.ExpectedError(t, testCase.expectedError, err)
+
+		// The channel has to be closed in any case.
+		_, ok := <-files
+		test.Assert(t, !ok, "files channel should be closed")
+
+		if testCase.cancelAfter == -1 {
+			test.Equals(t, testFileCount, received)
+		} else {
+			test.Assert(t, received < testFileCount, "walk should be aborted")
+		}
+
+		cancel()
+	}
+}
+
+// TestStreamFiles_concurrent checks if concurrent StreamFiles calls on
+// distinct directories return their own errors. Run it with -race.
+func TestStreamFiles_concurrent(t *testing.T) {
+	const calls = 8
+
+	var (
+		memMapFs = afero.NewMemMapFs()
+		wg       = sync.WaitGroup{}
+		expected = make([]error, calls)
+		actual   = make([]error, calls)
+	)
+
+	for i := 0; i < calls; i++ {
+		dir := fmt.Sprintf("/content-%d", i)
+		test.Ok(t, afero.WriteFile(memMapFs, filepath.Join(dir, "page.md"), []byte{}, 0644))
+	}
+
+	wg.Add(calls)
+
+	for i := 0; i < calls; i++ {
+		var (
+			dir      = fmt.Sprintf("/content-%d", i)
+			targetFs = memMapFs
+		)
+
+		// Every second directory can't be opened and yields its own error.
+		if i%2 == 1 {
+			expected[i] = fmt.Errorf("cannot open %s", dir)
+			targetFs = &failingFs{Fs: memMapFs, path: dir, err: expected[i]}
+		}
+
+		go func(i int, targetFs afero.Fs, dir string) {
+			defer wg.Done()
+			files := make(chan string)
+
+			go func() {
+				for range files {
+				}
+			}()
+
+			actual[i] = StreamFiles(targetFs, dir, files)
+		}(i, targetFs, dir)
+	}
+
+	wg.Wait()
+
+	for i := 0; i < calls; i++ {
+		test.ExpectedError(t, expected[i], actual[i])
+	}
+}
+
+// failingFs is a filesystem that fails to open the given path.
+type failingFs struct {
+	afero.Fs
+	path string
+	err  error
+}
+
+// Open returns the failingFs' error for the failing path.
+func (f *failingFs) Open(name string) (afero.File, error) {
+	if filepath.Clean(name) == filepath.Clean(f.path) {
+		return nil, f.err
+	}
+	return f.Fs.Open(name)
+}
+
+// TestStreamFilesWith_followSymlinks checks if StreamFilesWith walks into
+// symlinked directories when requested and detects symlink cycles.
+func TestStreamFilesWith_followSymlinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verless-fs")
+	test.Ok(t, err)
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	var (
+		content = filepath.Join(dir, "content")
+		shared  = filepath.Join(dir, "shared")
+	)
+
+	test.Ok(t, os.MkdirAll(content, 0755))
+	test.Ok(t, os.MkdirAll(shared, 0755))
+	test.Ok(t, ioutil.WriteFile(filepath.Join(content, "index.md"), []byte{}, 0644))
+	test.Ok(t, ioutil.WriteFile(filepath.Join(shared, "coffee.md"), []byte{}, 0644))
+
+	if err := os.Symlink(shared, filepath.Join(content, "blog")); err != nil {
+		t.Skipf("symlinks are not supported: %v", err)
+	}
+
+	tests := map[string]struct {
+		options       StreamFilesOptions
+		cycle         bool
+		dangling      bool
+		expected      []string
+		expectedError error
+	}{
+		"without following symlinks": {
+			expected: []string{"/index.md"},
+		},
+		"following symlinks": {
+			options:  StreamFilesOptions{FollowSymlinks: true},
+			expected: []string{"/blog/coffee.md", "/index.md"},
+		},
+		"self-referential symlink": {
+			options:       StreamFilesOptions{FollowSymlinks: true},
+			cycle:         true,
+			expectedError: ErrSymlinkCycle,
+		},
+		"dangling symlink": {
+			dangling: true,
+			expected: []string{"/index.md"},
+		},
+		"following a dangling symlink": {
+			options:  StreamFilesOptions{FollowSymlinks: true},
+			dangling: true,
+			expected: []string{"/blog/coffee.md", "/index.md"},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		var (
+			self     = filepath.Join(shared, "self")
+			dangling = filepath.Join(content, "missing.md")
+		)
+
+		if testCase.cycle {
+			test.Ok(t, os.Symlink(shared, self))
+		}
+		if testCase.dangling {
+			test.Ok(t, os.Symlink(filepath.Join(dir, "missing.md"), dangling))
+		}
+
+		var (
+			files    = make(chan string)
+			errCh    = make(chan error)
+			streamed = make([]string, 0)
+		)
+
+		go func() {
+			errCh <- StreamFilesWith(afero.NewOsFs(), content, testCase.options, files)
+		}()
+
+		for file := range files {
+			streamed = append(streamed, file)
+		}
+
+		result := test.ExpectedError(t, testCase.expectedError, <-errCh)
+		if result == test.IsCorrectNil {
+			sort.Strings(streamed)
+			test.Equals(t, toNative(testCase.expected), streamed)
+		}
+
+		_ = os.Remove(self)
+		_ = os.Remove(dangling)
+	}
+}
+
+// collectFiles streams all files inside path and returns them sorted.
+func collectFiles(fs afero.Fs, path string, filters ...func(file string) bool) ([]string, error) {
+	var (
+		files    = make(chan string)
+		errCh    = make(chan error)
+		streamed = make([]string, 0)
+	)
+
+	go func() {
+		errCh <- StreamFiles(fs, path, files, filters...)
+	}()
+
+	for file := range files {
+		streamed = append(streamed, file)
+	}
+
+	sort.Strings(streamed)
+
+	return streamed, <-errCh
+}
+
+// toNative converts slash-separated paths to the OS-specific format.
+func toNative(paths []string) []string {
+	native := make([]string, len(paths))
+
+	for i, path := range paths {
+		native[i] = filepath.FromSlash(path)
+	}
+
+	return native
+}
+
+// createTestFiles creates a temporary directory containing n files.
+func createTestFiles(t *testing.T, n int) string {
+	dir, err := ioutil.TempDir("", "verless-fs")
+	test.Ok(t, err)
+
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file-%d.md", i))
+		test.Ok(t, ioutil.WriteFile(path, []byte{}, 0644))
+	}
+
+	return dir
+}
+
+// TestStreamFilesWith_onFile checks if StreamFilesWith invokes the OnFile
+// callback exactly once for each file sent through the channel.
+func TestStreamFilesWith_onFile(t *testing.T) {
+	memMapFs := afero.NewMemMapFs()
+
+	for _, file := range []string{"/content/index.md", "/content/_draft.md", "/content/blog/coffee.md", "/content/blog/beans.jpg"} {
+		test.Ok(t, afero.WriteFile(memMapFs, file, []byte{}, 0644))
+	}
+
+	var (
+		files    = make(chan string)
+		streamed []string
+		reported []string
+	)
+
+	options := StreamFilesOptions{
+		OnFile: func(file string) {
+			reported = append(reported, file)
+		},
+	}
+
+	streamErr := make(chan error, 1)
+
+	go func() {
+		streamErr <- StreamFilesWith(memMapFs, "/content", options, files, MarkdownOnly, NoUnderscores)
+	}()
+
+	for file := range files {
+		streamed = append(streamed, file)
+	}
+	test.Ok(t, <-streamErr)
+
+	expected := toNative([]string{"/blog/coffee.md", "/index.md"})
+
+	test.Equals(t, expected, streamed)
+	test.Equals(t, expected, reported)
+}
@@ -0,0 +1,312 @@
+// Package images provides responsive images, i.e. resized variants of an
+// image that browsers can choose from using the srcset attribute.
+package images
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	// The GIF decoder is registered for image.Decode.
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/fs"
+)
+
+const (
+	// DefaultQuality is the JPEG quality used if no quality has been
+	// configured.
+	DefaultQuality int = 85
+	// DefaultSizes is the sizes attribute used if no sizes have been
+	// configured.
+	DefaultSizes string = "100vw"
+	// hashLength is the number of hex digits of the SHA-256 hash used as
+	// filename of cached variants.
+	hashLength int = 16
+)
+
+var (
+	// DefaultWidths are the widths of the variants created for an image
+	// if no widths have been configured.
+	DefaultWidths = []int{480, 800, 1200}
+
+	// ErrUnsupportedFormat states that an image is neither a JPEG, a PNG
+	// nor a GIF file.
+	ErrUnsupportedFormat = errors.New("unsupported image format, use JPEG, PNG or GIF")
+
+	// ErrOutsideProject states that an image path points to a file outside
+	// the project directory.
+	ErrOutsideProject = errors.New("image is outside the project directory")
+)
+
+// Options configure how the variants of an image are created.
+type Options struct {
+	// Path is the project path. Images are referenced by their path inside
+	// the project, like static/img/coffee.jpg.
+	Path string
+	// CacheDir stores the variants of all images, keyed by a hash of the
+	// image and the variant settings. Existing variants are re-used.
+	CacheDir string
+	// Widths are the widths of the variants in pixels. Images are never
+	// scaled up, so widths larger than the image are replaced with the
+	// image's own width. If Widths is empty, DefaultWidths are used.
+	Widths []int
+	// Quality is the quality of JPEG variants between 1 and 100. If it is
+	// 0, DefaultQuality is used.
+	Quality int
+	// Sizes is the value of the sizes attribute. If it is empty,
+	// DefaultSizes is used.
+	Sizes string
+	// BasePath is the path the website is served under, like /blog. It
+	// is prefixed to the URLs of all variants.
+	BasePath string
+}
+
+// Image represents the variants of a responsive image.
+type Image struct {
+	// Src is the URL of the largest variant, which is used as fallback.
+	Src string
+	// Srcset lists the URLs of all variants along with their widths, like
+	// /static/img/coffee-480w.jpg 480w, /static/img/coffee-800w.jpg 800w.
+	Srcset string
+	Sizes  string
+	// Width and Height are the dimensions of the largest variant.
+	Width  int
+	Height int
+}
+
+// Tag returns an <img> element displaying the image with the given
+// alternative text.
+func (i Image) Tag(alt string) string {
+	return fmt.Sprintf(`<img src="%s" srcset="%s" sizes="%s" width="%d" height="%d" alt="%s">`,
+		html.EscapeString(i.Src), html.EscapeString(i.Srcset), html.EscapeString(i.Sizes),
+		i.Width, i.Height, html.EscapeString(alt))
+}
+
+// Processor creates the variants of images and writes them into the
+// output directory. It is safe for concurrent usage.
+type Processor struct {
+	options Options
+	mutex   sync.Mutex
+	// images contains all processed images by their path.
+	images map[string]Image
+	// variants maps the paths of all variants inside the output directory
+	// to their files in the cache directory.
+	variants map[string]string
+}
+
+// New creates a new Processor using the given options.
+func New(options Options) *Processor {
+	if len(options.Widths) == 0 {
+		options.Widths = DefaultWidths
+	}
+	if options.Quality == 0 {
+		options.Quality = DefaultQuality
+	}
+	if options.Sizes == "" {
+		options.Sizes = DefaultSizes
+	}
+
+	p := Processor{
+		options:  options,
+		images:   make(map[string]Image),
+		variants: make(map[string]string),
+	}
+
+	return &p
+}
+
+// Funcs returns the image and imageTag template functions. image returns
+// the Image for an image path, and imageTag returns its <img> element.
+func (p *Processor) Funcs() template.FuncMap {
+	return template.FuncMap{
+		"image": p.Process,
+		"imageTag": func(src, alt string) (string, error) {
+			img, err := p.Process(src)
+			if err != nil {
+				return "", err
+			}
+			return img.Tag(alt), nil
+		},
+	}
+}
+
+// Process creates the variants of the image with the given path inside
+// the project, like static/img/coffee.jpg, unless they already exist in
+// the cache directory. The variants are written into the output directory
+// by Write, next to the original image: static/img/coffee-480w.jpg.
+func (p *Processor) Process(src string) (Image, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	src = path.Clean(strings.TrimPrefix(filepath.ToSlash(src), "/"))
+
+	if src == ".." || strings.HasPrefix(src, "../") {
+		return Image{}, fmt.Errorf("%s: %w", src, ErrOutsideProject)
+	}
+
+	if img, exists := p.images[src]; exists {
+		return img, nil
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(p.options.Path, filepath.FromSlash(src)))
+	if err != nil {
+		return Image{}, err
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return Image{}, fmt.Errorf("%s: %w", src, ErrUnsupportedFormat)
+	}
+
+	ext := path.Ext(src)
+	// GIF variants are encoded as PNG files, so that they keep their
+	// transparency but don't have a limited number of colors.
+	if format == "gif" {
+		ext = ".png"
+	}
+
+	var (
+		decoded  image.Image
+		srcset   []string
+		img      = Image{Sizes: p.options.Sizes}
+		basename = strings.TrimSuffix(src, path.Ext(src))
+	)
+
+	for _, width := range p.widths(cfg.Width) {
+		height := scaledHeight(cfg.Width, cfg.Height, width)
+		file := filepath.Join(p.options.CacheDir, p.variantHash(content, width)+ext)
+
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			if decoded == nil {
+				if decoded, _, err = image.Decode(bytes.NewReader(content)); err != nil {
+					return Image{}, fmt.Errorf("%s: %w", src, err)
+				}
+			}
+			if err := p.writeVariant(file, resize(decoded, width, height), format); err != nil {
+				return Image{}, err
+			}
+		}
+
+		variant := fmt.Sprintf("%s-%dw%s", basename, width, ext)
+		p.variants[variant] = file
+
+		img.Src = p.options.BasePath + "/" + variant
+		img.Width, img.Height = width, height
+		srcset = append(srcset, fmt.Sprintf("%s %dw", img.Src, width))
+	}
+
+	img.Srcset = strings.Join(srcset, ", ")
+	p.images[src] = img
+
+	return img, nil
+}
+
+// Write copies the variants of all processed images into the output
+// directory of the given filesystem.
+func (p *Processor) Write(targetFs afero.Fs, outputDir string) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for variant, file := range p.variants {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(outputDir, filepath.FromSlash(variant))
+
+		if err := targetFs.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		if err := afero.WriteFile(targetFs, target, content, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// widths returns the distinct widths of the variants for an image with
+// the given width in ascending order.
+func (p *Processor) widths(imageWidth int) []int {
+	var (
+		widths []int
+		seen   = make(map[int]bool)
+	)
+
+	for _, width := range p.options.Widths {
+		if width > imageWidth {
+			width = imageWidth
+		}
+		if width > 0 && !seen[width] {
+			widths = append(widths, width)
+			seen[width] = true
+		}
+	}
+
+	sort.Ints(widths)
+
+	return widths
+}
+
+// variantHash returns the hash identifying the variant of an image with
+// the given content and width.
+func (p *Processor) variantHash(content []byte, width int) string {
+	hash := sha256.New()
+	_, _ = hash.Write(content)
+	_, _ = hash.Write([]byte(strconv.Itoa(width) + "/" + strconv.Itoa(p.options.Quality)))
+
+	return hex.EncodeToString(hash.Sum(nil))[:hashLength]
+}
+
+// writeVariant encodes a variant in the format of the original image and
+// stores it in the cache directory.
+func (p *Processor) writeVariant(file string, variant image.Image, format string) error {
+	var (
+		b   bytes.Buffer
+		err error
+	)
+
+	switch format {
+	case "jpeg":
+		err = jpeg.Encode(&b, variant, &jpeg.Options{Quality: p.options.Quality})
+	default:
+		err = png.Encode(&b, variant)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	return fs.WriteFileAtomic(afero.NewOsFs(), file, b.Bytes(), 0644)
+}
+
+// scaledHeight returns the height of an image scaled from the given width
+// to newWidth, keeping the aspect ratio.
+func scaledHeight(width, height, newWidth int) int {
+	scaled := (height*newWidth + width/2) / width
+	if scaled < 1 {
+		return 1
+	}
+	return scaled
+}
@@ -0,0 +1,135 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/test"
+)
+
+// TestProcessor_Process checks if the variants of an image are created
+// and written into the output directory, and if the generated markup
+// references all variants.
+func TestProcessor_Process(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verless-images")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	writeTestImage(t, filepath.Join(dir, "static", "img", "coffee.png"), 100, 50)
+
+	options := Options{
+		Path:     dir,
+		CacheDir: filepath.Join(dir, ".verless", "images"),
+		Widths:   []int{80, 40, 200},
+		BasePath: "/blog",
+	}
+
+	img, err := New(options).Process("static/img/coffee.png")
+	test.Ok(t, err)
+
+	test.Equals(t, Image{
+		Src:    "/blog/static/img/coffee-100w.png",
+		Srcset: "/blog/static/img/coffee-40w.png 40w, /blog/static/img/coffee-80w.png 80w, /blog/static/img/coffee-100w.png 100w",
+		Sizes:  DefaultSizes,
+		Width:  100,
+		Height: 50,
+	}, img)
+
+	test.Equals(t, `<img src="/blog/static/img/coffee-100w.png" srcset="/blog/static/img/coffee-40w.png 40w, /blog/static/img/coffee-80w.png 80w, /blog/static/img/coffee-100w.png 100w" sizes="100vw" width="100" height="50" alt="A &#34;cup&#34;">`, img.Tag(`A "cup"`))
+
+	cached, err := ioutil.ReadDir(options.CacheDir)
+	test.Ok(t, err)
+	test.Equals(t, 3, len(cached))
+
+	// Overwrite a cached variant to check if it is re-used by the next
+	// build instead of being created again.
+	cachedFile := filepath.Join(options.CacheDir, cached[0].Name())
+	test.Ok(t, ioutil.WriteFile(cachedFile, []byte("cached"), 0644))
+
+	processor := New(options)
+	_, err = processor.Process("/static/img/coffee.png")
+	test.Ok(t, err)
+
+	memMapFs := afero.NewMemMapFs()
+	test.Ok(t, processor.Write(memMapFs, "/target"))
+
+	sizes := map[string]image.Point{
+		"/target/static/img/coffee-40w.png":  {X: 40, Y: 20},
+		"/target/static/img/coffee-80w.png":  {X: 80, Y: 40},
+		"/target/static/img/coffee-100w.png": {X: 100, Y: 50},
+	}
+
+	var reused int
+
+	for file, size := range sizes {
+		content, err := afero.ReadFile(memMapFs, file)
+		test.Ok(t, err)
+
+		if string(content) == "cached" {
+			reused++
+			continue
+		}
+
+		cfg, err := png.DecodeConfig(bytes.NewReader(content))
+		test.Ok(t, err)
+		test.Equals(t, size, image.Point{X: cfg.Width, Y: cfg.Height})
+	}
+
+	test.Equals(t, 1, reused)
+}
+
+// TestProcessor_Process_errors checks if invalid images are reported.
+func TestProcessor_Process_errors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verless-images")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	test.Ok(t, ioutil.WriteFile(filepath.Join(dir, "coffee.txt"), []byte("coffee"), 0644))
+
+	tests := map[string]struct {
+		src           string
+		expectedError error
+	}{
+		"unsupported format": {
+			src:           "coffee.txt",
+			expectedError: ErrUnsupportedFormat,
+		},
+		"outside project": {
+			src:           "../coffee.png",
+			expectedError: ErrOutsideProject,
+		},
+	}
+
+	processor := New(Options{Path: dir, CacheDir: filepath.Join(dir, "cache")})
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		_, err := processor.Process(testCase.src)
+		test.ExpectedError(t, testCase.expectedError, err)
+	}
+}
+
+// writeTestImage writes a PNG image with the given dimensions to file.
+func writeTestImage(tb testing.TB, file string, width, height int) {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+
+	var b bytes.Buffer
+	test.Ok(tb, png.Encode(&b, img))
+
+	test.Ok(tb, os.MkdirAll(filepath.Dir(file), 0755))
+	test.Ok(tb, ioutil.WriteFile(file, b.Bytes(), 0644))
+}
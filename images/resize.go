@@ -0,0 +1,70 @@
+package images
+
+import (
+	"image"
+	"image/draw"
+)
+
+// resize scales an image down to the given dimensions. Each pixel of the
+// resized image is the average of the pixels it covers in the original
+// image, which avoids the aliasing of simply picking pixels.
+func resize(src image.Image, width, height int) image.Image {
+	bounds := src.Bounds()
+
+	// Convert the image to RGBA first, so that the pixels can be read
+	// directly regardless of the original color model.
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	if bounds.Dx() == width && bounds.Dy() == height {
+		return rgba
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0, y1 := span(y, height, bounds.Dy())
+
+		for x := 0; x < width; x++ {
+			x0, x1 := span(x, width, bounds.Dx())
+
+			var r, g, b, a, n int
+
+			for sy := y0; sy < y1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					r += int(row[sx*4])
+					g += int(row[sx*4+1])
+					b += int(row[sx*4+2])
+					a += int(row[sx*4+3])
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// span returns the range of source pixels [start, end) covered by the
+// pixel i of a dimension scaled from srcSize to size. The range contains
+// at least one pixel.
+func span(i, size, srcSize int) (int, int) {
+	start := i * srcSize / size
+	end := (i + 1) * srcSize / size
+
+	if end <= start {
+		end = start + 1
+	}
+	if end > srcSize {
+		end = srcSize
+	}
+
+	return start, end
+}
@@ -0,0 +1,84 @@
+package minify
+
+import (
+	"bytes"
+	"strings"
+)
+
+// cssSeparators contains all characters around which whitespace can be
+// removed safely. Whitespace around + and - is significant in calc(),
+// and whitespace before : is significant in selectors like a :hover.
+const cssSeparators = "{};,>"
+
+// minifyCSS removes comments, collapses whitespace and removes it around
+// separators. Strings are kept as-is.
+func minifyCSS(content []byte) []byte {
+	var (
+		buf     bytes.Buffer
+		pending bool
+	)
+
+	buf.Grow(len(content))
+
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+
+		switch {
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := bytes.Index(content[i+2:], []byte("*/"))
+			if end < 0 {
+				return buf.Bytes()
+			}
+			i += 2 + end + 1
+			pending = true
+
+		case isSpace(c):
+			pending = true
+
+		default:
+			if pending && buf.Len() > 0 && !strings.ContainsRune(cssSeparators, rune(c)) && !endsWithAny(&buf, cssSeparators+":") {
+				buf.WriteByte(' ')
+			}
+			pending = false
+
+			// The last declaration of a block doesn't need a semicolon.
+			if c == '}' && endsWithAny(&buf, ";") {
+				buf.Truncate(buf.Len() - 1)
+			}
+
+			if c == '"' || c == '\'' {
+				end := stringEnd(content, i)
+				buf.Write(content[i:end])
+				i = end - 1
+				continue
+			}
+
+			buf.WriteByte(c)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// endsWithAny reports whether the last byte in buf is one of chars.
+func endsWithAny(buf *bytes.Buffer, chars string) bool {
+	b := buf.Bytes()
+	return len(b) > 0 && strings.IndexByte(chars, b[len(b)-1]) >= 0
+}
+
+// stringEnd returns the index after the closing quote of the string that
+// starts at index start. Escaped quotes don't close the string.
+func stringEnd(content []byte, start int) int {
+	quote := content[start]
+
+	for i := start + 1; i < len(content); i++ {
+		switch content[i] {
+		case '\\':
+			i++
+		case quote, '\n':
+			return i + 1
+		}
+	}
+
+	return len(content)
+}
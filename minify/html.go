@@ -0,0 +1,200 @@
+package minify
+
+import (
+	"bytes"
+	"strings"
+)
+
+// preservedTags contains all elements whose content is written as-is,
+// because whitespace is significant inside of them.
+var preservedTags = map[string]bool{
+	"pre":      true,
+	"code":     true,
+	"textarea": true,
+}
+
+// minifyHTML removes comments and collapses whitespace outside of tags
+// to a single space. Whitespace isn't removed completely, because it is
+// significant between inline elements. Inline scripts and styles are
+// minified as well.
+func minifyHTML(content []byte) []byte {
+	var (
+		buf bytes.Buffer
+		i   = 0
+	)
+
+	buf.Grow(len(content))
+
+	for i < len(content) {
+		switch {
+		case bytes.HasPrefix(content[i:], []byte("<!--")):
+			end := bytes.Index(content[i+4:], []byte("-->"))
+			if end < 0 {
+				buf.Write(content[i:])
+				return buf.Bytes()
+			}
+			end += i + 4 + len("-->")
+			// Conditional comments are interpreted by some browsers.
+			if bytes.HasPrefix(content[i:], []byte("<!--[if")) {
+				buf.Write(content[i:end])
+			}
+			i = end
+
+		case content[i] == '<' && i+1 < len(content) && isTagStart(content[i+1]):
+			end := tagEnd(content, i)
+			tag := content[i:end]
+			buf.Write(collapseTag(tag))
+			i = end
+
+			name := tagName(tag)
+			if name == "" || tag[1] == '/' || bytes.HasSuffix(tag, []byte("/>")) {
+				continue
+			}
+
+			if preservedTags[name] || name == "script" || name == "style" {
+				closing := closingTag(content, i, name)
+				inner := content[i:closing]
+
+				switch {
+				case name == "style":
+					inner = minifyCSS(inner)
+				case name == "script" && isJavaScript(tag):
+					inner = minifyJS(inner)
+				}
+
+				buf.Write(inner)
+				i = closing
+			}
+
+		case isSpace(content[i]):
+			for i < len(content) && isSpace(content[i]) {
+				i++
+			}
+			// Removed comments may leave whitespace on both sides.
+			if buf.Len() > 0 && i < len(content) && !endsWithAny(&buf, " ") {
+				buf.WriteByte(' ')
+			}
+
+		default:
+			buf.WriteByte(content[i])
+			i++
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// isTagStart reports whether c may follow the < of a tag.
+func isTagStart(c byte) bool {
+	return c == '/' || c == '!' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// tagEnd returns the index after the > closing the tag that starts at
+// index start. Quoted attribute values may contain a >.
+func tagEnd(content []byte, start int) int {
+	var quote byte
+
+	for i := start + 1; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '>':
+			return i + 1
+		}
+	}
+
+	return len(content)
+}
+
+// collapseTag collapses all whitespace inside a tag to a single space,
+// except for whitespace inside quoted attribute values.
+func collapseTag(tag []byte) []byte {
+	var (
+		buf   bytes.Buffer
+		quote byte
+	)
+
+	for i := 0; i < len(tag); i++ {
+		c := tag[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case isSpace(c):
+			for i+1 < len(tag) && isSpace(tag[i+1]) {
+				i++
+			}
+			if i+1 < len(tag) && tag[i+1] != '>' {
+				buf.WriteByte(' ')
+			}
+			continue
+		}
+		buf.WriteByte(c)
+	}
+
+	return buf.Bytes()
+}
+
+// tagName returns the lowercased element name of a tag like <pre> or
+// </pre>. For comments and doctypes, it returns "".
+func tagName(tag []byte) string {
+	name := bytes.TrimLeft(tag[1:], "/")
+
+	for i, c := range name {
+		if isSpace(c) || c == '>' || c == '/' {
+			name = name[:i]
+			break
+		}
+	}
+
+	if len(name) == 0 || name[0] == '!' {
+		return ""
+	}
+
+	return strings.ToLower(string(name))
+}
+
+// closingTag returns the index of the closing tag of the element with
+// the given name, starting the search at index start. If there is no
+// closing tag, the end of the content is returned.
+func closingTag(content []byte, start int, name string) int {
+	lower := bytes.ToLower(content[start:])
+	closing := []byte("</" + name)
+
+	for offset := 0; ; {
+		i := bytes.Index(lower[offset:], closing)
+		if i < 0 {
+			return len(content)
+		}
+		i += offset
+
+		// A closing tag like </pre might be the prefix of another element.
+		if end := i + len(closing); end == len(lower) || lower[end] == '>' || isSpace(lower[end]) {
+			return start + i
+		}
+		offset = i + len(closing)
+	}
+}
+
+// isJavaScript reports whether a <script> tag contains JavaScript and not
+// data like JSON or a template.
+func isJavaScript(tag []byte) bool {
+	lower := strings.ToLower(string(tag))
+
+	i := strings.Index(lower, "type=")
+	if i < 0 {
+		return true
+	}
+
+	value := strings.Trim(strings.Fields(lower[i+len("type="):] + " ")[0], `"'>/`)
+
+	return value == "" || value == "module" || strings.HasSuffix(value, "javascript")
+}
@@ -0,0 +1,72 @@
+package minify
+
+import (
+	"bytes"
+	"strings"
+)
+
+// minifyJS removes indentation, trailing whitespace, empty lines and
+// lines only containing a // comment. Line breaks are kept, because
+// automatic semicolon insertion depends on them. Lines inside template
+// literals are kept as-is.
+func minifyJS(content []byte) []byte {
+	var (
+		buf        bytes.Buffer
+		inTemplate bool
+	)
+
+	buf.Grow(len(content))
+
+	for _, line := range strings.Split(string(content), "\n") {
+		startsInTemplate := inTemplate
+		inTemplate = endsInTemplate(line, inTemplate)
+
+		if !startsInTemplate {
+			line = strings.TrimLeft(line, " \t\r")
+			if line == "" || strings.HasPrefix(line, "//") && !inTemplate {
+				continue
+			}
+		}
+		if !inTemplate {
+			line = strings.TrimRight(line, " \t\r")
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(line)
+	}
+
+	return buf.Bytes()
+}
+
+// endsInTemplate reports whether the given line ends inside of a template
+// literal. inTemplate indicates whether the line starts inside of one.
+func endsInTemplate(line string, inTemplate bool) bool {
+	var quote byte
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+
+		switch {
+		case c == '\\':
+			i++
+		case inTemplate:
+			if c == '`' {
+				inTemplate = false
+			}
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '`':
+			inTemplate = true
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return false
+		}
+	}
+
+	return inTemplate
+}
@@ -0,0 +1,67 @@
+// Package minify provides minifiers for the HTML, CSS and JavaScript
+// files written by verless.
+package minify
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// HTML is the media type of HTML files.
+	HTML string = "text/html"
+	// CSS is the media type of CSS files.
+	CSS string = "text/css"
+	// JS is the media type of JavaScript files.
+	JS string = "application/javascript"
+)
+
+// Minifier represents a minifier that removes unnecessary characters
+// from HTML, CSS or JavaScript files without changing their behavior.
+type Minifier interface {
+	// Minify minifies content of the given media type. Content of media
+	// types that aren't supported is returned unchanged.
+	Minify(mediaType string, content []byte) ([]byte, error)
+}
+
+// MediaType returns the media type of a file based on its extension. If
+// the file isn't an HTML, CSS or JavaScript file, it returns "".
+func MediaType(file string) string {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".html", ".htm":
+		return HTML
+	case ".css":
+		return CSS
+	case ".js":
+		return JS
+	}
+	return ""
+}
+
+// New creates the default minifier. It performs conservative
+// optimizations like removing comments and collapsing whitespace and
+// never touches the content of <pre>, <code> and <textarea> elements.
+func New() Minifier {
+	return &minifier{}
+}
+
+// minifier is the default Minifier implementation.
+type minifier struct{}
+
+// Minify minifies HTML, CSS and JavaScript content.
+func (m *minifier) Minify(mediaType string, content []byte) ([]byte, error) {
+	switch mediaType {
+	case HTML:
+		return minifyHTML(content), nil
+	case CSS:
+		return minifyCSS(content), nil
+	case JS:
+		return minifyJS(content), nil
+	}
+	return content, nil
+}
+
+// isSpace reports whether c is a whitespace character in HTML and CSS.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
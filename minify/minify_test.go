@@ -0,0 +1,168 @@
+package minify
+
+import (
+	"testing"
+
+	"github.com/verless/verless/test"
+)
+
+// TestMinifier_Minify checks if the default minifier removes unnecessary
+// characters and makes the content smaller.
+func TestMinifier_Minify(t *testing.T) {
+	tests := map[string]struct {
+		mediaType string
+		content   string
+		expected  string
+	}{
+		"HTML document": {
+			mediaType: HTML,
+			content: `<!DOCTYPE html>
+<html>
+    <head>
+        <!-- Styles -->
+        <link rel="stylesheet"   href="/css/style.css" />
+    </head>
+    <body>
+        <h1 class="title">
+            Coffee
+        </h1>
+        <p><b>Espresso</b> <i>and</i> milk</p>
+    </body>
+</html>
+`,
+			expected: `<!DOCTYPE html> <html> <head> <link rel="stylesheet" href="/css/style.css" /> </head> <body> <h1 class="title"> Coffee </h1> <p><b>Espresso</b> <i>and</i> milk</p> </body> </html>`,
+		},
+		"HTML with attribute values containing whitespace": {
+			mediaType: HTML,
+			content:   `<img alt="a  cup   of coffee"   src="/img/coffee.png">`,
+			expected:  `<img alt="a  cup   of coffee" src="/img/coffee.png">`,
+		},
+		"HTML with conditional comments": {
+			mediaType: HTML,
+			content:   "<!--[if IE]><p>Upgrade your browser</p><![endif]-->\n<!-- Content -->\n<p>Coffee</p>",
+			expected:  "<!--[if IE]><p>Upgrade your browser</p><![endif]--> <p>Coffee</p>",
+		},
+		"HTML with inline styles and scripts": {
+			mediaType: HTML,
+			content:   "<style>\n  body {\n    color: #333;\n  }\n</style>\n<script>\n  // Greet.\n  console.log('hi')\n</script>",
+			expected:  "<style>body{color:#333}</style> <script>console.log('hi')</script>",
+		},
+		"HTML with JSON script": {
+			mediaType: HTML,
+			content:   "<script type=\"application/ld+json\">\n  {\"name\": \"Coffee\"}\n</script>",
+			expected:  "<script type=\"application/ld+json\">\n  {\"name\": \"Coffee\"}\n</script>",
+		},
+		"CSS stylesheet": {
+			mediaType: CSS,
+			content: `/* Layout */
+body ,
+main > section {
+    margin: 0 auto;
+    width: calc(100% - 2rem);
+}
+
+a :hover { color: red !important; }
+`,
+			expected: `body,main>section{margin:0 auto;width:calc(100% - 2rem)}a :hover{color:red !important}`,
+		},
+		"CSS with strings": {
+			mediaType: CSS,
+			content:   `p::before { content: "  /* not a comment */  "; }`,
+			expected:  `p::before{content:"  /* not a comment */  "}`,
+		},
+		"JavaScript": {
+			mediaType: JS,
+			content: `// Initialize the page.
+function init() {
+    const url = "https://example.com"
+
+    return url
+}
+`,
+			expected: "function init() {\nconst url = \"https://example.com\"\nreturn url\n}",
+		},
+		"JavaScript with template literals": {
+			mediaType: JS,
+			content:   "const html = `\n    <p>\n\n        Coffee\n    </p>`\n    render(html)\n",
+			expected:  "const html = `\n    <p>\n\n        Coffee\n    </p>`\nrender(html)",
+		},
+	}
+
+	m := New()
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		minified, err := m.Minify(testCase.mediaType, []byte(testCase.content))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, string(minified))
+		test.Assert(t, len(minified) <= len(testCase.content), "minified content should not be larger")
+	}
+}
+
+// TestMinifier_Minify_codeBlocks checks if whitespace inside of code
+// blocks is preserved.
+func TestMinifier_Minify_codeBlocks(t *testing.T) {
+	tests := map[string]struct {
+		content string
+	}{
+		"pre element": {
+			content: "<pre>\nfunc main() {\n    fmt.Println(\"hi\")\n}\n</pre>",
+		},
+		"code block rendered from Markdown": {
+			content: "<pre><code class=\"language-go\">func main() {\n\tif true {\n\t\treturn\n\t}\n}\n</code></pre>",
+		},
+		"inline code": {
+			content: "<code>a  =  b</code>",
+		},
+		"uppercase tags": {
+			content: "<PRE>\n  indented\n    more\n</PRE>",
+		},
+		"textarea": {
+			content: "<textarea>\n  Dear  barista,\n</textarea>",
+		},
+	}
+
+	m := New()
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		html := "<div>\n    " + testCase.content + "\n</div>"
+
+		minified, err := m.Minify(HTML, []byte(html))
+		test.Ok(t, err)
+		test.Equals(t, "<div> "+testCase.content+" </div>", string(minified))
+	}
+}
+
+// TestMediaType checks if MediaType detects the media types of HTML, CSS
+// and JavaScript files.
+func TestMediaType(t *testing.T) {
+	tests := map[string]struct {
+		file     string
+		expected string
+	}{
+		"HTML file": {
+			file:     "/target/blog/index.html",
+			expected: HTML,
+		},
+		"CSS file": {
+			file:     "/target/css/style.CSS",
+			expected: CSS,
+		},
+		"JavaScript file": {
+			file:     "/target/js/main.js",
+			expected: JS,
+		},
+		"image": {
+			file:     "/target/img/coffee.png",
+			expected: "",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+		test.Equals(t, testCase.expected, MediaType(testCase.file))
+	}
+}
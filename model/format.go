@@ -0,0 +1,63 @@
+package model
+
+import (
+	"path"
+	"strings"
+)
+
+const (
+	// DefaultMediaType is the media type of output formats that don't
+	// declare a media type.
+	DefaultMediaType string = "text/html"
+)
+
+// OutputFormat is an additional rendering of pages like a plain text
+// version, which is rendered next to the HTML file of each page.
+type OutputFormat struct {
+	// Template is the template used for rendering pages in the format.
+	Template string
+	// MediaType is the media type of the rendered files like text/plain.
+	// If it is empty, DefaultMediaType is used.
+	MediaType string
+	// Extension replaces the .html extension of the rendered files, like
+	// txt for index.txt or amp.html for index.amp.html.
+	Extension string
+	// Sections restricts the format to pages inside the given routes like
+	// /blog. If it is empty, all pages are rendered in the format.
+	Sections []string
+}
+
+// Includes reports whether pages with the given route are rendered in the
+// output format.
+func (f *OutputFormat) Includes(route string) bool {
+	if len(f.Sections) == 0 {
+		return true
+	}
+
+	for _, section := range f.Sections {
+		section = path.Clean("/" + section)
+		if route == section || section == "/" || strings.HasPrefix(route, section+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Alternate is the rendering of a page in an output format.
+type Alternate struct {
+	// Name is the name of the output format like amp.
+	Name      string
+	MediaType string
+	// Href is the path of the rendered file like /blog/coffee/index.txt.
+	Href string
+}
+
+// AlternateHref returns the path of the file the page with the given Href
+// is rendered to in an output format with the given extension.
+func AlternateHref(href, extension string, cleanURLs bool) string {
+	if cleanURLs {
+		return path.Join(href, "index."+extension)
+	}
+	return strings.TrimSuffix(href, ".html") + "." + extension
+}
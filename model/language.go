@@ -0,0 +1,10 @@
+package model
+
+// Language represents a language of a multilingual site.
+type Language struct {
+	// Code is the language code like en or de.
+	Code string
+	Name string
+	// Href links to the home page of the language, like /de.
+	Href string
+}
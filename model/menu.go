@@ -0,0 +1,15 @@
+package model
+
+// Menu represents a named menu defined in the project configuration. Its
+// entries are sorted by their weight.
+type Menu []MenuEntry
+
+// MenuEntry represents an entry of a menu.
+type MenuEntry struct {
+	Name   string
+	URL    string
+	Weight int
+	// Active indicates that the entry links to the rendered page or to a
+	// section containing the rendered page.
+	Active bool
+}
@@ -4,6 +4,9 @@ import "time"
 
 const (
 	customListPageID string = "index"
+	// TagTaxonomy is the name of the taxonomy whose terms are the tags
+	// of a page.
+	TagTaxonomy string = "tag"
 )
 
 // Page represents a sub-page of the website.
@@ -14,17 +17,56 @@ type Page struct {
 	Title       string
 	Author      string
 	Date        time.Time
+	Modified    time.Time
 	Tags        []string
 	Img         string
 	Credit      string
 	Description string
+	// Canonical is the preferred URL of the page, e.g. the URL of the
+	// original article for a cross-post. Unless it is set in the front
+	// matter, it is derived from the base URL and Href.
+	Canonical string
+	// Slug replaces the filename as last segment of the route like intro
+	// for /blog/intro. The ID of the page is the slugified value.
+	Slug string
+	// Aliases are the former paths of the page like /old-path/. A stub
+	// redirecting to the page is written to each of them.
+	Aliases []string
+	// Taxonomies contains the terms of all configured taxonomies except
+	// for tags, keyed by the singular taxonomy name like category.
+	Taxonomies  map[string][]string
 	Content     string
+	Summary     string
+	TOC         TOC
+	WordCount   int
+	ReadingTime int
 	Related     []*Page
+	Similar     []*Page
 	Type        *Type
-	Hidden      bool
+	Template    string
+	Language    string
+	// Prev and Next are the pages listed before and after the page in its
+	// section, i.e. among the pages with the same route. They're nil for
+	// the first and the last page, respectively.
+	Prev *Page
+	Next *Page
+	// Translations contains the pages with the same path in all other
+	// languages of a multilingual site.
+	Translations []*Page
+	Hidden       bool
+	Draft        bool
+	Weight       int
+	NoIndex      bool
+	// Alternates contains the renderings of the page in all configured
+	// output formats, ordered by the name of the format.
+	Alternates []Alternate
+	// Diagrams reports whether the content contains diagrams that are
+	// rendered by a client-side library like Mermaid.
+	Diagrams bool
 
 	providedRelated []string
 	providedType    string
+	missingFields   []string
 }
 
 // IsCustomListPage returns whether the page is a custom list page that has
@@ -33,6 +75,15 @@ func (p *Page) IsCustomListPage() bool {
 	return p.ID == customListPageID
 }
 
+// Terms returns the terms of the given taxonomy like category. The terms
+// of the tag taxonomy are the tags of the page.
+func (p *Page) Terms(taxonomy string) []string {
+	if taxonomy == TagTaxonomy {
+		return p.Tags
+	}
+	return p.Taxonomies[taxonomy]
+}
+
 // ProvidedRelated returns all Fully Qualified Name URIs related to the page.
 func (p *Page) ProvidedRelated() []string {
 	return p.providedRelated
@@ -53,11 +104,37 @@ func (p *Page) SetProvidedType(providedType string) {
 	p.providedType = providedType
 }
 
+// MissingFields returns all required front matter fields that haven't
+// been provided for the page.
+func (p *Page) MissingFields() []string {
+	return p.missingFields
+}
+
+// AddMissingField records a required front matter field that hasn't been
+// provided for the page.
+func (p *Page) AddMissingField(field string) {
+	p.missingFields = append(p.missingFields, field)
+}
+
 // ListPage represents an overview page that is generated for
 // each content sub-directory.
 type ListPage struct {
 	Page
 	Pages []*Page
+	// TagList contains all tags of the website. It is only populated for
+	// the tag index generated by the tags plugin.
+	TagList []Tag
+	// TermList contains all terms of a taxonomy. It is only populated for
+	// the term index of each taxonomy, like /categories.
+	TermList []Term
+}
+
+// Tag represents a tag along with the number of pages using it.
+type Tag struct {
+	Name  string
+	Slug  string
+	Href  string
+	Count int
 }
 
 // Type represents a page type.
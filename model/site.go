@@ -13,6 +13,19 @@ type Site struct {
 	Nav    Nav
 	Root   *Node
 	Footer Footer
+	// Data contains the contents of all data files, keyed by their path
+	// inside the data directory without extension, like Data.authors for
+	// data/authors.yml.
+	Data map[string]interface{}
+	// Menus contains all menus defined in the project configuration, like
+	// Menus.main for the menu called main.
+	Menus map[string]Menu
+	// Languages contains all languages of a multilingual site, starting
+	// with the default language.
+	Languages []Language
+	// Taxonomies contains all taxonomies including tags, keyed by their
+	// plural name like Taxonomies.categories.
+	Taxonomies map[string]*Taxonomy
 }
 
 // NewSite creates a new, fully initialized Site instance.
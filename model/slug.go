@@ -0,0 +1,106 @@
+package model
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations contains the ASCII replacements of letters that can't
+// be decomposed into a base letter and diacritical marks, like ß.
+var transliterations = map[rune]string{
+	'ß': "ss",
+	'æ': "ae",
+	'Æ': "AE",
+	'œ': "oe",
+	'Œ': "OE",
+	'ø': "o",
+	'Ø': "O",
+	'đ': "d",
+	'Đ': "D",
+	'ð': "d",
+	'Ð': "D",
+	'ł': "l",
+	'Ł': "L",
+	'þ': "th",
+	'Þ': "TH",
+	'ı': "i",
+}
+
+// SlugOptions configure how texts are converted into slugs.
+type SlugOptions struct {
+	// Lowercase converts all letters into lowercase.
+	Lowercase bool
+	// Transliterate replaces letters with accents and other diacritical
+	// marks with their base letter, like é with e, and letters like ß
+	// with their ASCII spelling.
+	Transliterate bool
+}
+
+// Slugify converts a text into the form used in URLs. Spaces, underscores,
+// dots and dashes are replaced with single dashes, and all characters
+// except for letters and digits are removed, e.g. "Making Coffee!" becomes
+// "Making-Coffee", or "making-coffee" with SlugOptions.Lowercase.
+func Slugify(text string, options SlugOptions) string {
+	if options.Transliterate {
+		text = transliterate(text)
+	}
+
+	if options.Lowercase {
+		text = strings.ToLower(text)
+	}
+
+	var (
+		slug      strings.Builder
+		separated bool
+	)
+
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if separated && slug.Len() > 0 {
+				slug.WriteByte('-')
+			}
+			slug.WriteRune(r)
+			separated = false
+		case unicode.IsSpace(r) || strings.ContainsRune("_.-", r):
+			separated = true
+		}
+	}
+
+	return slug.String()
+}
+
+// SlugifyRoute slugifies all segments of a route like /Blog/Café, keeping
+// segments that would be empty as they are.
+func SlugifyRoute(route string, options SlugOptions) string {
+	segments := strings.Split(route, "/")
+
+	for i, segment := range segments {
+		if slug := Slugify(segment, options); slug != "" {
+			segments[i] = slug
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// transliterate removes all diacritical marks from the given text and
+// replaces the letters in transliterations.
+func transliterate(text string) string {
+	var result strings.Builder
+
+	for _, r := range norm.NFD.String(text) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if replacement, exists := transliterations[r]; exists {
+			result.WriteString(replacement)
+			continue
+		}
+		result.WriteRune(r)
+	}
+
+	return norm.NFC.String(result.String())
+}
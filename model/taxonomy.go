@@ -0,0 +1,24 @@
+package model
+
+// Taxonomy groups pages by terms provided in their front matter, like
+// tags or categories.
+type Taxonomy struct {
+	// Name is the singular name of the taxonomy like category.
+	Name string
+	// Plural is the plural name of the taxonomy like categories. It is
+	// used as directory of the term pages.
+	Plural string
+	// Terms contains all terms of the taxonomy, starting with the most
+	// used term.
+	Terms []Term
+}
+
+// Term represents a term of a taxonomy along with all pages using it.
+type Term struct {
+	Name  string
+	Slug  string
+	Href  string
+	Count int
+	// Pages contains all pages using the term, sorted by date.
+	Pages []*Page
+}
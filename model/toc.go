@@ -0,0 +1,55 @@
+package model
+
+import (
+	"html"
+	"strings"
+)
+
+// TOC represents the table of contents of a page. Each heading contains
+// all subsequent headings of lower levels as its children.
+type TOC []TOCEntry
+
+// TOCEntry represents a heading in the table of contents.
+type TOCEntry struct {
+	// Title is the plain text of the heading.
+	Title string
+	// ID is the heading's id attribute that can be used as anchor.
+	ID string
+	// Level is the heading level, e.g. 2 for <h2>.
+	Level    int
+	Children TOC
+}
+
+// String renders the table of contents as nested HTML lists linking to
+// the headings, so that it can be used as {{.Page.TOC}} in templates.
+func (t TOC) String() string {
+	if len(t) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	t.render(&sb)
+
+	return sb.String()
+}
+
+// render writes the table of contents as nested <ul> elements into sb.
+func (t TOC) render(sb *strings.Builder) {
+	sb.WriteString("<ul>")
+
+	for _, entry := range t {
+		sb.WriteString(`<li><a href="#`)
+		sb.WriteString(html.EscapeString(entry.ID))
+		sb.WriteString(`">`)
+		sb.WriteString(html.EscapeString(entry.Title))
+		sb.WriteString("</a>")
+
+		if len(entry.Children) > 0 {
+			entry.Children.render(sb)
+		}
+
+		sb.WriteString("</li>")
+	}
+
+	sb.WriteString("</ul>")
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	// htmlExtension is the file extension of rendered pages.
+	htmlExtension string = ".html"
+	// indexFile is the filename of rendered list pages.
+	indexFile string = "index.html"
+)
+
+// PageHref returns the Href of the page with the given route and ID. With
+// clean URLs, the Href points to the page's directory like /blog/coffee,
+// otherwise it points to the page's file like /blog/coffee.html.
+func PageHref(route, id string, cleanURLs bool) string {
+	href := path.Join("/", route, id)
+
+	if !cleanURLs {
+		href += htmlExtension
+	}
+
+	return href
+}
+
+// ListPageHref returns the Href of the list page with the given route.
+// With clean URLs, the Href points to the list page's directory like
+// /blog, otherwise it points to its index file like /blog/index.html.
+func ListPageHref(route string, cleanURLs bool) string {
+	href := path.Join("/", route)
+
+	if !cleanURLs {
+		href = path.Join(href, indexFile)
+	}
+
+	return href
+}
+
+// BasePath returns the path of the given base URL without a trailing
+// slash, like /blog for https://example.com/blog/. If the website isn't
+// served under a sub-path, BasePath returns an empty string.
+func BasePath(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSuffix(path.Clean("/"+u.Path), "/")
+}
@@ -0,0 +1,104 @@
+package out
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"github.com/verless/verless/out/style"
+)
+
+// Level is the verbosity of a Logger. A Logger prints all messages up to
+// its own level.
+type Level int
+
+const (
+	// LevelError only prints errors.
+	LevelError Level = iota
+	// LevelInfo prints errors and general information about the progress
+	// of a command. This is the default.
+	LevelInfo
+	// LevelDebug prints all messages, including each file read or written.
+	LevelDebug
+)
+
+var (
+	// defaultLogger is used by the package-level functions like T and Err.
+	defaultLogger = NewLogger(LevelInfo, os.Stdout, os.Stderr)
+)
+
+// Logger prints prefixed messages whose level doesn't exceed the level of
+// the logger. Errors are printed to a dedicated writer. A Logger is safe
+// for concurrent usage.
+type Logger struct {
+	mutex sync.Mutex
+	level Level
+	out   io.Writer
+	err   io.Writer
+}
+
+// NewLogger creates a new Logger printing messages up to the given level.
+// Errors are printed to err, all other messages to out.
+func NewLogger(level Level, out, err io.Writer) *Logger {
+	l := Logger{
+		level: level,
+		out:   out,
+		err:   err,
+	}
+
+	return &l
+}
+
+// Default returns the logger used by T and Err, which prints to stdout
+// and stderr. Its level can be changed using SetLevel.
+func Default() *Logger {
+	return defaultLogger
+}
+
+// SetLevel sets the level of the default logger.
+func SetLevel(level Level) {
+	defaultLogger.SetLevel(level)
+}
+
+// SetLevel sets the level of the logger.
+func (l *Logger) SetLevel(level Level) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.level = level
+}
+
+// Enabled reports whether messages with the given level are printed.
+func (l *Logger) Enabled(level Level) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return level <= l.level
+}
+
+// Debug prints a message only printed in verbose mode, like the name of
+// a file that has been written.
+func (l *Logger) Debug(prefix style.Emoji, format string, a ...interface{}) {
+	l.print(LevelDebug, l.out, prefix, format, a...)
+}
+
+// Info prints a message that is printed unless the logger is quiet.
+func (l *Logger) Info(prefix style.Emoji, format string, a ...interface{}) {
+	l.print(LevelInfo, l.out, prefix, format, a...)
+}
+
+// Error prints an error message, which is printed at every level.
+func (l *Logger) Error(prefix style.Emoji, format string, a ...interface{}) {
+	l.print(LevelError, l.err, prefix, format, a...)
+}
+
+func (l *Logger) print(level Level, w io.Writer, prefix style.Emoji, format string, a ...interface{}) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if level > l.level {
+		return
+	}
+
+	printf(w, prefix, format, a...)
+}
@@ -0,0 +1,63 @@
+package out
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/verless/verless/out/style"
+	"github.com/verless/verless/test"
+)
+
+// TestLogger checks if a Logger only prints the messages up to its level
+// and prints errors to the error writer.
+func TestLogger(t *testing.T) {
+	tests := map[string]struct {
+		level       Level
+		expectedOut string
+		expectedErr string
+	}{
+		"quiet": {
+			level:       LevelError,
+			expectedErr: "❌ error\n",
+		},
+		"default": {
+			level:       LevelInfo,
+			expectedOut: "✔️ info\n",
+			expectedErr: "❌ error\n",
+		},
+		"verbose": {
+			level:       LevelDebug,
+			expectedOut: "debug\n✔️ info\n",
+			expectedErr: "❌ error\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		var stdout, stderr bytes.Buffer
+
+		logger := NewLogger(testCase.level, &stdout, &stderr)
+		logger.Debug(style.None, "%s", "debug")
+		logger.Info(style.HeavyCheckMark, "%s", "info")
+		logger.Error(style.X, "%s", "error")
+
+		test.Equals(t, testCase.expectedOut, stdout.String())
+		test.Equals(t, testCase.expectedErr, stderr.String())
+	}
+}
+
+// TestLogger_SetLevel checks if changing the level of a Logger affects
+// the messages printed afterwards.
+func TestLogger_SetLevel(t *testing.T) {
+	var stdout bytes.Buffer
+
+	logger := NewLogger(LevelInfo, &stdout, &stdout)
+	logger.Debug(style.None, "hidden")
+
+	logger.SetLevel(LevelDebug)
+	test.Assert(t, logger.Enabled(LevelDebug), "debug messages should be enabled")
+	logger.Debug(style.None, "shown")
+
+	test.Equals(t, "shown\n", stdout.String())
+}
@@ -10,24 +10,20 @@ package out
 import (
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/verless/verless/out/style"
 )
 
-var (
-	outFile io.Writer = os.Stdout
-	errFile io.Writer = os.Stderr
-)
-
-// T prints a prefixed, formatted text to the out file as a new line.
+// T prints a prefixed, formatted text to the out file as a new line. The
+// text is printed at info level using the default logger.
 func T(prefix style.Emoji, format string, a ...interface{}) {
-	printf(outFile, prefix, format, a...)
+	defaultLogger.Info(prefix, format, a...)
 }
 
 // Err prints a prefixed, formatted text to the error file as a new line.
+// Errors are printed at every level.
 func Err(prefix style.Emoji, format string, a ...interface{}) {
-	printf(errFile, prefix, format, a...)
+	defaultLogger.Error(prefix, format, a...)
 }
 
 func printf(w io.Writer, prefix style.Emoji, format string, a ...interface{}) {
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	// DefaultDiagramElement is the HTML element wrapping diagrams if no
+	// element has been configured.
+	DefaultDiagramElement string = "pre"
+)
+
+var (
+	// ErrInvalidDiagramElement states that the configured element for
+	// wrapping diagrams isn't an HTML element name.
+	ErrInvalidDiagramElement = errors.New("invalid diagram element")
+
+	// elementName matches the names of HTML elements like pre or div.
+	elementName = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+	// kindDiagram is the node kind of diagramBlock.
+	kindDiagram = gast.NewNodeKind("Diagram")
+
+	// diagramsKey stores whether a document contains diagrams inside the
+	// parser context.
+	diagramsKey = parser.NewContextKey()
+)
+
+// DiagramOptions configure fenced code blocks containing diagrams, like
+// code blocks tagged with mermaid. Diagrams aren't highlighted but passed
+// through, so that a client-side library can render them.
+type DiagramOptions struct {
+	// Languages contains the languages of code blocks that are diagrams.
+	// If it is empty, no code block is treated as a diagram.
+	Languages []string
+	// Element is the HTML element wrapping a diagram, whose class is the
+	// language like <pre class="mermaid">. If it is empty,
+	// DefaultDiagramElement is used.
+	Element string
+}
+
+// element returns the effective element wrapping diagrams.
+func (o DiagramOptions) element() (string, error) {
+	if o.Element == "" {
+		return DefaultDiagramElement, nil
+	}
+
+	if !elementName.MatchString(o.Element) {
+		return "", fmt.Errorf("%s: %w", o.Element, ErrInvalidDiagramElement)
+	}
+
+	return o.Element, nil
+}
+
+// diagramBlock is a fenced code block containing a diagram.
+type diagramBlock struct {
+	gast.BaseBlock
+	language string
+}
+
+// Kind implements gast.Node.Kind.
+func (d *diagramBlock) Kind() gast.NodeKind {
+	return kindDiagram
+}
+
+// Dump implements gast.Node.Dump.
+func (d *diagramBlock) Dump(src []byte, level int) {
+	gast.DumpHelper(d, src, level, map[string]string{"Language": d.language}, nil)
+}
+
+// diagrams is a Markdown extension that turns fenced code blocks in one
+// of the diagram languages into diagram blocks.
+type diagrams struct {
+	languages map[string]bool
+	element   string
+}
+
+// newDiagrams creates the diagram extension for the given options.
+func newDiagrams(options DiagramOptions) (*diagrams, error) {
+	element, err := options.element()
+	if err != nil {
+		return nil, err
+	}
+
+	d := diagrams{
+		languages: make(map[string]bool, len(options.Languages)),
+		element:   element,
+	}
+
+	for _, language := range options.Languages {
+		d.languages[language] = true
+	}
+
+	return &d, nil
+}
+
+// Extend implements goldmark.Extender.Extend.
+func (d *diagrams) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(d, 1000),
+	))
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(d, 100),
+	))
+}
+
+// Transform implements parser.ASTTransformer.Transform. It replaces all
+// fenced code blocks in a diagram language with diagram blocks.
+func (d *diagrams) Transform(doc *gast.Document, reader text.Reader, pc parser.Context) {
+	var blocks []*gast.FencedCodeBlock
+
+	_ = gast.Walk(doc, func(n gast.Node, entering bool) (gast.WalkStatus, error) {
+		if block, ok := n.(*gast.FencedCodeBlock); ok && entering {
+			if d.languages[string(block.Language(reader.Source()))] {
+				blocks = append(blocks, block)
+			}
+		}
+		return gast.WalkContinue, nil
+	})
+
+	for _, block := range blocks {
+		diagram := diagramBlock{language: string(block.Language(reader.Source()))}
+		diagram.SetLines(block.Lines())
+		block.Parent().ReplaceChild(block.Parent(), block, &diagram)
+	}
+
+	if len(blocks) > 0 {
+		pc.Set(diagramsKey, true)
+	}
+}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (d *diagrams) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindDiagram, d.renderDiagram)
+}
+
+// renderDiagram renders a diagram as escaped text inside the configured
+// element, like <pre class="mermaid">graph TD; A-->B</pre>.
+func (d *diagrams) renderDiagram(w util.BufWriter, src []byte, node gast.Node, entering bool) (gast.WalkStatus, error) {
+	if !entering {
+		return gast.WalkContinue, nil
+	}
+
+	diagram := node.(*diagramBlock)
+
+	_, _ = fmt.Fprintf(w, `<%s class="%s">`, d.element, util.EscapeHTML([]byte(diagram.language)))
+
+	for i := 0; i < diagram.Lines().Len(); i++ {
+		line := diagram.Lines().At(i)
+		_, _ = w.Write(util.EscapeHTML(line.Value(src)))
+	}
+
+	_, _ = fmt.Fprintf(w, "</%s>\n", d.element)
+
+	return gast.WalkContinue, nil
+}
@@ -0,0 +1,313 @@
+package parser
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// emojis maps the names of the supported emoji shortcodes like tada to
+// their emoji. The names are the ones used by GitHub and Slack.
+var emojis = map[string]string{
+	// Smileys and people.
+	"smile":                 "😄",
+	"smiley":                "😃",
+	"grinning":              "😀",
+	"grin":                  "😁",
+	"laughing":              "😆",
+	"sweat_smile":           "😅",
+	"joy":                   "😂",
+	"rofl":                  "🤣",
+	"blush":                 "😊",
+	"innocent":              "😇",
+	"slightly_smiling_face": "🙂",
+	"upside_down_face":      "🙃",
+	"wink":                  "😉",
+	"relieved":              "😌",
+	"heart_eyes":            "😍",
+	"kissing_heart":         "😘",
+	"yum":                   "😋",
+	"stuck_out_tongue":      "😛",
+	"sunglasses":            "😎",
+	"nerd_face":             "🤓",
+	"thinking":              "🤔",
+	"neutral_face":          "😐",
+	"expressionless":        "😑",
+	"no_mouth":              "😶",
+	"smirk":                 "😏",
+	"unamused":              "😒",
+	"roll_eyes":             "🙄",
+	"grimacing":             "😬",
+	"pensive":               "😔",
+	"sleepy":                "😪",
+	"sleeping":              "😴",
+	"mask":                  "😷",
+	"dizzy_face":            "😵",
+	"exploding_head":        "🤯",
+	"confused":              "😕",
+	"worried":               "😟",
+	"frowning_face":         "☹️",
+	"open_mouth":            "😮",
+	"astonished":            "😲",
+	"flushed":               "😳",
+	"cry":                   "😢",
+	"sob":                   "😭",
+	"scream":                "😱",
+	"angry":                 "😠",
+	"rage":                  "😡",
+	"skull":                 "💀",
+	"poop":                  "💩",
+	"clown_face":            "🤡",
+	"ghost":                 "👻",
+	"alien":                 "👽",
+	"robot":                 "🤖",
+	"wave":                  "👋",
+	"ok_hand":               "👌",
+	"v":                     "✌️",
+	"crossed_fingers":       "🤞",
+	"point_up":              "☝️",
+	"point_right":           "👉",
+	"point_left":            "👈",
+	"point_down":            "👇",
+	"+1":                    "👍",
+	"thumbsup":              "👍",
+	"-1":                    "👎",
+	"thumbsdown":            "👎",
+	"fist":                  "✊",
+	"clap":                  "👏",
+	"raised_hands":          "🙌",
+	"pray":                  "🙏",
+	"handshake":             "🤝",
+	"muscle":                "💪",
+	"eyes":                  "👀",
+	"brain":                 "🧠",
+	// Symbols.
+	"heart":              "❤️",
+	"broken_heart":       "💔",
+	"sparkling_heart":    "💖",
+	"100":                "💯",
+	"boom":               "💥",
+	"collision":          "💥",
+	"sparkles":           "✨",
+	"star":               "⭐",
+	"star2":              "🌟",
+	"zap":                "⚡",
+	"fire":               "🔥",
+	"dizzy":              "💫",
+	"speech_balloon":     "💬",
+	"thought_balloon":    "💭",
+	"zzz":                "💤",
+	"warning":            "⚠️",
+	"no_entry":           "⛔",
+	"x":                  "❌",
+	"heavy_check_mark":   "✔️",
+	"white_check_mark":   "✅",
+	"question":           "❓",
+	"exclamation":        "❗",
+	"bangbang":           "‼️",
+	"information_source": "ℹ️",
+	"recycle":            "♻️",
+	"arrow_right":        "➡️",
+	"arrow_left":         "⬅️",
+	"arrow_up":           "⬆️",
+	"arrow_down":         "⬇️",
+	// Celebrations and objects.
+	"tada":                     "🎉",
+	"confetti_ball":            "🎊",
+	"balloon":                  "🎈",
+	"gift":                     "🎁",
+	"trophy":                   "🏆",
+	"medal_sports":             "🏅",
+	"crown":                    "👑",
+	"gem":                      "💎",
+	"bulb":                     "💡",
+	"memo":                     "📝",
+	"pencil":                   "📝",
+	"pencil2":                  "✏️",
+	"book":                     "📖",
+	"books":                    "📚",
+	"bookmark":                 "🔖",
+	"link":                     "🔗",
+	"paperclip":                "📎",
+	"pushpin":                  "📌",
+	"calendar":                 "📆",
+	"date":                     "📅",
+	"clipboard":                "📋",
+	"package":                  "📦",
+	"email":                    "📧",
+	"envelope":                 "✉️",
+	"phone":                    "☎️",
+	"iphone":                   "📱",
+	"computer":                 "💻",
+	"keyboard":                 "⌨️",
+	"camera":                   "📷",
+	"tv":                       "📺",
+	"mag":                      "🔍",
+	"lock":                     "🔒",
+	"unlock":                   "🔓",
+	"key":                      "🔑",
+	"hammer":                   "🔨",
+	"wrench":                   "🔧",
+	"gear":                     "⚙️",
+	"bell":                     "🔔",
+	"hourglass":                "⌛",
+	"watch":                    "⌚",
+	"alarm_clock":              "⏰",
+	"moneybag":                 "💰",
+	"chart_with_upwards_trend": "📈",
+	"rocket":                   "🚀",
+	"airplane":                 "✈️",
+	"car":                      "🚗",
+	"bike":                     "🚲",
+	"construction":             "🚧",
+	"triangular_flag_on_post":  "🚩",
+	"checkered_flag":           "🏁",
+	"art":                      "🎨",
+	"musical_note":             "🎵",
+	"headphones":               "🎧",
+	"video_game":               "🎮",
+	"bug":                      "🐛",
+	"lipstick":                 "💄",
+	"ring":                     "💍",
+	// Nature.
+	"sunny":            "☀️",
+	"cloud":            "☁️",
+	"umbrella":         "☔",
+	"snowflake":        "❄️",
+	"rainbow":          "🌈",
+	"ocean":            "🌊",
+	"earth_africa":     "🌍",
+	"earth_americas":   "🌎",
+	"earth_asia":       "🌏",
+	"crescent_moon":    "🌙",
+	"seedling":         "🌱",
+	"herb":             "🌿",
+	"four_leaf_clover": "🍀",
+	"evergreen_tree":   "🌲",
+	"deciduous_tree":   "🌳",
+	"cactus":           "🌵",
+	"rose":             "🌹",
+	"sunflower":        "🌻",
+	"cherry_blossom":   "🌸",
+	"fallen_leaf":      "🍂",
+	"dog":              "🐶",
+	"cat":              "🐱",
+	"mouse":            "🐭",
+	"rabbit":           "🐰",
+	"fox_face":         "🦊",
+	"bear":             "🐻",
+	"panda_face":       "🐼",
+	"koala":            "🐨",
+	"tiger":            "🐯",
+	"lion":             "🦁",
+	"cow":              "🐮",
+	"pig":              "🐷",
+	"frog":             "🐸",
+	"monkey":           "🐒",
+	"see_no_evil":      "🙈",
+	"chicken":          "🐔",
+	"penguin":          "🐧",
+	"bird":             "🐦",
+	"owl":              "🦉",
+	"unicorn":          "🦄",
+	"bee":              "🐝",
+	"butterfly":        "🦋",
+	"snail":            "🐌",
+	"turtle":           "🐢",
+	"snake":            "🐍",
+	"octopus":          "🐙",
+	"fish":             "🐟",
+	"whale":            "🐳",
+	"dolphin":          "🐬",
+	"crab":             "🦀",
+	// Food and drink.
+	"coffee":         "☕",
+	"tea":            "🍵",
+	"beer":           "🍺",
+	"beers":          "🍻",
+	"wine_glass":     "🍷",
+	"cocktail":       "🍸",
+	"tropical_drink": "🍹",
+	"champagne":      "🍾",
+	"milk_glass":     "🥛",
+	"apple":          "🍎",
+	"green_apple":    "🍏",
+	"banana":         "🍌",
+	"cherries":       "🍒",
+	"grapes":         "🍇",
+	"lemon":          "🍋",
+	"peach":          "🍑",
+	"strawberry":     "🍓",
+	"watermelon":     "🍉",
+	"avocado":        "🥑",
+	"tomato":         "🍅",
+	"carrot":         "🥕",
+	"corn":           "🌽",
+	"bread":          "🍞",
+	"croissant":      "🥐",
+	"cheese":         "🧀",
+	"egg":            "🥚",
+	"bacon":          "🥓",
+	"hamburger":      "🍔",
+	"fries":          "🍟",
+	"pizza":          "🍕",
+	"hotdog":         "🌭",
+	"taco":           "🌮",
+	"burrito":        "🌯",
+	"ramen":          "🍜",
+	"spaghetti":      "🍝",
+	"sushi":          "🍣",
+	"rice":           "🍚",
+	"cake":           "🍰",
+	"birthday":       "🎂",
+	"cookie":         "🍪",
+	"doughnut":       "🍩",
+	"chocolate_bar":  "🍫",
+	"candy":          "🍬",
+	"icecream":       "🍦",
+	"popcorn":        "🍿",
+}
+
+// emoji is a Markdown extension replacing emoji shortcodes like :tada:
+// with their emoji. Since code spans and code blocks aren't parsed for
+// inline elements, shortcodes inside code remain unchanged.
+type emoji struct{}
+
+// Extend implements goldmark.Extender.Extend.
+func (e *emoji) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(parser.WithInlineParsers(
+		util.Prioritized(&emojiParser{}, 999),
+	))
+}
+
+// emojiParser parses emoji shortcodes consisting of a known name between
+// two colons. Unknown names are left to the other parsers.
+type emojiParser struct{}
+
+// Trigger implements parser.InlineParser.Trigger.
+func (p *emojiParser) Trigger() []byte {
+	return []byte{':'}
+}
+
+// Parse implements parser.InlineParser.Parse.
+func (p *emojiParser) Parse(_ gast.Node, block text.Reader, _ parser.Context) gast.Node {
+	line, _ := block.PeekLine()
+
+	end := bytes.IndexByte(line[1:], ':')
+	if end < 1 {
+		return nil
+	}
+
+	value, exists := emojis[string(line[1:end+1])]
+	if !exists {
+		return nil
+	}
+
+	block.Advance(end + 2)
+
+	return gast.NewString([]byte(value))
+}
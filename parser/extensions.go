@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	gast "github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/extension/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+const (
+	// Footnotes enables footnotes like [^1] along with a definition
+	// like [^1]: Footnote text.
+	Footnotes string = "footnotes"
+	// DefinitionLists enables definition lists where a term is followed
+	// by a line starting with a colon.
+	DefinitionLists string = "definitionLists"
+	// Strikethrough enables strikethrough text like ~~deleted~~.
+	Strikethrough string = "strikethrough"
+)
+
+var (
+	// ErrUnknownExtension states that a configured Markdown extension
+	// doesn't exist.
+	ErrUnknownExtension = errors.New("unknown Markdown extension")
+)
+
+// extensions contains all optional Markdown extensions by their
+// lowercased names.
+var extensions = map[string]goldmark.Extender{
+	strings.ToLower(Footnotes):       &footnotes{},
+	strings.ToLower(DefinitionLists): extension.DefinitionList,
+	strings.ToLower(Strikethrough):   extension.Strikethrough,
+}
+
+// smartypants converts straight quotes to curly quotes, -- and --- to en
+// and em dashes, and ... to an ellipsis, like SmartyPants does. Unlike
+// goldmark's default, << and >> remain unchanged.
+var smartypants = extension.NewTypographer(
+	extension.WithTypographicSubstitutions(map[extension.TypographicPunctuation][]byte{
+		extension.LeftAngleQuote:  nil,
+		extension.RightAngleQuote: nil,
+	}),
+)
+
+// getExtensions returns the Markdown extensions with the given names.
+func getExtensions(names []string) ([]goldmark.Extender, error) {
+	var exts []goldmark.Extender
+
+	for _, name := range names {
+		ext, exists := extensions[strings.ToLower(name)]
+		if !exists {
+			return nil, fmt.Errorf("%s: %w", name, ErrUnknownExtension)
+		}
+		exts = append(exts, ext)
+	}
+
+	return exts, nil
+}
+
+// occurrenceAttr is the attribute storing how many references to the
+// same footnote precede a footnote link.
+var occurrenceAttr = []byte("occurrence")
+
+// footnotes extends goldmark's footnote extension so that footnotes can
+// be referenced several times without duplicating the IDs of the links.
+type footnotes struct{}
+
+// Extend implements goldmark.Extender.Extend.
+func (f *footnotes) Extend(m goldmark.Markdown) {
+	extension.Footnote.Extend(m)
+
+	m.Parser().AddOptions(parser.WithASTTransformers(
+		util.Prioritized(&footnoteTransformer{}, 1000),
+	))
+	// A lower priority value takes precedence over the link renderer of
+	// the footnote extension.
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(
+		util.Prioritized(&footnoteLinkRenderer{}, 100),
+	))
+}
+
+// footnoteTransformer numbers repeated references to the same footnote.
+type footnoteTransformer struct{}
+
+// Transform implements parser.ASTTransformer.Transform.
+func (f *footnoteTransformer) Transform(doc *gast.Document, _ text.Reader, _ parser.Context) {
+	occurrences := make(map[int]int)
+
+	_ = gast.Walk(doc, func(n gast.Node, entering bool) (gast.WalkStatus, error) {
+		if link, ok := n.(*ast.FootnoteLink); ok && entering {
+			occurrences[link.Index]++
+			link.SetAttribute(occurrenceAttr, occurrences[link.Index])
+		}
+		return gast.WalkContinue, nil
+	})
+}
+
+// footnoteLinkRenderer renders footnote links like goldmark's footnote
+// extension, but appends the occurrence to the IDs of repeated links.
+// The back link of a footnote points to its first reference.
+type footnoteLinkRenderer struct{}
+
+// RegisterFuncs implements renderer.NodeRenderer.RegisterFuncs.
+func (f *footnoteLinkRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFootnoteLink, f.renderFootnoteLink)
+}
+
+// renderFootnoteLink renders a single reference to a footnote.
+func (f *footnoteLinkRenderer) renderFootnoteLink(w util.BufWriter, _ []byte, node gast.Node, entering bool) (gast.WalkStatus, error) {
+	if !entering {
+		return gast.WalkContinue, nil
+	}
+
+	link := node.(*ast.FootnoteLink)
+	index := strconv.Itoa(link.Index)
+	id := "fnref:" + index
+
+	if occurrence, ok := link.AttributeString("occurrence"); ok && occurrence.(int) > 1 {
+		id += "-" + strconv.Itoa(occurrence.(int))
+	}
+
+	_, _ = fmt.Fprintf(w, `<sup id="%s"><a href="#fn:%s" class="footnote-ref" role="doc-noteref">%s</a></sup>`, id, index, index)
+
+	return gast.WalkContinue, nil
+}
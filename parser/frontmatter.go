@@ -0,0 +1,216 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v2"
+)
+
+// tomlLineError matches TOML errors reporting the position of the problem,
+// like (2, 7): was expecting token =, but got "\n" instead.
+var tomlLineError = regexp.MustCompile(`^\((\d+), \d+\): (.*)$`)
+
+// splitFrontMatter parses TOML front matter delimited by +++ lines or a
+// JSON object at the beginning of src, as known from Hugo. It returns the
+// metadata along with the remaining content. If src doesn't start with
+// TOML or JSON front matter, the returned metadata is nil and the YAML
+// front matter, if any, is left to the goldmark-meta extension.
+//
+// The values are converted to the types produced by the YAML parser, so
+// that all formats result in the same page: Integers become ints, and
+// dates become strings like 2020-10-14 or RFC 3339 timestamps.
+func splitFrontMatter(src []byte) (metadata, []byte, error) {
+	switch {
+	case isTOMLFrontMatter(src):
+		return splitTOML(src)
+	case isJSONFrontMatter(src):
+		return splitJSON(src)
+	}
+
+	return nil, src, nil
+}
+
+// ReadFrontMatter parses the YAML, TOML or JSON front matter at the
+// beginning of src and returns its values along with the remaining
+// content. If src doesn't start with front matter, the returned values
+// are nil. An invalid front matter results in an error wrapping
+// ErrInvalidFrontMatter.
+func ReadFrontMatter(src []byte) (map[string]interface{}, []byte, error) {
+	values, content, err := splitFrontMatter(src)
+	if err != nil || values != nil {
+		return values, content, err
+	}
+
+	return splitYAML(src)
+}
+
+// isTOMLFrontMatter reports whether src starts with a +++ line.
+func isTOMLFrontMatter(src []byte) bool {
+	line := src
+	if i := bytes.IndexByte(src, '\n'); i >= 0 {
+		line = src[:i]
+	}
+
+	return string(bytes.TrimSpace(line)) == "+++"
+}
+
+// isJSONFrontMatter reports whether src starts with a JSON object. An
+// object has to start with a key or be empty, so that content starting
+// with a shortcode like {{< youtube >}} isn't mistaken for front matter.
+func isJSONFrontMatter(src []byte) bool {
+	if len(src) == 0 || src[0] != '{' {
+		return false
+	}
+
+	rest := bytes.TrimLeft(src[1:], " \t\r\n")
+
+	return len(rest) > 0 && (rest[0] == '"' || rest[0] == '}')
+}
+
+// splitTOML parses the TOML front matter between the two +++ lines.
+func splitTOML(src []byte) (metadata, []byte, error) {
+	lines := bytes.SplitAfter(src, []byte("\n"))
+
+	var (
+		block  bytes.Buffer
+		offset = len(lines[0])
+		closed bool
+	)
+
+	for _, line := range lines[1:] {
+		offset += len(line)
+		if string(bytes.TrimSpace(line)) == "+++" {
+			closed = true
+			break
+		}
+		block.Write(line)
+	}
+
+	if !closed {
+		return nil, nil, fmt.Errorf("%w: missing closing +++", ErrInvalidFrontMatter)
+	}
+
+	tree, err := toml.LoadBytes(block.Bytes())
+	if err != nil {
+		if match := tomlLineError.FindStringSubmatch(err.Error()); match != nil {
+			// The TOML line numbers start after the opening delimiter.
+			line, _ := strconv.Atoi(match[1])
+			return nil, nil, fmt.Errorf("%w in line %d: %s", ErrInvalidFrontMatter, line+1, match[2])
+		}
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidFrontMatter, err)
+	}
+
+	return normalizeMetadata(tree.ToMap()), src[offset:], nil
+}
+
+// splitJSON parses the JSON object at the beginning of src. The content
+// starts after the line containing the closing brace.
+func splitJSON(src []byte) (metadata, []byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(src))
+	decoder.UseNumber()
+
+	var values map[string]interface{}
+
+	if err := decoder.Decode(&values); err != nil {
+		if syntaxErr, ok := err.(*json.SyntaxError); ok {
+			line := 1 + bytes.Count(src[:syntaxErr.Offset], []byte("\n"))
+			return nil, nil, fmt.Errorf("%w in line %d: %s", ErrInvalidFrontMatter, line, syntaxErr)
+		}
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidFrontMatter, strings.TrimPrefix(err.Error(), "json: "))
+	}
+
+	offset := int(decoder.InputOffset())
+	if i := bytes.IndexByte(src[offset:], '\n'); i >= 0 {
+		offset += i + 1
+	} else {
+		offset = len(src)
+	}
+
+	return normalizeMetadata(values), src[offset:], nil
+}
+
+// splitYAML parses the YAML front matter delimited by --- lines at the
+// beginning of src, which is read by the goldmark-meta extension for
+// Markdown files. If src doesn't start with YAML front matter, the
+// returned metadata is nil.
+func splitYAML(src []byte) (metadata, []byte, error) {
+	lines := bytes.SplitAfter(src, []byte("\n"))
+
+	if len(bytes.TrimSpace(lines[0])) == 0 || !isSeparator(lines[0]) {
+		return nil, src, nil
+	}
+
+	if err := checkFrontMatter(src); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		block  bytes.Buffer
+		offset = len(lines[0])
+	)
+
+	for _, line := range lines[1:] {
+		offset += len(line)
+		if isSeparator(line) {
+			break
+		}
+		block.Write(line)
+	}
+
+	var values metadata
+
+	if err := yaml.Unmarshal(block.Bytes(), &values); err != nil {
+		return nil, nil, fmt.Errorf("%w: %s", ErrInvalidFrontMatter, err)
+	}
+
+	return values, src[offset:], nil
+}
+
+// normalizeMetadata converts the values of TOML or JSON front matter to
+// the types produced by the YAML parser.
+func normalizeMetadata(values map[string]interface{}) metadata {
+	m := make(metadata, len(values))
+
+	for key, value := range values {
+		m[key] = normalizeValue(value)
+	}
+
+	return m
+}
+
+// normalizeValue converts a single TOML or JSON value, see
+// normalizeMetadata.
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return map[string]interface{}(normalizeMetadata(v))
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeValue(item)
+		}
+		return v
+	case int64:
+		return int(v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return int(i)
+		}
+		f, _ := v.Float64()
+		return f
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case toml.LocalDate:
+		return v.String()
+	case toml.LocalDateTime:
+		return v.In(time.UTC).Format(time.RFC3339)
+	}
+
+	return value
+}
@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/verless/verless/model"
+)
+
+var (
+	// htmlScripts matches script and style elements, whose contents are
+	// no text of the page.
+	htmlScripts = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)\s*>`)
+	// htmlTags matches HTML tags and comments.
+	htmlTags = regexp.MustCompile(`(?s)<!--.*?-->|<[^>]*>`)
+)
+
+// html is a parser for hand-authored HTML content files. The front matter
+// is read just like the front matter of Markdown files, but the content
+// is passed through as-is.
+type html struct {
+	wordsPerMinute int
+	summaryLength  int
+	taxonomies     []string
+}
+
+// NewHTML initializes and returns a new HTML parser. Only the options
+// that don't concern Markdown rendering are used, i.e. WordsPerMinute,
+// SummaryLength and Taxonomies.
+func NewHTML(options Options) (*html, error) {
+	if options.WordsPerMinute < 0 {
+		return nil, ErrInvalidWordsPerMinute
+	}
+	if options.WordsPerMinute == 0 {
+		options.WordsPerMinute = DefaultWordsPerMinute
+	}
+
+	if options.SummaryLength < 0 {
+		return nil, ErrInvalidSummaryLength
+	}
+	if options.SummaryLength == 0 {
+		options.SummaryLength = DefaultSummaryLength
+	}
+
+	h := html{
+		wordsPerMinute: options.WordsPerMinute,
+		summaryLength:  options.SummaryLength,
+		taxonomies:     options.Taxonomies,
+	}
+	return &h, nil
+}
+
+// ParsePage reads the front matter of an HTML content file and uses the
+// remaining HTML as page content without converting it. The summary is
+// read from the front matter or consists of everything before a line
+// containing <!--more-->, otherwise the first words of the content are
+// used.
+//
+// Like for Markdown files, the front matter may be YAML, TOML or JSON,
+// and ParsePage returns an error wrapping ErrInvalidFrontMatter if it is
+// invalid.
+func (h *html) ParsePage(src []byte) (model.Page, error) {
+	var page model.Page
+
+	frontMatter, src, err := ReadFrontMatter(src)
+	if err != nil {
+		return page, err
+	}
+
+	summary, content, hasMarker := splitHTMLSummary(string(src))
+
+	page.Content = content
+	page.WordCount = countHTMLWords(content)
+	page.ReadingTime = readingTime(page.WordCount, h.wordsPerMinute)
+
+	if err := checkFieldTypes(frontMatter); err != nil {
+		return page, err
+	}
+
+	readMetadata(frontMatter, &page)
+	readTaxonomies(frontMatter, h.taxonomies, &page)
+
+	// A summary from the front matter is HTML as well and therefore kept.
+	switch {
+	case page.Summary != "":
+	case hasMarker:
+		page.Summary = summary
+	default:
+		page.Summary = truncateHTML(page.Content, h.summaryLength)
+	}
+
+	return page, nil
+}
+
+// splitHTMLSummary returns everything before the summary marker and the
+// content without the line containing the marker. The returned bool is
+// false if there is no summary marker on its own line.
+func splitHTMLSummary(content string) (string, string, bool) {
+	offset := 0
+
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if strings.TrimSpace(line) == summaryMarker {
+			return content[:offset], content[:offset] + content[offset+len(line):], true
+		}
+		offset += len(line)
+	}
+
+	return "", content, false
+}
+
+// countHTMLWords counts the words of the text inside HTML content. Tags,
+// comments, scripts and styles are not considered as text.
+func countHTMLWords(content string) int {
+	text := htmlScripts.ReplaceAllString(content, " ")
+	text = htmlTags.ReplaceAllString(text, " ")
+
+	return len(strings.Fields(text))
+}
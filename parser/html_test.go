@@ -0,0 +1,79 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/verless/verless/test"
+)
+
+// TestHTML_ParsePage checks if the front matter of an HTML content file
+// is read and if the remaining HTML is used as content without changes.
+func TestHTML_ParsePage(t *testing.T) {
+	parser, err := NewHTML(Options{SummaryLength: 2, Taxonomies: []string{"category"}})
+	test.Ok(t, err)
+
+	tests := map[string]struct {
+		src             string
+		expectedTitle   string
+		expectedContent string
+		expectedSummary string
+		expectedWords   int
+		expectedMissing []string
+		expectedError   error
+	}{
+		"YAML front matter": {
+			src:             "---\nTitle: Espresso\nCategory: Guides\n---\n<h1>Espresso</h1>\n<p>Use *fresh* beans.</p>\n",
+			expectedTitle:   "Espresso",
+			expectedContent: "<h1>Espresso</h1>\n<p>Use *fresh* beans.</p>\n",
+			expectedSummary: "<h1>Espresso</h1>\n<p>Use…</p>",
+			expectedWords:   4,
+		},
+		"TOML front matter": {
+			src:             "+++\nTitle = \"Espresso\"\n+++\n<p>Espresso</p>\n",
+			expectedTitle:   "Espresso",
+			expectedContent: "<p>Espresso</p>\n",
+			expectedSummary: "<p>Espresso</p>\n",
+			expectedWords:   1,
+		},
+		"without front matter": {
+			src:             "<p>Espresso</p>\n",
+			expectedContent: "<p>Espresso</p>\n",
+			expectedSummary: "<p>Espresso</p>\n",
+			expectedWords:   1,
+			expectedMissing: []string{"Title"},
+		},
+		"summary marker": {
+			src:             "---\nTitle: Espresso\n---\n<p>Strong coffee.</p>\n<!--more-->\n<p>Made with pressure.</p>\n",
+			expectedTitle:   "Espresso",
+			expectedContent: "<p>Strong coffee.</p>\n<p>Made with pressure.</p>\n",
+			expectedSummary: "<p>Strong coffee.</p>\n",
+			expectedWords:   5,
+		},
+		"front matter summary with scripts and comments": {
+			src:             "---\nTitle: Espresso\nSummary: <em>Strong</em> coffee\n---\n<!-- draft -->\n<script>var x = 1;</script>\n<p>Espresso</p>\n",
+			expectedTitle:   "Espresso",
+			expectedContent: "<!-- draft -->\n<script>var x = 1;</script>\n<p>Espresso</p>\n",
+			expectedSummary: "<em>Strong</em> coffee",
+			expectedWords:   1,
+		},
+		"invalid front matter": {
+			src:           "---\nTitle: [Espresso\n---\n<p>Espresso</p>\n",
+			expectedError: ErrInvalidFrontMatter,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		page, err := parser.ParsePage([]byte(testCase.src))
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			continue
+		}
+
+		test.Equals(t, testCase.expectedTitle, page.Title)
+		test.Equals(t, testCase.expectedContent, page.Content)
+		test.Equals(t, testCase.expectedSummary, page.Summary)
+		test.Equals(t, testCase.expectedWords, page.WordCount)
+		test.Equals(t, testCase.expectedMissing, page.MissingFields())
+	}
+}
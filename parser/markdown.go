@@ -2,47 +2,259 @@ package parser
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 
+	chromahtml "github.com/alecthomas/chroma/formatters/html"
+	"github.com/alecthomas/chroma/styles"
 	"github.com/verless/verless/model"
 	"github.com/yuin/goldmark"
 	highlighting "github.com/yuin/goldmark-highlighting"
 	meta "github.com/yuin/goldmark-meta"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
 )
 
+const (
+	// DefaultStyle is the highlighting style used if no style has been
+	// configured.
+	DefaultStyle string = "github"
+)
+
+var (
+	// ErrUnknownStyle states that the configured highlighting style
+	// doesn't exist.
+	ErrUnknownStyle = errors.New("unknown highlighting style")
+	// ErrInvalidWordsPerMinute states that the configured reading speed
+	// is negative.
+	ErrInvalidWordsPerMinute = errors.New("words per minute must not be negative")
+	// ErrInvalidSummaryLength states that the configured summary length
+	// is negative.
+	ErrInvalidSummaryLength = errors.New("summary length must not be negative")
+	// ErrInvalidFrontMatter states that the front matter of a Markdown
+	// file is not valid YAML, TOML or JSON.
+	ErrInvalidFrontMatter = errors.New("invalid front matter")
+)
+
+// Options configure how Markdown content is rendered.
+type Options struct {
+	// Highlight configures the syntax highlighting of code blocks.
+	Highlight HighlightOptions
+	// Extensions contains the names of all enabled optional extensions
+	// like Footnotes.
+	Extensions []string
+	// TOC configures the table of contents of each page.
+	TOC TOCOptions
+	// WordsPerMinute is the reading speed used for estimating the reading
+	// time of a page. If it is 0, DefaultWordsPerMinute is used.
+	WordsPerMinute int
+	// SummaryLength is the number of words of summaries generated from the
+	// page content. If it is 0, DefaultSummaryLength is used.
+	SummaryLength int
+	// Taxonomies contains the singular names of all taxonomies like
+	// category, whose terms are read from the front matter.
+	Taxonomies []string
+	// Emoji replaces emoji shortcodes like :tada: with their emoji.
+	Emoji bool
+	// Smartypants converts quotes, dashes and ellipses to their
+	// typographic counterparts like curly quotes.
+	Smartypants bool
+	// Diagrams configures the code blocks that contain diagrams.
+	Diagrams DiagramOptions
+}
+
+// HighlightOptions configure the syntax highlighting of fenced code blocks
+// with a language hint. Code blocks in unknown languages are rendered as
+// plain <pre><code> elements.
+type HighlightOptions struct {
+	// Enabled enables syntax highlighting. If it is false, all code blocks
+	// are rendered as plain <pre><code> elements.
+	Enabled bool
+	// Style is the name of a Chroma style like monokai. If it is empty,
+	// DefaultStyle is used.
+	Style string
+	// LineNumbers prefixes each line of a code block with its number.
+	LineNumbers bool
+}
+
 // NewMarkdown initializes and returns a new Markdown parser.
-func NewMarkdown() *markdown {
+func NewMarkdown(options Options) (*markdown, error) {
+	optional, err := getExtensions(options.Extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := append([]goldmark.Extender{meta.Meta}, optional...)
+
+	if options.Emoji {
+		extensions = append(extensions, &emoji{})
+	}
+
+	if options.Smartypants {
+		extensions = append(extensions, smartypants)
+	}
+
+	diagrams, err := newDiagrams(options.Diagrams)
+	if err != nil {
+		return nil, err
+	}
+	if len(options.Diagrams.Languages) > 0 {
+		extensions = append(extensions, diagrams)
+	}
+
+	if options.Highlight.Enabled {
+		highlighter, err := newHighlighter(options.Highlight)
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, highlighter)
+	}
+
+	minLevel, maxLevel, err := options.TOC.levels()
+	if err != nil {
+		return nil, err
+	}
+
+	if options.WordsPerMinute < 0 {
+		return nil, ErrInvalidWordsPerMinute
+	}
+	if options.WordsPerMinute == 0 {
+		options.WordsPerMinute = DefaultWordsPerMinute
+	}
+
+	if options.SummaryLength < 0 {
+		return nil, ErrInvalidSummaryLength
+	}
+	if options.SummaryLength == 0 {
+		options.SummaryLength = DefaultSummaryLength
+	}
+
 	m := markdown{
 		gm: goldmark.New(
-			goldmark.WithExtensions(meta.Meta, highlighting.Highlighting),
+			goldmark.WithExtensions(extensions...),
+			goldmark.WithParserOptions(parser.WithAutoHeadingID()),
 		),
+		tocMinLevel:    minLevel,
+		tocMaxLevel:    maxLevel,
+		wordsPerMinute: options.WordsPerMinute,
+		summaryLength:  options.SummaryLength,
+		taxonomies:     options.Taxonomies,
+	}
+	return &m, nil
+}
+
+// newHighlighter creates the goldmark extension for highlighting code
+// blocks using Chroma.
+func newHighlighter(options HighlightOptions) (goldmark.Extender, error) {
+	style := options.Style
+	if style == "" {
+		style = DefaultStyle
 	}
-	return &m
+
+	if _, exists := styles.Registry[style]; !exists {
+		return nil, fmt.Errorf("%s: %w", style, ErrUnknownStyle)
+	}
+
+	return highlighting.NewHighlighting(
+		highlighting.WithStyle(style),
+		highlighting.WithFormatOptions(chromahtml.WithLineNumbers(options.LineNumbers)),
+	), nil
 }
 
 // markdown is an internal type that satisfies the build.Parser
 // interface and thus can be used for retrieving model.Pages.
 type markdown struct {
-	gm goldmark.Markdown
+	gm             goldmark.Markdown
+	tocMinLevel    int
+	tocMaxLevel    int
+	wordsPerMinute int
+	summaryLength  int
+	taxonomies     []string
 }
 
 // ParsePage converts the byte contents of a Markdown file to
 // an instance of model.Page.
+//
+// All headings get an id attribute generated from their text, and the
+// table of contents, the word count and the reading time are computed
+// from the parsed document.
+//
+// The summary of the page is taken from the Summary field in the front
+// matter. Otherwise, it consists of everything before a <!--more-->
+// marker or the first words of the content.
+//
+// The front matter is YAML delimited by --- lines by default. TOML front
+// matter delimited by +++ lines and JSON front matter consisting of an
+// object are supported as well. If the front matter is invalid, ParsePage
+// returns an error wrapping ErrInvalidFrontMatter that contains the line
+// of the problem.
 func (m *markdown) ParsePage(src []byte) (model.Page, error) {
 	var (
-		page model.Page
-		buf  bytes.Buffer
-		ctx  = parser.NewContext()
+		page    model.Page
+		buf     bytes.Buffer
+		summary bytes.Buffer
+		ctx     = parser.NewContext()
 	)
 
-	if err := m.gm.Convert(src, &buf, parser.WithContext(ctx)); err != nil {
+	// TOML and JSON front matter is removed before parsing the Markdown,
+	// while YAML front matter is read by the goldmark-meta extension.
+	frontMatter, src, err := splitFrontMatter(src)
+	if err != nil {
+		return page, err
+	}
+
+	if frontMatter == nil {
+		if err := checkFrontMatter(src); err != nil {
+			return page, err
+		}
+	}
+
+	doc := m.gm.Parser().Parse(text.NewReader(src), parser.WithContext(ctx))
+
+	marker := findSummaryMarker(doc, src)
+
+	if marker != nil {
+		for n := doc.FirstChild(); n != marker; n = n.NextSibling() {
+			if err := m.gm.Renderer().Render(&summary, src, n); err != nil {
+				return page, err
+			}
+		}
+		doc.RemoveChild(doc, marker)
+	}
+
+	if err := m.gm.Renderer().Render(&buf, src, doc); err != nil {
 		return page, err
 	}
 
 	page.Content = buf.String()
+	page.TOC = buildTOC(doc, src, m.tocMinLevel, m.tocMaxLevel)
+	page.WordCount = countWords(doc, src)
+	page.ReadingTime = readingTime(page.WordCount, m.wordsPerMinute)
+	page.Diagrams = ctx.Get(diagramsKey) != nil
 	metadata := meta.Get(ctx)
+	if frontMatter != nil {
+		metadata = frontMatter
+	}
+
+	if err := checkFieldTypes(metadata); err != nil {
+		return page, err
+	}
 
 	readMetadata(metadata, &page)
+	readTaxonomies(metadata, m.taxonomies, &page)
+
+	switch {
+	case page.Summary != "":
+		var converted bytes.Buffer
+		if err := m.gm.Convert([]byte(page.Summary), &converted); err != nil {
+			return page, err
+		}
+		page.Summary = converted.String()
+	case marker != nil:
+		page.Summary = summary.String()
+	default:
+		page.Summary = truncateHTML(page.Content, m.summaryLength)
+	}
 
 	return page, nil
 }
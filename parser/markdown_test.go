@@ -1,16 +1,21 @@
 package parser
 
 import (
+	"errors"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/verless/verless/model"
 	"github.com/verless/verless/test"
 )
 
 // TestMarkdown_ParsePage checks if a parsed Markdown file is
 // converted to a model.Page instance correctly.
 func TestMarkdown_ParsePage(t *testing.T) {
-	parser := NewMarkdown()
+	parser, err := NewMarkdown(Options{})
+	test.Ok(t, err)
 	tests := []struct {
 		src     string
 		title   string
@@ -29,10 +34,20 @@ Tags:
 
 This is a blog post.`,
 			title:   "Coffee Roasting Basics",
-			date:    time.Time{},
+			date:    time.Date(2020, 3, 30, 0, 0, 0, 0, time.UTC),
 			tags:    []string{"Coffee", "Roasting"},
 			content: "<p>This is a blog post.</p>\n",
 		},
+		{
+			src: `---
+Title: Coffee Roasting Basics
+Date: 2020-03-30T08:15:00+02:00
+---
+This is a blog post.`,
+			title:   "Coffee Roasting Basics",
+			date:    time.Date(2020, 3, 30, 6, 15, 0, 0, time.UTC),
+			content: "<p>This is a blog post.</p>\n",
+		},
 	}
 
 	for _, testCase := range tests {
@@ -42,7 +57,797 @@ This is a blog post.`,
 		}
 
 		test.Equals(t, testCase.title, page.Title)
+		test.Assert(t, testCase.date.Equal(page.Date), "expected date %v, got %v", testCase.date, page.Date)
 		test.Equals(t, testCase.tags, page.Tags)
 		test.Equals(t, testCase.content, page.Content)
 	}
 }
+
+// TestMarkdown_ParsePage_frontMatterFormats checks if YAML, TOML and JSON
+// front matter result in the same page.
+func TestMarkdown_ParsePage_frontMatterFormats(t *testing.T) {
+	parser, err := NewMarkdown(Options{Taxonomies: []string{"category"}})
+	test.Ok(t, err)
+
+	tests := map[string]string{
+		"YAML": `---
+Title: Coffee Roasting Basics
+Date: 2020-03-30
+Tags:
+    - Coffee
+    - Roasting
+Category: Guides
+Weight: 2
+Draft: true
+---
+
+This is a blog post.`,
+		"TOML": `+++
+Title = "Coffee Roasting Basics"
+Date = 2020-03-30
+Tags = ["Coffee", "Roasting"]
+Category = "Guides"
+Weight = 2
+Draft = true
++++
+
+This is a blog post.`,
+		"JSON": `{
+    "Title": "Coffee Roasting Basics",
+    "Date": "2020-03-30",
+    "Tags": ["Coffee", "Roasting"],
+    "Category": "Guides",
+    "Weight": 2,
+    "Draft": true
+}
+
+This is a blog post.`,
+	}
+
+	expected, err := parser.ParsePage([]byte(tests["YAML"]))
+	test.Ok(t, err)
+	test.Equals(t, "Coffee Roasting Basics", expected.Title)
+	test.Equals(t, 2, expected.Weight)
+
+	for name, src := range tests {
+		t.Log(name)
+
+		page, err := parser.ParsePage([]byte(src))
+		test.Ok(t, err)
+
+		test.Equals(t, expected.Title, page.Title)
+		test.Assert(t, expected.Date.Equal(page.Date), "expected date %v, got %v", expected.Date, page.Date)
+		test.Equals(t, expected.Tags, page.Tags)
+		test.Equals(t, expected.Taxonomies, page.Taxonomies)
+		test.Equals(t, expected.Weight, page.Weight)
+		test.Equals(t, expected.Draft, page.Draft)
+		test.Equals(t, expected.Content, page.Content)
+		test.Equals(t, expected.MissingFields(), page.MissingFields())
+	}
+}
+
+// TestMarkdown_ParsePage_missingFields checks if missing required front
+// matter fields are recorded in the parsed page.
+func TestMarkdown_ParsePage_missingFields(t *testing.T) {
+	parser, err := NewMarkdown(Options{})
+	test.Ok(t, err)
+
+	tests := map[string]struct {
+		src      string
+		expected []string
+	}{
+		"complete front matter": {
+			src: `---
+Title: Coffee Roasting Basics
+---
+This is a blog post.`,
+		},
+		"partial front matter": {
+			src: `---
+Date: 2020-03-30
+---
+This is a blog post.`,
+			expected: []string{"Title"},
+		},
+		"empty title": {
+			src: `---
+Title:
+---
+This is a blog post.`,
+			expected: []string{"Title"},
+		},
+		"no front matter": {
+			src:      `This is a blog post.`,
+			expected: []string{"Title"},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		page, err := parser.ParsePage([]byte(testCase.src))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, page.MissingFields())
+	}
+}
+
+// TestMarkdown_ParsePage_invalidFrontMatter checks if invalid YAML in the
+// front matter is reported along with the line in the Markdown file.
+func TestMarkdown_ParsePage_invalidFrontMatter(t *testing.T) {
+	parser, err := NewMarkdown(Options{})
+	test.Ok(t, err)
+
+	tests := map[string]struct {
+		src           string
+		expectedError string
+	}{
+		"valid front matter": {
+			src: "---\nTitle: Coffee\nTags:\n  - coffee\n---\nThis is a blog post.",
+		},
+		"missing colon": {
+			src:           "---\nTitle: Coffee\nTags\nAuthor: Barista\n---\nThis is a blog post.",
+			expectedError: "invalid front matter in line 4: could not find expected ':'",
+		},
+		"invalid indentation": {
+			src:           "---\nTitle: Coffee\n  Author: Barista\n---\n",
+			expectedError: "invalid front matter in line 3: mapping values are not allowed in this context",
+		},
+		"dashes inside the content": {
+			src: "This is a blog post.\n\n---\n\nTitle: : :",
+		},
+		"valid TOML front matter": {
+			src: "+++\nTitle = \"Coffee\"\n+++\nThis is a blog post.",
+		},
+		"invalid TOML front matter": {
+			src:           "+++\nTitle = \"Coffee\"\nAuthor Barista\n+++\nThis is a blog post.",
+			expectedError: "invalid front matter in line 3: was expecting token =, but got keys cannot contain new lines instead",
+		},
+		"unclosed TOML front matter": {
+			src:           "+++\nTitle = \"Coffee\"\nThis is a blog post.",
+			expectedError: "invalid front matter: missing closing +++",
+		},
+		"invalid JSON front matter": {
+			src:           "{\n  \"Title\": \"Coffee\",\n  \"Author\" \"Barista\"\n}\nThis is a blog post.",
+			expectedError: "invalid front matter in line 3: invalid character '\"' after object key",
+		},
+		"shortcode at the beginning of the content": {
+			src: "{{< youtube id >}}\n\nThis is a blog post.",
+		},
+		"wrong field types": {
+			src:           "---\nTitle: 2020\nDate: yesterday\nTags: coffee\nDraft: yes please\nWeight: high\n---\nThis is a blog post.",
+			expectedError: "invalid front matter: Title must be a string, got 2020, Date must be a date like 2020-10-14, got yesterday, Tags must be a list of strings, got coffee, Draft must be true or false, got yes please, Weight must be an integer, got high",
+		},
+		"wrong TOML field types": {
+			src:           "+++\nTitle = \"Coffee\"\nTags = [\"coffee\", 1]\nWeight = 2.5\n+++\nThis is a blog post.",
+			expectedError: "invalid front matter: Tags must be a list of strings, got [coffee 1], Weight must be an integer, got 2.5",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		_, err := parser.ParsePage([]byte(testCase.src))
+
+		if testCase.expectedError == "" {
+			test.Ok(t, err)
+			continue
+		}
+
+		test.Assert(t, errors.Is(err, ErrInvalidFrontMatter), "expected %v, got %v", ErrInvalidFrontMatter, err)
+		test.Equals(t, testCase.expectedError, err.Error())
+	}
+}
+
+// TestMarkdown_ParsePage_taxonomies checks if the terms of all configured
+// taxonomies are read from their singular and plural front matter fields.
+func TestMarkdown_ParsePage_taxonomies(t *testing.T) {
+	parser, err := NewMarkdown(Options{Taxonomies: []string{"tag", "category", "series"}})
+	test.Ok(t, err)
+
+	tests := map[string]struct {
+		src                string
+		expectedTags       []string
+		expectedTaxonomies map[string][]string
+	}{
+		"plural fields": {
+			src:          "---\nTitle: Coffee\nTags:\n  - coffee\nCategories:\n  - Drinks\n  - Recipes\nSeries:\n  - Brewing Basics\n---\n",
+			expectedTags: []string{"coffee"},
+			expectedTaxonomies: map[string][]string{
+				"category": {"Drinks", "Recipes"},
+				"series":   {"Brewing Basics"},
+			},
+		},
+		"singular fields": {
+			src:          "---\nTitle: Coffee\nTag: coffee\nCategory: Drinks\n---\n",
+			expectedTags: []string{"coffee"},
+			expectedTaxonomies: map[string][]string{
+				"category": {"Drinks"},
+			},
+		},
+		"without terms": {
+			src: "---\nTitle: Coffee\n---\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		page, err := parser.ParsePage([]byte(testCase.src))
+		test.Ok(t, err)
+
+		test.Equals(t, testCase.expectedTags, page.Tags)
+		test.Equals(t, testCase.expectedTaxonomies, page.Taxonomies)
+	}
+}
+
+// TestMarkdown_ParsePage_highlighting checks if fenced code blocks are
+// highlighted depending on the highlighting options and if code blocks
+// in unknown languages fall back to plain <pre><code> elements.
+func TestMarkdown_ParsePage_highlighting(t *testing.T) {
+	tests := map[string]struct {
+		options     HighlightOptions
+		src         string
+		contains    []string
+		notContains []string
+	}{
+		"Go code block": {
+			options:     HighlightOptions{Enabled: true},
+			src:         "```go\nfunc main() {}\n```",
+			contains:    []string{`<pre style="background-color:#fff">`, `<span style="color:#000;font-weight:bold">func</span>`},
+			notContains: []string{"<code"},
+		},
+		"Go code block with style and line numbers": {
+			options:  HighlightOptions{Enabled: true, Style: "monokai", LineNumbers: true},
+			src:      "```go\nfunc main() {}\n```",
+			contains: []string{`<pre style="color:#f8f8f2;background-color:#272822">`, `>1</span>`},
+		},
+		"unknown language": {
+			options:     HighlightOptions{Enabled: true},
+			src:         "```nosuchlang\nfunc main() {}\n```",
+			contains:    []string{"<pre><code class=\"language-nosuchlang\">func main() {}\n</code></pre>"},
+			notContains: []string{"<span"},
+		},
+		"highlighting disabled": {
+			src:         "```go\nfunc main() {}\n```",
+			contains:    []string{"<pre><code class=\"language-go\">func main() {}\n</code></pre>"},
+			notContains: []string{"<span"},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		parser, err := NewMarkdown(Options{Highlight: testCase.options})
+		test.Ok(t, err)
+
+		page, err := parser.ParsePage([]byte(testCase.src))
+		test.Ok(t, err)
+
+		for _, s := range testCase.contains {
+			test.Assert(t, strings.Contains(page.Content, s), "content should contain %s: %s", s, page.Content)
+		}
+		for _, s := range testCase.notContains {
+			test.Assert(t, !strings.Contains(page.Content, s), "content should not contain %s: %s", s, page.Content)
+		}
+	}
+}
+
+// TestNewMarkdown_unknownStyle checks if an unknown highlighting style is
+// rejected.
+func TestNewMarkdown_unknownStyle(t *testing.T) {
+	_, err := NewMarkdown(Options{Highlight: HighlightOptions{Enabled: true, Style: "no-such-style"}})
+	test.ExpectedError(t, ErrUnknownStyle, err)
+}
+
+// TestMarkdown_ParsePage_extensions checks if the optional Markdown
+// extensions are only applied if they have been enabled.
+func TestMarkdown_ParsePage_extensions(t *testing.T) {
+	tests := map[string]struct {
+		extensions []string
+		src        string
+		expected   string
+	}{
+		"footnotes": {
+			extensions: []string{Footnotes},
+			src:        "Espresso[^1].\n\n[^1]: Strong coffee.",
+			expected: `<p>Espresso<sup id="fnref:1"><a href="#fn:1" class="footnote-ref" role="doc-noteref">1</a></sup>.</p>
+<section class="footnotes" role="doc-endnotes">
+<hr>
+<ol>
+<li id="fn:1" role="doc-endnote">
+<p>Strong coffee. <a href="#fnref:1" class="footnote-backref" role="doc-backlink">&#x21a9;&#xfe0e;</a></p>
+</li>
+</ol>
+</section>
+`,
+		},
+		"footnotes disabled": {
+			src:      "Espresso[^1].\n\n[^1]: Strong coffee.",
+			expected: "<p>Espresso[^1].</p>\n<p>[^1]: Strong coffee.</p>\n",
+		},
+		"definition lists": {
+			extensions: []string{"definitionlists"},
+			src:        "Espresso\n:   Strong coffee.\n\nCrema\n:   The foam of an espresso.",
+			expected:   "<dl>\n<dt>Espresso</dt>\n<dd>Strong coffee.</dd>\n<dt>Crema</dt>\n<dd>The foam of an espresso.</dd>\n</dl>\n",
+		},
+		"definition lists disabled": {
+			src:      "Espresso\n:   Strong coffee.",
+			expected: "<p>Espresso\n:   Strong coffee.</p>\n",
+		},
+		"strikethrough": {
+			extensions: []string{Strikethrough},
+			src:        "~~Tea~~ Coffee",
+			expected:   "<p><del>Tea</del> Coffee</p>\n",
+		},
+		"strikethrough disabled": {
+			src:      "~~Tea~~ Coffee",
+			expected: "<p>~~Tea~~ Coffee</p>\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		parser, err := NewMarkdown(Options{Extensions: testCase.extensions})
+		test.Ok(t, err)
+
+		page, err := parser.ParsePage([]byte(testCase.src))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, page.Content)
+	}
+}
+
+// TestMarkdown_ParsePage_emoji checks if emoji shortcodes are replaced in
+// prose but left unchanged inside code, and only if emoji are enabled.
+func TestMarkdown_ParsePage_emoji(t *testing.T) {
+	tests := map[string]struct {
+		emoji    bool
+		src      string
+		expected string
+	}{
+		"prose": {
+			emoji:    true,
+			src:      "Fresh beans :smile: :tada:",
+			expected: "<p>Fresh beans 😄 🎉</p>\n",
+		},
+		"unknown shortcodes": {
+			emoji:    true,
+			src:      "Brew at 9:30 :no-such-emoji: and :coffee",
+			expected: "<p>Brew at 9:30 :no-such-emoji: and :coffee</p>\n",
+		},
+		"fenced code block": {
+			emoji:    true,
+			src:      "```\n:smile:\n```",
+			expected: "<pre><code>:smile:\n</code></pre>\n",
+		},
+		"code span": {
+			emoji:    true,
+			src:      "Type `:smile:` for :smile:",
+			expected: "<p>Type <code>:smile:</code> for 😄</p>\n",
+		},
+		"emoji disabled": {
+			src:      "Fresh beans :smile:",
+			expected: "<p>Fresh beans :smile:</p>\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		parser, err := NewMarkdown(Options{Emoji: testCase.emoji})
+		test.Ok(t, err)
+
+		page, err := parser.ParsePage([]byte(testCase.src))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, page.Content)
+	}
+}
+
+// TestMarkdown_ParsePage_smartypants checks if quotes, dashes and ellipses
+// are converted in prose but left unchanged inside code.
+func TestMarkdown_ParsePage_smartypants(t *testing.T) {
+	tests := map[string]struct {
+		smartypants bool
+		src         string
+		expected    string
+	}{
+		"quotes": {
+			smartypants: true,
+			src:         `"Espresso" isn't 'coffee'`,
+			expected:    "<p>&ldquo;Espresso&rdquo; isn&rsquo;t &lsquo;coffee&rsquo;</p>\n",
+		},
+		"dashes and ellipses": {
+			smartypants: true,
+			src:         "Espresso -- strong --- and more...",
+			expected:    "<p>Espresso &ndash; strong &mdash; and more&hellip;</p>\n",
+		},
+		"angle quotes": {
+			smartypants: true,
+			src:         "<< Espresso >>",
+			expected:    "<p>&lt;&lt; Espresso &gt;&gt;</p>\n",
+		},
+		"code span": {
+			smartypants: true,
+			src:         "Run `verless build --watch \"...\"` -- quickly",
+			expected:    "<p>Run <code>verless build --watch &quot;...&quot;</code> &ndash; quickly</p>\n",
+		},
+		"fenced code block": {
+			smartypants: true,
+			src:         "```\n\"Espresso\" -- ...\n```",
+			expected:    "<pre><code>&quot;Espresso&quot; -- ...\n</code></pre>\n",
+		},
+		"smartypants disabled": {
+			src:      `"Espresso" -- ...`,
+			expected: "<p>&quot;Espresso&quot; -- ...</p>\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		parser, err := NewMarkdown(Options{Smartypants: testCase.smartypants})
+		test.Ok(t, err)
+
+		page, err := parser.ParsePage([]byte(testCase.src))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, page.Content)
+	}
+}
+
+// TestMarkdown_ParsePage_diagrams checks if fenced code blocks in one of
+// the diagram languages are wrapped in the configured element without
+// being highlighted, while other code blocks remain unaffected.
+func TestMarkdown_ParsePage_diagrams(t *testing.T) {
+	tests := map[string]struct {
+		options          DiagramOptions
+		src              string
+		expected         string
+		expectedDiagrams bool
+	}{
+		"mermaid": {
+			options:          DiagramOptions{Languages: []string{"mermaid"}},
+			src:              "```mermaid\ngraph TD;\n    Beans-->Espresso;\n```",
+			expected:         "<pre class=\"mermaid\">graph TD;\n    Beans--&gt;Espresso;\n</pre>\n",
+			expectedDiagrams: true,
+		},
+		"configured element": {
+			options:          DiagramOptions{Languages: []string{"mermaid", "plantuml"}, Element: "div"},
+			src:              "```plantuml\nBeans -> Espresso\n```",
+			expected:         "<div class=\"plantuml\">Beans -&gt; Espresso\n</div>\n",
+			expectedDiagrams: true,
+		},
+		"other language": {
+			options:  DiagramOptions{Languages: []string{"mermaid"}},
+			src:      "```text\ngraph TD;\n```",
+			expected: "<pre><code class=\"language-text\">graph TD;\n</code></pre>\n",
+		},
+		"diagrams disabled": {
+			src:      "```mermaid\ngraph TD;\n```",
+			expected: "<pre><code class=\"language-mermaid\">graph TD;\n</code></pre>\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		parser, err := NewMarkdown(Options{Diagrams: testCase.options})
+		test.Ok(t, err)
+
+		page, err := parser.ParsePage([]byte(testCase.src))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, page.Content)
+		test.Equals(t, testCase.expectedDiagrams, page.Diagrams)
+	}
+}
+
+// TestMarkdown_ParsePage_diagramsHighlighting checks if diagrams aren't
+// highlighted while other code blocks still are.
+func TestMarkdown_ParsePage_diagramsHighlighting(t *testing.T) {
+	parser, err := NewMarkdown(Options{
+		Highlight: HighlightOptions{Enabled: true},
+		Diagrams:  DiagramOptions{Languages: []string{"mermaid"}},
+	})
+	test.Ok(t, err)
+
+	page, err := parser.ParsePage([]byte("```mermaid\ngraph TD;\n```\n\n```go\npackage main\n```"))
+	test.Ok(t, err)
+
+	test.Assert(t, strings.HasPrefix(page.Content, "<pre class=\"mermaid\">graph TD;\n</pre>\n"), "diagram should not be highlighted: %s", page.Content)
+	test.Assert(t, strings.Contains(page.Content, "<span"), "code block should be highlighted: %s", page.Content)
+}
+
+// TestNewMarkdown_invalidDiagramElement checks if NewMarkdown returns an
+// error for a diagram element that isn't an HTML element name.
+func TestNewMarkdown_invalidDiagramElement(t *testing.T) {
+	_, err := NewMarkdown(Options{Diagrams: DiagramOptions{Languages: []string{"mermaid"}, Element: "pre class=x"}})
+	test.ExpectedError(t, ErrInvalidDiagramElement, err)
+}
+
+// TestMarkdown_ParsePage_footnoteIDs checks if all IDs are unique when a
+// page contains several footnotes that are referenced multiple times.
+func TestMarkdown_ParsePage_footnoteIDs(t *testing.T) {
+	src := `Espresso[^espresso] with crema[^crema] is still espresso[^espresso].
+Really, it is espresso[^espresso].
+
+[^espresso]: Strong coffee.
+[^crema]: The foam of an espresso.`
+
+	parser, err := NewMarkdown(Options{Extensions: []string{Footnotes}})
+	test.Ok(t, err)
+
+	page, err := parser.ParsePage([]byte(src))
+	test.Ok(t, err)
+
+	ids := regexp.MustCompile(`id="([^"]+)"`).FindAllStringSubmatch(page.Content, -1)
+	seen := make(map[string]bool)
+
+	for _, id := range ids {
+		test.Assert(t, !seen[id[1]], "ID %s should be unique: %s", id[1], page.Content)
+		seen[id[1]] = true
+	}
+
+	test.Equals(t, 6, len(seen))
+
+	for _, id := range []string{"fnref:1", "fnref:1-2", "fnref:1-3", "fnref:2", "fn:1", "fn:2"} {
+		test.Assert(t, seen[id], "ID %s should exist: %s", id, page.Content)
+	}
+}
+
+// TestNewMarkdown_unknownExtension checks if an unknown extension is
+// rejected.
+func TestNewMarkdown_unknownExtension(t *testing.T) {
+	_, err := NewMarkdown(Options{Extensions: []string{Footnotes, "no-such-extension"}})
+	test.ExpectedError(t, ErrUnknownExtension, err)
+}
+
+// TestMarkdown_ParsePage_headingIDs checks if all headings get unique id
+// attributes, even if several headings have the same text.
+func TestMarkdown_ParsePage_headingIDs(t *testing.T) {
+	parser, err := NewMarkdown(Options{})
+	test.Ok(t, err)
+
+	page, err := parser.ParsePage([]byte("# Making Coffee\n\n## Usage\n\n## Usage\n\n### Usage"))
+	test.Ok(t, err)
+
+	expected := `<h1 id="making-coffee">Making Coffee</h1>
+<h2 id="usage">Usage</h2>
+<h2 id="usage-1">Usage</h2>
+<h3 id="usage-2">Usage</h3>
+`
+	test.Equals(t, expected, page.Content)
+}
+
+// TestMarkdown_ParsePage_toc checks if the table of contents nests each
+// heading under the preceding heading of a lower level and only contains
+// headings within the configured levels.
+func TestMarkdown_ParsePage_toc(t *testing.T) {
+	tests := map[string]struct {
+		options  TOCOptions
+		src      string
+		expected model.TOC
+	}{
+		"h2, h3 and h2": {
+			src: "## Espresso\n\n### Crema\n\n## Filter",
+			expected: model.TOC{
+				{Title: "Espresso", ID: "espresso", Level: 2, Children: model.TOC{
+					{Title: "Crema", ID: "crema", Level: 3},
+				}},
+				{Title: "Filter", ID: "filter", Level: 2},
+			},
+		},
+		"deep nesting": {
+			src: "# Coffee\n\n## Espresso\n\n### Crema\n\n#### Color\n\n## Filter",
+			expected: model.TOC{
+				{Title: "Coffee", ID: "coffee", Level: 1, Children: model.TOC{
+					{Title: "Espresso", ID: "espresso", Level: 2, Children: model.TOC{
+						{Title: "Crema", ID: "crema", Level: 3, Children: model.TOC{
+							{Title: "Color", ID: "color", Level: 4},
+						}},
+					}},
+					{Title: "Filter", ID: "filter", Level: 2},
+				}},
+			},
+		},
+		"limited levels": {
+			options: TOCOptions{MinLevel: 2, MaxLevel: 3},
+			src:     "# Coffee\n\n## Espresso\n\n### Crema\n\n#### Color\n\n## Filter `V60`",
+			expected: model.TOC{
+				{Title: "Espresso", ID: "espresso", Level: 2, Children: model.TOC{
+					{Title: "Crema", ID: "crema", Level: 3},
+				}},
+				{Title: "Filter V60", ID: "filter-v60", Level: 2},
+			},
+		},
+		"skipped level": {
+			src: "### Crema\n\n## Espresso\n\n#### Color",
+			expected: model.TOC{
+				{Title: "Crema", ID: "crema", Level: 3},
+				{Title: "Espresso", ID: "espresso", Level: 2, Children: model.TOC{
+					{Title: "Color", ID: "color", Level: 4},
+				}},
+			},
+		},
+		"no headings": {
+			src: "This is a blog post.",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		parser, err := NewMarkdown(Options{TOC: testCase.options})
+		test.Ok(t, err)
+
+		page, err := parser.ParsePage([]byte(testCase.src))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, page.TOC)
+	}
+}
+
+// TestTOC_String checks if the table of contents is rendered as nested
+// HTML lists.
+func TestTOC_String(t *testing.T) {
+	parser, err := NewMarkdown(Options{})
+	test.Ok(t, err)
+
+	page, err := parser.ParsePage([]byte("## Espresso & Milk\n\n### Crema\n\n## Filter"))
+	test.Ok(t, err)
+
+	expected := `<ul><li><a href="#espresso--milk">Espresso &amp; Milk</a><ul><li><a href="#crema">Crema</a></li></ul></li><li><a href="#filter">Filter</a></li></ul>`
+	test.Equals(t, expected, page.TOC.String())
+}
+
+// TestNewMarkdown_invalidTOCLevels checks if invalid heading levels for
+// the table of contents are rejected.
+func TestNewMarkdown_invalidTOCLevels(t *testing.T) {
+	tests := map[string]struct {
+		options TOCOptions
+	}{
+		"minimum level larger than maximum level": {
+			options: TOCOptions{MinLevel: 3, MaxLevel: 2},
+		},
+		"maximum level larger than 6": {
+			options: TOCOptions{MaxLevel: 7},
+		},
+		"negative minimum level": {
+			options: TOCOptions{MinLevel: -1},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		_, err := NewMarkdown(Options{TOC: testCase.options})
+		test.ExpectedError(t, ErrInvalidTOCLevels, err)
+	}
+}
+
+// TestMarkdown_ParsePage_wordCount checks if the word count only includes
+// the text of a page and if the reading time is rounded up.
+func TestMarkdown_ParsePage_wordCount(t *testing.T) {
+	tests := map[string]struct {
+		wordsPerMinute      int
+		src                 string
+		expectedWordCount   int
+		expectedReadingTime int
+	}{
+		"text with Markdown and HTML": {
+			src: `---
+Title: Making Coffee
+---
+# Making **barista-quality** coffee
+
+Coffee is *the* best [drink](/drinks) in the world.
+
+<div class="note">
+Not counted at all.
+</div>
+
+- Beans
+- Water`,
+			// 3 heading words, 8 paragraph words and 2 list items.
+			expectedWordCount:   13,
+			expectedReadingTime: 1,
+		},
+		"code blocks": {
+			src:                 "Espresso is strong.\n\n```go\nfunc main() {\n\tfmt.Println(\"ignored\")\n}\n```\n\n    indented code is ignored\n\nAnd tasty.",
+			expectedWordCount:   5,
+			expectedReadingTime: 1,
+		},
+		"custom reading speed": {
+			wordsPerMinute:      100,
+			src:                 strings.Repeat("coffee ", 250),
+			expectedWordCount:   250,
+			expectedReadingTime: 3,
+		},
+		"default reading speed": {
+			src:                 strings.Repeat("coffee ", 1000),
+			expectedWordCount:   1000,
+			expectedReadingTime: 5,
+		},
+		"empty page": {
+			src: "---\nTitle: Empty\n---\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		parser, err := NewMarkdown(Options{WordsPerMinute: testCase.wordsPerMinute})
+		test.Ok(t, err)
+
+		page, err := parser.ParsePage([]byte(testCase.src))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedWordCount, page.WordCount)
+		test.Equals(t, testCase.expectedReadingTime, page.ReadingTime)
+	}
+}
+
+// TestMarkdown_ParsePage_summary checks if the summary is taken from the
+// front matter, a <!--more--> marker or the first words of the content.
+func TestMarkdown_ParsePage_summary(t *testing.T) {
+	tests := map[string]struct {
+		summaryLength   int
+		src             string
+		expectedSummary string
+		expectedContent string
+	}{
+		"summary marker": {
+			src:             "# Coffee\n\nCoffee is **great**.\n\n<!--more-->\n\nMore about coffee.",
+			expectedSummary: "<h1 id=\"coffee\">Coffee</h1>\n<p>Coffee is <strong>great</strong>.</p>\n",
+			expectedContent: "<h1 id=\"coffee\">Coffee</h1>\n<p>Coffee is <strong>great</strong>.</p>\n<p>More about coffee.</p>\n",
+		},
+		"summary marker with spaces": {
+			src:             "Coffee is great.\n\n<!-- more -->\n\nMore about coffee.",
+			expectedSummary: "<p>Coffee is great.</p>\n",
+			expectedContent: "<p>Coffee is great.</p>\n<p>More about coffee.</p>\n",
+		},
+		"front matter summary overrides marker": {
+			src:             "---\nSummary: All about *coffee*.\n---\nCoffee is great.\n\n<!--more-->\n\nMore about coffee.",
+			expectedSummary: "<p>All about <em>coffee</em>.</p>\n",
+			expectedContent: "<p>Coffee is great.</p>\n<p>More about coffee.</p>\n",
+		},
+		"truncated content": {
+			summaryLength:   4,
+			src:             "Coffee is the best drink in the world.",
+			expectedSummary: "<p>Coffee is the best…</p>",
+			expectedContent: "<p>Coffee is the best drink in the world.</p>\n",
+		},
+		"truncated content with open elements": {
+			summaryLength:   3,
+			src:             "Coffee is [the best **drink**](/drinks \"All drinks\") in the world.",
+			expectedSummary: "<p>Coffee is <a href=\"/drinks\" title=\"All drinks\">the…</a></p>",
+			expectedContent: "<p>Coffee is <a href=\"/drinks\" title=\"All drinks\">the best <strong>drink</strong></a> in the world.</p>\n",
+		},
+		"truncated content with void elements": {
+			summaryLength:   2,
+			src:             "Coffee ![a cup of coffee](/cup.png) is the best drink.",
+			expectedSummary: "<p>Coffee <img src=\"/cup.png\" alt=\"a cup of coffee\"> is…</p>",
+			expectedContent: "<p>Coffee <img src=\"/cup.png\" alt=\"a cup of coffee\"> is the best drink.</p>\n",
+		},
+		"short content": {
+			src:             "Coffee is great.",
+			expectedSummary: "<p>Coffee is great.</p>\n",
+			expectedContent: "<p>Coffee is great.</p>\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		parser, err := NewMarkdown(Options{SummaryLength: testCase.summaryLength})
+		test.Ok(t, err)
+
+		page, err := parser.ParsePage([]byte(testCase.src))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedSummary, page.Summary)
+		test.Equals(t, testCase.expectedContent, page.Content)
+	}
+}
+
+// TestNewMarkdown_invalidSummaryLength checks if a negative summary length
+// is rejected.
+func TestNewMarkdown_invalidSummaryLength(t *testing.T) {
+	_, err := NewMarkdown(Options{SummaryLength: -1})
+	test.ExpectedError(t, ErrInvalidSummaryLength, err)
+}
@@ -1,17 +1,35 @@
 package parser
 
 import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/verless/verless/model"
+	"github.com/verless/verless/taxonomy"
+	"gopkg.in/yaml.v2"
 )
 
 const (
 	// dateFormat is the default date format expected for
-	// the Date field in Markdown files.
+	// the Date field in Markdown files. RFC 3339 timestamps
+	// are accepted as well.
 	dateFormat = "2006-01-02"
 )
 
+var (
+	// requiredFields are all metadata fields that should be provided for
+	// each page. Missing fields are recorded in the page.
+	requiredFields = []string{"Title"}
+
+	// yamlLineError matches YAML errors reporting the line of the problem,
+	// like yaml: line 2: mapping values are not allowed in this context.
+	yamlLineError = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+)
+
 type (
 	// metadata represents a set of metadata.
 	metadata map[string]interface{}
@@ -34,9 +52,108 @@ type (
 	assignFn func(val interface{})
 )
 
+// The kinds of front matter fields, described like in the error messages
+// of checkFieldTypes.
+const (
+	stringType = "a string"
+	dateType   = "a date like 2020-10-14"
+	listType   = "a list of strings"
+	boolType   = "true or false"
+	intType    = "an integer"
+)
+
+// fieldTypes contains the expected kinds of all known front matter fields.
+var fieldTypes = []struct {
+	field string
+	kind  string
+}{
+	{"Title", stringType},
+	{"Author", stringType},
+	{"Date", dateType},
+	{"Tags", listType},
+	{"Img", stringType},
+	{"Credit", stringType},
+	{"Description", stringType},
+	{"Canonical", stringType},
+	{"Slug", stringType},
+	{"Aliases", listType},
+	{"Summary", stringType},
+	{"Related", listType},
+	{"Type", stringType},
+	{"Layout", stringType},
+	{"Template", stringType},
+	{"Language", stringType},
+	{"Hidden", boolType},
+	{"Draft", boolType},
+	{"Weight", intType},
+	{"NoIndex", boolType},
+}
+
+// checkFieldTypes returns an error wrapping ErrInvalidFrontMatter if a
+// known front matter field has the wrong type, like a Title that is a
+// number or a Draft field that isn't a boolean.
+func checkFieldTypes(metadata metadata) error {
+	var problems []string
+
+	for _, fieldType := range fieldTypes {
+		value, exists := metadata[fieldType.field]
+		if !exists || value == nil || hasType(value, fieldType.kind) {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf("%s must be %s, got %v", fieldType.field, fieldType.kind, value))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidFrontMatter, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// hasType reports whether the value is of the given kind of fieldTypes.
+func hasType(value interface{}, kind string) bool {
+	switch kind {
+	case stringType:
+		_, ok := value.(string)
+		return ok
+	case dateType:
+		date, ok := value.(string)
+		if !ok {
+			return false
+		}
+		_, err := parseDate(date)
+		return err == nil
+	case listType:
+		list, ok := value.([]interface{})
+		if !ok {
+			return false
+		}
+		for _, item := range list {
+			if _, ok := item.(string); !ok {
+				return false
+			}
+		}
+		return true
+	case boolType:
+		_, ok := value.(bool)
+		return ok
+	case intType:
+		_, ok := value.(int)
+		return ok
+	}
+
+	return true
+}
+
 // readMetadata reads values from a metadata map and assigns the
 // values to the fields of a model.Page instance.
 func readMetadata(metadata metadata, page *model.Page) {
+	for _, field := range requiredFields {
+		if val, exists := metadata[field]; !exists || val == nil || val == "" {
+			page.AddMissingField(field)
+		}
+	}
+
 	readPrimitive(metadata["Title"], func(val interface{}) {
 		page.Title = val.(string)
 	})
@@ -65,6 +182,22 @@ func readMetadata(metadata metadata, page *model.Page) {
 		page.Description = val.(string)
 	})
 
+	readPrimitive(metadata["Canonical"], func(val interface{}) {
+		page.Canonical = val.(string)
+	})
+
+	readPrimitive(metadata["Slug"], func(val interface{}) {
+		page.Slug = val.(string)
+	})
+
+	readList(metadata["Aliases"], func(val interface{}) {
+		page.Aliases = append(page.Aliases, val.(string))
+	})
+
+	readPrimitive(metadata["Summary"], func(val interface{}) {
+		page.Summary = val.(string)
+	})
+
 	readList(metadata["Related"], func(val interface{}) {
 		page.AddProvidedRelated(val.(string))
 	})
@@ -73,9 +206,29 @@ func readMetadata(metadata metadata, page *model.Page) {
 		page.SetProvidedType(val.(string))
 	})
 
+	// Layout is accepted as an alias for Template.
+	for _, field := range []string{"Layout", "Template"} {
+		readPrimitive(metadata[field], func(val interface{}) {
+			page.Template = val.(string)
+		})
+	}
+
+	readPrimitive(metadata["Language"], func(val interface{}) {
+		page.Language = strings.ToLower(val.(string))
+	})
+
 	readPrimitive(metadata["Hidden"], func(val interface{}) {
 		page.Hidden = val.(bool)
 	})
+	readPrimitive(metadata["Draft"], func(val interface{}) {
+		page.Draft = val.(bool)
+	})
+	readPrimitive(metadata["Weight"], func(val interface{}) {
+		page.Weight = val.(int)
+	})
+	readPrimitive(metadata["NoIndex"], func(val interface{}) {
+		page.NoIndex = val.(bool)
+	})
 }
 
 // readPrimitive converts a field to a primitive value and
@@ -95,7 +248,7 @@ func readDate(field interface{}, assign assignFn) {
 		return
 	}
 
-	date, err := time.Parse(dateFormat, field.(string))
+	date, err := parseDate(field.(string))
 	if err != nil {
 		panic(err)
 	}
@@ -103,6 +256,15 @@ func readDate(field interface{}, assign assignFn) {
 	assign(date)
 }
 
+// parseDate parses a date like 2020-10-14 or an RFC 3339 timestamp.
+func parseDate(value string) (time.Time, error) {
+	date, err := time.Parse(dateFormat, value)
+	if err != nil {
+		date, err = time.Parse(time.RFC3339, value)
+	}
+	return date, err
+}
+
 // readList converts a field to a list and invokes the
 // assignFn for each item in that list.
 func readList(field interface{}, assign assignFn) {
@@ -116,3 +278,88 @@ func readList(field interface{}, assign assignFn) {
 		assign(list[i])
 	}
 }
+
+// readTaxonomies reads the terms of the given taxonomies from their
+// singular and plural fields, like Category and Categories, and assigns
+// them to the page. The terms of the tag taxonomy are added to the tags
+// of the page, which are always read from the Tags field.
+func readTaxonomies(metadata metadata, taxonomies []string, page *model.Page) {
+	for _, name := range taxonomies {
+		assign := func(val interface{}) {
+			term, ok := val.(string)
+			if !ok {
+				return
+			}
+			if name == model.TagTaxonomy {
+				page.Tags = append(page.Tags, term)
+				return
+			}
+			if page.Taxonomies == nil {
+				page.Taxonomies = make(map[string][]string)
+			}
+			page.Taxonomies[name] = append(page.Taxonomies[name], term)
+		}
+
+		singular, plural := strings.Title(name), strings.Title(taxonomy.Plural(name))
+
+		// A single term may also be provided as string.
+		if _, isList := metadata[singular].([]interface{}); isList {
+			readList(metadata[singular], assign)
+		} else {
+			readPrimitive(metadata[singular], assign)
+		}
+
+		if plural != singular && name != model.TagTaxonomy {
+			readList(metadata[plural], assign)
+		}
+	}
+}
+
+// checkFrontMatter parses the front matter block at the beginning of src
+// and returns an error if it isn't valid YAML. The block is determined
+// like the goldmark-meta extension does, which silently ignores invalid
+// front matter. The returned error contains the line inside src.
+func checkFrontMatter(src []byte) error {
+	lines := bytes.SplitAfter(src, []byte("\n"))
+
+	if len(bytes.TrimSpace(lines[0])) == 0 || !isSeparator(lines[0]) {
+		return nil
+	}
+
+	var block bytes.Buffer
+
+	for _, line := range lines[1:] {
+		if isSeparator(line) {
+			break
+		}
+		block.Write(line)
+	}
+
+	var values map[string]interface{}
+
+	err := yaml.Unmarshal(block.Bytes(), &values)
+	if err == nil {
+		return nil
+	}
+
+	if match := yamlLineError.FindStringSubmatch(err.Error()); match != nil {
+		// The YAML line numbers start after the opening separator.
+		line, _ := strconv.Atoi(match[1])
+		return fmt.Errorf("%w in line %d: %s", ErrInvalidFrontMatter, line+1, match[2])
+	}
+
+	return fmt.Errorf("%w: %s", ErrInvalidFrontMatter, strings.TrimPrefix(err.Error(), "yaml: "))
+}
+
+// isSeparator reports whether line separates the front matter from the
+// content. Like in goldmark-meta, this is a line consisting of dashes
+// only, ignoring surrounding whitespace. Hence, an empty line ends the
+// front matter as well.
+func isSeparator(line []byte) bool {
+	for _, c := range bytes.TrimSpace(line) {
+		if c != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+
+	gast "github.com/yuin/goldmark/ast"
+)
+
+const (
+	// DefaultSummaryLength is the number of words of generated summaries
+	// if no summary length has been configured.
+	DefaultSummaryLength int = 70
+	// summaryMarker separates the summary of a page from the remaining
+	// content. It has to be placed on its own line.
+	summaryMarker string = "<!--more-->"
+	// ellipsis is appended to truncated summaries.
+	ellipsis string = "…"
+)
+
+// voidElements contains all HTML elements that don't have a closing tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true, "img": true,
+	"input": true, "link": true, "meta": true, "source": true, "track": true, "wbr": true,
+}
+
+// findSummaryMarker returns the summary marker if it is a direct child
+// of the document, or nil if there is no marker.
+func findSummaryMarker(doc gast.Node, src []byte) gast.Node {
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		block, ok := n.(*gast.HTMLBlock)
+		if !ok {
+			continue
+		}
+
+		var value bytes.Buffer
+		for i := 0; i < block.Lines().Len(); i++ {
+			line := block.Lines().At(i)
+			value.Write(line.Value(src))
+		}
+
+		if strings.Join(strings.Fields(value.String()), "") == summaryMarker {
+			return n
+		}
+	}
+
+	return nil
+}
+
+// truncateHTML truncates HTML content after the given number of words.
+// Tags are never cut, and all elements that are open after the last word
+// are closed. If the content doesn't exceed the number of words, it is
+// returned unchanged.
+func truncateHTML(html string, words int) string {
+	var (
+		sb       strings.Builder
+		openTags []string
+		count    int
+		inWord   bool
+	)
+
+	for i := 0; i < len(html); i++ {
+		c := html[i]
+
+		if c == '<' {
+			end := strings.IndexByte(html[i:], '>')
+			if end < 0 {
+				break
+			}
+			tag := html[i : i+end+1]
+			sb.WriteString(tag)
+			i += end
+
+			openTags = trackTag(openTags, tag)
+			inWord = false
+			continue
+		}
+
+		isSpace := c == ' ' || c == '\n' || c == '\t' || c == '\r'
+
+		if !isSpace && !inWord {
+			if count == words {
+				return closeTags(strings.TrimRight(sb.String(), " \n\t\r")+ellipsis, openTags)
+			}
+			count++
+		}
+		inWord = !isSpace
+
+		sb.WriteByte(c)
+	}
+
+	return html
+}
+
+// trackTag updates the stack of open elements for the given tag.
+func trackTag(openTags []string, tag string) []string {
+	if strings.HasPrefix(tag, "<!") || strings.HasSuffix(tag, "/>") {
+		return openTags
+	}
+
+	name := strings.Trim(tag, "</>")
+	if i := strings.IndexAny(name, " \t\n\r/"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.ToLower(name)
+
+	if strings.HasPrefix(tag, "</") {
+		for i := len(openTags) - 1; i >= 0; i-- {
+			if openTags[i] == name {
+				return openTags[:i]
+			}
+		}
+		return openTags
+	}
+
+	if voidElements[name] {
+		return openTags
+	}
+
+	return append(openTags, name)
+}
+
+// closeTags appends closing tags for all open elements to html.
+func closeTags(html string, openTags []string) string {
+	var sb strings.Builder
+	sb.WriteString(html)
+
+	for i := len(openTags) - 1; i >= 0; i-- {
+		sb.WriteString("</" + openTags[i] + ">")
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,97 @@
+package parser
+
+import (
+	"errors"
+
+	"github.com/verless/verless/model"
+	gast "github.com/yuin/goldmark/ast"
+)
+
+const (
+	// minHeadingLevel is the level of <h1> headings.
+	minHeadingLevel int = 1
+	// maxHeadingLevel is the level of <h6> headings.
+	maxHeadingLevel int = 6
+)
+
+var (
+	// ErrInvalidTOCLevels states that the heading levels included in the
+	// table of contents aren't a range between 1 and 6.
+	ErrInvalidTOCLevels = errors.New("the table of contents requires heading levels from 1 to 6 with minLevel <= maxLevel")
+)
+
+// TOCOptions configure which headings are included in the table of
+// contents of a page.
+type TOCOptions struct {
+	// MinLevel is the lowest heading level included in the table of
+	// contents. If it is 0, headings starting from <h1> are included.
+	MinLevel int
+	// MaxLevel is the highest heading level included in the table of
+	// contents. If it is 0, headings up to <h6> are included.
+	MaxLevel int
+}
+
+// levels returns the effective range of heading levels.
+func (o TOCOptions) levels() (int, int, error) {
+	min, max := o.MinLevel, o.MaxLevel
+
+	if min == 0 {
+		min = minHeadingLevel
+	}
+	if max == 0 {
+		max = maxHeadingLevel
+	}
+
+	if min < minHeadingLevel || max > maxHeadingLevel || min > max {
+		return 0, 0, ErrInvalidTOCLevels
+	}
+
+	return min, max, nil
+}
+
+// buildTOC collects all headings within the given levels from the parsed
+// document and nests each heading under the preceding heading of a lower
+// level.
+func buildTOC(doc gast.Node, src []byte, minLevel, maxLevel int) model.TOC {
+	var (
+		toc model.TOC
+		// path contains pointers to the most recent entry of each
+		// nesting depth.
+		path []*model.TOCEntry
+	)
+
+	_ = gast.Walk(doc, func(n gast.Node, entering bool) (gast.WalkStatus, error) {
+		heading, ok := n.(*gast.Heading)
+		if !ok || !entering {
+			return gast.WalkContinue, nil
+		}
+		if heading.Level < minLevel || heading.Level > maxLevel {
+			return gast.WalkSkipChildren, nil
+		}
+
+		entry := model.TOCEntry{
+			Title: string(heading.Text(src)),
+			Level: heading.Level,
+		}
+		if id, ok := heading.AttributeString("id"); ok {
+			entry.ID = string(id.([]byte))
+		}
+
+		for len(path) > 0 && path[len(path)-1].Level >= entry.Level {
+			path = path[:len(path)-1]
+		}
+
+		if len(path) == 0 {
+			toc = append(toc, entry)
+			path = append(path, &toc[len(toc)-1])
+		} else {
+			parent := path[len(path)-1]
+			parent.Children = append(parent.Children, entry)
+			path = append(path, &parent.Children[len(parent.Children)-1])
+		}
+
+		return gast.WalkSkipChildren, nil
+	})
+
+	return toc
+}
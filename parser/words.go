@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"strings"
+
+	gast "github.com/yuin/goldmark/ast"
+)
+
+const (
+	// DefaultWordsPerMinute is the reading speed used for estimating the
+	// reading time if no reading speed has been configured.
+	DefaultWordsPerMinute int = 200
+)
+
+// countWords counts the words of the plain text of a parsed document.
+// Code blocks and raw HTML are not considered as text.
+func countWords(doc gast.Node, src []byte) int {
+	var sb strings.Builder
+
+	_ = gast.Walk(doc, func(n gast.Node, entering bool) (gast.WalkStatus, error) {
+		switch node := n.(type) {
+		case *gast.FencedCodeBlock, *gast.CodeBlock, *gast.HTMLBlock, *gast.RawHTML:
+			return gast.WalkSkipChildren, nil
+		case *gast.Text:
+			if entering {
+				sb.Write(node.Segment.Value(src))
+				if node.SoftLineBreak() || node.HardLineBreak() {
+					sb.WriteByte(' ')
+				}
+			}
+		case *gast.String:
+			if entering {
+				sb.Write(node.Value)
+			}
+		}
+
+		// Words in separate blocks like consecutive list items must not
+		// be joined.
+		if n.Type() == gast.TypeBlock {
+			sb.WriteByte(' ')
+		}
+
+		return gast.WalkContinue, nil
+	})
+
+	return len(strings.Fields(sb.String()))
+}
+
+// readingTime estimates the reading time in minutes for the given number
+// of words, rounded up to full minutes.
+func readingTime(words, wordsPerMinute int) int {
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
@@ -2,32 +2,83 @@
 package atom
 
 import (
-	"fmt"
+	"bytes"
+	"errors"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/feeds"
 	"github.com/spf13/afero"
+	"github.com/verless/verless/fs"
 	"github.com/verless/verless/model"
 )
 
 const (
-	// filename is the filename for the RSS feed.
+	// filename is the default filename for the Atom feed.
 	filename string = "atom.xml"
+	// rssFilename is the filename for the optional RSS 2.0 feed.
+	rssFilename string = "rss.xml"
 )
 
+var (
+	// ErrMissingBase states that the site's base URL hasn't been set,
+	// which is required to build the absolute URLs used in the feed.
+	ErrMissingBase = errors.New("the atom plugin requires site.meta.base to be set")
+)
+
+// Options configure the feed generated by the atom plugin.
+type Options struct {
+	// OutputPath is the feed's path relative to the output directory.
+	OutputPath string
+	// ItemLimit is the maximum number of feed items. If it is 0, all
+	// pages are included.
+	ItemLimit int
+	// Title overrides the site title as feed title.
+	Title string
+	// Author overrides the site author as feed author.
+	Author string
+	// Section restricts the feed to pages inside the given route.
+	Section string
+	// RSS additionally generates a RSS 2.0 feed.
+	RSS bool
+	// Created is the creation time of the feed, which is also used as its
+	// update time if no item has a date. If it is zero, the current time
+	// is used.
+	Created time.Time
+}
+
 // New creates a new atom plugin that generated a RSS feed with the
 // provided metadata and stores the XML file in outputDir.
-func New(meta *model.Meta, fs afero.Fs, outputDir string) *atom {
+func New(meta *model.Meta, options Options, fs afero.Fs, outputDir string) *atom {
+	if options.OutputPath == "" {
+		options.OutputPath = filename
+	}
+	if options.Title == "" {
+		options.Title = meta.Title
+	}
+	if options.Author == "" {
+		options.Author = meta.Author
+	}
+	if options.Created.IsZero() {
+		options.Created = time.Now()
+	}
+
+	base := strings.TrimSuffix(meta.Base, "/")
+
 	a := atom{
-		meta: meta,
+		meta:    meta,
+		options: options,
+		base:    base,
 		feed: &feeds.Feed{
-			Title:       meta.Title,
-			Link:        &feeds.Link{Href: meta.Base},
+			Title:       options.Title,
+			Link:        &feeds.Link{Href: base},
 			Description: meta.Description,
-			Author:      &feeds.Author{Name: meta.Author},
+			Author:      &feeds.Author{Name: options.Author},
 			Updated:     time.Time{},
-			Created:     time.Now(),
+			Created:     options.Created,
 			Subtitle:    meta.Subtitle,
 		},
 		fs:        fs,
@@ -41,19 +92,25 @@ func New(meta *model.Meta, fs afero.Fs, outputDir string) *atom {
 // as a feeds.Feed and renders those items in a XML file.s
 type atom struct {
 	meta      *model.Meta
+	options   Options
+	base      string
 	feed      *feeds.Feed
 	fs        afero.Fs
 	outputDir string
+	mutex     sync.Mutex
 }
 
 // ProcessPage takes a page to be processed by the plugin, reads
 // metadata for that page and creates a new feed item from it.
 func (a *atom) ProcessPage(page *model.Page) error {
-	if page.Hidden || page.IsCustomListPage() {
+	if page.Hidden || page.IsCustomListPage() || !a.inSection(page) {
 		return nil
 	}
 
-	canonical := fmt.Sprintf("%s%s/%s", a.meta.Base, page.Route, page.ID)
+	canonical := page.Canonical
+	if canonical == "" {
+		canonical = a.base + page.Href
+	}
 
 	item := &feeds.Item{
 		Title:       page.Title,
@@ -61,25 +118,95 @@ func (a *atom) ProcessPage(page *model.Page) error {
 		Description: page.Description,
 		Id:          canonical,
 		Created:     page.Date,
+		Content:     page.Content,
 	}
 
+	a.mutex.Lock()
 	a.feed.Add(item)
+	a.mutex.Unlock()
+
 	return nil
 }
 
-// PreWrite isn't needed by the atom plugin.
+// PreWrite makes sure that the feed can be generated before the website
+// gets rendered.
 func (a *atom) PreWrite(_ *model.Site) error {
+	if a.base == "" {
+		return ErrMissingBase
+	}
 	return nil
 }
 
 // PostWrite writes the internal feed.Feed instance into a file
-// directly in the output directory.
+// inside the output directory.
 func (a *atom) PostWrite() error {
-	path := filepath.Join(a.outputDir, filename)
-	atomFile, err := a.fs.Create(path)
-	if err != nil {
+	// Pages are processed concurrently, so the feed items have to be
+	// sorted to get a deterministic feed.
+	sort.Slice(a.feed.Items, func(i, j int) bool {
+		if !a.feed.Items[i].Created.Equal(a.feed.Items[j].Created) {
+			return a.feed.Items[i].Created.After(a.feed.Items[j].Created)
+		}
+		return a.feed.Items[i].Id < a.feed.Items[j].Id
+	})
+
+	if a.options.ItemLimit > 0 && len(a.feed.Items) > a.options.ItemLimit {
+		a.feed.Items = a.feed.Items[:a.options.ItemLimit]
+	}
+
+	// The feed has been updated when its newest item has been created.
+	for _, item := range a.feed.Items {
+		if !item.Created.IsZero() {
+			a.feed.Updated = item.Created
+			break
+		}
+	}
+
+	atomFeed := (&feeds.Atom{Feed: a.feed}).AtomFeed()
+
+	for i, entry := range atomFeed.Entries {
+		if created := a.feed.Items[i].Created; !created.IsZero() {
+			entry.Published = created.Format(time.RFC3339)
+		}
+	}
+
+	if err := a.writeFeed(a.options.OutputPath, atomFeed); err != nil {
+		return err
+	}
+
+	if a.options.RSS {
+		return a.writeFeed(rssFilename, &feeds.Rss{Feed: a.feed})
+	}
+
+	return nil
+}
+
+// writeFeed renders a feed as XML and writes it to the given path inside
+// the output directory.
+func (a *atom) writeFeed(file string, feed feeds.XmlFeed) error {
+	var buf bytes.Buffer
+
+	if err := feeds.WriteXML(feed, &buf); err != nil {
 		return err
 	}
 
-	return a.feed.WriteAtom(atomFile)
+	file = filepath.Join(a.outputDir, filepath.FromSlash(file))
+
+	if err := a.fs.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+
+	return fs.WriteFileAtomic(a.fs, file, buf.Bytes(), 0644)
+}
+
+// inSection reports whether the page is located inside the configured
+// section.
+func (a *atom) inSection(page *model.Page) bool {
+	section := strings.Trim(a.options.Section, "/")
+	if section == "" {
+		return true
+	}
+
+	route := strings.Trim(filepath.ToSlash(page.Route), "/")
+
+	return route == section || strings.HasPrefix(route, section+"/")
 }
@@ -1,8 +1,12 @@
 package atom
 
 import (
+	"encoding/xml"
 	"fmt"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/verless/verless/model"
@@ -12,10 +16,10 @@ import (
 var (
 	// testPages is a set of pages used for testing.
 	testPages = []model.Page{
-		{ID: "page-0", Route: "/route-0", Title: "Page 1"},
-		{ID: "page-1", Route: "/route-1/route-22/route-333", Title: "Page 2"},
-		{ID: "page-2", Route: "/route-2", Title: "Page 3"},
-		{ID: "page-3", Route: "/route-3", Title: "Page 4"},
+		{ID: "page-0", Route: "/route-0", Href: "/route-0/page-0", Title: "Page 1"},
+		{ID: "page-1", Route: "/route-1/route-22/route-333", Href: "/route-1/route-22/route-333/page-1", Title: "Page 2"},
+		{ID: "page-2", Route: "/route-2", Href: "/route-2/page-2", Title: "Page 3"},
+		{ID: "page-3", Route: "/route-3", Href: "/route-3/page-3", Title: "Page 4"},
 	}
 )
 
@@ -36,7 +40,7 @@ func TestAtom_ProcessPage(t *testing.T) {
 
 		a := New(&model.Meta{
 			Base: "https://example.com",
-		}, afero.NewOsFs(), "")
+		}, Options{}, afero.NewOsFs(), "")
 
 		for i, page := range testCase.pages {
 			t.Logf("process page number %v, route '%v'", i, page.Route)
@@ -55,3 +59,178 @@ func TestAtom_ProcessPage(t *testing.T) {
 		test.Equals(t, len(testCase.pages), len(a.feed.Items))
 	}
 }
+
+// atomNamespace is the XML namespace of Atom feeds as defined in RFC 4287.
+const atomNamespace = "http://www.w3.org/2005/Atom"
+
+// atomFeed represents the elements of an Atom feed that are validated.
+type atomFeed struct {
+	XMLName xml.Name `xml:"feed"`
+	ID      string   `xml:"id"`
+	Title   string   `xml:"title"`
+	Updated string   `xml:"updated"`
+	Author  string   `xml:"author>name"`
+	Entries []struct {
+		ID        string `xml:"id"`
+		Title     string `xml:"title"`
+		Updated   string `xml:"updated"`
+		Published string `xml:"published"`
+		Link      struct {
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Content string `xml:"content"`
+	} `xml:"entry"`
+}
+
+// validateAtom checks if the given feed contains all elements required by
+// RFC 4287 and if all IDs and links are absolute URLs.
+func validateAtom(tb testing.TB, data []byte) atomFeed {
+	var feed atomFeed
+
+	test.Ok(tb, xml.Unmarshal(data, &feed))
+	test.Equals(tb, atomNamespace, feed.XMLName.Space)
+
+	isAbsolute := func(rawURL string) bool {
+		u, err := url.Parse(rawURL)
+		return err == nil && u.IsAbs() && u.Host != ""
+	}
+	isTimestamp := func(timestamp string) bool {
+		_, err := time.Parse(time.RFC3339, timestamp)
+		return err == nil
+	}
+
+	test.Assert(tb, isAbsolute(feed.ID), "feed id %s should be an absolute URL", feed.ID)
+	test.Assert(tb, feed.Title != "", "feed title should not be empty")
+	test.Assert(tb, isTimestamp(feed.Updated), "feed updated %s should be a RFC 3339 timestamp", feed.Updated)
+
+	for _, entry := range feed.Entries {
+		test.Assert(tb, isAbsolute(entry.ID), "entry id %s should be an absolute URL", entry.ID)
+		test.Assert(tb, isAbsolute(entry.Link.Href), "entry link %s should be an absolute URL", entry.Link.Href)
+		test.Assert(tb, entry.Title != "", "entry title should not be empty")
+		test.Assert(tb, isTimestamp(entry.Updated), "entry updated %s should be a RFC 3339 timestamp", entry.Updated)
+		test.Assert(tb, isTimestamp(entry.Published), "entry published %s should be a RFC 3339 timestamp", entry.Published)
+	}
+
+	return feed
+}
+
+// TestAtom_PostWrite checks if the atom plugin writes a valid Atom feed
+// containing the most recent pages of the configured section.
+func TestAtom_PostWrite(t *testing.T) {
+	pages := []model.Page{
+		{ID: "espresso", Route: "/blog", Href: "/blog/espresso", Title: "Espresso", Date: time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC), Content: "<p>Espresso & Crema</p>"},
+		{ID: "moka-pot", Route: "/blog", Href: "/blog/moka-pot", Title: "Moka Pot", Date: time.Date(2020, 10, 3, 0, 0, 0, 0, time.UTC)},
+		{ID: "filter", Route: "/blog/brewing", Href: "/blog/brewing/filter", Title: "Filter", Date: time.Date(2020, 10, 2, 0, 0, 0, 0, time.UTC)},
+		{ID: "about", Route: "/", Href: "/about", Title: "About", Date: time.Date(2020, 10, 4, 0, 0, 0, 0, time.UTC)},
+	}
+
+	tests := map[string]struct {
+		options  Options
+		expected []string
+	}{
+		"all pages": {
+			expected: []string{"https://example.com/about", "https://example.com/blog/moka-pot", "https://example.com/blog/brewing/filter", "https://example.com/blog/espresso"},
+		},
+		"item limit": {
+			options:  Options{ItemLimit: 2},
+			expected: []string{"https://example.com/about", "https://example.com/blog/moka-pot"},
+		},
+		"section": {
+			options:  Options{Section: "/blog"},
+			expected: []string{"https://example.com/blog/moka-pot", "https://example.com/blog/brewing/filter", "https://example.com/blog/espresso"},
+		},
+		"empty section": {
+			options: Options{Section: "/news"},
+		},
+		"custom output path and rss": {
+			options:  Options{OutputPath: "feeds/blog.xml", Title: "Coffee Blog", Author: "Barista", RSS: true},
+			expected: []string{"https://example.com/about", "https://example.com/blog/moka-pot", "https://example.com/blog/brewing/filter", "https://example.com/blog/espresso"},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+
+		a := New(&model.Meta{
+			Title:  "verless",
+			Author: "verless",
+			Base:   "https://example.com/",
+		}, testCase.options, memMapFs, "/target")
+
+		for i := range pages {
+			test.Ok(t, a.ProcessPage(&pages[i]))
+		}
+
+		site := model.NewSite()
+		test.Ok(t, a.PreWrite(&site))
+		test.Ok(t, a.PostWrite())
+
+		outputPath := testCase.options.OutputPath
+		if outputPath == "" {
+			outputPath = filename
+		}
+
+		data, err := afero.ReadFile(memMapFs, "/target/"+outputPath)
+		test.Ok(t, err)
+
+		feed := validateAtom(t, data)
+
+		ids := make([]string, 0)
+		for _, entry := range feed.Entries {
+			ids = append(ids, entry.ID)
+		}
+		test.Equals(t, len(testCase.expected), len(ids))
+		if len(testCase.expected) > 0 {
+			test.Equals(t, testCase.expected, ids)
+		}
+
+		if testCase.options.Title != "" {
+			test.Equals(t, testCase.options.Title, feed.Title)
+			test.Equals(t, testCase.options.Author, feed.Author)
+		}
+
+		if contains(pages[0], testCase.expected) {
+			test.Assert(t, strings.Contains(string(data), "&lt;p&gt;Espresso &amp; Crema&lt;/p&gt;"), "content should be escaped")
+		}
+
+		exists, err := afero.Exists(memMapFs, "/target/"+rssFilename)
+		test.Ok(t, err)
+		test.Equals(t, testCase.options.RSS, exists)
+	}
+}
+
+// contains reports whether the page's canonical URL is in urls.
+func contains(page model.Page, urls []string) bool {
+	for _, u := range urls {
+		if strings.HasSuffix(u, "/"+page.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// TestAtom_PreWrite checks if the atom plugin fails without base URL.
+func TestAtom_PreWrite(t *testing.T) {
+	tests := map[string]struct {
+		base          string
+		expectedError error
+	}{
+		"with base URL": {
+			base: "https://example.com",
+		},
+		"without base URL": {
+			expectedError: ErrMissingBase,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		a := New(&model.Meta{Base: testCase.base}, Options{}, afero.NewMemMapFs(), "/target")
+
+		site := model.NewSite()
+		test.ExpectedError(t, testCase.expectedError, a.PreWrite(&site))
+	}
+}
@@ -0,0 +1,110 @@
+// Package plugin provides the plugin interface and a registry for all
+// plugins that can be enabled in the project configuration.
+package plugin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/config"
+	"github.com/verless/verless/model"
+)
+
+var (
+	// ErrPluginNotFound states that no plugin has been registered under
+	// the requested name.
+	ErrPluginNotFound = errors.New("plugin not found")
+)
+
+// Plugin represents a verless plugin. Plugins are invoked in the order
+// they've been enabled in the project configuration.
+type Plugin interface {
+	// ProcessPage will be invoked after parsing the page. Must be safe
+	// for concurrent usage.
+	ProcessPage(page *model.Page) error
+	// PreWrite will be invoked before writing the site.
+	PreWrite(site *model.Site) error
+	// PostWrite will be invoked after writing the site.
+	PostWrite() error
+}
+
+// Config is passed to a plugin factory and provides everything needed to
+// initialize the plugin.
+type Config struct {
+	// Project is the project configuration.
+	Project *config.Config
+	// Settings are the plugin's settings from the pluginConfig key of the
+	// project configuration.
+	Settings map[string]interface{}
+	// Fs is the filesystem the website is written to.
+	Fs afero.Fs
+	// OutputDir is the output directory inside Fs.
+	OutputDir string
+	// Preview indicates a build including drafts or future pages.
+	Preview bool
+	// Timestamp is the build timestamp to be used in generated files
+	// instead of the current time, see core.Build.Timestamp.
+	Timestamp time.Time
+}
+
+// Factory creates a new plugin instance for a build.
+type Factory func(cfg Config) (Plugin, error)
+
+var (
+	factories = make(map[string]Factory)
+	mutex     sync.RWMutex
+)
+
+// Register makes a plugin available under the given name, so that it
+// can be enabled in the project configuration. Register panics if the
+// name is empty or has already been registered.
+func Register(name string, factory Factory) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if name == "" || factory == nil {
+		panic("plugin: name and factory must not be empty")
+	}
+
+	if _, exists := factories[name]; exists {
+		panic(fmt.Sprintf("plugin: %s has already been registered", name))
+	}
+
+	factories[name] = factory
+}
+
+// New creates a new instance of the plugin registered under name.
+func New(name string, cfg Config) (Plugin, error) {
+	mutex.RLock()
+	factory, exists := factories[name]
+	mutex.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("%s: %w", name, ErrPluginNotFound)
+	}
+
+	p, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return p, nil
+}
+
+// Names returns the names of all registered plugins in lexical order.
+func Names() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
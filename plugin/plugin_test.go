@@ -0,0 +1,74 @@
+package plugin
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/test"
+)
+
+// nopPlugin is a plugin that doesn't do anything.
+type nopPlugin struct{}
+
+func (nopPlugin) ProcessPage(_ *model.Page) error { return nil }
+func (nopPlugin) PreWrite(_ *model.Site) error    { return nil }
+func (nopPlugin) PostWrite() error                { return nil }
+
+// errFactory is returned by a failing plugin factory.
+var errFactory = errors.New("invalid settings")
+
+// init registers the plugins used for testing. Registering them in the
+// tests would fail if the tests are run multiple times.
+func init() {
+	Register("test-nop", func(_ Config) (Plugin, error) {
+		return nopPlugin{}, nil
+	})
+	Register("test-failing", func(_ Config) (Plugin, error) {
+		return nil, errFactory
+	})
+}
+
+// TestNew checks if New creates instances of registered plugins only.
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		name          string
+		expectedError error
+	}{
+		"registered plugin": {
+			name: "test-nop",
+		},
+		"failing factory": {
+			name:          "test-failing",
+			expectedError: errFactory,
+		},
+		"unknown plugin": {
+			name:          "test-unknown",
+			expectedError: ErrPluginNotFound,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		p, err := New(testCase.name, Config{})
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			continue
+		}
+
+		test.Equals(t, nopPlugin{}, p)
+	}
+
+	test.Equals(t, []string{"test-failing", "test-nop"}, Names())
+}
+
+// TestRegister checks if Register refuses to register a plugin twice.
+func TestRegister(t *testing.T) {
+	defer func() {
+		test.Assert(t, recover() != nil, "registering a plugin twice should panic")
+	}()
+
+	Register("test-nop", func(_ Config) (Plugin, error) {
+		return nopPlugin{}, nil
+	})
+}
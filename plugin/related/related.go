@@ -0,0 +1,145 @@
+// Package related provides and implements the related plugin.
+package related
+
+import (
+	"sort"
+
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/plugin/tags"
+	"github.com/verless/verless/tree"
+)
+
+const (
+	// defaultLimit is the number of related pages that are stored if no
+	// limit has been configured.
+	defaultLimit int = 5
+)
+
+// New creates a new related plugin that stores up to limit related pages
+// for each page. If sameSection is true, only pages from the same
+// section, i.e. with the same route, are considered as related.
+func New(limit int, sameSection bool) *related {
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	r := related{
+		limit:       limit,
+		sameSection: sameSection,
+	}
+
+	return &r
+}
+
+// related is the actual related plugin. It ranks all pages by the number
+// of tags they share with a given page.
+type related struct {
+	limit       int
+	sameSection bool
+}
+
+// ProcessPage isn't needed by the related plugin, because related pages
+// can only be determined once all pages have been registered.
+func (r *related) ProcessPage(_ *model.Page) error {
+	return nil
+}
+
+// PreWrite determines the related pages for each page in the site model
+// and stores them as the page's Similar pages.
+func (r *related) PreWrite(site *model.Site) error {
+	var pages []*model.Page
+
+	_ = tree.Walk(site.Root, func(_ string, node tree.Node) error {
+		n := node.(*model.Node)
+		for i := range n.Pages {
+			pages = append(pages, &n.Pages[i])
+		}
+		return nil
+	}, -1)
+
+	// Index the pages by tag, so that only pages sharing at least one
+	// tag have to be compared.
+	tagged := make(map[string][]*model.Page)
+
+	for _, page := range pages {
+		if page.Hidden {
+			continue
+		}
+		for _, tag := range uniqueTags(page) {
+			tagged[tag] = append(tagged[tag], page)
+		}
+	}
+
+	for _, page := range pages {
+		page.Similar = r.rank(page, tagged)
+	}
+
+	return nil
+}
+
+// PostWrite isn't needed by the related plugin.
+func (r *related) PostWrite() error {
+	return nil
+}
+
+// rank returns the pages that share the most tags with the given page,
+// limited to the configured number of pages. Pages sharing the same
+// number of tags are sorted by date, starting with the newest page.
+func (r *related) rank(page *model.Page, tagged map[string][]*model.Page) []*model.Page {
+	scores := make(map[*model.Page]int)
+
+	for _, tag := range uniqueTags(page) {
+		for _, candidate := range tagged[tag] {
+			if candidate == page || (r.sameSection && candidate.Route != page.Route) {
+				continue
+			}
+			scores[candidate]++
+		}
+	}
+
+	if len(scores) == 0 {
+		return nil
+	}
+
+	ranked := make([]*model.Page, 0, len(scores))
+	for candidate := range scores {
+		ranked = append(ranked, candidate)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		switch {
+		case scores[a] != scores[b]:
+			return scores[a] > scores[b]
+		case !a.Date.Equal(b.Date):
+			return a.Date.After(b.Date)
+		default:
+			return a.Href < b.Href
+		}
+	})
+
+	if len(ranked) > r.limit {
+		ranked = ranked[:r.limit]
+	}
+
+	return ranked
+}
+
+// uniqueTags returns the sanitized tags of a page without duplicates.
+// Tags like "Making Coffee" and "making-coffee" are considered equal.
+func uniqueTags(page *model.Page) []string {
+	var (
+		slugs []string
+		seen  = make(map[string]bool)
+	)
+
+	for _, tag := range page.Tags {
+		tag = tags.Slug(tag)
+		if tag != "" && !seen[tag] {
+			seen[tag] = true
+			slugs = append(slugs, tag)
+		}
+	}
+
+	return slugs
+}
@@ -0,0 +1,97 @@
+package related
+
+import (
+	"testing"
+	"time"
+
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/test"
+	"github.com/verless/verless/tree"
+)
+
+// day returns the date of the given day in October 2020.
+func day(d int) time.Time {
+	return time.Date(2020, 10, d, 0, 0, 0, 0, time.UTC)
+}
+
+var (
+	// testPages is a set of pages with overlapping tags used for testing.
+	testPages = []model.Page{
+		{ID: "espresso", Route: "/coffee", Href: "/coffee/espresso", Date: day(1), Tags: []string{"Coffee", "Espresso", "Machines"}},
+		{ID: "moka-pot", Route: "/coffee", Href: "/coffee/moka-pot", Date: day(2), Tags: []string{"coffee", "machines"}},
+		{ID: "grinders", Route: "/coffee", Href: "/coffee/grinders", Date: day(3), Tags: []string{"Machines"}},
+		{ID: "latte-art", Route: "/coffee", Href: "/coffee/latte-art", Date: day(4), Tags: []string{"Espresso", "Milk"}},
+		{ID: "tea", Route: "/tea", Href: "/tea/tea", Date: day(5), Tags: []string{"Machines", "Coffee"}},
+		{ID: "secret", Route: "/coffee", Href: "/coffee/secret", Date: day(6), Tags: []string{"Coffee", "Espresso"}, Hidden: true},
+		{ID: "untagged", Route: "/coffee", Href: "/coffee/untagged", Date: day(7)},
+	}
+)
+
+// TestRelated_PreWrite checks if the related plugin ranks the related
+// pages by the number of shared tags and by date.
+func TestRelated_PreWrite(t *testing.T) {
+	tests := map[string]struct {
+		limit       int
+		sameSection bool
+		expected    map[string][]string
+	}{
+		"default limit": {
+			expected: map[string][]string{
+				"espresso":  {"/tea/tea", "/coffee/moka-pot", "/coffee/latte-art", "/coffee/grinders"},
+				"moka-pot":  {"/tea/tea", "/coffee/espresso", "/coffee/grinders"},
+				"grinders":  {"/tea/tea", "/coffee/moka-pot", "/coffee/espresso"},
+				"latte-art": {"/coffee/espresso"},
+				"tea":       {"/coffee/moka-pot", "/coffee/espresso", "/coffee/grinders"},
+				"secret":    {"/coffee/espresso", "/tea/tea", "/coffee/latte-art", "/coffee/moka-pot"},
+				"untagged":  nil,
+			},
+		},
+		"limit of two pages": {
+			limit: 2,
+			expected: map[string][]string{
+				"espresso": {"/tea/tea", "/coffee/moka-pot"},
+				"tea":      {"/coffee/moka-pot", "/coffee/espresso"},
+			},
+		},
+		"same section only": {
+			sameSection: true,
+			expected: map[string][]string{
+				"espresso": {"/coffee/moka-pot", "/coffee/latte-art", "/coffee/grinders"},
+				"tea":      nil,
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		site := model.NewSite()
+
+		for _, page := range testPages {
+			n, err := tree.ResolveOrInitNode(page.Route, site.Root)
+			test.Ok(t, err)
+
+			node := n.(*model.Node)
+			node.Pages = append(node.Pages, page)
+		}
+
+		test.Ok(t, New(testCase.limit, testCase.sameSection).PreWrite(&site))
+
+		_ = tree.Walk(site.Root, func(_ string, node tree.Node) error {
+			for _, page := range node.(*model.Node).Pages {
+				expected, exists := testCase.expected[page.ID]
+				if !exists {
+					continue
+				}
+
+				var hrefs []string
+				for _, similar := range page.Similar {
+					hrefs = append(hrefs, similar.Href)
+				}
+
+				test.Equals(t, expected, hrefs)
+			}
+			return nil
+		}, -1)
+	}
+}
@@ -0,0 +1,103 @@
+// Package robots provides and implements the robots plugin.
+package robots
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/model"
+)
+
+const (
+	// filename is the filename for the robots.txt file.
+	filename string = "robots.txt"
+	// sitemapFile is the filename of the sitemap generated by the sitemap
+	// plugin.
+	sitemapFile string = "sitemap.xml"
+)
+
+var (
+	// defaultRules allows all crawlers to index the entire website.
+	defaultRules = []string{"User-agent: *", "Disallow:"}
+	// previewRules prevents all crawlers from indexing the website.
+	previewRules = []string{"User-agent: *", "Disallow: /"}
+)
+
+// Options configure the robots.txt file generated by the robots plugin.
+type Options struct {
+	// Rules are the lines of the robots.txt file. If no rules are given,
+	// all crawlers are allowed to index the website.
+	Rules []string
+	// Sitemap adds a reference to the sitemap generated by the sitemap
+	// plugin.
+	Sitemap bool
+	// Preview disallows indexing the website, regardless of Rules. This
+	// is used for builds including drafts or future pages.
+	Preview bool
+}
+
+// New creates a new robots plugin that writes a robots.txt file to
+// outputDir.
+func New(meta *model.Meta, options Options, fs afero.Fs, outputDir string) *robots {
+	r := robots{
+		base:      strings.TrimSuffix(meta.Base, "/"),
+		options:   options,
+		fs:        fs,
+		outputDir: outputDir,
+	}
+
+	return &r
+}
+
+// robots is the actual robots plugin.
+type robots struct {
+	base      string
+	options   Options
+	fs        afero.Fs
+	outputDir string
+}
+
+// ProcessPage isn't needed by the robots plugin.
+func (r *robots) ProcessPage(_ *model.Page) error {
+	return nil
+}
+
+// PreWrite isn't needed by the robots plugin.
+func (r *robots) PreWrite(_ *model.Site) error {
+	return nil
+}
+
+// PostWrite writes the robots.txt file directly into the output
+// directory.
+func (r *robots) PostWrite() error {
+	return fs.WriteFileAtomic(r.fs, filepath.Join(r.outputDir, filename), r.render(), 0644)
+}
+
+// render returns the contents of the robots.txt file.
+func (r *robots) render() []byte {
+	var buf bytes.Buffer
+
+	rules := r.options.Rules
+
+	switch {
+	case r.options.Preview:
+		rules = previewRules
+	case len(rules) == 0:
+		rules = defaultRules
+	}
+
+	for _, rule := range rules {
+		buf.WriteString(strings.TrimSpace(rule) + "\n")
+	}
+
+	// A preview build shouldn't point crawlers to any of its pages.
+	if r.options.Sitemap && !r.options.Preview && r.base != "" {
+		fmt.Fprintf(&buf, "\nSitemap: %s/%s\n", r.base, sitemapFile)
+	}
+
+	return buf.Bytes()
+}
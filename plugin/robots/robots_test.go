@@ -0,0 +1,65 @@
+package robots
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/test"
+)
+
+// TestRobots_PostWrite checks if the robots plugin writes the expected
+// robots.txt file for production and preview builds.
+func TestRobots_PostWrite(t *testing.T) {
+	tests := map[string]struct {
+		base     string
+		options  Options
+		expected string
+	}{
+		"default production output": {
+			base:     "https://example.com/",
+			options:  Options{Sitemap: true},
+			expected: "User-agent: *\nDisallow:\n\nSitemap: https://example.com/sitemap.xml\n",
+		},
+		"without sitemap plugin": {
+			base:     "https://example.com",
+			expected: "User-agent: *\nDisallow:\n",
+		},
+		"without base URL": {
+			options:  Options{Sitemap: true},
+			expected: "User-agent: *\nDisallow:\n",
+		},
+		"preview build": {
+			base:     "https://example.com",
+			options:  Options{Sitemap: true, Preview: true, Rules: []string{"User-agent: *", "Disallow: /private/"}},
+			expected: "User-agent: *\nDisallow: /\n",
+		},
+		"custom rules": {
+			base: "https://example.com",
+			options: Options{Sitemap: true, Rules: []string{
+				"User-agent: *",
+				"Disallow: /private/",
+				"",
+				"User-agent: BadBot",
+				"Disallow: /",
+			}},
+			expected: "User-agent: *\nDisallow: /private/\n\nUser-agent: BadBot\nDisallow: /\n\nSitemap: https://example.com/sitemap.xml\n",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+
+		r := New(&model.Meta{Base: testCase.base}, testCase.options, memMapFs, "/target")
+
+		site := model.NewSite()
+		test.Ok(t, r.PreWrite(&site))
+		test.Ok(t, r.PostWrite())
+
+		content, err := afero.ReadFile(memMapFs, "/target/robots.txt")
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, string(content))
+	}
+}
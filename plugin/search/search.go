@@ -0,0 +1,233 @@
+// Package search provides and implements the search plugin.
+package search
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/tree"
+)
+
+const (
+	// filename is the default filename for the search index.
+	filename string = "search-index.json"
+	// defaultExcerptLength is the number of words of the indexed body if
+	// no excerpt length has been configured.
+	defaultExcerptLength int = 200
+)
+
+const (
+	// FieldTitle is the title of a page.
+	FieldTitle = "title"
+	// FieldURL is the path of a page like /blog/coffee.
+	FieldURL = "url"
+	// FieldTags contains the tags of a page.
+	FieldTags = "tags"
+	// FieldDescription is the description of a page.
+	FieldDescription = "description"
+	// FieldBody is the plain text of the page content, truncated after
+	// the configured number of words.
+	FieldBody = "body"
+)
+
+var (
+	// ErrUnknownField states that a configured field can't be indexed.
+	ErrUnknownField = errors.New("unknown field, must be title, url, tags, description or body")
+	// ErrInvalidExcerptLength states that the configured excerpt length
+	// is negative.
+	ErrInvalidExcerptLength = errors.New("excerpt length must not be negative")
+
+	// defaultFields are the fields indexed if no fields have been
+	// configured.
+	defaultFields = []string{FieldTitle, FieldURL, FieldTags, FieldBody}
+
+	// htmlScripts matches script and style elements, whose contents are no
+	// text of the page.
+	htmlScripts = regexp.MustCompile(`(?is)<(script|style)\b.*?</(script|style)\s*>`)
+	// htmlTags matches HTML tags and comments. The element name of tags
+	// is captured.
+	htmlTags = regexp.MustCompile(`(?s)<!--.*?-->|</?([a-zA-Z][a-zA-Z0-9]*)[^>]*>`)
+)
+
+// inlineElements contains the HTML elements that don't separate words, so
+// that highlighted code like <span>fmt</span>.<span>Println</span> remains
+// a single word.
+var inlineElements = map[string]bool{
+	"a": true, "abbr": true, "b": true, "code": true, "del": true, "em": true, "i": true, "ins": true,
+	"kbd": true, "mark": true, "q": true, "s": true, "small": true, "span": true, "strong": true,
+	"sub": true, "sup": true, "u": true,
+}
+
+// Options configure the search index generated by the search plugin.
+type Options struct {
+	// OutputPath is the index's path relative to the output directory.
+	OutputPath string
+	// Fields are the fields of each record. If no fields are given, the
+	// title, URL, tags and body are indexed.
+	Fields []string
+	// ExcerptLength is the maximum number of words of the indexed body.
+	// If it is 0, defaultExcerptLength is used.
+	ExcerptLength int
+}
+
+// New creates a new search plugin that writes a JSON search index for
+// all pages of the site model to outputDir.
+func New(options Options, fs afero.Fs, outputDir string) *search {
+	if options.OutputPath == "" {
+		options.OutputPath = filename
+	}
+	if len(options.Fields) == 0 {
+		options.Fields = defaultFields
+	}
+	if options.ExcerptLength == 0 {
+		options.ExcerptLength = defaultExcerptLength
+	}
+
+	s := search{
+		options:   options,
+		fs:        fs,
+		outputDir: outputDir,
+	}
+
+	return &s
+}
+
+// search is the actual search plugin. It walks the final site model and
+// writes a record for each page.
+type search struct {
+	options   Options
+	site      *model.Site
+	fs        afero.Fs
+	outputDir string
+}
+
+// record is the search index entry of a page, mapping the configured
+// fields to their values.
+type record map[string]interface{}
+
+// ProcessPage isn't needed by the search plugin, because the records are
+// sorted by the final URLs of the pages.
+func (s *search) ProcessPage(_ *model.Page) error {
+	return nil
+}
+
+// PreWrite validates the configuration and stores the site model, which
+// is walked once all other plugins have finished modifying it.
+func (s *search) PreWrite(site *model.Site) error {
+	for _, field := range s.options.Fields {
+		switch field {
+		case FieldTitle, FieldURL, FieldTags, FieldDescription, FieldBody:
+		default:
+			return fmt.Errorf("%s: %w", field, ErrUnknownField)
+		}
+	}
+
+	if s.options.ExcerptLength < 0 {
+		return fmt.Errorf("%d: %w", s.options.ExcerptLength, ErrInvalidExcerptLength)
+	}
+
+	s.site = site
+
+	return nil
+}
+
+// PostWrite writes the search index into the output directory.
+func (s *search) PostWrite() error {
+	data, err := json.Marshal(s.records())
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(s.outputDir, filepath.FromSlash(s.options.OutputPath))
+
+	if err := s.fs.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+
+	return fs.WriteFileAtomic(s.fs, file, data, 0644)
+}
+
+// records returns the records for all pages, sorted by their URL. Drafts,
+// hidden pages and pages flagged with NoIndex are excluded.
+func (s *search) records() []record {
+	var pages []*model.Page
+
+	_ = tree.Walk(s.site.Root, func(_ string, node tree.Node) error {
+		n := node.(*model.Node)
+
+		for i := range n.Pages {
+			if page := &n.Pages[i]; !page.Draft && !page.Hidden && !page.NoIndex {
+				pages = append(pages, page)
+			}
+		}
+
+		return nil
+	}, -1)
+
+	sort.Slice(pages, func(i, j int) bool {
+		return pages[i].Href < pages[j].Href
+	})
+
+	records := make([]record, len(pages))
+	for i, page := range pages {
+		records[i] = s.record(page)
+	}
+
+	return records
+}
+
+// record creates the record of a page containing the configured fields.
+func (s *search) record(page *model.Page) record {
+	r := make(record, len(s.options.Fields))
+
+	for _, field := range s.options.Fields {
+		switch field {
+		case FieldTitle:
+			r[field] = page.Title
+		case FieldURL:
+			r[field] = page.Href
+		case FieldTags:
+			tags := page.Tags
+			if tags == nil {
+				tags = []string{}
+			}
+			r[field] = tags
+		case FieldDescription:
+			r[field] = page.Description
+		case FieldBody:
+			r[field] = excerpt(page.Content, s.options.ExcerptLength)
+		}
+	}
+
+	return r
+}
+
+// excerpt converts rendered HTML content into plain text and truncates it
+// after the given number of words. Tags, comments, scripts and styles are
+// removed, entities are unescaped and whitespace is collapsed. Tags other
+// than inline elements are treated as whitespace.
+func excerpt(content string, words int) string {
+	text := htmlScripts.ReplaceAllString(content, " ")
+	text = htmlTags.ReplaceAllStringFunc(text, func(tag string) string {
+		if name := htmlTags.FindStringSubmatch(tag)[1]; inlineElements[strings.ToLower(name)] {
+			return ""
+		}
+		return " "
+	})
+
+	fields := strings.Fields(html.UnescapeString(text))
+	if len(fields) > words {
+		fields = fields[:words]
+	}
+
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,138 @@
+package search
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/test"
+	"github.com/verless/verless/tree"
+)
+
+// testSite creates a site model containing drafts, hidden pages and
+// noindex pages next to regular pages with HTML content.
+func testSite(tb testing.TB) model.Site {
+	pages := []model.Page{
+		{
+			ID: "espresso", Route: "/blog", Title: "Espresso", Tags: []string{"coffee", "espresso"}, Description: "Dark and strong.",
+			Content: "<h2 id=\"crema\">Crema</h2>\n<p>Espresso has a <strong>thick</strong> crema &amp; tastes great.</p>\n<script>track();</script>\n<!-- draft note -->",
+		},
+		{ID: "moka-pot", Route: "/blog", Title: "Moka pot", Content: "<pre><code><span>fmt</span>.<span>Println</span>(&#34;brew&#34;)\n</code></pre><p>Done.<br>Enjoy!</p>"},
+		{ID: "tea", Route: "/blog", Title: "Tea", Content: "<p>Green.</p>", Draft: true},
+		{ID: "secret", Route: "/blog", Title: "Secret", Hidden: true},
+		{ID: "imprint", Route: "/", Title: "Imprint", NoIndex: true},
+		{ID: "about", Route: "/", Title: "About", Content: "<p>We love coffee.</p>"},
+	}
+
+	site := model.NewSite()
+
+	for _, page := range pages {
+		page.Href = model.PageHref(page.Route, page.ID, true)
+
+		n, err := tree.ResolveOrInitNode(page.Route, site.Root)
+		test.Ok(tb, err)
+
+		node := n.(*model.Node)
+		node.Pages = append(node.Pages, page)
+	}
+
+	return site
+}
+
+// TestSearch_PostWrite checks if the search plugin writes an index
+// containing exactly the expected records.
+func TestSearch_PostWrite(t *testing.T) {
+	tests := map[string]struct {
+		options      Options
+		expectedPath string
+		expected     []map[string]interface{}
+	}{
+		"default options": {
+			expectedPath: "/target/search-index.json",
+			expected: []map[string]interface{}{
+				{"title": "About", "url": "/about", "tags": []interface{}{}, "body": "We love coffee."},
+				{"title": "Espresso", "url": "/blog/espresso", "tags": []interface{}{"coffee", "espresso"}, "body": "Crema Espresso has a thick crema & tastes great."},
+				{"title": "Moka pot", "url": "/blog/moka-pot", "tags": []interface{}{}, "body": "fmt.Println(\"brew\") Done. Enjoy!"},
+			},
+		},
+		"custom fields and excerpt length": {
+			options: Options{
+				OutputPath:    "search/index.json",
+				Fields:        []string{"url", "description", "body"},
+				ExcerptLength: 3,
+			},
+			expectedPath: "/target/search/index.json",
+			expected: []map[string]interface{}{
+				{"url": "/about", "description": "", "body": "We love coffee."},
+				{"url": "/blog/espresso", "description": "Dark and strong.", "body": "Crema Espresso has"},
+				{"url": "/blog/moka-pot", "description": "", "body": "fmt.Println(\"brew\") Done. Enjoy!"},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+		site := testSite(t)
+
+		s := New(testCase.options, memMapFs, "/target")
+
+		test.Ok(t, s.PreWrite(&site))
+		test.Ok(t, s.PostWrite())
+
+		data, err := afero.ReadFile(memMapFs, testCase.expectedPath)
+		test.Ok(t, err)
+
+		var records []map[string]interface{}
+		test.Ok(t, json.Unmarshal(data, &records))
+		test.Equals(t, testCase.expected, records)
+	}
+}
+
+// TestSearch_PostWrite_emptySite checks if the search plugin writes an
+// empty array for a site without indexable pages.
+func TestSearch_PostWrite_emptySite(t *testing.T) {
+	memMapFs := afero.NewMemMapFs()
+	site := model.NewSite()
+
+	s := New(Options{}, memMapFs, "/target")
+
+	test.Ok(t, s.PreWrite(&site))
+	test.Ok(t, s.PostWrite())
+
+	data, err := afero.ReadFile(memMapFs, "/target/search-index.json")
+	test.Ok(t, err)
+	test.Equals(t, "[]", string(data))
+}
+
+// TestSearch_PreWrite checks if the search plugin rejects an invalid
+// configuration.
+func TestSearch_PreWrite(t *testing.T) {
+	tests := map[string]struct {
+		options       Options
+		expectedError error
+	}{
+		"valid configuration": {
+			options: Options{Fields: []string{"title", "description"}, ExcerptLength: 50},
+		},
+		"unknown field": {
+			options:       Options{Fields: []string{"title", "summary"}},
+			expectedError: ErrUnknownField,
+		},
+		"negative excerpt length": {
+			options:       Options{ExcerptLength: -1},
+			expectedError: ErrInvalidExcerptLength,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		site := model.NewSite()
+		s := New(testCase.options, afero.NewMemMapFs(), "/target")
+
+		test.ExpectedError(t, testCase.expectedError, s.PreWrite(&site))
+	}
+}
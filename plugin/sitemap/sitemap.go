@@ -0,0 +1,230 @@
+// Package sitemap provides and implements the sitemap plugin.
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/tree"
+)
+
+const (
+	// filename is the filename for the sitemap.
+	filename string = "sitemap.xml"
+	// namespace is the XML namespace of the sitemap protocol.
+	namespace string = "http://www.sitemaps.org/schemas/sitemap/0.9"
+)
+
+var (
+	// ErrMissingBase states that the site's base URL hasn't been set,
+	// which is required to build the absolute URLs used in the sitemap.
+	ErrMissingBase = errors.New("the sitemap plugin requires site.meta.base to be set")
+	// ErrInvalidChangeFreq states that the configured change frequency
+	// isn't supported by the sitemap protocol.
+	ErrInvalidChangeFreq = errors.New("invalid change frequency")
+	// ErrInvalidPriority states that the configured priority isn't
+	// within the range of 0.0 and 1.0.
+	ErrInvalidPriority = errors.New("priority has to be between 0.0 and 1.0")
+)
+
+// changeFreqs contains all change frequencies of the sitemap protocol.
+var changeFreqs = []string{"always", "hourly", "daily", "weekly", "monthly", "yearly", "never"}
+
+// Options configure the sitemap generated by the sitemap plugin.
+type Options struct {
+	// ChangeFreq is the change frequency used for all URLs, e.g. weekly.
+	ChangeFreq string
+	// Priority is the priority used for all URLs. If it is 0, no
+	// priority is set.
+	Priority float64
+	// CleanURLs has to match the build's clean URLs setting, so that the
+	// locations of list pages point to the rendered files.
+	CleanURLs bool
+}
+
+// New creates a new sitemap plugin that generates a sitemap for all pages
+// of the site model and stores the XML file in outputDir.
+func New(meta *model.Meta, options Options, fs afero.Fs, outputDir string) *sitemap {
+	s := sitemap{
+		base:      strings.TrimSuffix(meta.Base, "/"),
+		options:   options,
+		fs:        fs,
+		outputDir: outputDir,
+	}
+
+	return &s
+}
+
+// sitemap is the actual sitemap plugin. It walks the final site model
+// and writes an URL entry for each page.
+type sitemap struct {
+	base      string
+	options   Options
+	site      *model.Site
+	fs        afero.Fs
+	outputDir string
+}
+
+// urlSet represents the root element of a sitemap.
+type urlSet struct {
+	XMLName xml.Name `xml:"urlset"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	URLs    []url    `xml:"url"`
+}
+
+// url represents a single URL entry of a sitemap.
+type url struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod,omitempty"`
+	ChangeFreq string `xml:"changefreq,omitempty"`
+	Priority   string `xml:"priority,omitempty"`
+}
+
+// ProcessPage isn't needed by the sitemap plugin, because the sitemap
+// has to contain all pages including list pages.
+func (s *sitemap) ProcessPage(_ *model.Page) error {
+	return nil
+}
+
+// PreWrite validates the configuration and stores the site model, which
+// is walked once all other plugins have finished modifying it.
+func (s *sitemap) PreWrite(site *model.Site) error {
+	if s.base == "" {
+		return ErrMissingBase
+	}
+
+	if s.options.ChangeFreq != "" && !isChangeFreq(s.options.ChangeFreq) {
+		return fmt.Errorf("%s: %w", s.options.ChangeFreq, ErrInvalidChangeFreq)
+	}
+
+	if s.options.Priority < 0 || s.options.Priority > 1 {
+		return fmt.Errorf("%v: %w", s.options.Priority, ErrInvalidPriority)
+	}
+
+	s.site = site
+
+	return nil
+}
+
+// PostWrite writes the sitemap directly into the output directory.
+func (s *sitemap) PostWrite() error {
+	set := urlSet{
+		Xmlns: namespace,
+		URLs:  s.urls(),
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(set); err != nil {
+		return err
+	}
+
+	return fs.WriteFileAtomic(s.fs, filepath.Join(s.outputDir, filename), buf.Bytes(), 0644)
+}
+
+// urls returns the URL entries for all pages and list pages, sorted by
+// their location. Drafts and pages flagged with NoIndex are excluded.
+func (s *sitemap) urls() []url {
+	var urls []url
+
+	_ = tree.Walk(s.site.Root, func(route string, node tree.Node) error {
+		n := node.(*model.Node)
+
+		if lp := &n.ListPage; !lp.Draft && !lp.NoIndex {
+			loc := lp.Canonical
+			if loc == "" {
+				loc = s.base + model.ListPageHref(route, s.options.CleanURLs)
+			}
+			urls = append(urls, s.url(loc, listPageModified(lp)))
+		}
+
+		for _, page := range n.Pages {
+			if page.Draft || page.NoIndex {
+				continue
+			}
+			loc := page.Canonical
+			if loc == "" {
+				loc = s.base + page.Href
+			}
+			urls = append(urls, s.url(loc, modified(&page)))
+		}
+
+		return nil
+	}, -1)
+
+	sort.Slice(urls, func(i, j int) bool {
+		return urls[i].Loc < urls[j].Loc
+	})
+
+	return urls
+}
+
+// url creates a new URL entry using the configured change frequency and
+// priority.
+func (s *sitemap) url(loc string, lastMod time.Time) url {
+	u := url{
+		Loc:        loc,
+		ChangeFreq: s.options.ChangeFreq,
+	}
+
+	if !lastMod.IsZero() {
+		u.LastMod = lastMod.Format(time.RFC3339)
+	}
+
+	if s.options.Priority > 0 {
+		u.Priority = strconv.FormatFloat(s.options.Priority, 'f', -1, 64)
+	}
+
+	return u
+}
+
+// modified returns the date of the page. If the page doesn't have a date,
+// the modification time of its content file is returned.
+func modified(page *model.Page) time.Time {
+	if !page.Date.IsZero() {
+		return page.Date
+	}
+	return page.Modified
+}
+
+// listPageModified returns the date of a list page. If the list page
+// hasn't been created from an index.md file, the date of its newest page
+// is returned.
+func listPageModified(listPage *model.ListPage) time.Time {
+	lastMod := modified(&listPage.Page)
+
+	if lastMod.IsZero() {
+		for _, page := range listPage.Pages {
+			if m := modified(page); m.After(lastMod) {
+				lastMod = m
+			}
+		}
+	}
+
+	return lastMod
+}
+
+// isChangeFreq reports whether the given change frequency is supported
+// by the sitemap protocol.
+func isChangeFreq(changeFreq string) bool {
+	for _, c := range changeFreqs {
+		if c == changeFreq {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package sitemap
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/test"
+	"github.com/verless/verless/tree"
+)
+
+var (
+	// testDate is the date of the test pages.
+	testDate = time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	// testModified is the modification time of the test content files.
+	testModified = time.Date(2020, 10, 14, 8, 15, 0, 0, time.UTC)
+)
+
+// testSite creates a site model containing drafts, noindex pages and
+// pages with characters that have to be escaped.
+func testSite(tb testing.TB, cleanURLs bool) model.Site {
+	pages := []model.Page{
+		{ID: "espresso", Route: "/blog", Date: testDate, Modified: testModified},
+		{ID: "moka-pot", Route: "/blog", Modified: testModified},
+		{ID: "beans&milk", Route: "/blog", Date: testDate},
+		{ID: "tea", Route: "/blog", Date: testDate, Draft: true},
+		{ID: "imprint", Route: "/", NoIndex: true},
+	}
+
+	site := model.NewSite()
+
+	for _, page := range pages {
+		page.Href = model.PageHref(page.Route, page.ID, cleanURLs)
+
+		n, err := tree.ResolveOrInitNode(page.Route, site.Root)
+		test.Ok(tb, err)
+
+		node := n.(*model.Node)
+		node.Pages = append(node.Pages, page)
+		node.ListPage.Pages = append(node.ListPage.Pages, &node.Pages[len(node.Pages)-1])
+	}
+
+	return site
+}
+
+// TestSitemap_PostWrite checks if the sitemap plugin writes a sitemap
+// containing exactly the expected URLs.
+func TestSitemap_PostWrite(t *testing.T) {
+	tests := map[string]struct {
+		options  Options
+		expected []url
+	}{
+		"default options": {
+			options: Options{CleanURLs: true},
+			expected: []url{
+				{Loc: "https://example.com/"},
+				{Loc: "https://example.com/blog", LastMod: "2020-10-14T08:15:00Z"},
+				{Loc: "https://example.com/blog/beans&milk", LastMod: "2020-10-01T00:00:00Z"},
+				{Loc: "https://example.com/blog/espresso", LastMod: "2020-10-01T00:00:00Z"},
+				{Loc: "https://example.com/blog/moka-pot", LastMod: "2020-10-14T08:15:00Z"},
+			},
+		},
+		"change frequency and priority": {
+			options: Options{ChangeFreq: "weekly", Priority: 0.5, CleanURLs: true},
+			expected: []url{
+				{Loc: "https://example.com/", ChangeFreq: "weekly", Priority: "0.5"},
+				{Loc: "https://example.com/blog", LastMod: "2020-10-14T08:15:00Z", ChangeFreq: "weekly", Priority: "0.5"},
+				{Loc: "https://example.com/blog/beans&milk", LastMod: "2020-10-01T00:00:00Z", ChangeFreq: "weekly", Priority: "0.5"},
+				{Loc: "https://example.com/blog/espresso", LastMod: "2020-10-01T00:00:00Z", ChangeFreq: "weekly", Priority: "0.5"},
+				{Loc: "https://example.com/blog/moka-pot", LastMod: "2020-10-14T08:15:00Z", ChangeFreq: "weekly", Priority: "0.5"},
+			},
+		},
+		"without clean URLs": {
+			expected: []url{
+				{Loc: "https://example.com/blog/beans&milk.html", LastMod: "2020-10-01T00:00:00Z"},
+				{Loc: "https://example.com/blog/espresso.html", LastMod: "2020-10-01T00:00:00Z"},
+				{Loc: "https://example.com/blog/index.html", LastMod: "2020-10-14T08:15:00Z"},
+				{Loc: "https://example.com/blog/moka-pot.html", LastMod: "2020-10-14T08:15:00Z"},
+				{Loc: "https://example.com/index.html"},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		memMapFs := afero.NewMemMapFs()
+		site := testSite(t, testCase.options.CleanURLs)
+
+		s := New(&model.Meta{Base: "https://example.com/"}, testCase.options, memMapFs, "/target")
+
+		test.Ok(t, s.PreWrite(&site))
+		test.Ok(t, s.PostWrite())
+
+		data, err := afero.ReadFile(memMapFs, "/target/sitemap.xml")
+		test.Ok(t, err)
+		test.Assert(t, strings.Contains(string(data), "beans&amp;milk"), "URLs should be escaped")
+
+		var set urlSet
+		test.Ok(t, xml.Unmarshal(data, &set))
+		test.Equals(t, namespace, set.XMLName.Space)
+		test.Equals(t, testCase.expected, set.URLs)
+	}
+}
+
+// TestSitemap_PreWrite checks if the sitemap plugin rejects an invalid
+// configuration.
+func TestSitemap_PreWrite(t *testing.T) {
+	tests := map[string]struct {
+		base          string
+		options       Options
+		expectedError error
+	}{
+		"valid configuration": {
+			base:    "https://example.com",
+			options: Options{ChangeFreq: "daily", Priority: 1},
+		},
+		"missing base URL": {
+			expectedError: ErrMissingBase,
+		},
+		"invalid change frequency": {
+			base:          "https://example.com",
+			options:       Options{ChangeFreq: "sometimes"},
+			expectedError: ErrInvalidChangeFreq,
+		},
+		"invalid priority": {
+			base:          "https://example.com",
+			options:       Options{Priority: 1.5},
+			expectedError: ErrInvalidPriority,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		site := model.NewSite()
+		s := New(&model.Meta{Base: testCase.base}, testCase.options, afero.NewMemMapFs(), "/target")
+
+		test.ExpectedError(t, testCase.expectedError, s.PreWrite(&site))
+	}
+}
@@ -2,86 +2,66 @@
 package tags
 
 import (
-	"path/filepath"
-	"strings"
-
 	"github.com/verless/verless/model"
+	"github.com/verless/verless/taxonomy"
 	"github.com/verless/verless/tree"
 )
 
-const (
-	// tagsDir is the target directory for all tag directories.
-	tagsDir string = "/tags"
-)
-
-// New creates a new tags plugin that uses templates from the given
-// build path and outputs the tag directories to outputDir.
-func New() *tags {
+// New creates a new tags plugin. If generatePages is true, the plugin
+// registers a list page for each tag and an index of all tags in the
+// site model, which are then rendered by the writer. cleanURLs has to
+// match the build's clean URLs setting.
+func New(generatePages, cleanURLs bool) *tags {
 	t := tags{
-		m: make(map[string]*model.ListPage),
+		Taxonomy: taxonomy.New(taxonomy.Options{
+			Name:          model.TagTaxonomy,
+			GeneratePages: generatePages,
+			CleanURLs:     cleanURLs,
+		}),
+		generatePages: generatePages,
 	}
 
 	return &t
 }
 
-// tags is the actual tags plugin that maintains a map with all
-// tags from all processed pages.
+// tags is the actual tags plugin. It is the taxonomy of all tags from
+// all processed pages.
 type tags struct {
-	m map[string]*model.ListPage
+	*taxonomy.Taxonomy
+	generatePages bool
 }
 
-// ProcessPage creates a new map entry for each tag in the processed
-// page and adds the page to the entry's list page.
-func (t *tags) ProcessPage(page *model.Page) error {
-	for _, tag := range page.Tags {
-		//sanitizing the tags like "Making Coffee" to "making-coffee"
-		tag = strings.Replace(tag, " ", "-", -1)
-		tag = strings.ToLower(tag)
-
-		if _, exists := t.m[tag]; !exists {
-			t.createListPage(tag)
-		}
-		t.m[tag].Pages = append(t.m[tag].Pages, page)
-	}
-
-	return nil
+// Slug converts a tag into the form used for its directory, e.g. from
+// "Making Coffee" to "making-coffee".
+func Slug(tag string) string {
+	return taxonomy.Slug(tag)
 }
 
-// PreWrite registers each list page in the site model. Those list
-// pages will be rendered by the writer.
+// PreWrite registers each list page in the site model along with an
+// index of all tags. Those list pages will be rendered by the writer.
 func (t *tags) PreWrite(site *model.Site) error {
-	node := model.NewNode()
-	node.ListPage.Route = tagsDir
-
-	if err := tree.CreateNode(tagsDir, site.Root, node); err != nil {
+	if err := t.Taxonomy.PreWrite(site); err != nil {
 		return err
 	}
 
-	for tag, listPage := range t.m {
-		path := filepath.ToSlash(filepath.Join(tagsDir, tag))
-
-		node := model.NewNode()
-		node.ListPage = *listPage
-
-		if err := tree.CreateNode(path, site.Root, node); err != nil {
-			return err
-		}
+	if !t.generatePages {
+		return nil
 	}
 
-	return nil
-}
+	node, err := tree.ResolveNode(t.Dir(), site.Root)
+	if err != nil {
+		return err
+	}
+	index := node.(*model.Node)
+
+	for _, term := range index.ListPage.TermList {
+		index.ListPage.TagList = append(index.ListPage.TagList, model.Tag{
+			Name:  term.Name,
+			Slug:  term.Slug,
+			Href:  term.Href,
+			Count: term.Count,
+		})
+	}
 
-// PostWrite isn't needed by the tags plugin.
-func (t *tags) PostWrite() error {
 	return nil
 }
-
-// createListPage initializes a new list page for a given key.
-func (t *tags) createListPage(key string) {
-	t.m[key] = &model.ListPage{
-		Pages: make([]*model.Page, 0),
-		Page: model.Page{
-			Route: tagsDir + "/" + key,
-		},
-	}
-}
@@ -17,8 +17,8 @@ var (
 	}
 )
 
-// TestTags_ProcessPage checks if the tags plugin creates a new map
-// entry for each tag and stores the respective pages in those entries.
+// TestTags_ProcessPage checks if the tags plugin creates a new entry for
+// each tag and stores the respective pages in those entries.
 func TestTags_ProcessPage(t *testing.T) {
 	tests := map[string]struct {
 		pages         []model.Page
@@ -32,21 +32,38 @@ func TestTags_ProcessPage(t *testing.T) {
 	for name, testCase := range tests {
 		t.Log(name)
 
-		tagger := New()
+		tagger := New(true, true)
+		expected := make(map[string][]string)
 
-		for i, page := range testCase.pages {
+		for i := range testCase.pages {
+			page := &testCase.pages[i]
 			t.Logf("process page number %v, route '%v'", i, page.Route)
-			err := tagger.ProcessPage(&page)
+			err := tagger.ProcessPage(page)
 			if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
 				return
 			}
 
 			for _, tag := range page.Tags {
-				taggerTag, exists := tagger.m[tag]
+				expected[tag] = append(expected[tag], page.ID)
+			}
+		}
+
+		// The entries of the tags are only accessible through the site
+		// model.
+		s := model.NewSite()
+		test.Ok(t, tagger.PreWrite(&s))
+
+		taxonomy, exists := s.Taxonomies["tags"]
+		test.Assert(t, exists, "tags taxonomy should exist")
+		test.Equals(t, len(expected), len(taxonomy.Terms))
 
-				test.Assert(t, exists, "tag should exist")
-				test.NotEquals(t, nil, taggerTag)
-				test.Assert(t, len(tagger.m[tag].Pages) > 0, "tag should exist")
+		for _, term := range taxonomy.Terms {
+			ids, exists := expected[term.Name]
+			test.Assert(t, exists, "tag %s should exist", term.Name)
+			test.Equals(t, len(ids), term.Count)
+
+			for _, page := range term.Pages {
+				test.Assert(t, contains(ids, page.ID), "tag %s shouldn't contain %s", term.Name, page.ID)
 			}
 		}
 	}
@@ -56,23 +73,20 @@ func TestTags_ProcessPage(t *testing.T) {
 // dedicated routes in the site model.
 func TestTags_PreWrite(t *testing.T) {
 	tests := map[string]struct {
-		tagsListPages map[string]*model.ListPage
+		tags          []string
 		expectedError error
 	}{
 		"normal list pages": {
-			tagsListPages: map[string]*model.ListPage{
-				"test1": {},
-				"test2": {},
-				"test3": {},
-			},
+			tags: []string{"test1", "test2", "test3"},
 		},
 	}
 
 	for name, testCase := range tests {
 		t.Log(name)
 
-		tagger := New()
-		tagger.m = testCase.tagsListPages
+		tagger := New(true, true)
+		test.Ok(t, tagger.ProcessPage(&model.Page{ID: "page-0", Route: "/route-0", Tags: testCase.tags}))
+
 		s := model.NewSite()
 		err := tagger.PreWrite(&s)
 		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
@@ -83,7 +97,7 @@ func TestTags_PreWrite(t *testing.T) {
 		test.Equals(t, true, ok)
 		test.NotEquals(t, nil, tags)
 
-		for tag := range tagger.m {
+		for _, tag := range testCase.tags {
 			child, ok := tags.Children()[tag]
 			test.Equals(t, true, ok)
 			test.NotEquals(t, nil, child)
@@ -94,3 +108,71 @@ func TestTags_PreWrite(t *testing.T) {
 }
 
 func TestTags_PostWrite(t *testing.T) {}
+
+// TestTags_generatePages checks if the tags plugin creates a list page for
+// each tag and an index of all tags only if pages should be generated.
+func TestTags_generatePages(t *testing.T) {
+	pages := []model.Page{
+		{ID: "page-0", Route: "/blog", Tags: []string{"Making Coffee", "espresso"}},
+		{ID: "page-1", Route: "/blog", Tags: []string{"making coffee", "Making  Coffee"}},
+		{ID: "page-2", Route: "/blog", Tags: []string{"Espresso"}},
+		{ID: "page-3", Route: "/blog", Tags: []string{"Making Coffee"}},
+	}
+
+	tests := map[string]struct {
+		generatePages bool
+		expected      []model.Tag
+	}{
+		"with pages": {
+			generatePages: true,
+			expected: []model.Tag{
+				{Name: "Making Coffee", Slug: "making-coffee", Href: "/tags/making-coffee", Count: 3},
+				{Name: "Espresso", Slug: "espresso", Href: "/tags/espresso", Count: 2},
+			},
+		},
+		"without pages": {
+			generatePages: false,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		tagger := New(testCase.generatePages, true)
+
+		for i := range pages {
+			test.Ok(t, tagger.ProcessPage(&pages[i]))
+		}
+
+		s := model.NewSite()
+		test.Ok(t, tagger.PreWrite(&s))
+
+		index, exists := s.Root.Children()["tags"]
+		test.Equals(t, testCase.generatePages, exists)
+
+		if !exists {
+			continue
+		}
+
+		test.Equals(t, testCase.expected, index.(*model.Node).ListPage.TagList)
+
+		for _, tag := range testCase.expected {
+			child, exists := index.Children()[tag.Slug]
+			test.Assert(t, exists, "%s should have a list page", tag.Slug)
+
+			listPage := child.(*model.Node).ListPage
+			test.Equals(t, tag.Name, listPage.Title)
+			test.Equals(t, tag.Count, len(listPage.Pages))
+		}
+	}
+}
+
+// contains determines whether a slice contains the given value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -1,3 +1,4 @@
+import datetime
 import os
 import shutil
 import subprocess
@@ -48,7 +49,8 @@ def build(go_os, go_arch, git_data):
     env["GOARCH"] = go_arch
 
     ld_flags = "-X github.com/verless/verless/config.GitTag={0} -X github.com/verless/verless/config.GitCommit={1}" \
-        .format(git_data["tag"], git_data["commit"])
+        " -X github.com/verless/verless/config.BuildDate={2}" \
+        .format(git_data["tag"], git_data["commit"], git_data["date"])
 
     subprocess.Popen(
         ["go", "build", "-v", "-ldflags", ld_flags, "-o", target, "cmd/verless/main.go"],
@@ -73,7 +75,8 @@ def package(go_os, go_arch):
 def get_git_data():
     """
     Read the latest annotated Git tag without revision number as
-    well as the short hash of the latest Git commit.
+    well as the short hash of the latest Git commit. The current
+    UTC time is stored as the build date.
 
     The data is stored and returned as a dictionary.
     """
@@ -83,11 +86,14 @@ def get_git_data():
     git_commit = subprocess.check_output(["git", "rev-parse", "--short", "HEAD"])
     git_commit = git_commit.decode("utf-8")
 
+    build_date = datetime.datetime.utcnow().strftime("%Y-%m-%dT%H:%M:%SZ")
+
     log_info("reading git data: tag {0}, commit {1}".format(git_tag, git_commit))
 
     return {
         "tag": git_tag,
         "commit": git_commit,
+        "date": build_date,
     }
 
 
@@ -0,0 +1,318 @@
+package scss
+
+import (
+	"fmt"
+	"strings"
+)
+
+type nodeKind int
+
+const (
+	ruleNode nodeKind = iota
+	atRuleNode
+	declNode
+	varNode
+	importNode
+	statementNode
+)
+
+// node is a rule, an at-rule, a declaration or a statement inside an
+// SCSS file.
+type node struct {
+	kind nodeKind
+	// text is the selector of a rule, the header of an at-rule like
+	// @media screen, the name of a property or variable, the arguments of
+	// an @import rule or an entire statement like @charset "UTF-8".
+	text string
+	// value is the value of a property or variable.
+	value    string
+	line     int
+	children []*node
+}
+
+// parser parses SCSS source code whose comments have been removed into
+// a list of nodes.
+type parser struct {
+	file string
+	src  string
+	pos  int
+	line int
+}
+
+// parse parses the given SCSS source code.
+func parse(file, src string) ([]*node, error) {
+	stripped, err := stripComments(file, src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{file: file, src: stripped, line: 1}
+
+	return p.parseBlock(0)
+}
+
+// parseBlock parses all nodes up to the closing brace of the block that
+// has been opened in the given line. Line 0 denotes the top level of the
+// file, which ends with the source code instead.
+func (p *parser) parseBlock(openLine int) ([]*node, error) {
+	var nodes []*node
+
+	for {
+		p.skipSpace()
+
+		if p.pos >= len(p.src) {
+			if openLine > 0 {
+				return nil, p.errorf(openLine, "missing } for the block opened in this line")
+			}
+			return nodes, nil
+		}
+
+		line := p.line
+		text, end := p.readSegment()
+		text = strings.TrimSpace(text)
+
+		switch end {
+		case '{':
+			if text == "" {
+				return nil, p.errorf(line, "missing selector")
+			}
+
+			children, err := p.parseBlock(p.line)
+			if err != nil {
+				return nil, err
+			}
+
+			kind := ruleNode
+			if strings.HasPrefix(text, "@") {
+				if err := p.checkAtRule(text, line); err != nil {
+					return nil, err
+				}
+				kind = atRuleNode
+			} else if err := p.checkSelector(text, line); err != nil {
+				return nil, err
+			}
+			nodes = append(nodes, &node{kind: kind, text: text, line: line, children: children})
+
+		case ';', '}':
+			if text != "" {
+				n, err := p.parseStatement(text, line)
+				if err != nil {
+					return nil, err
+				}
+				nodes = append(nodes, n)
+			}
+
+			if end == '}' {
+				if openLine == 0 {
+					return nil, p.errorf(p.line, "unexpected }")
+				}
+				return nodes, nil
+			}
+
+		default:
+			return nil, p.errorf(line, "expected ; or { after %q", text)
+		}
+	}
+}
+
+// parseStatement parses a statement terminated by a semicolon, which is
+// a declaration, a variable declaration or an at-rule without block.
+func (p *parser) parseStatement(text string, line int) (*node, error) {
+	switch {
+	case strings.HasPrefix(text, "$"):
+		i := strings.Index(text, ":")
+		if i < 0 {
+			return nil, p.errorf(line, "expected : in variable declaration %q", text)
+		}
+		value := strings.TrimSpace(text[i+1:])
+		if err := p.checkValue(value, line); err != nil {
+			return nil, err
+		}
+		return &node{kind: varNode, text: strings.TrimSpace(text[1:i]), value: value, line: line}, nil
+
+	case strings.HasPrefix(text, "@import"):
+		return &node{kind: importNode, text: strings.TrimSpace(strings.TrimPrefix(text, "@import")), line: line}, nil
+
+	case strings.HasPrefix(text, "@"):
+		if err := p.checkAtRule(text, line); err != nil {
+			return nil, err
+		}
+		return &node{kind: statementNode, text: text, line: line}, nil
+	}
+
+	i := indexOutsideInterpolation(text, ':')
+	if i < 0 {
+		return nil, p.errorf(line, "expected : in declaration %q", text)
+	}
+
+	value := strings.TrimSpace(text[i+1:])
+	if err := p.checkValue(value, line); err != nil {
+		return nil, err
+	}
+	return &node{kind: declNode, text: strings.TrimSpace(text[:i]), value: value, line: line}, nil
+}
+
+// readSegment reads the source code up to the next semicolon or brace
+// that isn't part of a string, parentheses or an interpolation, and
+// returns the read text along with that character. At the end of the
+// source code, the returned character is 0.
+func (p *parser) readSegment() (string, byte) {
+	var (
+		start = p.pos
+		depth = 0
+	)
+
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+
+		switch {
+		case c == '"' || c == '\'':
+			p.skipString(c)
+			continue
+		case c == '#' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '{':
+			p.skipInterpolation()
+			continue
+		case c == '(':
+			depth++
+		case c == ')' && depth > 0:
+			depth--
+		case c == '\n':
+			p.line++
+		case depth == 0 && (c == ';' || c == '{' || c == '}'):
+			p.pos++
+			return p.src[start : p.pos-1], c
+		}
+
+		p.pos++
+	}
+
+	return p.src[start:], 0
+}
+
+// skipString skips a string delimited by the given quote.
+func (p *parser) skipString(quote byte) {
+	for p.pos++; p.pos < len(p.src); p.pos++ {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos++
+		case '\n':
+			p.line++
+		case quote:
+			p.pos++
+			return
+		}
+	}
+}
+
+// skipInterpolation skips an interpolation like #{$name}.
+func (p *parser) skipInterpolation() {
+	for ; p.pos < len(p.src); p.pos++ {
+		switch p.src[p.pos] {
+		case '\n':
+			p.line++
+		case '}':
+			p.pos++
+			return
+		}
+	}
+}
+
+// skipSpace skips all whitespace characters.
+func (p *parser) skipSpace() {
+	for ; p.pos < len(p.src) && isSpace(p.src[p.pos]); p.pos++ {
+		if p.src[p.pos] == '\n' {
+			p.line++
+		}
+	}
+}
+
+// errorf returns an error for the given line of the parsed file.
+func (p *parser) errorf(line int, format string, a ...interface{}) error {
+	return newError(p.file, line, fmt.Sprintf(format, a...))
+}
+
+// stripComments removes all comments from the source code. Line breaks
+// inside block comments are kept, so that the lines of all nodes are the
+// same as in the original source code. Slashes inside strings and
+// unquoted URLs like url(http://example.com) don't start a comment.
+func stripComments(file, src string) (string, error) {
+	var (
+		b     strings.Builder
+		inURL bool
+	)
+
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+
+		switch {
+		case c == '"' || c == '\'':
+			j := i + 1
+			for ; j < len(src) && src[j] != c && src[j] != '\n'; j++ {
+				if src[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(src) {
+				j = len(src) - 1
+			}
+			b.WriteString(src[i : j+1])
+			i = j
+			continue
+
+		case inURL:
+			inURL = c != ')'
+
+		case c == '(' && i >= 3 && strings.EqualFold(src[i-3:i], "url"):
+			inURL = true
+
+		case c == '/' && strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			if i < len(src) {
+				b.WriteByte('\n')
+			}
+			continue
+
+		case c == '/' && strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return "", newError(file, strings.Count(src[:i], "\n")+1, "missing */ for the comment opened in this line")
+			}
+			comment := src[i : i+2+end+2]
+			b.WriteString(strings.Repeat("\n", strings.Count(comment, "\n")))
+			b.WriteByte(' ')
+			i += len(comment) - 1
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String(), nil
+}
+
+// indexOutsideInterpolation returns the index of the first occurrence of
+// c in s that isn't part of an interpolation like #{$name}.
+func indexOutsideInterpolation(s string, c byte) int {
+	depth := 0
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '#' && i+1 < len(s) && s[i+1] == '{':
+			depth++
+			i++
+		case s[i] == '}' && depth > 0:
+			depth--
+		case s[i] == c && depth == 0:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// isSpace reports whether c is a whitespace character.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,492 @@
+// Package scss provides a compiler for a subset of SCSS. It isn't a Sass
+// implementation.
+//
+// The subset consists of the features commonly used by themes: variables
+// including the !default and !global flags, nested rules with the parent
+// selector &, nested at-rules like @media, interpolations of a single
+// variable like #{$name} and @import rules for other SCSS files and
+// partials. Variable values are inserted as written without evaluating
+// them. Other features like mixins, functions, control directives and
+// arithmetics aren't supported and are reported as an error with the file
+// and line.
+package scss
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrInvalidSCSS states that an SCSS file cannot be compiled.
+	ErrInvalidSCSS = errors.New("invalid SCSS")
+
+	// variablePattern matches variable references like $main-color.
+	variablePattern = regexp.MustCompile(`\$[A-Za-z_][\w-]*`)
+
+	// interpolationPattern matches interpolations like #{$main-color}.
+	interpolationPattern = regexp.MustCompile(`#\{([^}]*)\}`)
+)
+
+// newError returns an error for the given line of an SCSS file.
+func newError(file string, line int, msg string) error {
+	return fmt.Errorf("%s:%d: %w: %s", file, line, ErrInvalidSCSS, msg)
+}
+
+// CompileFile compiles the SCSS file with the given path to CSS. Files
+// imported using @import are resolved relative to the importing file.
+// The returned error contains the file and line of the problem.
+func CompileFile(file string) ([]byte, error) {
+	c := compiler{}
+
+	nodes, err := c.load(file)
+	if err != nil {
+		return nil, err
+	}
+
+	blocks, err := c.compile(file, nodes, context{scope: newScope(nil)})
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(strings.Join(blocks, "\n")), nil
+}
+
+// compiler compiles a tree of parsed SCSS nodes into CSS blocks.
+type compiler struct {
+	// imports contains the files that are currently being compiled, from
+	// the compiled file to the innermost imported file.
+	imports []string
+}
+
+// context is the context in which nodes are compiled.
+type context struct {
+	// selectors are the resolved selectors of the enclosing rule. They
+	// are empty at the top level and inside top-level at-rules.
+	selectors []string
+	// inAtRule is set inside at-rules like @font-face, which allow
+	// declarations without a rule.
+	inAtRule bool
+	scope    *scope
+}
+
+// load reads and parses an SCSS file.
+func (c *compiler) load(file string) ([]*node, error) {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return parse(file, string(src))
+}
+
+// compile compiles the given nodes of an SCSS file into CSS blocks like
+// rules. Declarations are grouped into a rule of the enclosing selectors
+// until a nested rule follows, just like Sass does.
+func (c *compiler) compile(file string, nodes []*node, ctx context) ([]string, error) {
+	var (
+		blocks []string
+		decls  []string
+	)
+
+	flush := func() {
+		if len(decls) == 0 {
+			return
+		}
+		if len(ctx.selectors) == 0 {
+			blocks = append(blocks, strings.Join(decls, ""))
+		} else {
+			blocks = append(blocks, block(strings.Join(ctx.selectors, ", "), decls))
+		}
+		decls = nil
+	}
+
+	for _, n := range nodes {
+		switch n.kind {
+		case varNode:
+			if err := c.declareVariable(file, n, ctx.scope); err != nil {
+				return nil, err
+			}
+
+		case declNode:
+			if len(ctx.selectors) == 0 && !ctx.inAtRule {
+				return nil, newError(file, n.line, fmt.Sprintf("declaration %s outside of a rule", n.text))
+			}
+
+			name, err := interpolate(file, n.line, n.text, ctx.scope)
+			if err != nil {
+				return nil, err
+			}
+			value, err := substitute(file, n.line, n.value, ctx.scope)
+			if err != nil {
+				return nil, err
+			}
+
+			decls = append(decls, fmt.Sprintf("%s: %s;\n", name, value))
+
+		case ruleNode:
+			flush()
+
+			selector, err := interpolate(file, n.line, n.text, ctx.scope)
+			if err != nil {
+				return nil, err
+			}
+			selectors, err := resolveSelectors(file, n.line, ctx.selectors, selector)
+			if err != nil {
+				return nil, err
+			}
+
+			inner, err := c.compile(file, n.children, context{selectors: selectors, scope: newScope(ctx.scope)})
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, inner...)
+
+		case atRuleNode:
+			flush()
+
+			header, err := substitute(file, n.line, n.text, ctx.scope)
+			if err != nil {
+				return nil, err
+			}
+
+			inner := context{selectors: ctx.selectors, inAtRule: true, scope: newScope(ctx.scope)}
+			// The selectors of keyframes must not be nested into the
+			// enclosing rule.
+			if strings.HasSuffix(strings.Fields(header)[0], "keyframes") {
+				inner.selectors = nil
+			}
+
+			children, err := c.compile(file, n.children, inner)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, header+" {\n"+indent(strings.Join(children, "\n"))+"}\n")
+
+		case importNode:
+			flush()
+
+			imported, err := c.compileImports(file, n, ctx)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, imported...)
+
+		case statementNode:
+			flush()
+
+			statement, err := substitute(file, n.line, n.text, ctx.scope)
+			if err != nil {
+				return nil, err
+			}
+			blocks = append(blocks, statement+";\n")
+		}
+	}
+
+	flush()
+
+	return blocks, nil
+}
+
+// declareVariable assigns the value of a variable declaration.
+func (c *compiler) declareVariable(file string, n *node, sc *scope) error {
+	value := n.value
+	target := sc
+
+	flags := map[string]bool{}
+	for _, flag := range []string{"!default", "!global"} {
+		if strings.HasSuffix(value, flag) {
+			flags[flag] = true
+			value = strings.TrimSpace(strings.TrimSuffix(value, flag))
+		}
+	}
+
+	if flags["!default"] {
+		if _, exists := sc.lookup(n.text); exists {
+			return nil
+		}
+	}
+	if flags["!global"] {
+		target = sc.root()
+	}
+
+	value, err := substitute(file, n.line, value, sc)
+	if err != nil {
+		return err
+	}
+
+	target.set(n.text, value)
+
+	return nil
+}
+
+// compileImports compiles the SCSS files imported by an @import rule
+// inside the given context. Imports of plain CSS files or URLs are kept
+// as @import rules.
+func (c *compiler) compileImports(file string, n *node, ctx context) ([]string, error) {
+	var blocks []string
+
+	for _, arg := range splitList(n.text) {
+		name := strings.Trim(arg, `"'`)
+
+		if name == arg || strings.HasSuffix(name, ".css") || strings.Contains(name, "://") {
+			blocks = append(blocks, "@import "+arg+";\n")
+			continue
+		}
+
+		importedFile, err := resolveImport(filepath.Dir(file), name)
+		if err != nil {
+			return nil, newError(file, n.line, err.Error())
+		}
+
+		for _, current := range c.imports {
+			if current == importedFile {
+				return nil, newError(file, n.line, fmt.Sprintf("%s imports itself", name))
+			}
+		}
+
+		nodes, err := c.load(importedFile)
+		if err != nil {
+			return nil, err
+		}
+
+		c.imports = append(c.imports, file)
+		imported, err := c.compile(importedFile, nodes, ctx)
+		c.imports = c.imports[:len(c.imports)-1]
+
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, imported...)
+	}
+
+	return blocks, nil
+}
+
+// resolveImport returns the path of an imported SCSS file like colors,
+// which may be colors.scss or the partial _colors.scss.
+func resolveImport(dir, name string) (string, error) {
+	name = filepath.FromSlash(name)
+	base, file := filepath.Split(name)
+
+	candidates := []string{name}
+	if filepath.Ext(name) != ".scss" {
+		candidates = []string{name + ".scss", filepath.Join(base, "_"+file+".scss")}
+	}
+
+	for _, candidate := range candidates {
+		path := filepath.Join(dir, candidate)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("cannot find imported file %s", filepath.ToSlash(name))
+}
+
+// resolveSelectors combines the selectors of a nested rule with the
+// selectors of its enclosing rule. The parent selector & is replaced
+// with each enclosing selector. Selectors without & are nested as
+// descendants.
+func resolveSelectors(file string, line int, parents []string, selector string) ([]string, error) {
+	var selectors []string
+
+	for _, child := range splitList(selector) {
+		child = strings.Join(strings.Fields(child), " ")
+
+		if len(parents) == 0 {
+			if strings.Contains(child, "&") {
+				return nil, newError(file, line, fmt.Sprintf("parent selector & in top-level selector %s", child))
+			}
+			selectors = append(selectors, child)
+			continue
+		}
+
+		for _, parent := range parents {
+			if strings.Contains(child, "&") {
+				selectors = append(selectors, strings.ReplaceAll(child, "&", parent))
+			} else {
+				selectors = append(selectors, parent+" "+child)
+			}
+		}
+	}
+
+	return selectors, nil
+}
+
+// interpolate replaces all interpolations like #{$name} with the values
+// of their variables.
+func interpolate(file string, line int, s string, sc *scope) (string, error) {
+	var err error
+
+	result := interpolationPattern.ReplaceAllStringFunc(s, func(match string) string {
+		expr := strings.TrimSpace(interpolationPattern.FindStringSubmatch(match)[1])
+
+		if !variablePattern.MatchString(expr) || variablePattern.FindString(expr) != expr {
+			if err == nil {
+				err = newError(file, line, fmt.Sprintf("unsupported interpolation %s", match))
+			}
+			return match
+		}
+
+		value, e := substitute(file, line, expr, sc)
+		if e != nil && err == nil {
+			err = e
+		}
+
+		// Quoted strings are inserted without their quotes.
+		return strings.Trim(value, `"'`)
+	})
+
+	return result, err
+}
+
+// substitute replaces all interpolations and variable references inside
+// a value with the values of their variables. Whitespace is collapsed.
+func substitute(file string, line int, s string, sc *scope) (string, error) {
+	s, err := interpolate(file, line, s, sc)
+	if err != nil {
+		return "", err
+	}
+
+	s = variablePattern.ReplaceAllStringFunc(s, func(match string) string {
+		value, exists := sc.lookup(match[1:])
+		if !exists {
+			if err == nil {
+				err = newError(file, line, fmt.Sprintf("undefined variable %s", match))
+			}
+			return match
+		}
+		return value
+	})
+
+	return collapseSpace(s), err
+}
+
+// block formats a rule with the given selector and declarations.
+func block(selector string, decls []string) string {
+	return selector + " {\n" + indent(strings.Join(decls, "")) + "}\n"
+}
+
+// indent indents all non-empty lines by two spaces.
+func indent(s string) string {
+	lines := strings.SplitAfter(s, "\n")
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = "  " + line
+		}
+	}
+
+	return strings.Join(lines, "")
+}
+
+// splitList splits a comma-separated list like a selector list. Commas
+// inside strings and parentheses like in :not(a, b) are ignored.
+func splitList(s string) []string {
+	var (
+		items []string
+		depth int
+		quote byte
+		start int
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case (c == ')' || c == ']') && depth > 0:
+			depth--
+		case c == ',' && depth == 0:
+			items = append(items, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(items, strings.TrimSpace(s[start:]))
+}
+
+// collapseSpace collapses all whitespace outside of strings into single
+// spaces and trims the result.
+func collapseSpace(s string) string {
+	var (
+		b     strings.Builder
+		quote byte
+		space bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if quote == 0 && isSpace(c) {
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote = c
+		}
+
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+// scope holds the variables declared inside a block.
+type scope struct {
+	vars   map[string]string
+	parent *scope
+}
+
+// newScope creates a new scope nested into the given parent scope.
+func newScope(parent *scope) *scope {
+	return &scope{vars: make(map[string]string), parent: parent}
+}
+
+// lookup returns the value of the variable with the given name, which is
+// searched in this scope and then in all enclosing scopes. Like in Sass,
+// hyphens and underscores in variable names are interchangeable.
+func (s *scope) lookup(name string) (string, bool) {
+	name = strings.ReplaceAll(name, "_", "-")
+
+	for current := s; current != nil; current = current.parent {
+		if value, exists := current.vars[name]; exists {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+// set assigns a value to the variable with the given name in this scope.
+func (s *scope) set(name, value string) {
+	s.vars[strings.ReplaceAll(name, "_", "-")] = value
+}
+
+// root returns the global scope.
+func (s *scope) root() *scope {
+	for s.parent != nil {
+		s = s.parent
+	}
+	return s
+}
@@ -0,0 +1,268 @@
+package scss
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/verless/verless/test"
+)
+
+// TestCompileFile checks if SCSS files are compiled into the expected
+// CSS and if invalid files are reported with their file and line.
+func TestCompileFile(t *testing.T) {
+	tests := map[string]struct {
+		files         map[string]string
+		expected      string
+		expectedError string
+	}{
+		"variables and nesting": {
+			files: map[string]string{
+				"style.scss": `$main-color: #333;
+$spacing: 4px;
+
+// Navigation
+.nav {
+  color: $main-color;
+
+  a {
+    padding: $spacing 2px;
+
+    &:hover { color: blue }
+  }
+}
+`,
+			},
+			expected: `.nav {
+  color: #333;
+}
+
+.nav a {
+  padding: 4px 2px;
+}
+
+.nav a:hover {
+  color: blue;
+}
+`,
+		},
+		"selector lists and interpolation": {
+			files: map[string]string{
+				"style.scss": `$size: large;
+h1, h2 {
+  .icon-#{$size}, &.title { margin: 0; }
+}
+`,
+			},
+			expected: `h1 .icon-large, h2 .icon-large, h1.title, h2.title {
+  margin: 0;
+}
+`,
+		},
+		"declarations after a nested rule": {
+			files: map[string]string{
+				"style.scss": `.card {
+  color: red;
+  p { margin: 0; }
+  padding: 1em;
+}
+`,
+			},
+			expected: `.card {
+  color: red;
+}
+
+.card p {
+  margin: 0;
+}
+
+.card {
+  padding: 1em;
+}
+`,
+		},
+		"nested media query": {
+			files: map[string]string{
+				"style.scss": `$mobile: 600px;
+.sidebar {
+  width: 30%;
+  @media (max-width: $mobile) {
+    display: none;
+  }
+}
+`,
+			},
+			expected: `.sidebar {
+  width: 30%;
+}
+
+@media (max-width: 600px) {
+  .sidebar {
+    display: none;
+  }
+}
+`,
+		},
+		"imported partial with default variables": {
+			files: map[string]string{
+				"_colors.scss": "$main: #333 !default;\n$accent: blue !default;\n",
+				"style.scss":   "$main: red;\n@import \"colors\";\n@import \"print.css\";\na { color: $main; border-color: $accent; }\n",
+			},
+			expected: `@import "print.css";
+
+a {
+  color: red;
+  border-color: blue;
+}
+`,
+		},
+		"scoped and global variables": {
+			files: map[string]string{
+				"style.scss": "$color: red;\n.a { $color: blue; $width: 1px !global; color: $color; }\n.b { color: $color; width: $width; }\n",
+			},
+			expected: `.a {
+  color: blue;
+}
+
+.b {
+  color: red;
+  width: 1px;
+}
+`,
+		},
+		"comments and URLs": {
+			files: map[string]string{
+				"style.scss": "/* Header\n   styles */\n.header { background: url(http://example.com/bg.png); content: \"//\"; }\n",
+			},
+			expected: `.header {
+  background: url(http://example.com/bg.png);
+  content: "//";
+}
+`,
+		},
+		"undefined variable": {
+			files: map[string]string{
+				"style.scss": ".a {\n  color: $missing;\n}\n",
+			},
+			expectedError: "style.scss:2: invalid SCSS: undefined variable $missing",
+		},
+		"missing closing brace": {
+			files: map[string]string{
+				"style.scss": "a { color: red; }\n.b {\n  color: blue;\n",
+			},
+			expectedError: "style.scss:2: invalid SCSS: missing } for the block opened in this line",
+		},
+		"error inside an imported file": {
+			files: map[string]string{
+				"_base.scss": "body {\n  margin 0;\n}\n",
+				"style.scss": "@import 'base';\n",
+			},
+			expectedError: "_base.scss:2: invalid SCSS: expected : in declaration \"margin 0\"",
+		},
+		"mixin": {
+			files: map[string]string{
+				"style.scss": "$gap: 4px;\n@mixin spaced {\n  margin: $gap;\n}\n",
+			},
+			expectedError: "style.scss:2: invalid SCSS: unsupported at-rule @mixin",
+		},
+		"include": {
+			files: map[string]string{
+				"style.scss": ".a {\n  color: red;\n  @include spaced;\n}\n",
+			},
+			expectedError: "style.scss:3: invalid SCSS: unsupported at-rule @include",
+		},
+		"control directive": {
+			files: map[string]string{
+				"style.scss": "$dark: true;\n\n@if $dark {\n  body { color: white; }\n}\n",
+			},
+			expectedError: "style.scss:3: invalid SCSS: unsupported at-rule @if",
+		},
+		"function": {
+			files: map[string]string{
+				"style.scss": "$main-color: #333;\n.a {\n  color: darken($main-color, 10%);\n}\n",
+			},
+			expectedError: "style.scss:3: invalid SCSS: unsupported function darken() in \"darken($main-color, 10%)\"",
+		},
+		"arithmetics with variables": {
+			files: map[string]string{
+				"style.scss": "$spacing: 4px;\n$large: $spacing * 2;\n",
+			},
+			expectedError: "style.scss:2: invalid SCSS: unsupported arithmetics in \"$spacing * 2\"",
+		},
+		"arithmetics with numbers": {
+			files: map[string]string{
+				"style.scss": ".a {\n  width: 100px - 20px;\n}\n",
+			},
+			expectedError: "style.scss:2: invalid SCSS: unsupported arithmetics in \"100px - 20px\"",
+		},
+		"placeholder selector": {
+			files: map[string]string{
+				"style.scss": "%button {\n  padding: 4px;\n}\n",
+			},
+			expectedError: "style.scss:1: invalid SCSS: unsupported placeholder selector %button",
+		},
+		"nested properties": {
+			files: map[string]string{
+				"style.scss": ".a {\n  font: {\n    family: serif;\n  }\n}\n",
+			},
+			expectedError: "style.scss:2: invalid SCSS: unsupported nested properties font:",
+		},
+		"keyframes with percentages": {
+			files: map[string]string{
+				"style.scss": "@keyframes fade {\n  0%, 50% { opacity: 0; }\n  100% { opacity: 1; }\n}\n",
+			},
+			expected: `@keyframes fade {
+  0%, 50% {
+    opacity: 0;
+  }
+
+  100% {
+    opacity: 1;
+  }
+}
+`,
+		},
+		"CSS functions and calc": {
+			files: map[string]string{
+				"style.scss": "$gap: 4px;\n.a {\n  width: calc(100% - #{$gap} * 2);\n  margin: 0 -$gap 50% $gap;\n  color: color-mix(in srgb, red 50%, blue);\n  font: 12px/1.5 serif;\n}\n",
+			},
+			expected: `.a {
+  width: calc(100% - 4px * 2);
+  margin: 0 -4px 50% 4px;
+  color: color-mix(in srgb, red 50%, blue);
+  font: 12px/1.5 serif;
+}
+`,
+		},
+		"missing import": {
+			files: map[string]string{
+				"style.scss": "\n@import \"missing\";\n",
+			},
+			expectedError: "style.scss:2: invalid SCSS: cannot find imported file missing",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		dir, err := ioutil.TempDir("", "verless-scss")
+		test.Ok(t, err)
+
+		for file, content := range testCase.files {
+			test.Ok(t, ioutil.WriteFile(filepath.Join(dir, file), []byte(content), 0644))
+		}
+
+		css, err := CompileFile(filepath.Join(dir, "style.scss"))
+		_ = os.RemoveAll(dir)
+
+		if testCase.expectedError != "" {
+			test.ExpectedError(t, ErrInvalidSCSS, err)
+			test.Equals(t, filepath.Join(dir, testCase.expectedError), err.Error())
+			continue
+		}
+
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, string(css))
+	}
+}
@@ -0,0 +1,97 @@
+package scss
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// unsupportedAtRules contains the Sass at-rules that the compiler
+	// doesn't support. Plain CSS at-rules like @media are passed through.
+	unsupportedAtRules = map[string]bool{
+		"@at-root":  true,
+		"@content":  true,
+		"@debug":    true,
+		"@each":     true,
+		"@else":     true,
+		"@error":    true,
+		"@extend":   true,
+		"@for":      true,
+		"@forward":  true,
+		"@function": true,
+		"@if":       true,
+		"@include":  true,
+		"@mixin":    true,
+		"@return":   true,
+		"@use":      true,
+		"@warn":     true,
+		"@while":    true,
+	}
+
+	// sassFunctionPattern matches calls of Sass functions that aren't CSS
+	// functions as well, like darken($main-color, 10%).
+	sassFunctionPattern = regexp.MustCompile(`(?:^|[^\w-])(lighten|darken|saturate|desaturate|adjust-hue|adjust-color|scale-color|change-color|mix|complement|transparentize|opacify|fade-in|fade-out|percentage|ceil|floor|random|map-get|map-merge|map-remove|map-keys|map-values|map-has-key|nth|set-nth|length|join|append|zip|index|unquote|quote|str-length|str-insert|str-index|str-slice|to-upper-case|to-lower-case|type-of|unit|unitless|comparable|if)\(`)
+
+	// variableArithmeticPattern matches operations on variables like
+	// $spacing * 2 or 100% - $width. A minus directly in front of a
+	// variable like -$spacing is a negation and works by substitution.
+	variableArithmeticPattern = regexp.MustCompile(`\$[\w-]+\s*(?:[+*/%]|-\s)|(?:[+*/]|\s-\s)\s*\$`)
+
+	// numberArithmeticPattern matches operations on numbers like 2px * 3
+	// or 10px + 5px. Divisions are ambiguous in CSS values like 12px/1.5
+	// and are left untouched.
+	numberArithmeticPattern = regexp.MustCompile(`\d[a-z%]*\s*\*\s*[\d.]|\d[a-z%]*\s+[+-]\s+[\d.]`)
+
+	// placeholderPattern matches placeholder selectors like %button,
+	// which are only used by @extend.
+	placeholderPattern = regexp.MustCompile(`(?:^|[\s,>+~])%[\w-]`)
+
+	// calcPattern matches calc() expressions including nested parentheses,
+	// whose arithmetic is evaluated by the browser.
+	calcPattern = regexp.MustCompile(`calc\((?:[^()]|\([^()]*\))*\)`)
+)
+
+// checkAtRule returns an error if the given statement or at-rule header
+// uses a Sass at-rule like @mixin that isn't supported.
+func (p *parser) checkAtRule(text string, line int) error {
+	keyword := strings.Fields(text)[0]
+	if i := strings.IndexAny(keyword, "(;"); i > 0 {
+		keyword = keyword[:i]
+	}
+
+	if unsupportedAtRules[keyword] {
+		return p.errorf(line, "unsupported at-rule %s", keyword)
+	}
+
+	return nil
+}
+
+// checkSelector returns an error if the given selector of a rule is a
+// placeholder selector or the name of nested properties like font:, which
+// aren't supported.
+func (p *parser) checkSelector(selector string, line int) error {
+	if strings.HasSuffix(selector, ":") {
+		return p.errorf(line, "unsupported nested properties %s", selector)
+	}
+	if placeholderPattern.MatchString(selector) {
+		return p.errorf(line, "unsupported placeholder selector %s", selector)
+	}
+
+	return nil
+}
+
+// checkValue returns an error if the given value of a declaration or
+// variable uses a Sass function or arithmetics, which aren't supported.
+func (p *parser) checkValue(value string, line int) error {
+	if match := sassFunctionPattern.FindStringSubmatch(value); match != nil {
+		return p.errorf(line, "unsupported function %s() in %q", match[1], value)
+	}
+
+	expr := calcPattern.ReplaceAllString(value, "calc()")
+
+	if variableArithmeticPattern.MatchString(expr) || numberArithmeticPattern.MatchString(expr) {
+		return p.errorf(line, "unsupported arithmetics in %q", value)
+	}
+
+	return nil
+}
@@ -0,0 +1,396 @@
+// Package shortcode provides shortcodes, which insert the output of a
+// template into Markdown content.
+//
+// A shortcode like {{< youtube dQw4w9WgXcQ autoplay="true" >}} invokes
+// the template called youtube with the positional argument dQw4w9WgXcQ
+// and the named argument autoplay. Arguments containing spaces have to be
+// enclosed in double quotes. A shortcode can be escaped by writing it as
+// {{</* youtube dQw4w9WgXcQ */>}}, which is rendered as the shortcode
+// itself instead of its output.
+package shortcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+const (
+	// Before renders shortcodes before converting the Markdown content to
+	// HTML, so that their output is converted as well. This is the
+	// default.
+	Before string = "before"
+	// After inserts the output of shortcodes into the converted HTML.
+	After string = "after"
+
+	openDelim          = "{{<"
+	closeDelim         = ">}}"
+	escapedOpenDelim   = "{{</*"
+	escapedCloseDelim  = "*/>}}"
+	placeholderPattern = "verless-shortcode-%d"
+
+	// maxDepth is the maximum number of nested shortcodes, i.e. shortcodes
+	// whose output contains further shortcodes.
+	maxDepth int = 10
+)
+
+var (
+	// ErrUnknownShortcode states that the theme doesn't provide a template
+	// for a shortcode.
+	ErrUnknownShortcode = errors.New("unknown shortcode")
+
+	// ErrInvalidShortcode states that a shortcode is not properly closed or
+	// its arguments can't be parsed.
+	ErrInvalidShortcode = errors.New("invalid shortcode")
+
+	// ErrRecursion states that a shortcode directly or indirectly invokes
+	// itself, or that shortcodes are nested too deeply.
+	ErrRecursion = errors.New("recursive shortcode")
+)
+
+// Call represents the invocation of a shortcode. It is passed to the
+// shortcode template.
+type Call struct {
+	Name string
+	// Args contains all positional arguments.
+	Args []string
+	// Params contains all named arguments like autoplay="true".
+	Params map[string]string
+}
+
+// Get returns the positional argument with the given index if key is an
+// int, or the named argument with the given name otherwise. If there is
+// no such argument, Get returns an empty string.
+func (c Call) Get(key interface{}) string {
+	switch key := key.(type) {
+	case int:
+		if key >= 0 && key < len(c.Args) {
+			return c.Args[key]
+		}
+		return ""
+	case string:
+		return c.Params[key]
+	}
+	return ""
+}
+
+// Shortcodes renders the shortcodes in Markdown content using the
+// templates of a theme. It is safe for concurrent usage.
+type Shortcodes struct {
+	templates map[string]*template.Template
+}
+
+// New parses the given template files, which are keyed by their shortcode
+// name like youtube, and returns a Shortcodes instance rendering them.
+// funcs contains the functions available in the templates.
+func New(files map[string]string, funcs template.FuncMap) (*Shortcodes, error) {
+	s := Shortcodes{
+		templates: make(map[string]*template.Template, len(files)),
+	}
+
+	for name, file := range files {
+		text, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		tpl, err := template.New(name).Funcs(funcs).Parse(string(text))
+		if err != nil {
+			return nil, err
+		}
+
+		s.templates[name] = tpl
+	}
+
+	return &s, nil
+}
+
+// Render replaces all shortcodes in the Markdown content of src with
+// their output. A front matter block at the beginning of src is left
+// untouched. Errors contain the line and column of the shortcode.
+func (s *Shortcodes) Render(src []byte) ([]byte, error) {
+	return s.replace(src, func(output string) string {
+		return output
+	})
+}
+
+// Extract replaces all shortcodes in the Markdown content of src with
+// placeholders and returns their outputs, which can be inserted into the
+// converted HTML using Restore. Like Render, Extract leaves a front
+// matter block untouched.
+func (s *Shortcodes) Extract(src []byte) ([]byte, []string, error) {
+	var outputs []string
+
+	content, err := s.replace(src, func(output string) string {
+		outputs = append(outputs, output)
+		return fmt.Sprintf(placeholderPattern, len(outputs)-1)
+	})
+
+	return content, outputs, err
+}
+
+// Restore replaces the placeholders created by Extract with the outputs
+// of the corresponding shortcodes. A placeholder that makes up an entire
+// paragraph replaces the paragraph, so that block elements don't end up
+// inside a <p> element.
+func Restore(html string, outputs []string) string {
+	// Replace the placeholders starting with the highest index, so that
+	// verless-shortcode-1 doesn't replace a part of verless-shortcode-10.
+	for i := len(outputs) - 1; i >= 0; i-- {
+		placeholder := fmt.Sprintf(placeholderPattern, i)
+		html = strings.Replace(html, "<p>"+placeholder+"</p>", outputs[i], -1)
+		html = strings.Replace(html, placeholder, outputs[i], -1)
+	}
+
+	return html
+}
+
+// replace renders all shortcodes after the front matter of src and
+// writes the result of insert for each output instead of the shortcode.
+func (s *Shortcodes) replace(src []byte, insert func(output string) string) ([]byte, error) {
+	start := frontMatterEnd(src)
+
+	content, pos, err := s.expand(string(src[start:]), nil, insert)
+	if err != nil {
+		line, column := position(src, start+pos)
+		return nil, fmt.Errorf("line %d, column %d: %w", line, column, err)
+	}
+
+	return append(src[:start:start], content...), nil
+}
+
+// expand renders all shortcodes in content, which has been produced by
+// the shortcodes in stack. If rendering fails, expand returns the offset
+// of the offending shortcode in content.
+func (s *Shortcodes) expand(content string, stack []string, insert func(output string) string) (string, int, error) {
+	var (
+		b   strings.Builder
+		pos int
+	)
+
+	for {
+		i := strings.Index(content[pos:], openDelim)
+		if i < 0 {
+			b.WriteString(content[pos:])
+			return b.String(), 0, nil
+		}
+
+		b.WriteString(content[pos : pos+i])
+		pos += i
+
+		if strings.HasPrefix(content[pos:], escapedOpenDelim) {
+			end := strings.Index(content[pos:], escapedCloseDelim)
+			if end < 0 {
+				return "", pos, fmt.Errorf("missing %s: %w", escapedCloseDelim, ErrInvalidShortcode)
+			}
+
+			inner := content[pos+len(escapedOpenDelim) : pos+end]
+			b.WriteString(openDelim + inner + closeDelim)
+			pos += end + len(escapedCloseDelim)
+			continue
+		}
+
+		end := strings.Index(content[pos:], closeDelim)
+		if end < 0 {
+			return "", pos, fmt.Errorf("missing %s: %w", closeDelim, ErrInvalidShortcode)
+		}
+
+		call, err := parseCall(content[pos+len(openDelim) : pos+end])
+		if err != nil {
+			return "", pos, err
+		}
+
+		output, err := s.execute(call, stack)
+		if err != nil {
+			return "", pos, err
+		}
+
+		b.WriteString(insert(output))
+		pos += end + len(closeDelim)
+	}
+}
+
+// execute renders the shortcode template for the given call, including
+// all shortcodes in its output.
+func (s *Shortcodes) execute(call Call, stack []string) (string, error) {
+	// Copy the stack so that sibling shortcodes don't share it.
+	stack = append(stack[:len(stack):len(stack)], call.Name)
+
+	for _, name := range stack[:len(stack)-1] {
+		if name == call.Name {
+			return "", fmt.Errorf("%s: %w", strings.Join(stack, " -> "), ErrRecursion)
+		}
+	}
+
+	if len(stack) > maxDepth {
+		return "", fmt.Errorf("%s: more than %d nested shortcodes: %w", strings.Join(stack, " -> "), maxDepth, ErrRecursion)
+	}
+
+	tpl, exists := s.templates[call.Name]
+	if !exists {
+		return "", fmt.Errorf("%s: %w", call.Name, ErrUnknownShortcode)
+	}
+
+	var b strings.Builder
+
+	if err := tpl.Execute(&b, call); err != nil {
+		return "", err
+	}
+
+	output, _, err := s.expand(b.String(), stack, func(output string) string {
+		return output
+	})
+
+	return output, err
+}
+
+// parseCall parses the contents of a shortcode between its delimiters,
+// like youtube dQw4w9WgXcQ autoplay="true".
+func parseCall(inner string) (Call, error) {
+	call := Call{
+		Params: make(map[string]string),
+	}
+
+	rest := strings.TrimSpace(inner)
+
+	for first := true; rest != ""; first = false {
+		var (
+			key, value string
+			err        error
+		)
+
+		if value, rest, err = readValue(rest); err != nil {
+			return Call{}, err
+		}
+
+		if strings.HasPrefix(rest, "=") {
+			if first {
+				return Call{}, fmt.Errorf("missing shortcode name: %w", ErrInvalidShortcode)
+			}
+			key = value
+			if value, rest, err = readValue(rest[1:]); err != nil {
+				return Call{}, err
+			}
+		}
+
+		if rest != "" && !isSpace(rest[0]) {
+			return Call{}, fmt.Errorf("unexpected %q: %w", rest[0], ErrInvalidShortcode)
+		}
+		rest = strings.TrimSpace(rest)
+
+		switch {
+		case first:
+			call.Name = value
+		case key != "":
+			call.Params[key] = value
+		default:
+			call.Args = append(call.Args, value)
+		}
+	}
+
+	if call.Name == "" {
+		return Call{}, fmt.Errorf("missing shortcode name: %w", ErrInvalidShortcode)
+	}
+
+	return call, nil
+}
+
+// readValue reads a bare or quoted value from the beginning of s and
+// returns the value along with the rest of s. A bare value ends before
+// whitespace or an equals sign.
+func readValue(s string) (string, string, error) {
+	if !strings.HasPrefix(s, `"`) {
+		end := strings.IndexFunc(s, func(r rune) bool {
+			return r == '=' || r == '"' || (r < 128 && isSpace(byte(r)))
+		})
+		if end < 0 {
+			end = len(s)
+		}
+		return s[:end], s[end:], nil
+	}
+
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			value, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", fmt.Errorf("%s: %w", s[:i+1], ErrInvalidShortcode)
+			}
+			return value, s[i+1:], nil
+		}
+	}
+
+	return "", "", fmt.Errorf("unterminated quoted argument: %w", ErrInvalidShortcode)
+}
+
+// isSpace reports whether c is an ASCII whitespace character.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// frontMatterEnd returns the offset of the first byte after the front
+// matter block at the beginning of src, which is either YAML delimited by
+// --- lines, TOML delimited by +++ lines or a JSON object. If there is no
+// front matter, frontMatterEnd returns 0.
+func frontMatterEnd(src []byte) int {
+	for _, delimiter := range []string{"---", "+++"} {
+		if bytes.HasPrefix(src, []byte(delimiter)) {
+			return delimitedEnd(src, delimiter)
+		}
+	}
+
+	if bytes.HasPrefix(src, []byte("{")) {
+		rest := bytes.TrimLeft(src[1:], " \t\r\n")
+		if len(rest) == 0 || (rest[0] != '"' && rest[0] != '}') {
+			return 0
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(src))
+		var object map[string]interface{}
+		if err := decoder.Decode(&object); err != nil {
+			return 0
+		}
+
+		return lineEnd(src, int(decoder.InputOffset()))
+	}
+
+	return 0
+}
+
+// delimitedEnd returns the offset of the first byte after the front
+// matter block enclosed by the given delimiter lines, or 0 if the block
+// isn't closed.
+func delimitedEnd(src []byte, delimiter string) int {
+	i := bytes.Index(src[len(delimiter):], []byte("\n"+delimiter))
+	if i < 0 {
+		return 0
+	}
+
+	return lineEnd(src, len(delimiter)+i+len("\n"+delimiter))
+}
+
+// lineEnd returns the offset of the first byte after the line containing
+// the given offset.
+func lineEnd(src []byte, offset int) int {
+	if j := bytes.IndexByte(src[offset:], '\n'); j >= 0 {
+		return offset + j + 1
+	}
+
+	return len(src)
+}
+
+// position returns the line and column of the given offset in src, both
+// starting at 1.
+func position(src []byte, offset int) (int, int) {
+	line := 1 + bytes.Count(src[:offset], []byte("\n"))
+	column := offset - bytes.LastIndexByte(src[:offset], '\n')
+
+	return line, column
+}
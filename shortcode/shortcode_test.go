@@ -0,0 +1,124 @@
+package shortcode
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/verless/verless/test"
+)
+
+// TestShortcodes_Render checks if shortcodes are replaced with the output
+// of their templates and if invalid shortcodes are reported.
+func TestShortcodes_Render(t *testing.T) {
+	templates := map[string]string{
+		"youtube": `<iframe src="https://www.youtube.com/embed/{{.Get 0}}" title="{{.Get "title"}}"></iframe>`,
+		"args":    `{{.Name}}:{{range .Args}}[{{.}}]{{end}}`,
+		"video":   `{{"{{<"}} youtube {{.Get 0}} {{">}}"}}`,
+		"loop":    `{{"{{<"}} again {{">}}"}}`,
+		"again":   `{{"{{<"}} loop {{">}}"}}`,
+	}
+
+	tests := map[string]struct {
+		src           string
+		expected      string
+		expectedError error
+	}{
+		"positional and named arguments": {
+			src:      `Watch {{< youtube dQw4w9WgXcQ title="Making Coffee" >}}!`,
+			expected: `Watch <iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ" title="Making Coffee"></iframe>!`,
+		},
+		"quoted arguments": {
+			src:      `{{< args "a b" c "d \"e\"" >}}`,
+			expected: `args:[a b][c][d "e"]`,
+		},
+		"nested shortcodes": {
+			src:      `{{< video dQw4w9WgXcQ >}}`,
+			expected: `<iframe src="https://www.youtube.com/embed/dQw4w9WgXcQ" title=""></iframe>`,
+		},
+		"escaped shortcode": {
+			src:      `Use {{</* youtube id */>}} for videos.`,
+			expected: `Use {{< youtube id >}} for videos.`,
+		},
+		"front matter": {
+			src:      "---\nTitle: \"{{< args >}}\"\n---\n{{< args >}}",
+			expected: "---\nTitle: \"{{< args >}}\"\n---\nargs:",
+		},
+		"TOML front matter": {
+			src:      "+++\nTitle = \"{{< args >}}\"\n+++\n{{< args >}}",
+			expected: "+++\nTitle = \"{{< args >}}\"\n+++\nargs:",
+		},
+		"JSON front matter": {
+			src:      "{\n  \"Title\": \"{{< args >}}\"\n}\n{{< args >}}",
+			expected: "{\n  \"Title\": \"{{< args >}}\"\n}\nargs:",
+		},
+		"shortcode at the beginning": {
+			src:      "{{< args >}}\n\nCoffee",
+			expected: "args:\n\nCoffee",
+		},
+		"unknown shortcode": {
+			src:           "Coffee\n\n{{< vimeo 1234 >}}",
+			expectedError: ErrUnknownShortcode,
+		},
+		"recursive shortcodes": {
+			src:           `{{< loop >}}`,
+			expectedError: ErrRecursion,
+		},
+		"unclosed shortcode": {
+			src:           `{{< youtube dQw4w9WgXcQ`,
+			expectedError: ErrInvalidShortcode,
+		},
+		"unterminated argument": {
+			src:           `{{< youtube "dQw4w9WgXcQ >}}`,
+			expectedError: ErrInvalidShortcode,
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "verless-shortcode")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	files := make(map[string]string)
+
+	for name, content := range templates {
+		files[name] = filepath.Join(dir, name+".html")
+		test.Ok(t, ioutil.WriteFile(files[name], []byte(content), 0644))
+	}
+
+	shortcodes, err := New(files, nil)
+	test.Ok(t, err)
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		rendered, err := shortcodes.Render([]byte(testCase.src))
+
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			continue
+		}
+
+		test.Equals(t, testCase.expected, string(rendered))
+	}
+}
+
+// TestShortcodes_Extract checks if the outputs of extracted shortcodes are
+// inserted into the HTML by Restore.
+func TestShortcodes_Extract(t *testing.T) {
+	dir, err := ioutil.TempDir("", "verless-shortcode")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "note.html")
+	test.Ok(t, ioutil.WriteFile(file, []byte(`<aside>{{.Get 0}}</aside>`), 0644))
+
+	shortcodes, err := New(map[string]string{"note": file}, nil)
+	test.Ok(t, err)
+
+	src, outputs, err := shortcodes.Extract([]byte("{{< note *hot* >}}\n\nHot {{< note coffee >}}"))
+	test.Ok(t, err)
+	test.Equals(t, "verless-shortcode-0\n\nHot verless-shortcode-1", string(src))
+
+	html := Restore("<p>verless-shortcode-0</p>\n<p>Hot verless-shortcode-1</p>\n", outputs)
+	test.Equals(t, "<aside>*hot*</aside>\n<p>Hot <aside>coffee</aside></p>\n", html)
+}
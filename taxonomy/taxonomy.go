@@ -0,0 +1,194 @@
+// Package taxonomy provides taxonomies, which group pages by the terms
+// provided in their front matter, like tags or categories.
+package taxonomy
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/verless/verless/builder"
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/tree"
+)
+
+// Options configure a taxonomy.
+type Options struct {
+	// Name is the singular name of the taxonomy like category. The terms
+	// of a page are obtained using model.Page.Terms.
+	Name string
+	// GeneratePages registers a list page for each term and an index of
+	// all terms in the site model, which are then rendered by the writer.
+	GeneratePages bool
+	// CleanURLs has to match the build's clean URLs setting.
+	CleanURLs bool
+}
+
+// New creates a new taxonomy. The taxonomy implements plugin.Plugin, so
+// that it can process all pages and register its terms in the site model
+// like a plugin.
+func New(options Options) *Taxonomy {
+	t := Taxonomy{
+		options: options,
+		dir:     "/" + Plural(options.Name),
+		m:       make(map[string]*model.ListPage),
+	}
+
+	return &t
+}
+
+// Taxonomy maintains a list page for each term used by the processed
+// pages.
+type Taxonomy struct {
+	options Options
+	// dir is the target directory for all term directories.
+	dir   string
+	m     map[string]*model.ListPage
+	mutex sync.Mutex
+}
+
+// Plural returns the plural form of a taxonomy name, e.g. categories for
+// category. Names ending on s, like series, are returned unchanged.
+func Plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"):
+		return name
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return strings.TrimSuffix(name, "y") + "ies"
+	}
+	return name + "s"
+}
+
+// Slug converts a term into the form used for its directory, e.g. from
+// "Making Coffee" to "making-coffee".
+func Slug(term string) string {
+	return strings.ToLower(strings.Join(strings.Fields(term), "-"))
+}
+
+// Dir returns the directory containing the term directories, like
+// /categories.
+func (t *Taxonomy) Dir() string {
+	return t.dir
+}
+
+// ProcessPage creates a new map entry for each term of the processed
+// page and adds the page to the entry's list page.
+func (t *Taxonomy) ProcessPage(page *model.Page) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// A page may use several spellings of the same term, but it should
+	// only be listed once.
+	added := make(map[string]bool)
+
+	for _, term := range page.Terms(t.options.Name) {
+		slug := Slug(term)
+		if slug == "" || added[slug] {
+			continue
+		}
+		added[slug] = true
+
+		if _, exists := t.m[slug]; !exists {
+			t.createListPage(slug, term)
+		}
+
+		// Pages are processed concurrently, so the lexically smallest
+		// spelling is used as term name to get deterministic results.
+		if term < t.m[slug].Title {
+			t.m[slug].Title = term
+		}
+
+		t.m[slug].Pages = append(t.m[slug].Pages, page)
+	}
+
+	return nil
+}
+
+// PreWrite registers the taxonomy with all of its terms in the site
+// model. If pages should be generated, it also registers each list page
+// along with an index of all terms. Those list pages will be rendered by
+// the writer.
+func (t *Taxonomy) PreWrite(site *model.Site) error {
+	var node *model.Node
+
+	if t.options.GeneratePages {
+		node = model.NewNode()
+		node.ListPage.Route = t.dir
+
+		if err := tree.CreateNode(t.dir, site.Root, node); err != nil {
+			return err
+		}
+	}
+
+	taxonomy := model.Taxonomy{
+		Name:   t.options.Name,
+		Plural: Plural(t.options.Name),
+		Terms:  make([]model.Term, 0, len(t.m)),
+	}
+
+	for slug, listPage := range t.m {
+		route := path.Join(t.dir, slug)
+
+		// Weights only apply to pages within the same directory, so term
+		// pages are always sorted by date.
+		if err := builder.SortPages(listPage.Pages, builder.SortByDate); err != nil {
+			return err
+		}
+
+		taxonomy.Terms = append(taxonomy.Terms, model.Term{
+			Name:  listPage.Title,
+			Slug:  slug,
+			Href:  model.ListPageHref(route, t.options.CleanURLs),
+			Count: len(listPage.Pages),
+			Pages: listPage.Pages,
+		})
+
+		if !t.options.GeneratePages {
+			continue
+		}
+
+		termNode := model.NewNode()
+		termNode.ListPage = *listPage
+
+		if err := tree.CreateNode(route, site.Root, termNode); err != nil {
+			return err
+		}
+	}
+
+	// The most used terms are listed first.
+	sort.Slice(taxonomy.Terms, func(i, j int) bool {
+		a, b := taxonomy.Terms[i], taxonomy.Terms[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Slug < b.Slug
+	})
+
+	if node != nil {
+		node.ListPage.TermList = taxonomy.Terms
+	}
+
+	if site.Taxonomies == nil {
+		site.Taxonomies = make(map[string]*model.Taxonomy)
+	}
+	site.Taxonomies[taxonomy.Plural] = &taxonomy
+
+	return nil
+}
+
+// PostWrite isn't needed by taxonomies.
+func (t *Taxonomy) PostWrite() error {
+	return nil
+}
+
+// createListPage initializes a new list page for a given key.
+func (t *Taxonomy) createListPage(key, name string) {
+	t.m[key] = &model.ListPage{
+		Pages: make([]*model.Page, 0),
+		Page: model.Page{
+			Route: t.dir + "/" + key,
+			Title: name,
+		},
+	}
+}
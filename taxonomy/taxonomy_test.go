@@ -0,0 +1,200 @@
+package taxonomy
+
+import (
+	"testing"
+
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/test"
+)
+
+var (
+	// testPages is a set of pages used for testing.
+	testPages = []model.Page{
+		{ID: "page-0", Route: "/route-0", Tags: []string{"t-1", "t-2"}},
+		{ID: "page-1", Route: "/route-1", Tags: []string{"t-1", "t-3"}},
+		{ID: "page-2", Route: "/route-2", Tags: []string{"t-2", "t-3"}},
+		{ID: "page-3", Route: "/route-3", Tags: []string{"t-2"}},
+	}
+)
+
+// TestTaxonomy_ProcessPage checks if a taxonomy creates a new map entry
+// for each term and stores the respective pages in those entries.
+func TestTaxonomy_ProcessPage(t *testing.T) {
+	tests := map[string]struct {
+		pages         []model.Page
+		expectedError error
+	}{
+		"normal pages": {
+			pages: testPages,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		taxonomy := New(Options{Name: model.TagTaxonomy, GeneratePages: true, CleanURLs: true})
+
+		for i, page := range testCase.pages {
+			t.Logf("process page number %v, route '%v'", i, page.Route)
+			err := taxonomy.ProcessPage(&page)
+			if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+				return
+			}
+
+			for _, tag := range page.Tags {
+				term, exists := taxonomy.m[tag]
+
+				test.Assert(t, exists, "term should exist")
+				test.NotEquals(t, nil, term)
+				test.Assert(t, len(taxonomy.m[tag].Pages) > 0, "term should exist")
+			}
+		}
+	}
+}
+
+// TestTaxonomy_PreWrite checks if a taxonomy registers all terms as
+// dedicated routes in the site model.
+func TestTaxonomy_PreWrite(t *testing.T) {
+	tests := map[string]struct {
+		termListPages map[string]*model.ListPage
+		expectedError error
+	}{
+		"normal list pages": {
+			termListPages: map[string]*model.ListPage{
+				"test1": {},
+				"test2": {},
+				"test3": {},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		taxonomy := New(Options{Name: model.TagTaxonomy, GeneratePages: true, CleanURLs: true})
+		taxonomy.m = testCase.termListPages
+		s := model.NewSite()
+		err := taxonomy.PreWrite(&s)
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			continue
+		}
+
+		tags, ok := s.Root.Children()["tags"]
+		test.Equals(t, true, ok)
+		test.NotEquals(t, nil, tags)
+
+		for term := range taxonomy.m {
+			child, ok := tags.Children()[term]
+			test.Equals(t, true, ok)
+			test.NotEquals(t, nil, child)
+			test.NotEquals(t, nil, child.(*model.Node).ListPage)
+			test.NotEquals(t, nil, child.(*model.Node).ListPage.Page)
+		}
+	}
+}
+
+// TestTaxonomy_terms checks if two taxonomies group the pages by their
+// own terms and register them in the site model.
+func TestTaxonomy_terms(t *testing.T) {
+	pages := []model.Page{
+		{ID: "page-0", Route: "/blog", Taxonomies: map[string][]string{"category": {"Coffee"}, "series": {"Brewing Basics"}}},
+		{ID: "page-1", Route: "/blog", Taxonomies: map[string][]string{"category": {"coffee", "Tea"}}},
+		{ID: "page-2", Route: "/blog", Taxonomies: map[string][]string{"series": {"Brewing  Basics"}}},
+		{ID: "page-3", Route: "/blog", Tags: []string{"Coffee"}},
+	}
+
+	tests := map[string]struct {
+		name          string
+		generatePages bool
+		expected      []model.Term
+		expectedPages map[string][]string
+	}{
+		"categories": {
+			name:          "category",
+			generatePages: true,
+			expected: []model.Term{
+				{Name: "Coffee", Slug: "coffee", Href: "/categories/coffee", Count: 2},
+				{Name: "Tea", Slug: "tea", Href: "/categories/tea", Count: 1},
+			},
+			expectedPages: map[string][]string{
+				"coffee": {"page-0", "page-1"},
+				"tea":    {"page-1"},
+			},
+		},
+		"series without pages": {
+			name: "series",
+			expected: []model.Term{
+				{Name: "Brewing  Basics", Slug: "brewing-basics", Href: "/series/brewing-basics", Count: 2},
+			},
+			expectedPages: map[string][]string{
+				"brewing-basics": {"page-0", "page-2"},
+			},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		taxonomy := New(Options{Name: testCase.name, GeneratePages: testCase.generatePages, CleanURLs: true})
+
+		for i := range pages {
+			test.Ok(t, taxonomy.ProcessPage(&pages[i]))
+		}
+
+		s := model.NewSite()
+		test.Ok(t, taxonomy.PreWrite(&s))
+
+		plural := Plural(testCase.name)
+		terms := s.Taxonomies[plural].Terms
+		test.Equals(t, testCase.expected, withoutPages(terms))
+
+		for _, term := range terms {
+			var ids []string
+			for _, page := range term.Pages {
+				ids = append(ids, page.ID)
+			}
+			test.Equals(t, testCase.expectedPages[term.Slug], ids)
+		}
+
+		index, exists := s.Root.Children()[plural]
+		test.Equals(t, testCase.generatePages, exists)
+
+		if !exists {
+			continue
+		}
+
+		test.Equals(t, testCase.expected, withoutPages(index.(*model.Node).ListPage.TermList))
+
+		for _, term := range testCase.expected {
+			child, exists := index.Children()[term.Slug]
+			test.Assert(t, exists, "%s should have a list page", term.Slug)
+			test.Equals(t, term.Count, len(child.(*model.Node).ListPage.Pages))
+		}
+	}
+}
+
+// withoutPages returns a copy of the given terms without their pages.
+func withoutPages(terms []model.Term) []model.Term {
+	result := make([]model.Term, len(terms))
+	for i, term := range terms {
+		term.Pages = nil
+		result[i] = term
+	}
+	return result
+}
+
+// TestPlural checks if the plural forms of taxonomy names are correct.
+func TestPlural(t *testing.T) {
+	tests := map[string]string{
+		"tag":      "tags",
+		"category": "categories",
+		"series":   "series",
+		"day":      "days",
+		"author":   "authors",
+	}
+
+	for name, expected := range tests {
+		t.Log(name)
+		test.Equals(t, expected, Plural(name))
+	}
+}
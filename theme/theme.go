@@ -5,13 +5,15 @@
 package theme
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
 
 	"github.com/spf13/viper"
-	"github.com/verless/verless/config"
 )
 
 const (
@@ -26,75 +28,334 @@ const (
 	PageTemplate     = "page.html"
 	ListPageTemplate = "list-page.html"
 	configFilename   = "theme"
+	// PartialsDir is the directory inside TemplatesDir containing partial
+	// templates, which can be included by all other templates.
+	PartialsDir = "partials"
+	// ShortcodesDir is the directory containing the shortcode templates
+	// that can be used in Markdown content.
+	ShortcodesDir = "shortcodes"
+)
+
+var (
+	// ErrInvalidTheme states that a theme is not usable for a build.
+	ErrInvalidTheme = errors.New("invalid theme")
+
+	// ErrTemplateNotFound states that a template exists neither in a
+	// theme nor in any of its parent themes.
+	ErrTemplateNotFound = errors.New("template not found")
+
+	// ErrParentCycle states that the parent themes of a theme reference
+	// each other in a cycle.
+	ErrParentCycle = errors.New("cyclic parent themes")
 )
 
 // Path returns the directory path for the theme with the given name
-// inside the given path. Path does not ensure that the directory
+// inside the given themes directory, which usually is the themes
+// directory of a project. Path does not ensure that the directory
 // physically exists.
-func Path(path, name string) string {
-	return filepath.Join(path, config.ThemesDir, name)
+func Path(dir, name string) string {
+	return filepath.Join(dir, name)
 }
 
 // TemplatePath returns the template directory path of a given theme.
-func TemplatePath(path, name string) string {
-	return filepath.Join(Path(path, name), TemplatesDir)
+func TemplatePath(dir, name string) string {
+	return filepath.Join(Path(dir, name), TemplatesDir)
 }
 
 // GeneratedPath returns the generated directory path of a given theme.
-func GeneratedPath(path, name string) string {
-	return filepath.Join(Path(path, name), GeneratedDir)
+func GeneratedPath(dir, name string) string {
+	return filepath.Join(Path(dir, name), GeneratedDir)
 }
 
 // CssPath returns the css directory path of a given theme.
-func CssPath(path, name string) string {
-	return filepath.Join(Path(path, name), CssDir)
+func CssPath(dir, name string) string {
+	return filepath.Join(Path(dir, name), CssDir)
 }
 
 // JsPath returns the js directory path of a given theme.
-func JsPath(path, name string) string {
-	return filepath.Join(Path(path, name), JsDir)
+func JsPath(dir, name string) string {
+	return filepath.Join(Path(dir, name), JsDir)
 }
 
 // AssetsPath returns the assets directory path of a given theme.
-func AssetsPath(path, name string) string {
-	return filepath.Join(Path(path, name), AssetsDir)
+func AssetsPath(dir, name string) string {
+	return filepath.Join(Path(dir, name), AssetsDir)
+}
+
+// ShortcodesPath returns the shortcodes directory path of a given theme.
+func ShortcodesPath(dir, name string) string {
+	return filepath.Join(Path(dir, name), ShortcodesDir)
 }
 
 // Exists determines whether a theme with the provided name inside
-// the given path exists.
-func Exists(path, name string) bool {
-	if _, err := os.Stat(Path(path, name)); os.IsNotExist(err) {
+// the given themes directory exists.
+func Exists(dir, name string) bool {
+	if _, err := os.Stat(Path(dir, name)); os.IsNotExist(err) {
 		return false
 	}
 	return true
 }
 
+// List returns the names of all themes inside the given themes directory
+// in lexical order. Only directories containing a theme.yml file are
+// considered as themes. If the themes directory doesn't exist, List
+// returns an empty slice.
+func List(dir string) ([]string, error) {
+	themes := []string{}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return themes, nil
+		}
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		cfgFile := filepath.Join(Path(dir, entry.Name()), configFilename+".yml")
+
+		if info, err := os.Stat(cfgFile); err == nil && info.Mode().IsRegular() {
+			themes = append(themes, entry.Name())
+		}
+	}
+
+	return themes, nil
+}
+
+// Validate checks if the theme with the given name inside the given
+// themes directory is usable for a build. This is the case if the theme
+// or one of its parent themes contains all required templates and
+// theme.yml, if present, can be parsed. The returned error lists all
+// missing or unparseable files.
+func Validate(dir, name string) error {
+	info, err := os.Stat(Path(dir, name))
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("%w %s: directory %s doesn't exist", ErrInvalidTheme, name, Path(dir, name))
+	}
+
+	var problems []string
+
+	_, cfgErr := GetConfig(dir, name)
+	if cfgErr != nil {
+		problems = append(problems, fmt.Sprintf("cannot parse theme configuration: %s", cfgErr.Error()))
+	}
+
+	for _, tpl := range []string{ListPageTemplate, PageTemplate} {
+		file := filepath.Join(TemplatePath(dir, name), tpl)
+
+		// Without a valid configuration, the parent themes are unknown
+		// and only the theme's own templates can be checked.
+		if cfgErr != nil {
+			if info, err := os.Stat(file); err != nil || !info.Mode().IsRegular() {
+				problems = append(problems, fmt.Sprintf("missing %s", file))
+			}
+			continue
+		}
+
+		_, err := ResolveTemplate(dir, name, tpl)
+
+		switch {
+		case err == nil:
+		case errors.Is(err, ErrTemplateNotFound):
+			problems = append(problems, fmt.Sprintf("missing %s", file))
+		default:
+			problems = append(problems, err.Error())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w %s: %s", ErrInvalidTheme, name, strings.Join(problems, ", "))
+	}
+
+	return nil
+}
+
+// ResolveTemplate returns the path of the template file with the given
+// name for the theme with the given name inside the given themes
+// directory.
+//
+// If the theme doesn't contain the template, its parent theme specified
+// in theme.yml is considered, and so on. ResolveTemplate returns an error
+// if none of the themes contains the template or if the parent themes
+// form a cycle.
+func ResolveTemplate(dir, name, tplName string) (string, error) {
+	var (
+		visited = make(map[string]bool)
+		chain   []string
+	)
+
+	for current := name; current != ""; {
+		chain = append(chain, current)
+
+		if visited[current] {
+			return "", fmt.Errorf("%w: %s", ErrParentCycle, strings.Join(chain, " -> "))
+		}
+		visited[current] = true
+
+		file := filepath.Join(TemplatePath(dir, current), tplName)
+
+		if info, err := os.Stat(file); err == nil && info.Mode().IsRegular() {
+			return file, nil
+		}
+
+		cfg, err := GetConfig(dir, current)
+		if err != nil {
+			return "", err
+		}
+
+		current = cfg.Parent
+	}
+
+	return "", fmt.Errorf("%s in theme %s: %w", tplName, name, ErrTemplateNotFound)
+}
+
+// Partials returns the files of all partial templates available to the
+// theme with the given name inside the given themes directory. The files
+// are keyed by their template name, which is their path inside the
+// templates directory without extension, like partials/header.
+//
+// Partials of parent themes are available as well unless the theme
+// provides a partial with the same name itself.
+func Partials(dir, name string) (map[string]string, error) {
+	return templateFiles(dir, name, func(current string) (string, string) {
+		tplDir := TemplatePath(dir, current)
+		return filepath.Join(tplDir, PartialsDir), tplDir
+	})
+}
+
+// Shortcodes returns the files of all shortcode templates available to
+// the theme with the given name inside the given themes directory. The
+// files are keyed by their shortcode name, which is their path inside the
+// shortcodes directory without extension, like youtube.
+//
+// Like partials, shortcodes of parent themes are available as well unless
+// the theme provides a shortcode with the same name itself.
+func Shortcodes(dir, name string) (map[string]string, error) {
+	return templateFiles(dir, name, func(current string) (string, string) {
+		shortcodesDir := ShortcodesPath(dir, current)
+		return shortcodesDir, shortcodesDir
+	})
+}
+
+// templateFiles collects the files inside a directory of the given theme
+// and its parent themes. For each theme, dirs returns the directory to
+// collect and the directory the keys are relative to. Files of a theme
+// take precedence over files of its parent themes.
+func templateFiles(dir, name string, dirs func(current string) (string, string)) (map[string]string, error) {
+	var (
+		files   = make(map[string]string)
+		visited = make(map[string]bool)
+		chain   []string
+	)
+
+	for current := name; current != ""; {
+		chain = append(chain, current)
+
+		if visited[current] {
+			return nil, fmt.Errorf("%w: %s", ErrParentCycle, strings.Join(chain, " -> "))
+		}
+		visited[current] = true
+
+		root, base := dirs(current)
+
+		err := filepath.Walk(root, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(base, file)
+			if err != nil {
+				return err
+			}
+
+			key := filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+
+			if _, exists := files[key]; !exists {
+				files[key] = file
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		cfg, err := GetConfig(dir, current)
+		if err != nil {
+			return nil, err
+		}
+
+		current = cfg.Parent
+	}
+
+	return files, nil
+}
+
 // Config represents a theme configuration. This is the configuration
 // stored in the theme.yml file, which currently is not mandatory.
 type Config struct {
 	Version string
-	Build   struct {
+	// Parent is the name of a theme whose templates are used if this
+	// theme doesn't provide a template itself.
+	Parent string
+	Build  struct {
 		Before []string
 	}
+	// Bundle configures the bundling of the theme's CSS and JavaScript
+	// files. It can be overridden by the project configuration.
+	Bundle Bundle
+	// SCSS configures the compilation of the theme's SCSS files using the
+	// built-in compiler for a subset of SCSS, see package scss.
+	SCSS struct {
+		// Enabled compiles all .scss files inside the css and assets
+		// directories into .css files. Files using features outside of
+		// the SCSS subset fail the build.
+		Enabled bool
+	}
+}
+
+// Bundle configures the concatenation of all CSS files inside the css
+// and assets directories of a theme into assets/bundle.css, and of all
+// JavaScript files inside the js and assets directories into
+// assets/bundle.js.
+type Bundle struct {
+	Enabled bool
+	// CSS and JS contain filenames like reset.css that are concatenated
+	// first in the given order. All other files follow in lexical order.
+	CSS []string
+	JS  []string
 }
 
 // GetConfig returns the configuration stored in theme.yml of the theme
-// with the given name inside the given path. Since theme.yml isn't
-// mandatory, GetConfig returns an empty config if it doesn't exist.
-func GetConfig(path, name string) (Config, error) {
-	viper.AddConfigPath(Path(path, name))
-	viper.SetConfigName(configFilename)
+// with the given name inside the given themes directory. Since theme.yml
+// isn't mandatory, GetConfig returns an empty config if it doesn't exist.
+func GetConfig(dir, name string) (Config, error) {
+	// Use a dedicated viper instance so that the config paths of other
+	// themes or the project configuration don't interfere.
+	v := viper.New()
+	v.AddConfigPath(Path(dir, name))
+	v.SetConfigName(configFilename)
 
 	var cfg Config
 
-	if err := viper.ReadInConfig(); err != nil {
+	if err := v.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			return Config{}, err
 		}
 		return cfg, nil
 	}
 
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		return Config{}, err
 	}
 
@@ -106,8 +367,8 @@ func GetConfig(path, name string) (Config, error) {
 //
 // Note that the command context directory is the the theme directory
 // instead of the project directory.
-func RunBeforeHooks(path, name string) error {
-	cfg, err := GetConfig(path, name)
+func RunBeforeHooks(dir, name string) error {
+	cfg, err := GetConfig(dir, name)
 	if err != nil {
 		return err
 	}
@@ -115,7 +376,7 @@ func RunBeforeHooks(path, name string) error {
 	for _, beforeHook := range cfg.Build.Before {
 		parts := strings.Split(beforeHook, " ")
 		cmd := exec.Command(parts[0], parts[1:]...)
-		cmd.Dir = Path(path, name)
+		cmd.Dir = Path(dir, name)
 		cmd.Stderr = os.Stderr
 		cmd.Stdout = os.Stdout
 
@@ -0,0 +1,283 @@
+package theme
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/verless/verless/test"
+)
+
+// TestValidate checks if Validate accepts usable themes and reports all
+// missing or unparseable files of broken themes.
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		files           map[string]string
+		expectedError   error
+		expectedMessage []string
+	}{
+		"valid theme": {
+			files: map[string]string{
+				"templates/list-page.html": "",
+				"templates/page.html":      "",
+				"theme.yml":                "version: 1",
+			},
+		},
+		"valid theme without theme.yml": {
+			files: map[string]string{
+				"templates/list-page.html": "",
+				"templates/page.html":      "",
+			},
+		},
+		"missing page template": {
+			files: map[string]string{
+				"templates/list-page.html": "",
+			},
+			expectedError:   ErrInvalidTheme,
+			expectedMessage: []string{PageTemplate},
+		},
+		"malformed theme.yml": {
+			files: map[string]string{
+				"templates/list-page.html": "",
+				"templates/page.html":      "",
+				"theme.yml":                "version: [1",
+			},
+			expectedError:   ErrInvalidTheme,
+			expectedMessage: []string{"theme configuration"},
+		},
+		"missing templates and malformed theme.yml": {
+			files: map[string]string{
+				"theme.yml": "version: [1",
+			},
+			expectedError:   ErrInvalidTheme,
+			expectedMessage: []string{ListPageTemplate, PageTemplate, "theme configuration"},
+		},
+		"missing theme": {
+			expectedError: ErrInvalidTheme,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		dir, err := ioutil.TempDir("", "verless-theme")
+		test.Ok(t, err)
+
+		for file, content := range testCase.files {
+			path := filepath.Join(Path(dir, Default), filepath.FromSlash(file))
+			test.Ok(t, os.MkdirAll(filepath.Dir(path), 0755))
+			test.Ok(t, ioutil.WriteFile(path, []byte(content), 0644))
+		}
+
+		err = Validate(dir, Default)
+		_ = os.RemoveAll(dir)
+
+		if testCase.expectedError == nil {
+			test.Ok(t, err)
+			continue
+		}
+
+		test.Assert(t, errors.Is(err, testCase.expectedError), "expected %v, got %v", testCase.expectedError, err)
+
+		for _, message := range testCase.expectedMessage {
+			test.Assert(t, strings.Contains(err.Error(), message), "%v should mention %s", err, message)
+		}
+	}
+}
+
+// TestList checks if List returns all directories containing a theme.yml
+// file inside the themes directory.
+func TestList(t *testing.T) {
+	tests := map[string]struct {
+		files    []string
+		expected []string
+	}{
+		"multiple themes": {
+			files:    []string{"themes/default/theme.yml", "themes/dark/theme.yml"},
+			expected: []string{"dark", "default"},
+		},
+		"stray directory": {
+			files:    []string{"themes/default/theme.yml", "themes/backup/templates/page.html"},
+			expected: []string{"default"},
+		},
+		"stray file": {
+			files:    []string{"themes/default/theme.yml", "themes/README.md"},
+			expected: []string{"default"},
+		},
+		"missing themes directory": {
+			expected: []string{},
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		dir, err := ioutil.TempDir("", "verless-theme")
+		test.Ok(t, err)
+
+		for _, file := range testCase.files {
+			path := filepath.Join(dir, filepath.FromSlash(file))
+			test.Ok(t, os.MkdirAll(filepath.Dir(path), 0755))
+			test.Ok(t, ioutil.WriteFile(path, []byte{}, 0644))
+		}
+
+		themes, err := List(filepath.Join(dir, "themes"))
+		_ = os.RemoveAll(dir)
+
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, themes)
+	}
+}
+
+// TestResolveTemplate checks if ResolveTemplate falls back to the parent
+// themes and detects cyclic parent themes.
+func TestResolveTemplate(t *testing.T) {
+	tests := map[string]struct {
+		files         map[string]string
+		theme         string
+		expected      string
+		expectedError error
+	}{
+		"own template": {
+			files: map[string]string{
+				"child/theme.yml":           "parent: base",
+				"child/templates/page.html": "",
+				"base/templates/page.html":  "",
+			},
+			theme:    "child",
+			expected: "child/templates/page.html",
+		},
+		"single-level inheritance": {
+			files: map[string]string{
+				"child/theme.yml":          "parent: base",
+				"base/templates/page.html": "",
+			},
+			theme:    "child",
+			expected: "base/templates/page.html",
+		},
+		"two-level inheritance": {
+			files: map[string]string{
+				"child/theme.yml":          "parent: middle",
+				"middle/theme.yml":         "parent: base",
+				"base/templates/page.html": "",
+			},
+			theme:    "child",
+			expected: "base/templates/page.html",
+		},
+		"missing template": {
+			files: map[string]string{
+				"child/theme.yml": "parent: base",
+				"base/theme.yml":  "",
+			},
+			theme:         "child",
+			expectedError: ErrTemplateNotFound,
+		},
+		"cyclic parents": {
+			files: map[string]string{
+				"child/theme.yml":  "parent: middle",
+				"middle/theme.yml": "parent: child",
+			},
+			theme:         "child",
+			expectedError: ErrParentCycle,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		dir, err := ioutil.TempDir("", "verless-theme")
+		test.Ok(t, err)
+
+		for file, content := range testCase.files {
+			path := filepath.Join(dir, "themes", filepath.FromSlash(file))
+			test.Ok(t, os.MkdirAll(filepath.Dir(path), 0755))
+			test.Ok(t, ioutil.WriteFile(path, []byte(content), 0644))
+		}
+
+		file, err := ResolveTemplate(filepath.Join(dir, "themes"), testCase.theme, PageTemplate)
+		_ = os.RemoveAll(dir)
+
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			continue
+		}
+
+		test.Equals(t, filepath.Join(dir, "themes", filepath.FromSlash(testCase.expected)), file)
+	}
+}
+
+// TestPartials checks if Partials finds all partial templates of a theme
+// and its parent themes, preferring the partials of the theme itself.
+func TestPartials(t *testing.T) {
+	tests := map[string]struct {
+		files         map[string]string
+		expected      map[string]string
+		expectedError error
+	}{
+		"own partials": {
+			files: map[string]string{
+				"child/templates/partials/header.html":   "",
+				"child/templates/partials/nav/main.html": "",
+				"child/templates/page.html":              "",
+			},
+			expected: map[string]string{
+				"partials/header":   "child/templates/partials/header.html",
+				"partials/nav/main": "child/templates/partials/nav/main.html",
+			},
+		},
+		"inherited partials": {
+			files: map[string]string{
+				"child/theme.yml":                      "parent: base",
+				"child/templates/partials/header.html": "",
+				"base/templates/partials/header.html":  "",
+				"base/templates/partials/footer.html":  "",
+			},
+			expected: map[string]string{
+				"partials/header": "child/templates/partials/header.html",
+				"partials/footer": "base/templates/partials/footer.html",
+			},
+		},
+		"no partials": {
+			files: map[string]string{
+				"child/templates/page.html": "",
+			},
+			expected: map[string]string{},
+		},
+		"cyclic parents": {
+			files: map[string]string{
+				"child/theme.yml": "parent: base",
+				"base/theme.yml":  "parent: child",
+			},
+			expectedError: ErrParentCycle,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		dir, err := ioutil.TempDir("", "verless-theme")
+		test.Ok(t, err)
+
+		for file, content := range testCase.files {
+			path := filepath.Join(dir, "themes", filepath.FromSlash(file))
+			test.Ok(t, os.MkdirAll(filepath.Dir(path), 0755))
+			test.Ok(t, ioutil.WriteFile(path, []byte(content), 0644))
+		}
+
+		partials, err := Partials(filepath.Join(dir, "themes"), "child")
+		_ = os.RemoveAll(dir)
+
+		if test.ExpectedError(t, testCase.expectedError, err) != test.IsCorrectNil {
+			continue
+		}
+
+		expected := make(map[string]string)
+		for key, file := range testCase.expected {
+			expected[key] = filepath.Join(dir, "themes", filepath.FromSlash(file))
+		}
+
+		test.Equals(t, expected, partials)
+	}
+}
@@ -5,6 +5,8 @@ package tpl
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 	"text/template"
 )
 
@@ -20,7 +22,12 @@ var (
 // Register parses a template file and registers the instance under
 // the given key. If a template with the key has already registered,
 // Register will return an error unless the registration is forced.
-func Register(key string, path string, force bool) (*template.Template, error) {
+//
+// All functions used by the template have to be provided by funcs. They
+// can be replaced before executing the template using Funcs. partials
+// maps the names of additional templates like partials/header to their
+// files. The template can include them using the template action.
+func Register(key string, path string, force bool, funcs template.FuncMap, partials map[string]string) (*template.Template, error) {
 	if templates == nil {
 		templates = make(map[string]*template.Template)
 	}
@@ -31,11 +38,22 @@ func Register(key string, path string, force bool) (*template.Template, error) {
 		}
 	}
 
-	tpl, err := template.ParseFiles(path)
+	tpl, err := template.New(filepath.Base(path)).Funcs(funcs).ParseFiles(path)
 	if err != nil {
 		return nil, err
 	}
 
+	for name, file := range partials {
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := tpl.New(name).Parse(string(b)); err != nil {
+			return nil, err
+		}
+	}
+
 	templates[key] = tpl
 
 	return templates[key], nil
@@ -47,9 +47,9 @@ func TestRegister(t *testing.T) {
 
 	for _, testCase := range tests {
 		t.Logf("Testing '%s'", testCase.testName)
-		pageTplPath := filepath.Join(theme.TemplatePath(projectPath, theme.Default), theme.PageTemplate)
+		pageTplPath := filepath.Join(theme.TemplatePath(filepath.Join(projectPath, "themes"), theme.Default), theme.PageTemplate)
 
-		_, err := Register(testCase.key, pageTplPath, testCase.force)
+		_, err := Register(testCase.key, pageTplPath, testCase.force, nil, nil)
 		test.ExpectedError(t, testCase.expectedError, err)
 	}
 }
@@ -12,6 +12,7 @@ package tree
 import (
 	"errors"
 	"fmt"
+	"sort"
 )
 
 var (
@@ -140,6 +141,9 @@ func ResolveNode(path string, root Node) (Node, error) {
 // maxDepth is counted starting from 0, which represents the root
 // node. Set maxDepth to -1 to walk down the entire tree.
 //
+// The children of each node are visited in the lexical order of their
+// edges, so that walking the same tree always yields the same order.
+//
 // As soon as an error arises in one of the walkFns, the error is handed
 // up to the caller.
 func Walk(root Node, walkFn func(path string, node Node) error, maxDepth int) error {
@@ -157,8 +161,16 @@ func walkNode(node Node, walkFn func(path string, node Node) error, maxDepth, cu
 		return err
 	}
 
-	for edge, child := range node.Children() {
-		if err := walkNode(child, walkFn, maxDepth, curDepth, concat(curPath, edge)); err != nil {
+	children := node.Children()
+
+	edges := make([]string, 0, len(children))
+	for edge := range children {
+		edges = append(edges, edge)
+	}
+	sort.Strings(edges)
+
+	for _, edge := range edges {
+		if err := walkNode(children[edge], walkFn, maxDepth, curDepth, concat(curPath, edge)); err != nil {
 			return err
 		}
 	}
@@ -201,6 +201,22 @@ func TestWalk(t *testing.T) {
 	}
 }
 
+// TestWalk_order checks if the Walk function visits the children of each
+// node in the lexical order of their edges.
+func TestWalk_order(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		var paths []string
+
+		err := Walk(&root, func(path string, _ Node) error {
+			paths = append(paths, path)
+			return nil
+		}, -1)
+		test.Ok(t, err)
+
+		test.Equals(t, []string{"/", "/blog", "/blog/coffee", "/tags", "/tags/coffee"}, paths)
+	}
+}
+
 // TestWalkPath tests if the WalkPath correctly invokes the
 // walkFn for each node in a given path.
 func TestWalkPath(t *testing.T) {
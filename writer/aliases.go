@@ -0,0 +1,105 @@
+package writer
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"path"
+	"path/filepath"
+
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/out/style"
+	"github.com/verless/verless/tree"
+)
+
+var (
+	// ErrAliasCollision states that an alias of a page has the same path
+	// as another page or another alias.
+	ErrAliasCollision = errors.New("alias collides with an existing page")
+)
+
+// aliasStub is the redirect page written to each alias. It is formatted
+// with the URL of the target page and its canonical URL.
+const aliasStub = `<!DOCTYPE html>
+<html>
+<head>
+<title>%[1]s</title>
+<link rel="canonical" href="%[2]s" />
+<meta name="robots" content="noindex" />
+<meta charset="utf-8" />
+<meta http-equiv="refresh" content="0; url=%[1]s" />
+</head>
+</html>
+`
+
+// aliasFile returns the path of the stub for the given alias relative to
+// the output directory. Aliases ending on .html are used as filename,
+// all other aliases are considered directories like /old-path/.
+func aliasFile(alias string) string {
+	alias = path.Join("/", alias)
+
+	if path.Ext(alias) == ".html" {
+		return alias
+	}
+	return path.Join(alias, IndexFile)
+}
+
+// writeAliases writes a redirect stub to each alias of all pages. The
+// pages have to be rendered first, so that aliases colliding with a
+// rendered page are detected.
+func (w *writer) writeAliases() error {
+	aliases := make(map[string]string)
+
+	return tree.Walk(w.site.Root, func(_ string, node tree.Node) error {
+		n := node.(*model.Node)
+
+		pages := []*model.Page{&n.ListPage.Page}
+		for i := range n.Pages {
+			pages = append(pages, &n.Pages[i])
+		}
+
+		for _, page := range pages {
+			for _, alias := range page.Aliases {
+				file := aliasFile(alias)
+
+				if href, exists := w.outputFiles[file]; exists {
+					return fmt.Errorf("alias %s of %s is the path of %s: %w", alias, page.Href, href, ErrAliasCollision)
+				}
+				if href, exists := aliases[file]; exists {
+					return fmt.Errorf("alias %s of %s is also an alias of %s: %w", alias, page.Href, href, ErrAliasCollision)
+				}
+				aliases[file] = page.Href
+
+				if err := w.writeAlias(file, page); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}, -1)
+}
+
+// writeAlias writes a stub that redirects to the given page to file.
+func (w *writer) writeAlias(file string, page *model.Page) error {
+	canonical := page.Canonical
+	if canonical == "" {
+		canonical = w.absURL(page.Href)
+	}
+
+	stub := fmt.Sprintf(aliasStub, html.EscapeString(w.relURL(page.Href)), html.EscapeString(canonical))
+	file = filepath.Join(w.ctx.OutputDir, filepath.FromSlash(file))
+
+	if err := w.ctx.Fs.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+
+	if err := fs.WriteFileAtomic(w.ctx.Fs, file, []byte(stub), 0644); err != nil {
+		return err
+	}
+
+	w.ctx.Logger.Debug(style.None, "wrote %s", file)
+
+	return nil
+}
@@ -0,0 +1,248 @@
+package writer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/minify"
+	"github.com/verless/verless/scss"
+)
+
+const (
+	// fingerprintLength is the number of hex digits of the SHA-256 hash
+	// that are inserted into the filename of fingerprinted assets.
+	fingerprintLength int = 8
+	// scssExt is the file extension of SCSS files.
+	scssExt string = ".scss"
+)
+
+var (
+	// ErrUnknownAsset states that an asset referenced by the fingerprint
+	// template function doesn't exist.
+	ErrUnknownAsset = errors.New("unknown CSS or JavaScript asset")
+	// ErrAmbiguousAsset states that an asset referenced by its filename
+	// exists in several directories.
+	ErrAmbiguousAsset = errors.New("asset exists in several directories, use its full path")
+)
+
+// assetExts contains the file extensions of all assets that are
+// minified and fingerprinted.
+var assetExts = map[string]bool{
+	".css": true,
+	".js":  true,
+}
+
+// templateSources is a filter that only lets pass template sources, which
+// are never copied from the asset directories of a theme.
+var templateSources = fs.Extensions(".html", ".tmpl")
+
+// themeAssetFilters returns the filters that only let pass the files
+// inside the css, js and assets directories of a theme that are served
+// as they are. Files starting with an underscore, like partials of a CSS
+// preprocessor, and template sources are skipped. If SCSS compilation is
+// enabled, SCSS files are skipped as well.
+func (w *writer) themeAssetFilters() []func(file string) bool {
+	skipped := templateSources
+	if w.ctx.SCSS {
+		skipped = fs.Or(templateSources, fs.Extensions(scssExt))
+	}
+
+	return []func(file string) bool{
+		fs.NoUnderscores,
+		fs.Not(skipped),
+	}
+}
+
+// compileStylesheets compiles all SCSS files inside src into CSS files
+// inside dest, like style.scss into style.css. Partials starting with an
+// underscore aren't compiled on their own. Like all other assets, the
+// compiled files are registered, minified and fingerprinted.
+func (w *writer) compileStylesheets(src, dest string) error {
+	return filepath.Walk(src, func(file string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || filepath.Ext(file) != scssExt || !fs.NoUnderscores(file) {
+			return nil
+		}
+
+		css, err := scss.CompileFile(file)
+		if err != nil {
+			return err
+		}
+
+		// Files are copied into dest without their directory structure,
+		// so the compiled files are written the same way.
+		asset := filepath.Join(dest, strings.TrimSuffix(info.Name(), scssExt)+".css")
+
+		if err := w.ctx.Fs.MkdirAll(dest, 0700); err != nil {
+			return err
+		}
+
+		if err := fs.WriteFileAtomic(w.ctx.Fs, asset, css, 0644); err != nil {
+			return err
+		}
+
+		return w.registerAsset(asset)
+	})
+}
+
+// processAssets registers all CSS and JavaScript files that have been
+// copied from src to dest, so that they can be referenced using the
+// fingerprint template function. If minification is enabled, the files
+// are minified. If fingerprinting is enabled, the files are renamed to
+// their fingerprinted filenames like style.<hash>.css. Files that don't
+// match all filters haven't been copied and are skipped.
+func (w *writer) processAssets(src, dest string, fileOnly bool, filters ...func(file string) bool) error {
+	return filepath.Walk(src, func(file string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || !assetExts[filepath.Ext(file)] {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, file)
+		if err != nil {
+			return err
+		}
+		for _, filter := range filters {
+			if !filter(rel) {
+				return nil
+			}
+		}
+		// Files are copied the same way as fs.CopyFromOS does.
+		if fileOnly {
+			rel = filepath.Base(rel)
+		}
+
+		return w.registerAsset(filepath.Join(dest, rel))
+	})
+}
+
+// registerAsset minifies and fingerprints a CSS or JavaScript file inside
+// the output directory if enabled, and registers it for the fingerprint
+// template function.
+func (w *writer) registerAsset(asset string) error {
+	target := asset
+
+	if w.ctx.Minifier != nil {
+		if err := w.minifyFile(asset); err != nil {
+			return err
+		}
+	}
+
+	if w.ctx.Fingerprint {
+		content, err := afero.ReadFile(w.ctx.Fs, asset)
+		if err != nil {
+			return err
+		}
+		target = fingerprintedName(asset, content)
+
+		if err := w.ctx.Fs.Rename(asset, target); err != nil {
+			return err
+		}
+	}
+
+	name, err := filepath.Rel(w.ctx.OutputDir, asset)
+	if err != nil {
+		return err
+	}
+	href, err := filepath.Rel(w.ctx.OutputDir, target)
+	if err != nil {
+		return err
+	}
+
+	w.assets[filepath.ToSlash(name)] = "/" + filepath.ToSlash(href)
+
+	return nil
+}
+
+// minifyFile minifies a CSS or JavaScript file in place.
+func (w *writer) minifyFile(file string) error {
+	content, err := afero.ReadFile(w.ctx.Fs, file)
+	if err != nil {
+		return err
+	}
+
+	minified, err := w.ctx.Minifier.Minify(minify.MediaType(file), content)
+	if err != nil {
+		return fmt.Errorf("minifying %s: %w", file, err)
+	}
+
+	return fs.WriteFileAtomic(w.ctx.Fs, file, minified, 0644)
+}
+
+// fingerprintedName inserts a short SHA-256 prefix of the content into
+// the filename, e.g. css/style.css becomes css/style.1a2b3c4d.css.
+func fingerprintedName(file string, content []byte) string {
+	hash := sha256.Sum256(content)
+	ext := filepath.Ext(file)
+
+	return fmt.Sprintf("%s.%s%s", strings.TrimSuffix(file, ext), hex.EncodeToString(hash[:])[:fingerprintLength], ext)
+}
+
+// fingerprint returns the URL of a CSS or JavaScript asset, which is
+// fingerprinted if fingerprinting is enabled. The asset is specified by
+// its path inside the output directory like css/style.css, or just by
+// its filename like style.css if the filename is unique.
+func (w *writer) fingerprint(name string) (string, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+
+	if href, exists := w.assets[name]; exists {
+		return href, nil
+	}
+
+	var match string
+
+	for asset, href := range w.assets {
+		if path.Base(asset) != name {
+			continue
+		}
+		if match != "" {
+			return "", fmt.Errorf("%s: %w", name, ErrAmbiguousAsset)
+		}
+		match = href
+	}
+
+	if match == "" {
+		return "", fmt.Errorf("%s: %w", name, ErrUnknownAsset)
+	}
+
+	return match, nil
+}
+
+// rewriteAssetRefs replaces all absolute references to assets inside
+// HTML attributes like href="/css/style.css" with the URLs of their
+// fingerprinted files.
+func (w *writer) rewriteAssetRefs(html []byte) []byte {
+	if !w.ctx.Fingerprint || len(w.assets) == 0 {
+		return html
+	}
+
+	if w.refReplacer == nil {
+		var pairs []string
+		for asset, href := range w.assets {
+			for _, quote := range []string{`"`, `'`} {
+				pairs = append(pairs, "="+quote+"/"+asset+quote, "="+quote+href+quote)
+			}
+		}
+		w.refReplacer = strings.NewReplacer(pairs...)
+	}
+
+	return []byte(w.refReplacer.Replace(string(html)))
+}
@@ -0,0 +1,201 @@
+package writer
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/verless/verless/fs"
+	"github.com/verless/verless/scss"
+	"github.com/verless/verless/theme"
+)
+
+const (
+	// bundleName is the filename of the CSS and JavaScript bundles
+	// without extension.
+	bundleName string = "bundle"
+)
+
+// bundleSource is a CSS or JavaScript file that is part of a bundle.
+type bundleSource struct {
+	name string
+	file string
+}
+
+// writeBundles concatenates the CSS and JavaScript files of the theme
+// into assets/bundle.css and assets/bundle.js if bundling is enabled.
+// The bundles are registered like all other assets, so that they are
+// minified and fingerprinted as well. Empty bundles aren't written.
+func (w *writer) writeBundles() error {
+	if !w.ctx.Bundle.Enabled {
+		return nil
+	}
+
+	cssExts := []string{".css"}
+	if w.ctx.SCSS {
+		cssExts = append(cssExts, scssExt)
+	}
+
+	bundles := []struct {
+		ext   string
+		exts  []string
+		dirs  []string
+		order []string
+	}{
+		{
+			ext:   ".css",
+			exts:  cssExts,
+			dirs:  []string{theme.CssPath(w.ctx.ThemesDir, w.ctx.Theme), theme.AssetsPath(w.ctx.ThemesDir, w.ctx.Theme)},
+			order: w.ctx.Bundle.CSS,
+		},
+		{
+			ext:   ".js",
+			exts:  []string{".js"},
+			dirs:  []string{theme.JsPath(w.ctx.ThemesDir, w.ctx.Theme), theme.AssetsPath(w.ctx.ThemesDir, w.ctx.Theme)},
+			order: w.ctx.Bundle.JS,
+		},
+	}
+
+	for _, bundle := range bundles {
+		sources, err := bundleSources(bundle.dirs, bundle.exts, bundle.order)
+		if err != nil {
+			return err
+		}
+		if len(sources) == 0 {
+			continue
+		}
+
+		var content bytes.Buffer
+
+		for i, source := range sources {
+			b, err := readBundleSource(source.file)
+			if err != nil {
+				return err
+			}
+
+			// A statement at the end of a JavaScript file might not be
+			// terminated, so that it would be continued by the next file.
+			if i > 0 && bundle.ext == ".js" {
+				content.WriteString(";\n")
+			}
+
+			content.Write(b)
+
+			if !bytes.HasSuffix(b, []byte("\n")) {
+				content.WriteByte('\n')
+			}
+		}
+
+		file := filepath.Join(w.ctx.OutputDir, theme.AssetsDir, bundleName+bundle.ext)
+
+		if err := w.ctx.Fs.MkdirAll(filepath.Dir(file), 0700); err != nil {
+			return err
+		}
+
+		if err := fs.WriteFileAtomic(w.ctx.Fs, file, content.Bytes(), 0644); err != nil {
+			return err
+		}
+
+		if err := w.registerAsset(file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bundleSources returns all files with one of the given extensions inside
+// the given directories and their subdirectories, except for files
+// starting with an underscore. The files listed in order come first in
+// the given order, followed by all other files sorted by their filenames.
+// An existing bundle is never part of the sources.
+func bundleSources(dirs []string, exts []string, order []string) ([]bundleSource, error) {
+	var sources []bundleSource
+
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() || !fs.NoUnderscores(file) {
+				return nil
+			}
+
+			ext := filepath.Ext(file)
+			if info.Name() == bundleName+ext || !containsString(exts, ext) {
+				return nil
+			}
+
+			sources = append(sources, bundleSource{name: info.Name(), file: file})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	position := make(map[string]int)
+
+	for i, name := range order {
+		if _, exists := position[name]; exists {
+			continue
+		}
+		position[name] = i
+
+		found := false
+		for _, source := range sources {
+			found = found || source.name == name
+		}
+		if !found {
+			return nil, fmt.Errorf("bundling %s: %w", name, ErrUnknownAsset)
+		}
+	}
+
+	sort.SliceStable(sources, func(i, j int) bool {
+		a, aOrdered := position[sources[i].name]
+		b, bOrdered := position[sources[j].name]
+
+		switch {
+		case aOrdered && bOrdered:
+			return a < b
+		case aOrdered != bOrdered:
+			return aOrdered
+		}
+		return sources[i].name < sources[j].name
+	})
+
+	return sources, nil
+}
+
+// readBundleSource reads a file that is part of a bundle. SCSS files are
+// compiled to CSS.
+func readBundleSource(file string) ([]byte, error) {
+	if filepath.Ext(file) == scssExt {
+		return scss.CompileFile(file)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// containsString reports whether the slice contains the given string.
+func containsString(slice []string, s string) bool {
+	for _, item := range slice {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// bundle returns the URL of the CSS or JavaScript bundle, which is
+// fingerprinted if fingerprinting is enabled. The bundle is specified by
+// its type, which is either css or js.
+func (w *writer) bundle(kind string) (string, error) {
+	return w.fingerprint(path.Join(theme.AssetsDir, bundleName+"."+kind))
+}
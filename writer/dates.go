@@ -0,0 +1,95 @@
+package writer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateLayouts maps the named formats accepted by dateFormat to their
+// layouts. Any other format is used as a Go layout.
+var dateLayouts = map[string]string{
+	"rfc3339": time.RFC3339,
+	"rfc1123": time.RFC1123Z,
+	"date":    "2006-01-02",
+	"human":   "January 2, 2006",
+}
+
+// dateFormat formats a date using a named format like human or a Go
+// layout like 02.01.2006. The date is converted to the site's time zone
+// first. Dates may also be provided as strings in RFC 3339 format or
+// as YYYY-MM-DD. Zero dates result in an empty string, so that pages
+// without a date don't display January 1, 0001.
+func (w *writer) dateFormat(format string, date interface{}) (string, error) {
+	t, err := toTime(date)
+	if err != nil {
+		return "", err
+	}
+
+	if t.IsZero() {
+		return "", nil
+	}
+
+	layout, ok := dateLayouts[strings.ToLower(format)]
+	if !ok {
+		layout = format
+	}
+
+	if w.ctx.Location != nil {
+		t = t.In(w.ctx.Location)
+	}
+
+	return t.Format(layout), nil
+}
+
+// dateInZone converts a date to the time zone with the given IANA name,
+// like Europe/Berlin.
+func (w *writer) dateInZone(zone string, date interface{}) (time.Time, error) {
+	t, err := toTime(date)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return t.In(location), nil
+}
+
+// now returns the build time in the site's time zone.
+func (w *writer) now() time.Time {
+	now := time.Now()
+	if w.ctx.Now != nil {
+		now = w.ctx.Now()
+	}
+
+	if w.ctx.Location != nil {
+		now = now.In(w.ctx.Location)
+	}
+
+	return now
+}
+
+// toTime converts a date passed to a template function to a time.Time.
+func toTime(date interface{}) (time.Time, error) {
+	switch d := date.(type) {
+	case time.Time:
+		return d, nil
+	case *time.Time:
+		if d == nil {
+			return time.Time{}, nil
+		}
+		return *d, nil
+	case string:
+		for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+			if t, err := time.Parse(layout, d); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot parse date %q, expected RFC 3339 or YYYY-MM-DD", d)
+	default:
+		return time.Time{}, fmt.Errorf("cannot use %T as date", date)
+	}
+}
@@ -0,0 +1,85 @@
+package writer
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/verless/verless/test"
+)
+
+// TestWriter_dateFormat checks if templates can format a date using named
+// formats and Go layouts in the site's time zone.
+func TestWriter_dateFormat(t *testing.T) {
+	date := time.Date(2020, 10, 14, 22, 30, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		template string
+		timezone string
+		data     interface{}
+		expected string
+	}{
+		"named formats": {
+			template: `{{dateFormat "human" .}}|{{dateFormat "date" .}}|{{dateFormat "RFC3339" .}}`,
+			data:     date,
+			expected: "October 14, 2020|2020-10-14|2020-10-14T22:30:00Z",
+		},
+		"go layout": {
+			template: `{{. | dateFormat "02.01.2006 15:04"}}`,
+			data:     date,
+			expected: "14.10.2020 22:30",
+		},
+		"site time zone": {
+			template: `{{dateFormat "2006-01-02 15:04 MST" .}}`,
+			timezone: "Europe/Berlin",
+			data:     date,
+			expected: "2020-10-15 00:30 CEST",
+		},
+		"other time zone": {
+			template: `{{dateInZone "America/New_York" . | dateFormat "15:04 MST"}}`,
+			data:     date,
+			expected: "18:30 EDT",
+		},
+		"date string": {
+			template: `{{dateFormat "human" .}}`,
+			data:     "2020-10-14",
+			expected: "October 14, 2020",
+		},
+		"zero date": {
+			template: `{{dateFormat "human" .}}`,
+			data:     time.Time{},
+			expected: "",
+		},
+		"now": {
+			template: `{{now | dateFormat "rfc3339"}}`,
+			timezone: "Europe/Berlin",
+			expected: "2020-10-15T00:30:00+02:00",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		ctx := Context{
+			Now: func() time.Time {
+				return date
+			},
+		}
+
+		if testCase.timezone != "" {
+			location, err := time.LoadLocation(testCase.timezone)
+			test.Ok(t, err)
+			ctx.Location = location
+		}
+
+		w := New(ctx)
+
+		tpl, err := template.New(name).Funcs(w.funcs()).Parse(testCase.template)
+		test.Ok(t, err)
+
+		var b strings.Builder
+		test.Ok(t, tpl.Execute(&b, testCase.data))
+		test.Equals(t, testCase.expected, b.String())
+	}
+}
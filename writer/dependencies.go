@@ -0,0 +1,168 @@
+package writer
+
+import (
+	"text/template"
+	"text/template/parse"
+
+	"github.com/verless/verless/model"
+)
+
+// linkFields contains the fields of model.Page that link to other pages,
+// in the order they're recorded as dependencies.
+var linkFields = []string{"Prev", "Next", "Related", "Similar", "Translations"}
+
+// siteFuncs contains the template functions that return arbitrary pages
+// of the site model.
+var siteFuncs = map[string]bool{
+	"page":  true,
+	"pages": true,
+}
+
+// templateRefs describes the linked pages a template and its partials
+// may display.
+type templateRefs struct {
+	// fields contains the used link fields like Next.
+	fields map[string]bool
+	// siteFuncs reports whether the page or pages function is used, so
+	// that any page of the site may be displayed.
+	siteFuncs bool
+}
+
+// templateRefs returns the references of the given template, which are
+// determined once for each template.
+func (w *writer) templateRefs(tpl *template.Template) templateRefs {
+	if refs, exists := w.refs[tpl]; exists {
+		return refs
+	}
+
+	refs := templateRefs{fields: make(map[string]bool)}
+
+	for _, t := range tpl.Templates() {
+		if t.Tree != nil {
+			refs.walk(t.Tree.Root)
+		}
+	}
+
+	if w.refs == nil {
+		w.refs = make(map[*template.Template]templateRefs)
+	}
+	w.refs[tpl] = refs
+
+	return refs
+}
+
+// walk records all link fields and site functions used inside the given
+// node. Fields are matched by their name only, so that .Next is found
+// regardless of the value it is called on.
+func (r *templateRefs) walk(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			r.walk(child)
+		}
+	case *parse.ActionNode:
+		r.walk(n.Pipe)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			r.walk(cmd)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			r.walk(arg)
+		}
+	case *parse.FieldNode:
+		r.addFields(n.Ident)
+	case *parse.VariableNode:
+		r.addFields(n.Ident)
+	case *parse.ChainNode:
+		r.walk(n.Node)
+		r.addFields(n.Field)
+	case *parse.IdentifierNode:
+		if siteFuncs[n.Ident] {
+			r.siteFuncs = true
+		}
+	case *parse.IfNode:
+		r.walkBranch(&n.BranchNode)
+	case *parse.RangeNode:
+		r.walkBranch(&n.BranchNode)
+	case *parse.WithNode:
+		r.walkBranch(&n.BranchNode)
+	case *parse.TemplateNode:
+		r.walk(n.Pipe)
+	}
+}
+
+// walkBranch walks the pipeline and both lists of an if, range or with
+// action.
+func (r *templateRefs) walkBranch(n *parse.BranchNode) {
+	r.walk(n.Pipe)
+	r.walk(n.List)
+	r.walk(n.ElseList)
+}
+
+// addFields records the given identifiers that are link fields.
+func (r *templateRefs) addFields(idents []string) {
+	for _, ident := range idents {
+		for _, field := range linkFields {
+			if ident == field {
+				r.fields[field] = true
+			}
+		}
+	}
+}
+
+// dependencies returns the linked pages of p that are displayed by one
+// of the given templates, like Next:/blog/coffee. A missing link like
+// the Prev link of the first page is recorded as Prev: so that a new
+// link is detected as well. If a template may display any page of the
+// site, ok is false.
+func (w *writer) dependencies(p *model.Page, templates []*template.Template) (dependencies []string, ok bool) {
+	fields := make(map[string]bool)
+
+	for _, tpl := range templates {
+		refs := w.templateRefs(tpl)
+		if refs.siteFuncs {
+			return nil, false
+		}
+		for field := range refs.fields {
+			fields[field] = true
+		}
+	}
+
+	for _, field := range linkFields {
+		if !fields[field] {
+			continue
+		}
+
+		var linked []*model.Page
+
+		switch field {
+		case "Prev":
+			linked = []*model.Page{p.Prev}
+		case "Next":
+			linked = []*model.Page{p.Next}
+		case "Related":
+			linked = p.Related
+		case "Similar":
+			linked = p.Similar
+		case "Translations":
+			linked = p.Translations
+		}
+
+		for _, page := range linked {
+			href := ""
+			if page != nil {
+				href = page.Href
+			}
+			dependencies = append(dependencies, field+":"+href)
+		}
+	}
+
+	return dependencies, true
+}
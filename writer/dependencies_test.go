@@ -0,0 +1,58 @@
+package writer
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/test"
+)
+
+// TestWriter_dependencies checks if the dependencies of a page only
+// contain the linked pages displayed by its templates and partials.
+func TestWriter_dependencies(t *testing.T) {
+	var (
+		b = model.Page{Href: "/blog/b"}
+		c = model.Page{Href: "/blog/c"}
+		p = model.Page{Href: "/blog/a", Next: &b, Similar: []*model.Page{&b, &c}}
+	)
+
+	tests := map[string]struct {
+		template   string
+		partial    string
+		expected   []string
+		expectedOK bool
+	}{
+		"no links": {
+			template:   "{{.Page.Title}}",
+			expectedOK: true,
+		},
+		"next and prev": {
+			template:   "{{with .Page.Next}}{{.Title}}{{end}}{{if .Page.Prev}}{{.Page.Prev.Title}}{{end}}",
+			expected:   []string{"Prev:", "Next:/blog/b"},
+			expectedOK: true,
+		},
+		"similar pages in partial": {
+			template:   `{{partial "similar" .}}`,
+			partial:    "{{range $page := .Page.Similar}}{{$page.Title}}{{end}}",
+			expected:   []string{"Similar:/blog/b", "Similar:/blog/c"},
+			expectedOK: true,
+		},
+		"pages function": {
+			template: `{{range pages "/blog"}}{{.Title}}{{end}}`,
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		w := writer{}
+
+		tpl := template.Must(template.New("page").Funcs(w.funcs()).Parse(testCase.template))
+		template.Must(tpl.New("partials/similar").Parse(testCase.partial))
+
+		dependencies, ok := w.dependencies(&p, []*template.Template{tpl})
+		test.Equals(t, testCase.expectedOK, ok)
+		test.Equals(t, testCase.expected, dependencies)
+	}
+}
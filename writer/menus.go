@@ -0,0 +1,71 @@
+package writer
+
+import (
+	"path"
+	"strings"
+
+	"github.com/verless/verless/model"
+)
+
+// siteFor returns the site model for rendering the page with the given
+// Href. In contrast to the writer's site model, the menu entries linking
+// to that page or to a section containing it are marked as active.
+func (w *writer) siteFor(href string) *model.Site {
+	if len(w.site.Menus) == 0 {
+		return &w.site
+	}
+
+	site := w.site
+	site.Menus = make(map[string]model.Menu, len(w.site.Menus))
+
+	current := normalizeHref(href)
+
+	for name, menu := range w.site.Menus {
+		entries := make(model.Menu, len(menu))
+
+		for i, entry := range menu {
+			entry.Active = isActiveURL(entry.URL, current)
+			entries[i] = entry
+		}
+
+		site.Menus[name] = entries
+	}
+
+	return &site
+}
+
+// isActiveURL reports whether a menu entry URL links to the page with
+// the given normalized Href or to a section containing that page. The
+// home page only counts as active if it is the page itself.
+func isActiveURL(url, current string) bool {
+	if url == "" || isAbsoluteURL(url) {
+		return false
+	}
+
+	target := normalizeHref(url)
+
+	if target == current {
+		return true
+	}
+
+	return target != "/" && strings.HasPrefix(current, target+"/")
+}
+
+// normalizeHref converts an Href like /blog/index.html or /blog/ to its
+// clean form /blog, so that Hrefs can be compared independently of the
+// build.cleanURLs setting.
+func normalizeHref(href string) string {
+	if i := strings.IndexAny(href, "?#"); i >= 0 {
+		href = href[:i]
+	}
+
+	href = path.Clean("/" + href)
+	href = strings.TrimSuffix(href, "/"+IndexFile)
+	href = strings.TrimSuffix(href, ".html")
+
+	if href == "" {
+		return "/"
+	}
+
+	return href
+}
@@ -0,0 +1,48 @@
+package writer
+
+import (
+	"testing"
+
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/test"
+)
+
+// TestWriter_siteFor checks if the menu entries linking to a page or its
+// section are active, regardless of whether clean URLs are used.
+func TestWriter_siteFor(t *testing.T) {
+	menu := model.Menu{
+		{Name: "Home", URL: "/"},
+		{Name: "Blog", URL: "/blog/"},
+		{Name: "About", URL: "/about.html"},
+		{Name: "Docs", URL: "https://x.test/blog"},
+	}
+
+	tests := map[string]struct {
+		href     string
+		expected []bool
+	}{
+		"home page":                    {href: "/", expected: []bool{true, false, false, false}},
+		"home page without clean URLs": {href: "/index.html", expected: []bool{true, false, false, false}},
+		"section":                      {href: "/blog/index.html", expected: []bool{false, true, false, false}},
+		"page inside section":          {href: "/blog/coffee.html", expected: []bool{false, true, false, false}},
+		"page":                         {href: "/about", expected: []bool{false, false, true, false}},
+		"similar path":                 {href: "/blogroll", expected: []bool{false, false, false, false}},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		w := New(Context{})
+		w.site = model.Site{Menus: map[string]model.Menu{"main": menu}}
+
+		site := w.siteFor(testCase.href)
+
+		active := make([]bool, len(menu))
+		for i, entry := range site.Menus["main"] {
+			active[i] = entry.Active
+		}
+
+		test.Equals(t, testCase.expected, active)
+		test.Assert(t, !w.site.Menus["main"][0].Active, "the writer's site model must not be changed")
+	}
+}
@@ -0,0 +1,61 @@
+package writer
+
+import (
+	"path"
+	"strings"
+
+	"github.com/verless/verless/model"
+	"github.com/verless/verless/tree"
+)
+
+// page returns the page with the given route like /blog/coffee. Routes
+// may also be written as Hrefs like /blog/coffee.html. For a section like
+// /blog, the page created from its index.md file is returned. If there is
+// no such page, page returns nil.
+func (w *writer) page(route string) *model.Page {
+	route = strings.TrimSuffix(path.Clean("/"+route), ".html")
+	route = strings.TrimSuffix(route, "/index")
+
+	if parent, ok := w.node(path.Dir(route)); ok {
+		id := path.Base(route)
+
+		for i := range parent.Pages {
+			if parent.Pages[i].ID == id {
+				return &parent.Pages[i]
+			}
+		}
+	}
+
+	if node, ok := w.node(route); ok && node.ListPage.Page.ID != "" {
+		return &node.ListPage.Page
+	}
+
+	return nil
+}
+
+// pages returns all pages inside the section with the given route like
+// /blog, including the pages of its sub-sections. The pages are sorted
+// like in list pages. Hidden pages aren't included. If the section does
+// not exist, pages returns an empty slice.
+func (w *writer) pages(route string) []*model.Page {
+	node, ok := w.node(path.Clean("/" + route))
+	if !ok {
+		return []*model.Page{}
+	}
+
+	return node.ListPage.Pages
+}
+
+// node returns the node of the site model with the given route.
+func (w *writer) node(route string) (*model.Node, bool) {
+	if w.site.Root == nil {
+		return nil, false
+	}
+
+	n, err := tree.ResolveNode(route, w.site.Root)
+	if err != nil {
+		return nil, false
+	}
+
+	return n.(*model.Node), true
+}
@@ -0,0 +1,31 @@
+package writer
+
+import (
+	"errors"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/verless/verless/theme"
+)
+
+// partialFunc returns the partial template function for the given
+// template. It executes the partial with the given name like header,
+// which is the template partials/header, and returns the output. This
+// allows using the output in pipelines, in contrast to the template
+// action.
+func partialFunc(tpl *template.Template) func(name string, data interface{}) (string, error) {
+	return func(name string, data interface{}) (string, error) {
+		if tpl == nil {
+			return "", errors.New("partial can only be used in page and list page templates")
+		}
+
+		var b strings.Builder
+
+		if err := tpl.ExecuteTemplate(&b, path.Join(theme.PartialsDir, name), data); err != nil {
+			return "", err
+		}
+
+		return b.String(), nil
+	}
+}
@@ -10,6 +10,8 @@ type page struct {
 	Nav    *model.Nav
 	Page   *model.Page
 	Footer *model.Footer
+	// Site is the entire site model, which provides Site.Data.
+	Site *model.Site
 }
 
 // listPage is a wrapper for ListPage-related templates.
@@ -18,4 +20,14 @@ type listPage struct {
 	Nav  *model.Nav
 	*model.ListPage
 	Footer *model.Footer
+	// Site is the entire site model, which provides Site.Data.
+	Site *model.Site
+	// CurrentPage is the number of the rendered page, starting at 1.
+	CurrentPage int
+	// TotalPages is the number of pages the list page is split into.
+	TotalPages int
+	// PrevURL is the URL of the previous page or empty on the first page.
+	PrevURL string
+	// NextURL is the URL of the next page or empty on the last page.
+	NextURL string
 }
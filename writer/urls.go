@@ -0,0 +1,67 @@
+package writer
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/verless/verless/model"
+)
+
+// rootRelativeRefPattern matches href and src attributes whose values are
+// root-relative, like href="/blog". The attribute and the opening quote
+// are captured separately from the value.
+var rootRelativeRefPattern = regexp.MustCompile(`(?i)(\s(?:href|src)\s*=\s*["'])(/[^"']*)`)
+
+// isAbsoluteURL reports whether the URL contains a scheme or a host, like
+// https://example.com or //example.com.
+func isAbsoluteURL(ref string) bool {
+	if strings.HasPrefix(ref, "//") {
+		return true
+	}
+	u, err := url.Parse(ref)
+	return err == nil && u.Scheme != ""
+}
+
+// relURL prefixes a path inside the website with the base path, turning
+// /coffee into /blog/coffee for a website served under /blog. Absolute
+// URLs are returned unchanged.
+func (w *writer) relURL(ref string) string {
+	if isAbsoluteURL(ref) {
+		return ref
+	}
+
+	return model.BasePath(w.ctx.BaseURL) + "/" + strings.TrimPrefix(ref, "/")
+}
+
+// absURL turns a path inside the website into an absolute URL using the
+// base URL, like https://example.com/blog/coffee. Absolute URLs are
+// returned unchanged. Without a base URL, absURL behaves like relURL.
+func (w *writer) absURL(ref string) string {
+	if isAbsoluteURL(ref) || w.ctx.BaseURL == "" {
+		return w.relURL(ref)
+	}
+
+	return strings.TrimSuffix(w.ctx.BaseURL, "/") + "/" + strings.TrimPrefix(ref, "/")
+}
+
+// rewriteBaseRefs prefixes all root-relative references inside href and
+// src attributes with the base path. References that already start with
+// the base path, e.g. when created using relURL, are left unchanged.
+func (w *writer) rewriteBaseRefs(html []byte) []byte {
+	basePath := model.BasePath(w.ctx.BaseURL)
+	if basePath == "" {
+		return html
+	}
+
+	return rootRelativeRefPattern.ReplaceAllFunc(html, func(match []byte) []byte {
+		groups := rootRelativeRefPattern.FindSubmatch(match)
+		ref := string(groups[2])
+
+		if strings.HasPrefix(ref, "//") || ref == basePath || strings.HasPrefix(ref, basePath+"/") {
+			return match
+		}
+
+		return []byte(string(groups[1]) + basePath + ref)
+	})
+}
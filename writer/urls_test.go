@@ -0,0 +1,57 @@
+package writer
+
+import (
+	"testing"
+
+	"github.com/verless/verless/test"
+)
+
+// TestWriter_absURL_relURL checks if paths are prefixed with the base URL
+// and if absolute URLs are returned unchanged.
+func TestWriter_absURL_relURL(t *testing.T) {
+	tests := map[string]struct {
+		baseURL     string
+		ref         string
+		expectedAbs string
+		expectedRel string
+	}{
+		"base URL with path": {
+			baseURL:     "https://x.test/blog/",
+			ref:         "/coffee",
+			expectedAbs: "https://x.test/blog/coffee",
+			expectedRel: "/blog/coffee",
+		},
+		"base URL without path": {
+			baseURL:     "https://x.test",
+			ref:         "coffee",
+			expectedAbs: "https://x.test/coffee",
+			expectedRel: "/coffee",
+		},
+		"no base URL": {
+			ref:         "/coffee",
+			expectedAbs: "/coffee",
+			expectedRel: "/coffee",
+		},
+		"absolute URL": {
+			baseURL:     "https://x.test/blog/",
+			ref:         "https://example.com/coffee",
+			expectedAbs: "https://example.com/coffee",
+			expectedRel: "https://example.com/coffee",
+		},
+		"protocol-relative URL": {
+			baseURL:     "https://x.test/blog/",
+			ref:         "//example.com/coffee",
+			expectedAbs: "//example.com/coffee",
+			expectedRel: "//example.com/coffee",
+		},
+	}
+
+	for name, testCase := range tests {
+		t.Log(name)
+
+		w := New(Context{BaseURL: testCase.baseURL})
+
+		test.Equals(t, testCase.expectedAbs, w.absURL(testCase.ref))
+		test.Equals(t, testCase.expectedRel, w.relURL(testCase.ref))
+	}
+}
@@ -3,28 +3,102 @@
 package writer
 
 import (
+	"bytes"
+	"fmt"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/verless/verless/config"
 	"github.com/verless/verless/fs"
+	"github.com/verless/verless/images"
+	"github.com/verless/verless/minify"
 	"github.com/verless/verless/model"
+	"github.com/verless/verless/out"
+	"github.com/verless/verless/out/style"
 	"github.com/verless/verless/theme"
 	"github.com/verless/verless/tpl"
 	"github.com/verless/verless/tree"
 )
 
 const (
-	indexFile string = "index.html"
+	// IndexFile is the filename of each rendered page.
+	IndexFile string = "index.html"
+	// PaginationDir is the directory inside a list page's directory that
+	// contains all pages except for the first one, e.g. page/2.
+	PaginationDir string = "page"
 )
 
 type Context struct {
+	// Fs is the filesystem the website is written to. The theme is always
+	// read from disk.
 	Fs                 afero.Fs
 	Path               string
 	OutputDir          string
 	Theme              string
 	RecompileTemplates bool
+	// ThemesDir is the directory containing Theme. If it is empty, the
+	// themes directory inside Path is used.
+	ThemesDir string
+	// KeepOutputDir prevents the writer from removing the output
+	// directory before writing the site.
+	KeepOutputDir bool
+	// KeepFiles contains paths inside the output directory like CNAME
+	// that are kept when removing the output directory.
+	KeepFiles []string
+	// SkipPage reports whether the page with the given Href doesn't have
+	// to be rendered again. dependencies contains the linked pages its
+	// templates display, like Next:/blog/coffee. Pages are only skipped
+	// if they already exist in the output directory. List pages and pages
+	// whose templates use the page or pages function are always rendered.
+	SkipPage func(href string, dependencies []string) bool
+	// PageSize is the maximum number of pages listed on a single list
+	// page. If it is 0, list pages aren't paginated.
+	PageSize int
+	// CleanURLs renders pages to <page>/index.html instead of <page>.html.
+	CleanURLs bool
+	// Fingerprint renames CSS and JavaScript files to filenames containing
+	// a hash of their content and rewrites all references to them.
+	Fingerprint bool
+	// Bundle configures the concatenation of the theme's CSS and
+	// JavaScript files into assets/bundle.css and assets/bundle.js.
+	Bundle theme.Bundle
+	// SCSS compiles the .scss files inside the css and assets directories
+	// of the theme into .css files instead of copying them.
+	SCSS bool
+	// Minifier minifies all rendered pages and all CSS and JavaScript
+	// files. If it is nil, nothing is minified.
+	Minifier minify.Minifier
+	// BaseURL is the URL the website is served under. If it contains a
+	// path like /blog, all root-relative references to pages and files
+	// are prefixed with that path.
+	BaseURL string
+	// Location is the site's time zone used by the date template
+	// functions. If it is nil, dates are kept in their own time zone.
+	Location *time.Location
+	// Now returns the build time used by the now template function. If
+	// it is nil, the current time is used.
+	Now func() time.Time
+	// Images creates the responsive images requested by the image and
+	// imageTag template functions. If it is nil, these functions aren't
+	// available.
+	Images *images.Processor
+	// OutputFormats contains the output formats referenced by the
+	// Alternates of the pages, keyed by their name.
+	OutputFormats map[string]*model.OutputFormat
+	// DiagramScript is inserted before the closing body tag of each page
+	// containing a diagram, e.g. a <script> tag loading Mermaid.
+	DiagramScript string
+	// Logger prints each rendered and skipped page at debug level. If it
+	// is nil, the default logger of the out package is used.
+	Logger *out.Logger
+	// PageWritten is called with the path of each rendered page, including
+	// list pages and their paginated pages.
+	PageWritten func(file string)
 }
 
 // New creates a new writer that renders the site model in the given
@@ -34,6 +108,14 @@ func New(ctx Context) *writer {
 		ctx.Theme = theme.Default
 	}
 
+	if ctx.ThemesDir == "" {
+		ctx.ThemesDir = filepath.Join(ctx.Path, config.ThemesDir)
+	}
+
+	if ctx.Logger == nil {
+		ctx.Logger = out.Default()
+	}
+
 	w := writer{ctx: ctx}
 
 	return &w
@@ -42,18 +124,36 @@ func New(ctx Context) *writer {
 type writer struct {
 	site model.Site
 	ctx  Context
+	// assets maps the paths of all CSS and JavaScript files inside the
+	// output directory to their URLs.
+	assets      map[string]string
+	refReplacer *strings.Replacer
+	// outputFiles maps the paths of all rendered pages relative to the
+	// output directory to their URLs.
+	outputFiles map[string]string
+	// refs caches the linked pages displayed by each template.
+	refs map[*template.Template]templateRefs
 }
 
 // Write renders the entire site model to the writer's filesystem.
 //
 // Basically, it creates a directory for each page and renders the
-// page using its respective template. It also copies all assets.
+// page using its respective template. It also copies all assets. The
+// assets are copied first, so that pages can reference fingerprinted
+// assets. Finally, a redirect stub is written for each page alias.
 func (w *writer) Write(site model.Site) error {
-	if err := fs.Rmdir(w.ctx.Fs, w.ctx.OutputDir); err != nil {
-		return err
+	if !w.ctx.KeepOutputDir {
+		if err := fs.RmdirExcept(w.ctx.Fs, w.ctx.OutputDir, w.ctx.KeepFiles...); err != nil {
+			return err
+		}
 	}
 
 	w.site = site
+	w.outputFiles = make(map[string]string)
+
+	if err := w.copyDirs(); err != nil {
+		return err
+	}
 
 	err := tree.Walk(w.site.Root, func(_ string, node tree.Node) error {
 		for _, p := range node.(*model.Node).Pages {
@@ -62,6 +162,7 @@ func (w *writer) Write(site model.Site) error {
 				Nav:    &w.site.Nav,
 				Page:   &p,
 				Footer: &w.site.Footer,
+				Site:   w.siteFor(p.Href),
 			}); err != nil {
 				return err
 			}
@@ -78,82 +179,366 @@ func (w *writer) Write(site model.Site) error {
 			Nav:      &w.site.Nav,
 			ListPage: &lp,
 			Footer:   &w.site.Footer,
+			Site:     w.siteFor(lp.Route),
 		})
 	}, -1)
-
 	if err != nil {
 		return err
 	}
 
-	if err := w.copyDirs(); err != nil {
+	if err := w.writeAliases(); err != nil {
 		return err
 	}
 
+	// The images have been requested by the rendered pages and shortcodes,
+	// so their variants can only be written afterwards.
+	if w.ctx.Images != nil {
+		return w.ctx.Images.Write(w.ctx.Fs, w.ctx.OutputDir)
+	}
+
 	return nil
 }
 
 // writePage renders a single page by applying the associated template
-// and writing the file inside the output directory.
+// and writing the file inside the output directory. The page is also
+// rendered in each of its output formats.
 func (w *writer) writePage(route string, page page) error {
-	path := filepath.Join(w.ctx.OutputDir, route, page.Page.ID)
+	href := model.PageHref(route, page.Page.ID, w.ctx.CleanURLs)
 
-	if err := w.ctx.Fs.MkdirAll(path, 0700); err != nil {
+	pageTpl, err := w.loadTemplate(page.Page, theme.PageTemplate)
+	if err != nil {
 		return err
 	}
 
-	file, err := w.ctx.Fs.Create(filepath.Join(path, indexFile))
-	if err != nil {
+	formatTpls := make([]*template.Template, len(page.Page.Alternates))
+
+	for i, alternate := range page.Page.Alternates {
+		format, exists := w.ctx.OutputFormats[alternate.Name]
+		if !exists {
+			return fmt.Errorf("%s: output format %s has not been declared", page.Page.Href, alternate.Name)
+		}
+
+		if formatTpls[i], err = w.template(format.Template); err != nil {
+			return err
+		}
+	}
+
+	skip := w.skipPage(page.Page, append([]*template.Template{pageTpl}, formatTpls...))
+
+	if err := w.writePageFile(OutputFile(href, w.ctx.CleanURLs), href, pageTpl, &page, skip); err != nil {
 		return err
 	}
 
-	pageTpl, err := w.loadTemplate(page.Page.Type, theme.PageTemplate)
-	if err != nil {
+	for i, alternate := range page.Page.Alternates {
+		if err := w.writePageFile(alternate.Href, alternate.Href, formatTpls[i], &page, skip); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// skipPage reports whether the given page rendered with the given
+// templates doesn't have to be rendered again.
+func (w *writer) skipPage(p *model.Page, templates []*template.Template) bool {
+	if w.ctx.SkipPage == nil {
+		return false
+	}
+
+	dependencies, ok := w.dependencies(p, templates)
+	if !ok {
+		return false
+	}
+
+	return w.ctx.SkipPage(p.Href, dependencies)
+}
+
+// writePageFile renders the page with the given template to outputFile,
+// which is available under href. If skip is set, the page is unchanged
+// and is skipped if the file already exists.
+func (w *writer) writePageFile(outputFile, href string, tpl *template.Template, page *page, skip bool) error {
+	file := filepath.Join(w.ctx.OutputDir, filepath.FromSlash(outputFile))
+
+	w.outputFiles[outputFile] = href
+
+	if skip {
+		if exists, _ := afero.Exists(w.ctx.Fs, file); exists {
+			w.ctx.Logger.Debug(style.None, "skipping unchanged %s", file)
+			return nil
+		}
+	}
+
+	if err := w.ctx.Fs.MkdirAll(filepath.Dir(file), 0700); err != nil {
 		return err
 	}
 
-	return pageTpl.Execute(file, &page)
+	return w.render(file, tpl, page)
+}
+
+// OutputFile returns the path of the file a page with the given Href is
+// rendered to, relative to the output directory.
+func OutputFile(href string, cleanURLs bool) string {
+	if cleanURLs {
+		return path.Join(href, IndexFile)
+	}
+	return href
 }
 
 // writeListPage does the same thing as writePage but for list pages.
+// If pagination is enabled, the list page is split into several pages
+// where the first page is rendered to the list page's directory and all
+// further pages are rendered to PaginationDir/<n>.
 func (w *writer) writeListPage(route string, listPage listPage) error {
-	path := filepath.Join(w.ctx.OutputDir, route)
-
-	if err := w.ctx.Fs.MkdirAll(path, 0700); err != nil {
+	listPageTpl, err := w.loadTemplate(&listPage.Page, theme.ListPageTemplate)
+	if err != nil {
 		return err
 	}
 
-	file, err := w.ctx.Fs.Create(filepath.Join(path, indexFile))
-	if err != nil {
+	pages := listPage.Pages
+	pageSize := len(pages)
+
+	if w.ctx.PageSize > 0 {
+		pageSize = w.ctx.PageSize
+	}
+
+	totalPages := 1
+	if pageSize > 0 && len(pages) > pageSize {
+		totalPages = (len(pages) + pageSize - 1) / pageSize
+	}
+
+	for n := 1; n <= totalPages; n++ {
+		start := (n - 1) * pageSize
+		end := start + pageSize
+		if end > len(pages) {
+			end = len(pages)
+		}
+
+		lp := *listPage.ListPage
+		lp.Pages = pages[start:end]
+
+		// Only the first page can have a canonical URL set in the front
+		// matter of a custom list page. All further pages are canonical
+		// themselves.
+		if (n > 1 || lp.Canonical == "") && w.ctx.BaseURL != "" {
+			lp.Canonical = w.absURL(w.paginationURL(route, n))
+		}
+
+		current := listPage
+		current.ListPage = &lp
+		current.CurrentPage = n
+		current.TotalPages = totalPages
+
+		if n > 1 {
+			current.PrevURL = w.paginationURL(route, n-1)
+		}
+		if n < totalPages {
+			current.NextURL = w.paginationURL(route, n+1)
+		}
+
+		w.outputFiles[path.Join(paginationPath(route, n), IndexFile)] = w.paginationURL(route, n)
+
+		path := filepath.Join(w.ctx.OutputDir, filepath.FromSlash(paginationPath(route, n)))
+
+		if err := w.ctx.Fs.MkdirAll(path, 0700); err != nil {
+			return err
+		}
+
+		if err := w.render(filepath.Join(path, IndexFile), listPageTpl, &current); err != nil {
+			return err
+		}
+	}
+
+	// When the output directory is kept, pages from previous builds that
+	// exceed the current number of pages have to be removed.
+	if w.ctx.KeepOutputDir {
+		return w.removePagination(route, totalPages+1)
+	}
+
+	return nil
+}
+
+// removePagination removes all rendered pages of a list page starting
+// with the given page number.
+func (w *writer) removePagination(route string, from int) error {
+	for n := from; ; n++ {
+		path := filepath.Join(w.ctx.OutputDir, filepath.FromSlash(paginationPath(route, n)))
+
+		exists, err := afero.Exists(w.ctx.Fs, filepath.Join(path, IndexFile))
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return nil
+		}
+
+		if err := w.ctx.Fs.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+}
+
+// paginationPath returns the route of the n-th page of a list page.
+func paginationPath(route string, n int) string {
+	if n == 1 {
+		return route
+	}
+	return path.Join(route, PaginationDir, strconv.Itoa(n))
+}
+
+// paginationURL returns the URL of the n-th page of a list page.
+func (w *writer) paginationURL(route string, n int) string {
+	url := paginationPath(route, n)
+
+	if !w.ctx.CleanURLs {
+		return model.ListPageHref(url, false)
+	}
+
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	return url
+}
+
+// render executes the template with the given data and writes the
+// result to file. The file is only written if the template has been
+// executed successfully, so that it never contains partial output.
+// Files of other output formats than HTML aren't minified.
+func (w *writer) render(file string, tpl *template.Template, data interface{}) error {
+	var buf bytes.Buffer
+
+	if err := tpl.Execute(&buf, data); err != nil {
 		return err
 	}
 
-	listPageTpl, err := w.loadTemplate(listPage.Type, theme.ListPageTemplate)
-	if err != nil {
+	var (
+		html   = buf.Bytes()
+		isHTML = filepath.Ext(file) == ".html"
+	)
+
+	if p, ok := data.(*page); ok && p.Page.Diagrams && w.ctx.DiagramScript != "" && isHTML {
+		html = insertBeforeBody(html, w.ctx.DiagramScript)
+	}
+
+	html = w.rewriteBaseRefs(w.rewriteAssetRefs(html))
+
+	if w.ctx.Minifier != nil && isHTML {
+		minified, err := w.ctx.Minifier.Minify(minify.HTML, html)
+		if err != nil {
+			return fmt.Errorf("minifying %s: %w", file, err)
+		}
+		html = minified
+	}
+
+	if err := fs.WriteFileAtomic(w.ctx.Fs, file, html, 0644); err != nil {
 		return err
 	}
 
-	return listPageTpl.Execute(file, &listPage)
+	w.ctx.Logger.Debug(style.None, "wrote %s", file)
+
+	if w.ctx.PageWritten != nil {
+		w.ctx.PageWritten(file)
+	}
+
+	return nil
 }
 
-// loadTemplate considers a page type and a default template, decides
-// which template to use and loads that template from the registry.
-func (w *writer) loadTemplate(t *model.Type, defaultTpl string) (*template.Template, error) {
+// insertBeforeBody inserts the snippet before the closing body tag of the
+// given HTML document. If there is no body tag, the snippet is appended.
+func insertBeforeBody(html []byte, snippet string) []byte {
+	i := bytes.LastIndex(bytes.ToLower(html), []byte("</body>"))
+	if i < 0 {
+		return append(html, snippet...)
+	}
+
+	inserted := make([]byte, 0, len(html)+len(snippet))
+	inserted = append(inserted, html[:i]...)
+	inserted = append(inserted, snippet...)
+	inserted = append(inserted, html[i:]...)
+
+	return inserted
+}
+
+// loadTemplate considers the template selected by a page, the page type
+// and a default template, decides which template to use and loads that
+// template from the registry.
+func (w *writer) loadTemplate(p *model.Page, defaultTpl string) (*template.Template, error) {
 	var pageTpl string
 
 	switch {
-	case t != nil && t.Template != "":
-		pageTpl = t.Template
+	case p.Template != "":
+		pageTpl = p.Template
+	case p.Type != nil && p.Type.Template != "":
+		pageTpl = p.Type.Template
 	default:
 		pageTpl = defaultTpl
 	}
 
-	if !w.ctx.RecompileTemplates && tpl.IsRegistered(pageTpl) {
-		return tpl.Get(pageTpl)
+	return w.template(pageTpl)
+}
+
+// template loads the template with the given name from the registry. If
+// the template hasn't been registered yet, it is parsed from the theme.
+func (w *writer) template(name string) (*template.Template, error) {
+	var (
+		result *template.Template
+		err    error
+	)
+
+	if !w.ctx.RecompileTemplates && tpl.IsRegistered(name) {
+		result, err = tpl.Get(name)
+	} else {
+		var tplPath string
+		if tplPath, err = theme.ResolveTemplate(w.ctx.ThemesDir, w.ctx.Theme, name); err != nil {
+			return nil, err
+		}
+		var partials map[string]string
+		if partials, err = theme.Partials(w.ctx.ThemesDir, w.ctx.Theme); err != nil {
+			return nil, err
+		}
+		result, err = tpl.Register(name, tplPath, w.ctx.RecompileTemplates, w.funcs(), partials)
+	}
+
+	if err != nil {
+		return nil, err
 	}
 
-	tplPath := filepath.Join(theme.TemplatePath(w.ctx.Path, w.ctx.Theme), pageTpl)
+	// Registered templates may have been parsed by a previous writer, so
+	// the functions have to be bound to this writer. The partial function
+	// needs the template itself for executing the partials.
+	return result.Funcs(w.funcs()).Funcs(template.FuncMap{"partial": partialFunc(result)}), nil
+}
 
-	return tpl.Register(pageTpl, tplPath, w.ctx.RecompileTemplates)
+// Funcs returns the functions available in page and list page templates.
+// They can be used for parsing templates without rendering them.
+func Funcs() template.FuncMap {
+	w := writer{
+		ctx: Context{Images: images.New(images.Options{})},
+	}
+	return w.funcs()
+}
+
+// funcs returns the functions available in all templates.
+func (w *writer) funcs() template.FuncMap {
+	funcs := template.FuncMap{
+		"fingerprint": w.fingerprint,
+		"bundle":      w.bundle,
+		"absURL":      w.absURL,
+		"relURL":      w.relURL,
+		"dateFormat":  w.dateFormat,
+		"dateInZone":  w.dateInZone,
+		"now":         w.now,
+		"page":        w.page,
+		"pages":       w.pages,
+		"partial":     partialFunc(nil),
+	}
+
+	if w.ctx.Images != nil {
+		for name, fn := range w.ctx.Images.Funcs() {
+			funcs[name] = fn
+		}
+	}
+
+	return funcs
 }
 
 func (w *writer) copyDirs() error {
@@ -161,6 +546,8 @@ func (w *writer) copyDirs() error {
 		src      string
 		dest     string
 		fileOnly bool
+		filters  []func(file string) bool
+		scss     bool
 	}{
 		{
 			src:      filepath.Join(w.ctx.Path, config.StaticDir),
@@ -168,32 +555,48 @@ func (w *writer) copyDirs() error {
 			fileOnly: false,
 		},
 		{
-			src:      theme.CssPath(w.ctx.Path, w.ctx.Theme),
+			src:      theme.CssPath(w.ctx.ThemesDir, w.ctx.Theme),
 			dest:     filepath.Join(w.ctx.OutputDir, theme.CssDir),
 			fileOnly: true,
+			filters:  w.themeAssetFilters(),
+			scss:     true,
 		},
 		{
-			src:      theme.JsPath(w.ctx.Path, w.ctx.Theme),
+			src:      theme.JsPath(w.ctx.ThemesDir, w.ctx.Theme),
 			dest:     filepath.Join(w.ctx.OutputDir, theme.JsDir),
 			fileOnly: true,
+			filters:  w.themeAssetFilters(),
 		},
 		{
-			src:      theme.AssetsPath(w.ctx.Path, w.ctx.Theme),
+			src:      theme.AssetsPath(w.ctx.ThemesDir, w.ctx.Theme),
 			dest:     filepath.Join(w.ctx.OutputDir, theme.AssetsDir),
 			fileOnly: true,
+			filters:  w.themeAssetFilters(),
+			scss:     true,
 		},
 		{
-			src:      theme.GeneratedPath(w.ctx.Path, w.ctx.Theme),
+			src:      theme.GeneratedPath(w.ctx.ThemesDir, w.ctx.Theme),
 			dest:     filepath.Join(w.ctx.OutputDir, theme.GeneratedDir),
 			fileOnly: false,
 		},
 	}
 
+	w.assets = make(map[string]string)
+	w.refReplacer = nil
+
 	for _, dir := range dirs {
-		if err := fs.CopyFromOS(w.ctx.Fs, dir.src, dir.dest, dir.fileOnly); err != nil {
+		if err := fs.CopyFromOS(w.ctx.Fs, dir.src, dir.dest, dir.fileOnly, dir.filters...); err != nil {
+			return err
+		}
+		if err := w.processAssets(dir.src, dir.dest, dir.fileOnly, dir.filters...); err != nil {
 			return err
 		}
+		if dir.scss && w.ctx.SCSS {
+			if err := w.compileStylesheets(dir.src, dir.dest); err != nil {
+				return err
+			}
+		}
 	}
 
-	return nil
+	return w.writeBundles()
 }